@@ -0,0 +1,136 @@
+package mac_prefs
+
+import (
+	"fmt"
+	"sort"
+)
+
+// DefaultApplyHooks maps the domains whose changes only take effect when a process restarts
+// to those processes.
+var DefaultApplyHooks = map[string][]string{
+	"com.apple.dock":           {"Dock"},
+	"com.apple.finder":         {"Finder"},
+	"com.apple.systemuiserver": {"SystemUIServer"},
+}
+
+// Restart is a process a Client restarts in Apply so that changes to its preferences take
+// effect.
+type Restart struct {
+	// Process is the name of the process, e.g. "Dock".
+	Process string
+	// User is the user whose process is restarted. AnyUser restarts the process of every user.
+	User UserType
+}
+
+func (r Restart) String() string {
+	switch r.User {
+	case CurrentUser:
+		return r.Process
+	case AnyUser:
+		return r.Process + " (all users)"
+	default:
+		return fmt.Sprintf("%s (%s)", r.Process, r.User)
+	}
+}
+
+// WithApplyHooks makes the Client note the processes that must restart to pick up its
+// writes, according to hooks, which maps application IDs to process names;
+// DefaultApplyHooks covers the Dock, the Finder, and the menu bar. Nothing is restarted
+// until Apply is called, so a script can make all of its changes and restart the Dock once,
+// as it would with killall Dock.
+func WithApplyHooks(hooks map[string][]string) Option {
+	return func(c *Client) {
+		c.applyHooks = hooks
+	}
+}
+
+// PendingRestarts returns the processes Apply would restart, sorted by process and user.
+// A Client created with WithDryRun lists the processes its planned changes would restart.
+func (c *Client) PendingRestarts() []Restart {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.pendingRestarts()
+}
+
+// pendingRestarts lists the pending restarts. c.mu must be held.
+func (c *Client) pendingRestarts() []Restart {
+	pending := c.restarts
+	if c.dryRun {
+		pending = make(map[Restart]bool)
+		for _, p := range c.Planned() {
+			for _, process := range c.applyHooks[p.ApplicationID] {
+				pending[Restart{Process: process, User: p.Scope.User}] = true
+			}
+		}
+	}
+
+	restarts := make([]Restart, 0, len(pending))
+	for r := range pending {
+		restarts = append(restarts, r)
+	}
+	sort.Slice(restarts, func(i, j int) bool {
+		if restarts[i].Process != restarts[j].Process {
+			return restarts[i].Process < restarts[j].Process
+		}
+		return restarts[i].User < restarts[j].User
+	})
+	return restarts
+}
+
+// Apply restarts the processes whose preferences were written through the Client since the
+// last Apply, each once, so the changes take effect. Processes that are not running are
+// skipped; launchd starts the Dock, the Finder, and SystemUIServer again on its own.
+// Restarting the processes of another user, or of every user, requires root privileges. A
+// Client created with WithDryRun restarts nothing and returns what it would restart.
+//
+// Returns:
+//   - []Restart: The processes restarted, or that would be restarted in a dry run.
+//   - error: An error if a process cannot be restarted. The remaining processes are still
+//     restarted, and those that failed stay pending.
+func (c *Client) Apply() ([]Restart, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	restarts := c.pendingRestarts()
+	if c.dryRun {
+		return restarts, nil
+	}
+
+	var firstErr error
+	done := make([]Restart, 0, len(restarts))
+	for _, r := range restarts {
+		if err := restartProcess(r); err != nil {
+			if firstErr == nil {
+				firstErr = err
+			}
+			continue
+		}
+		delete(c.restarts, r)
+		done = append(done, r)
+	}
+	return done, firstErr
+}
+
+// markRestarts notes the processes the apply hooks restart for mutations. c.mu must be held.
+func (c *Client) markRestarts(mutations ...mutation) {
+	for _, m := range mutations {
+		for _, process := range c.applyHooks[m.appID] {
+			if c.restarts == nil {
+				c.restarts = make(map[Restart]bool)
+			}
+			c.restarts[Restart{Process: process, User: m.scope.User}] = true
+		}
+	}
+}
+
+// restartProcess terminates the process of r with killall.
+func restartProcess(r Restart) error {
+	switch r.User {
+	case CurrentUser:
+		return killall(processUser(), r.Process)
+	case AnyUser:
+		return killall("", r.Process)
+	default:
+		return killall(string(r.User), r.Process)
+	}
+}
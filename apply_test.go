@@ -0,0 +1,83 @@
+package mac_prefs
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestClientApply(t *testing.T) {
+	dir := t.TempDir()
+	args := filepath.Join(dir, "args")
+	killall := filepath.Join(dir, "killall")
+	script := "#!/bin/sh\necho \"$@\" >> " + args + "\n" +
+		"if [ \"$1\" = Finder ]; then echo 'Operation not permitted' >&2; exit 1; fi\n"
+	if err := os.WriteFile(killall, []byte(script), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	defer func(old string) { killallCommand = old }(killallCommand)
+	killallCommand = killall
+
+	c := NewClient(WithStore(NewMemoryStore()), WithApplyHooks(DefaultApplyHooks))
+	if err := c.Set("autohide", true, "com.apple.dock", CurrentUserAnyHost); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.Set("tilesize", 48, "com.apple.dock", PreferenceScope{User: "alice", Host: AnyHost}); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.Delete("ShowPathbar", "com.apple.finder", AnyUserAnyHost); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.Set("a", 1, "com.example", CurrentUserAnyHost); err != nil {
+		t.Fatal(err)
+	}
+
+	want := []Restart{{"Dock", "alice"}, {"Dock", CurrentUser}, {"Finder", AnyUser}}
+	if got := c.PendingRestarts(); !reflect.DeepEqual(got, want) {
+		t.Fatalf("PendingRestarts() = %v, want %v", got, want)
+	}
+
+	done, err := c.Apply()
+	if err == nil {
+		t.Error("Apply() error = nil, want the Finder error")
+	}
+	if want := want[:2]; !reflect.DeepEqual(done, want) {
+		t.Errorf("Apply() = %v, want %v", done, want)
+	}
+	if got, want := c.PendingRestarts(), want[2:]; !reflect.DeepEqual(got, want) {
+		t.Errorf("PendingRestarts() after Apply() = %v, want the failed restart %v", got, want)
+	}
+
+	data, err := os.ReadFile(args)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "-u alice Dock\n-u " + processUser() + " Dock\nFinder\n"; string(data) != want {
+		t.Errorf("killall calls = %q, want %q", data, want)
+	}
+}
+
+func TestClientApplyDryRun(t *testing.T) {
+	defer func(old string) { killallCommand = old }(killallCommand)
+	killallCommand = filepath.Join(t.TempDir(), "missing")
+
+	store := NewMemoryStore()
+	if err := store.Set("autohide", true, "com.apple.dock", CurrentUserAnyHost); err != nil {
+		t.Fatal(err)
+	}
+	c := NewClient(WithStore(store), WithApplyHooks(DefaultApplyHooks), WithDryRun())
+	if err := c.Set("autohide", true, "com.apple.dock", CurrentUserAnyHost); err != nil {
+		t.Fatal(err)
+	}
+	if got := c.PendingRestarts(); len(got) != 0 {
+		t.Errorf("PendingRestarts() of an unchanged value = %v", got)
+	}
+	if err := c.Set("Clock", "HH:mm", "com.apple.systemuiserver", CurrentUserAnyHost); err != nil {
+		t.Fatal(err)
+	}
+	done, err := c.Apply()
+	if want := []Restart{{"SystemUIServer", CurrentUser}}; err != nil || !reflect.DeepEqual(done, want) {
+		t.Errorf("Apply() in dry run = %v, %v, want %v", done, err, want)
+	}
+}
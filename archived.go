@@ -0,0 +1,36 @@
+package mac_prefs
+
+import (
+	"fmt"
+
+	"github.com/weswhet/mac_prefs/keyedarchive"
+)
+
+// GetArchived retrieves a value stored as NSKeyedArchiver data and decodes it with
+// keyedarchive.Decode. Use keyedarchive.Encode to write such a value.
+//
+// Parameters:
+//   - key: The preference key to retrieve.
+//   - applicationID: The bundle identifier of the application for which to retrieve the preference.
+//   - scope: The PreferenceScope to read from.
+//
+// Returns:
+//   - interface{}: The decoded root object.
+//   - bool: Whether the key is set.
+//   - error: An error if the value cannot be read or is not a keyed archive.
+func GetArchived(key string, applicationID string, scope PreferenceScope) (interface{}, bool, error) {
+	return NewClient().GetArchived(key, applicationID, scope)
+}
+
+// GetArchived retrieves and decodes a keyed archive. See GetArchived.
+func (c *Client) GetArchived(key string, applicationID string, scope PreferenceScope) (interface{}, bool, error) {
+	return getTyped(c, key, applicationID, scope, archivedValue)
+}
+
+func archivedValue(value interface{}) (interface{}, error) {
+	data, ok := value.([]byte)
+	if !ok {
+		return nil, fmt.Errorf("value of type %T is not data", value)
+	}
+	return keyedarchive.Decode(data)
+}
@@ -0,0 +1,36 @@
+package mac_prefs
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/weswhet/mac_prefs/keyedarchive"
+)
+
+func TestClientGetArchived(t *testing.T) {
+	c := NewClient(WithStore(NewMemoryStore()))
+	const app = "com.example.archived"
+
+	value := map[string]interface{}{"tiles": []interface{}{"a", "b"}}
+	data, err := keyedarchive.Encode(value)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := c.Set("Archived", data, app, CurrentUserAnyHost); err != nil {
+		t.Fatalf("Set() error = %v", err)
+	}
+	if err := c.Set("Plain", []byte("plain"), app, CurrentUserAnyHost); err != nil {
+		t.Fatalf("Set() error = %v", err)
+	}
+
+	got, ok, err := c.GetArchived("Archived", app, CurrentUserAnyHost)
+	if err != nil || !ok || !reflect.DeepEqual(got, value) {
+		t.Fatalf("GetArchived() = %#v, %v, %v", got, ok, err)
+	}
+	if _, ok, err := c.GetArchived("Plain", app, CurrentUserAnyHost); !ok || err == nil {
+		t.Fatalf("GetArchived(Plain) = %v, %v, want true and an error", ok, err)
+	}
+	if _, ok, err := c.GetArchived("Missing", app, CurrentUserAnyHost); ok || err != nil {
+		t.Fatalf("GetArchived(Missing) = %v, %v, want false, nil", ok, err)
+	}
+}
@@ -0,0 +1,165 @@
+package mac_prefs
+
+import "fmt"
+
+// ArrayAppend appends values to the array stored under key, creating the array if the key is
+// not set, like `defaults write -array-add`. The array is read, modified, and written back
+// while the Client is locked.
+//
+// Parameters:
+//   - key: The preference key holding the array.
+//   - applicationID: The bundle identifier of the application for which to update the preference.
+//   - scope: The PreferenceScope to write to.
+//   - unique: Whether to skip values that are already in the array.
+//   - values: The values to append.
+//
+// Returns:
+//   - error: An error if the stored value is not an array or cannot be read or written.
+func ArrayAppend(key string, applicationID string, scope PreferenceScope, unique bool, values ...interface{}) error {
+	return NewClient().ArrayAppend(key, applicationID, scope, unique, values...)
+}
+
+// ArrayAppend appends values to an array-valued preference. See ArrayAppend.
+func (c *Client) ArrayAppend(key string, applicationID string, scope PreferenceScope, unique bool, values ...interface{}) error {
+	return c.updateArray(key, applicationID, scope, func(items []interface{}) ([]interface{}, error) {
+		return insertItems(items, len(items), unique, values), nil
+	})
+}
+
+// ArrayInsert inserts values into the array stored under key before the element at index,
+// creating the array if the key is not set. A negative index counts from the end of the array,
+// so -1 inserts before the last element. The array is read, modified, and written back while
+// the Client is locked.
+//
+// Parameters:
+//   - key: The preference key holding the array.
+//   - applicationID: The bundle identifier of the application for which to update the preference.
+//   - scope: The PreferenceScope to write to.
+//   - index: The position to insert at, from 0 to the length of the array.
+//   - unique: Whether to skip values that are already in the array.
+//   - values: The values to insert.
+//
+// Returns:
+//   - error: An error if the stored value is not an array, index is out of range, or the
+//     preference cannot be read or written.
+func ArrayInsert(key string, applicationID string, scope PreferenceScope, index int, unique bool, values ...interface{}) error {
+	return NewClient().ArrayInsert(key, applicationID, scope, index, unique, values...)
+}
+
+// ArrayInsert inserts values into an array-valued preference. See ArrayInsert.
+func (c *Client) ArrayInsert(key string, applicationID string, scope PreferenceScope, index int, unique bool, values ...interface{}) error {
+	return c.updateArray(key, applicationID, scope, func(items []interface{}) ([]interface{}, error) {
+		i := index
+		if i < 0 {
+			i += len(items)
+		}
+		if i < 0 || i > len(items) {
+			return nil, fmt.Errorf("index %d is out of range for %d elements", index, len(items))
+		}
+		return insertItems(items, i, unique, values), nil
+	})
+}
+
+// ArrayRemove removes every element equal to one of values from the array stored under key.
+// Numbers of different Go types are equal if their values are, as in Diff. Removing the last
+// element leaves an empty array. The array is read, modified, and written back while the
+// Client is locked.
+//
+// Parameters:
+//   - key: The preference key holding the array.
+//   - applicationID: The bundle identifier of the application for which to update the preference.
+//   - scope: The PreferenceScope to write to.
+//   - values: The values to remove.
+//
+// Returns:
+//   - int: The number of elements removed.
+//   - error: An error if the stored value is not an array or cannot be read or written.
+func ArrayRemove(key string, applicationID string, scope PreferenceScope, values ...interface{}) (int, error) {
+	return NewClient().ArrayRemove(key, applicationID, scope, values...)
+}
+
+// ArrayRemove removes elements from an array-valued preference. See ArrayRemove.
+func (c *Client) ArrayRemove(key string, applicationID string, scope PreferenceScope, values ...interface{}) (int, error) {
+	removed := 0
+	err := c.updateArray(key, applicationID, scope, func(items []interface{}) ([]interface{}, error) {
+		result := make([]interface{}, 0, len(items))
+		for _, item := range items {
+			if containsValue(values, item) {
+				removed++
+				continue
+			}
+			result = append(result, item)
+		}
+		if removed == 0 {
+			return nil, nil
+		}
+		return result, nil
+	})
+	return removed, err
+}
+
+// ArrayDedupe removes repeated elements from the array stored under key, keeping the first
+// occurrence of each.
+//
+// Parameters:
+//   - key: The preference key holding the array.
+//   - applicationID: The bundle identifier of the application for which to update the preference.
+//   - scope: The PreferenceScope to write to.
+//
+// Returns:
+//   - int: The number of elements removed.
+//   - error: An error if the stored value is not an array or cannot be read or written.
+func ArrayDedupe(key string, applicationID string, scope PreferenceScope) (int, error) {
+	return NewClient().ArrayDedupe(key, applicationID, scope)
+}
+
+// ArrayDedupe removes repeated elements from an array-valued preference. See ArrayDedupe.
+func (c *Client) ArrayDedupe(key string, applicationID string, scope PreferenceScope) (int, error) {
+	removed := 0
+	err := c.updateArray(key, applicationID, scope, func(items []interface{}) ([]interface{}, error) {
+		result := insertItems(nil, 0, true, items)
+		removed = len(items) - len(result)
+		if removed == 0 {
+			return nil, nil
+		}
+		return result, nil
+	})
+	return removed, err
+}
+
+// updateArray passes the array stored under key, or nil if the key is not set, to update and
+// writes back the array it returns. A nil result leaves the preference unchanged.
+func (c *Client) updateArray(key string, applicationID string, scope PreferenceScope, update func([]interface{}) ([]interface{}, error)) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	current, err := c.store.Get(key, applicationID, scope)
+	if err != nil {
+		return err
+	}
+	items, ok := sliceItems(current)
+	if !ok && current != nil {
+		return fmt.Errorf("error updating %s: value of type %T is not an array", key, current)
+	}
+	result, err := update(items)
+	if err != nil {
+		return fmt.Errorf("error updating %s: %v", key, err)
+	}
+	if result == nil {
+		return nil
+	}
+	return c.set(key, result, applicationID, scope)
+}
+
+// insertItems returns a copy of items with values inserted at index, skipping values that are
+// already present if unique is true.
+func insertItems(items []interface{}, index int, unique bool, values []interface{}) []interface{} {
+	result := append(make([]interface{}, 0, len(items)+len(values)), items[:index]...)
+	for _, value := range values {
+		if unique && (containsValue(items, value) || containsValue(result[index:], value)) {
+			continue
+		}
+		result = append(result, value)
+	}
+	return append(result, items[index:]...)
+}
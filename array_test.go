@@ -0,0 +1,77 @@
+package mac_prefs
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestClientArrayOperations(t *testing.T) {
+	c := NewClient(WithStore(NewMemoryStore()))
+	get := func() interface{} {
+		t.Helper()
+		value, err := c.Get("apps", "com.example", CurrentUserAnyHost)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return value
+	}
+
+	if err := c.ArrayAppend("apps", "com.example", CurrentUserAnyHost, false, "Safari", "Mail"); err != nil {
+		t.Fatalf("ArrayAppend() error = %v", err)
+	}
+	if err := c.ArrayAppend("apps", "com.example", CurrentUserAnyHost, true, "Mail", "Notes", "Notes"); err != nil {
+		t.Fatalf("ArrayAppend() error = %v", err)
+	}
+	if got, want := get(), []interface{}{"Safari", "Mail", "Notes"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("after ArrayAppend, apps = %#v, want %#v", got, want)
+	}
+
+	if err := c.ArrayInsert("apps", "com.example", CurrentUserAnyHost, 0, false, "Finder"); err != nil {
+		t.Fatalf("ArrayInsert() error = %v", err)
+	}
+	if err := c.ArrayInsert("apps", "com.example", CurrentUserAnyHost, -1, true, "Safari", "Music"); err != nil {
+		t.Fatalf("ArrayInsert() error = %v", err)
+	}
+	if err := c.ArrayInsert("apps", "com.example", CurrentUserAnyHost, 9, false, "x"); err == nil {
+		t.Error("ArrayInsert() out of range expected error")
+	}
+	if got, want := get(), []interface{}{"Finder", "Safari", "Mail", "Music", "Notes"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("after ArrayInsert, apps = %#v, want %#v", got, want)
+	}
+
+	if err := c.ArrayAppend("apps", "com.example", CurrentUserAnyHost, false, "Mail", "Finder"); err != nil {
+		t.Fatal(err)
+	}
+	if n, err := c.ArrayDedupe("apps", "com.example", CurrentUserAnyHost); err != nil || n != 2 {
+		t.Errorf("ArrayDedupe() = %d, %v, want 2", n, err)
+	}
+	if n, err := c.ArrayRemove("apps", "com.example", CurrentUserAnyHost, "Mail", "Photos"); err != nil || n != 1 {
+		t.Errorf("ArrayRemove() = %d, %v, want 1", n, err)
+	}
+	if got, want := get(), []interface{}{"Finder", "Safari", "Music", "Notes"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("after ArrayRemove, apps = %#v, want %#v", got, want)
+	}
+
+	if err := c.Set("size", 48, "com.example", CurrentUserAnyHost); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.ArrayAppend("size", "com.example", CurrentUserAnyHost, false, 1); err == nil {
+		t.Error("ArrayAppend() to a number expected error")
+	}
+}
+
+func TestClientArrayRemoveNumbers(t *testing.T) {
+	c := NewClient(WithStore(NewMemoryStore()))
+	if err := c.Set("ports", []int{80, 443, 8080}, "com.example", CurrentUserAnyHost); err != nil {
+		t.Fatal(err)
+	}
+	if n, err := c.ArrayRemove("ports", "com.example", CurrentUserAnyHost, int64(80), 8080.0); err != nil || n != 2 {
+		t.Errorf("ArrayRemove() = %d, %v, want 2", n, err)
+	}
+	if n, err := c.ArrayRemove("missing", "com.example", CurrentUserAnyHost, 1); err != nil || n != 0 {
+		t.Errorf("ArrayRemove() of a missing key = %d, %v", n, err)
+	}
+	if v, _ := c.Get("missing", "com.example", CurrentUserAnyHost); v != nil {
+		t.Errorf("ArrayRemove() wrote %#v to a missing key", v)
+	}
+}
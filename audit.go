@@ -0,0 +1,83 @@
+package mac_prefs
+
+import (
+	"os"
+	"os/user"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// AuditEvent records one preference key changed through a Client. The values of sensitive
+// keys are masked by the Redactor of the Client.
+type AuditEvent struct {
+	// ApplicationID is the domain of the key.
+	ApplicationID string
+	// Key is the preference key that changed.
+	Key string
+	// Old is the value before the change, or nil if the key was not set.
+	Old interface{}
+	// New is the value written, or nil if the key was removed.
+	New interface{}
+	// Scope is the (user, host) slot that changed.
+	Scope PreferenceScope
+	// Time is when the change was written.
+	Time time.Time
+	// User is the name of the user the process runs as, or its uid if the name is unknown.
+	User string
+}
+
+// OnMutation registers fn to be called with an AuditEvent for every key the Client changes:
+// Set, Delete, the helpers built on them such as Increment and SetPath, committed
+// transactions, ReplaceAll, MigrateDomain, and Undo. Events are sent after the change is
+// written, so failed writes are not reported. Registering a hook makes each Set and Delete
+// read the previous value first.
+//
+// fn is called synchronously while the Client holds its write lock, so it must not write
+// through the Client. Changes made by other Clients or processes are not reported; use Watch
+// for those.
+func (c *Client) OnMutation(fn func(AuditEvent)) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.auditHooks = append(c.auditHooks, fn)
+}
+
+// audit passes mutations to the audit hooks of the Client. c.mu must be held.
+func (c *Client) audit(mutations ...mutation) {
+	if len(c.auditHooks) == 0 {
+		return
+	}
+	now := time.Now()
+	name := processUser()
+	for _, m := range mutations {
+		event := AuditEvent{
+			ApplicationID: m.appID,
+			Key:           m.key,
+			Old:           c.redactor.Redact(m.key, c.readValue(m.old)),
+			New:           c.redactor.Redact(m.key, c.readValue(m.new)),
+			Scope:         m.scope,
+			Time:          now,
+			User:          name,
+		}
+		for _, fn := range c.auditHooks {
+			fn(event)
+		}
+	}
+}
+
+var (
+	processUserOnce sync.Once
+	processUserName string
+)
+
+// processUser returns the name of the user the process runs as, or its uid.
+func processUser() string {
+	processUserOnce.Do(func() {
+		if u, err := user.Current(); err == nil && u.Username != "" {
+			processUserName = u.Username
+		} else {
+			processUserName = strconv.Itoa(os.Getuid())
+		}
+	})
+	return processUserName
+}
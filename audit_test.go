@@ -0,0 +1,123 @@
+package mac_prefs
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestClientOnMutation(t *testing.T) {
+	c := NewClient(WithStore(NewMemoryStore()), WithUndo(0))
+	var events []AuditEvent
+	c.OnMutation(func(e AuditEvent) { events = append(events, e) })
+
+	if err := c.Set("a", 1, "com.example", CurrentUserAnyHost); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := c.Increment("a", "com.example", CurrentUserAnyHost, 2); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.Delete("a", "com.example", CurrentUserAnyHost); err != nil {
+		t.Fatal(err)
+	}
+	tx := c.Begin()
+	if err := tx.Set("b", "x", "com.example.other", CurrentUserAnyHost); err != nil {
+		t.Fatal(err)
+	}
+	if err := tx.Commit(); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.Undo(1); err != nil {
+		t.Fatal(err)
+	}
+
+	want := []struct {
+		appID, key string
+		old, new   interface{}
+	}{
+		{"com.example", "a", nil, 1},
+		{"com.example", "a", 1, 3},
+		{"com.example", "a", 3, nil},
+		{"com.example.other", "b", nil, "x"},
+		{"com.example.other", "b", "x", nil},
+	}
+	if len(events) != len(want) {
+		t.Fatalf("got %d events %+v, want %d", len(events), events, len(want))
+	}
+	for i, w := range want {
+		e := events[i]
+		if e.ApplicationID != w.appID || e.Key != w.key || !valuesEqual(e.Old, w.old) || !valuesEqual(e.New, w.new) {
+			t.Errorf("event %d = %+v, want %+v", i, e, w)
+		}
+		if e.Scope != CurrentUserAnyHost || e.Time.IsZero() || e.User == "" {
+			t.Errorf("event %d = %+v", i, e)
+		}
+	}
+}
+
+func TestClientOnMutationWithoutUndo(t *testing.T) {
+	store := failingStore{MemoryStore: NewMemoryStore(), failAppID: "com.example.fail"}
+	c := NewClient(WithStore(store))
+	if err := c.Set("a", "old", "com.example", CurrentUserAnyHost); err != nil {
+		t.Fatal(err)
+	}
+	var events []AuditEvent
+	c.OnMutation(func(e AuditEvent) { events = append(events, e) })
+
+	if err := c.ReplaceAll("com.example", CurrentUserAnyHost, map[string]interface{}{"b": true}); err != nil {
+		t.Fatal(err)
+	}
+	tx := c.Begin()
+	if err := tx.Set("c", 1, "com.example.fail", CurrentUserAnyHost); err != nil {
+		t.Fatal(err)
+	}
+	if err := tx.Commit(); err == nil {
+		t.Fatal("Commit() error = nil, want an error")
+	}
+
+	got := make(map[string][2]interface{})
+	for _, e := range events {
+		got[e.Key] = [2]interface{}{e.Old, e.New}
+	}
+	want := map[string][2]interface{}{"a": {"old", nil}, "b": {nil, true}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("events = %v, want %v", got, want)
+	}
+	if c.UndoLen() != 0 {
+		t.Errorf("UndoLen() = %d without undo enabled", c.UndoLen())
+	}
+}
+
+func TestClientReplaceAllRecordsOnlyChanges(t *testing.T) {
+	c := NewClient(WithStore(NewMemoryStore()), WithUndo(2))
+	for key, value := range map[string]interface{}{"kept": 1, "changed": "old", "removed": true} {
+		if err := c.Set(key, value, "com.example", CurrentUserAnyHost); err != nil {
+			t.Fatalf("Set() error = %v", err)
+		}
+	}
+	var events []AuditEvent
+	c.OnMutation(func(e AuditEvent) { events = append(events, e) })
+
+	desired := map[string]interface{}{"kept": 1, "changed": "new", "added": 2.5, "absent": nil}
+	if err := c.ReplaceAll("com.example", CurrentUserAnyHost, desired); err != nil {
+		t.Fatalf("ReplaceAll() error = %v", err)
+	}
+	var keys []string
+	for _, e := range events {
+		keys = append(keys, e.Key)
+	}
+	if want := []string{"added", "changed", "removed"}; !reflect.DeepEqual(keys, want) {
+		t.Errorf("audited keys = %v, want %v", keys, want)
+	}
+	if c.UndoLen() != 2 {
+		t.Fatalf("UndoLen() = %d, want 2", c.UndoLen())
+	}
+	if err := c.Undo(2); err != nil {
+		t.Fatalf("Undo() error = %v", err)
+	}
+	if got, _ := c.Get("changed", "com.example", CurrentUserAnyHost); got != "old" {
+		t.Errorf("changed = %#v after Undo, want old", got)
+	}
+	if got, _ := c.Get("removed", "com.example", CurrentUserAnyHost); got != true {
+		t.Errorf("removed = %#v after Undo, want true", got)
+	}
+}
@@ -0,0 +1,117 @@
+package mac_prefs
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// WithBackupDir makes the Client save every domain slot to a binary plist in dir before it
+// first writes to it, so a rollout that goes wrong can be undone with Import. The plist is
+// serialized by CoreFoundation, so nulls and sub-second dates are kept exactly. Each
+// slot is backed up once per Client, when the first write reaches it; the file is named after
+// the domain, the scope, and the time, e.g. com.apple.dock.user-anyhost.20240229-123000.000.plist.
+// A write whose backup fails is not made. dir is created if it does not exist, and backups
+// are readable only by their owner, since preferences can hold secrets.
+func WithBackupDir(dir string) Option {
+	return func(c *Client) {
+		c.backupDir = dir
+	}
+}
+
+// backupStore is a Store that backs up each slot of the Store it wraps before the first write
+// to it.
+type backupStore struct {
+	Store
+	dir string
+
+	mu     sync.Mutex
+	backed map[prefSlot]bool
+}
+
+func newBackupStore(store Store, dir string) *backupStore {
+	return &backupStore{Store: store, dir: dir, backed: make(map[prefSlot]bool)}
+}
+
+// backup saves slot to the backup directory unless it has been saved already.
+func (s *backupStore) backup(slot prefSlot) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.backed[slot] {
+		return nil
+	}
+
+	values, err := s.Store.List(slot.appID, slot.scope)
+	if err != nil {
+		return fmt.Errorf("error backing up %s: %v", slot.appID, err)
+	}
+	data, err := marshalPlistData(values, FormatBinary)
+	if err != nil {
+		return fmt.Errorf("error backing up %s: %v", slot.appID, err)
+	}
+	if err := os.MkdirAll(s.dir, 0o700); err != nil {
+		return fmt.Errorf("error creating backup directory: %v", err)
+	}
+	name := fmt.Sprintf("%s.%s.%s.plist", backupFileName(slot.appID), backupScopeName(slot.scope), time.Now().Format("20060102-150405.000"))
+	if err := os.WriteFile(filepath.Join(s.dir, name), data, 0o600); err != nil {
+		return fmt.Errorf("error backing up %s: %v", slot.appID, err)
+	}
+	s.backed[slot] = true
+	return nil
+}
+
+// backupScopeName spells a scope for backup file names, e.g. "user-anyhost".
+func backupScopeName(scope PreferenceScope) string {
+	user := string(scope.User)
+	switch scope.User {
+	case CurrentUser:
+		user = "user"
+	case AnyUser:
+		user = "anyuser"
+	}
+	host := "anyhost"
+	if scope.Host == CurrentHost {
+		host = "currenthost"
+	}
+	return backupFileName(user) + "-" + host
+}
+
+// backupFileName makes s safe to use in a file name inside the backup directory, so an
+// application ID or user name such as "../x" cannot name a file elsewhere.
+func backupFileName(s string) string {
+	return strings.NewReplacer("/", "_", string(filepath.Separator), "_").Replace(s)
+}
+
+// Set backs up the slot and sets a value.
+func (s *backupStore) Set(key string, value interface{}, applicationID string, scope PreferenceScope) error {
+	if err := s.backup(prefSlot{applicationID, scope}); err != nil {
+		return err
+	}
+	return s.Store.Set(key, value, applicationID, scope)
+}
+
+// Delete backs up the slot and removes a key.
+func (s *backupStore) Delete(key string, applicationID string, scope PreferenceScope) error {
+	if err := s.backup(prefSlot{applicationID, scope}); err != nil {
+		return err
+	}
+	return s.Store.Delete(key, applicationID, scope)
+}
+
+// SetMultiple backs up the slot and writes several keys, in one write if the wrapped Store is
+// a BatchStore.
+func (s *backupStore) SetMultiple(keysToSet map[string]interface{}, keysToRemove []string, applicationID string, scope PreferenceScope) error {
+	slot := prefSlot{applicationID, scope}
+	if err := s.backup(slot); err != nil {
+		return err
+	}
+	return writeSlot(s.Store, slot, batchValues(keysToSet, keysToRemove))
+}
+
+// GetMultiple retrieves several keys, in one read if the wrapped Store is a MultiGetStore.
+func (s *backupStore) GetMultiple(keys []string, applicationID string, scope PreferenceScope) (map[string]interface{}, error) {
+	return readSlot(s.Store, prefSlot{applicationID, scope}, keys)
+}
@@ -0,0 +1,93 @@
+//go:build darwin && cgo
+
+package mac_prefs
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestClientBackupDir(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "backups")
+	store := failingStore{MemoryStore: NewMemoryStore(), failAppID: "com.example.fail"}
+	old := map[string]interface{}{
+		"old":     "value",
+		"tiles":   []interface{}{"a", nil},
+		"created": time.Date(2024, 2, 29, 12, 30, 0, 250000000, time.UTC),
+	}
+	for key, value := range old {
+		if err := store.Set(key, value, "com.example", CurrentUserAnyHost); err != nil {
+			t.Fatal(err)
+		}
+	}
+	c := NewClient(WithStore(store), WithBackupDir(dir))
+
+	if err := c.Set("a", 1, "com.example", CurrentUserAnyHost); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.Set("b", 2, "com.example", CurrentUserAnyHost); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.Delete("a", "com.example.other", CurrentUserCurrentHost); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.Set("a", 1, "com.example.fail", CurrentUserAnyHost); err == nil {
+		t.Error("Set() error = nil, want the backup error")
+	}
+	if value, _ := store.Get("a", "com.example.fail", CurrentUserAnyHost); value != nil {
+		t.Errorf("Set() wrote %v although the backup failed", value)
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, entry := range entries {
+		names = append(names, entry.Name())
+	}
+	if len(names) != 2 || !strings.HasPrefix(names[0], "com.example.other.user-currenthost.") || !strings.HasPrefix(names[1], "com.example.user-anyhost.") {
+		t.Fatalf("backups = %v", names)
+	}
+	if info, _ := entries[1].Info(); info.Mode().Perm() != 0o600 {
+		t.Errorf("backup mode = %v, want 0600", info.Mode().Perm())
+	}
+
+	backup, err := readPlistFile(filepath.Join(dir, names[1]))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(backup, old) {
+		t.Errorf("backup = %v, want the domain before the first write %v", backup, old)
+	}
+}
+
+func TestClientBackupDirEscapedName(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "backups")
+	c := NewClient(WithStore(NewMemoryStore()), WithBackupDir(dir))
+	if err := c.Set("a", 1, "../../escape", PreferenceScope{User: "../bob", Host: AnyHost}); err != nil {
+		t.Fatal(err)
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 || !strings.HasPrefix(entries[0].Name(), ".._.._escape..._bob-anyhost.") {
+		t.Fatalf("backups = %v, want one file inside the backup directory", entries)
+	}
+}
+
+func TestClientBackupDirDryRun(t *testing.T) {
+	dir := t.TempDir()
+	c := NewClient(WithStore(NewMemoryStore()), WithBackupDir(dir), WithDryRun())
+	if err := c.Set("a", 1, "com.example", CurrentUserAnyHost); err != nil {
+		t.Fatal(err)
+	}
+	if entries, _ := os.ReadDir(dir); len(entries) != 0 {
+		t.Errorf("dry run made %d backups", len(entries))
+	}
+}
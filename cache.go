@@ -0,0 +1,287 @@
+package mac_prefs
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// CacheOptions configures the read cache enabled by WithCache.
+type CacheOptions struct {
+	// TTL is how long a value is served from the cache after it was read. Zero or less keeps
+	// values until they are invalidated.
+	TTL time.Duration
+	// Watch starts a watcher for every domain slot read through the cache, so changes made by
+	// other processes invalidate the cached values of their keys. Call Client.Close to stop
+	// the watchers.
+	Watch bool
+	// WatchInterval is the polling interval of the watchers. DefaultWatchInterval is used if
+	// it is zero or less.
+	WatchInterval time.Duration
+}
+
+// CacheStats reports how effective the read cache of a Client is.
+type CacheStats struct {
+	// Hits is the number of reads served from the cache.
+	Hits uint64
+	// Misses is the number of reads that went to the store.
+	Misses uint64
+	// Entries is the number of keys currently cached.
+	Entries int
+}
+
+// WithCache makes the Client remember the values it reads, including keys that are not set, so
+// daemons polling the same keys do not pay a cfprefsd round trip on every read. Writes through
+// the Client invalidate the keys they touch; changes made elsewhere are picked up when the TTL
+// expires or, with opts.Watch, when a watcher reports them.
+func WithCache(opts CacheOptions) Option {
+	return func(c *Client) {
+		c.cacheOptions = &opts
+	}
+}
+
+// CacheStats returns the hit and miss counts of the read cache. It is zero if the cache is not
+// enabled.
+func (c *Client) CacheStats() CacheStats {
+	if c.cache == nil {
+		return CacheStats{}
+	}
+	return c.cache.stats()
+}
+
+// Close stops the background work of the Client, such as cache watchers and the thread
+// started by WithDedicatedThread, and releases the CFStrings interned by WithStringCache. The
+// Client remains usable, but its cache is no longer invalidated by changes made by other
+// processes, strings are no longer reused, and calls run on the calling goroutine.
+//
+// Returns:
+//   - error: Always nil; the error is reserved for resources that can fail to close.
+func (c *Client) Close() error {
+	if c.cache != nil {
+		c.cache.cancel()
+	}
+	if c.thread == nil {
+		return c.names.Close()
+	}
+	var err error
+	c.thread.do(func() { err = c.names.Close() })
+	c.thread.close()
+	return err
+}
+
+// cacheStore is a Store that caches the values read from the Store it wraps.
+type cacheStore struct {
+	Store
+	opts   CacheOptions
+	ctx    context.Context
+	cancel context.CancelFunc
+
+	mu         sync.Mutex
+	entries    map[cacheKey]cacheEntry
+	watched    map[prefSlot]bool
+	generation uint64
+	hits       uint64
+	misses     uint64
+}
+
+type cacheKey struct {
+	slot prefSlot
+	key  string
+}
+
+type cacheEntry struct {
+	value   interface{}
+	expires time.Time
+}
+
+// fresh reports whether the entry has not expired at now.
+func (e cacheEntry) fresh(now time.Time) bool {
+	return e.expires.IsZero() || now.Before(e.expires)
+}
+
+func newCacheStore(store Store, opts CacheOptions) *cacheStore {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &cacheStore{
+		Store:   store,
+		opts:    opts,
+		ctx:     ctx,
+		cancel:  cancel,
+		entries: make(map[cacheKey]cacheEntry),
+		watched: make(map[prefSlot]bool),
+	}
+}
+
+// Get retrieves a value from the cache or, if it is not cached or has expired, from the
+// wrapped Store.
+func (s *cacheStore) Get(key string, applicationID string, scope PreferenceScope) (interface{}, error) {
+	k := cacheKey{prefSlot{applicationID, scope}, key}
+
+	s.mu.Lock()
+	entry, ok := s.entries[k]
+	if ok && entry.fresh(time.Now()) {
+		s.hits++
+		s.mu.Unlock()
+		return entry.value, nil
+	}
+	s.misses++
+	generation := s.generation
+	watch := s.claimWatch(k.slot)
+	s.mu.Unlock()
+
+	if watch {
+		s.watch(k.slot)
+	}
+	value, err := s.Store.Get(key, applicationID, scope)
+	if err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	// A write or change reported while the value was read may have made it stale.
+	if s.generation == generation {
+		s.entries[k] = s.newEntry(value)
+	}
+	return value, nil
+}
+
+// GetMultiple retrieves the cached values of keys and reads the others from the wrapped
+// Store, in one read if it is a MultiGetStore.
+func (s *cacheStore) GetMultiple(keys []string, applicationID string, scope PreferenceScope) (map[string]interface{}, error) {
+	slot := prefSlot{applicationID, scope}
+	values := make(map[string]interface{}, len(keys))
+	var missing []string
+
+	s.mu.Lock()
+	now := time.Now()
+	for _, key := range keys {
+		entry, ok := s.entries[cacheKey{slot, key}]
+		if !ok || !entry.fresh(now) {
+			s.misses++
+			missing = append(missing, key)
+			continue
+		}
+		s.hits++
+		if entry.value != nil {
+			values[key] = entry.value
+		}
+	}
+	generation := s.generation
+	watch := len(missing) > 0 && s.claimWatch(slot)
+	s.mu.Unlock()
+
+	if len(missing) == 0 {
+		return values, nil
+	}
+	if watch {
+		s.watch(slot)
+	}
+	read, err := readSlot(s.Store, slot, missing)
+	if err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, key := range missing {
+		value := read[key]
+		if value != nil {
+			values[key] = value
+		}
+		if s.generation == generation {
+			s.entries[cacheKey{slot, key}] = s.newEntry(value)
+		}
+	}
+	return values, nil
+}
+
+// claimWatch reports whether the caller should start a watcher for slot, marking it watched.
+// s.mu must be held.
+func (s *cacheStore) claimWatch(slot prefSlot) bool {
+	if !s.opts.Watch || s.watched[slot] {
+		return false
+	}
+	s.watched[slot] = true
+	return true
+}
+
+func (s *cacheStore) newEntry(value interface{}) cacheEntry {
+	entry := cacheEntry{value: value}
+	if s.opts.TTL > 0 {
+		entry.expires = time.Now().Add(s.opts.TTL)
+	}
+	return entry
+}
+
+// Set sets a value in the wrapped Store and invalidates its cached value.
+func (s *cacheStore) Set(key string, value interface{}, applicationID string, scope PreferenceScope) error {
+	defer s.invalidate(prefSlot{applicationID, scope}, key)
+	return s.Store.Set(key, value, applicationID, scope)
+}
+
+// Delete removes a key from the wrapped Store and invalidates its cached value.
+func (s *cacheStore) Delete(key string, applicationID string, scope PreferenceScope) error {
+	defer s.invalidate(prefSlot{applicationID, scope}, key)
+	return s.Store.Delete(key, applicationID, scope)
+}
+
+// SetMultiple writes to the wrapped Store, in one write if it is a BatchStore, and invalidates
+// the cached values of the keys.
+func (s *cacheStore) SetMultiple(keysToSet map[string]interface{}, keysToRemove []string, applicationID string, scope PreferenceScope) error {
+	slot := prefSlot{applicationID, scope}
+	keys := make([]string, 0, len(keysToSet)+len(keysToRemove))
+	values := make(map[string]interface{}, len(keysToSet)+len(keysToRemove))
+	for _, key := range keysToRemove {
+		keys = append(keys, key)
+		values[key] = nil
+	}
+	for key, value := range keysToSet {
+		keys = append(keys, key)
+		values[key] = value
+	}
+	defer s.invalidate(slot, keys...)
+	return writeSlot(s.Store, slot, values)
+}
+
+func (s *cacheStore) invalidate(slot prefSlot, keys ...string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.generation++
+	for _, key := range keys {
+		delete(s.entries, cacheKey{slot, key})
+	}
+}
+
+// invalidateSlot drops every cached value of slot.
+func (s *cacheStore) invalidateSlot(slot prefSlot) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.generation++
+	for key := range s.entries {
+		if key.slot == slot {
+			delete(s.entries, key)
+		}
+	}
+}
+
+// watch invalidates the cached values of slot whenever a watcher reports a change to it.
+func (s *cacheStore) watch(slot prefSlot) {
+	events, err := s.Store.Watch(s.ctx, slot.appID, slot.scope, s.opts.WatchInterval)
+	if err != nil {
+		s.mu.Lock()
+		delete(s.watched, slot)
+		s.mu.Unlock()
+		return
+	}
+	go func() {
+		for e := range events {
+			s.invalidate(slot, e.Key)
+		}
+	}()
+}
+
+func (s *cacheStore) stats() CacheStats {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return CacheStats{Hits: s.hits, Misses: s.misses, Entries: len(s.entries)}
+}
@@ -0,0 +1,143 @@
+package mac_prefs
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestClientCache(t *testing.T) {
+	store := NewMemoryStore()
+	c := NewClient(WithStore(store), WithCache(CacheOptions{}))
+	defer c.Close()
+	if err := store.Set("tilesize", 48, "com.apple.dock", CurrentUserAnyHost); err != nil {
+		t.Fatal(err)
+	}
+
+	for i := 0; i < 3; i++ {
+		if got, err := c.Get("tilesize", "com.apple.dock", CurrentUserAnyHost); err != nil || got != 48 {
+			t.Fatalf("Get() = %#v, %v, want 48", got, err)
+		}
+	}
+	if _, err := c.Get("missing", "com.apple.dock", CurrentUserAnyHost); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := c.Get("missing", "com.apple.dock", CurrentUserAnyHost); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := c.CacheStats(), (CacheStats{Hits: 3, Misses: 2, Entries: 2}); got != want {
+		t.Errorf("CacheStats() = %+v, want %+v", got, want)
+	}
+
+	// A change made behind the Client's back is not seen without a TTL or watcher.
+	if err := store.Set("tilesize", 64, "com.apple.dock", CurrentUserAnyHost); err != nil {
+		t.Fatal(err)
+	}
+	if got, _ := c.Get("tilesize", "com.apple.dock", CurrentUserAnyHost); got != 48 {
+		t.Errorf("Get() = %#v, want the cached 48", got)
+	}
+
+	// Writes through the Client invalidate the key.
+	if err := c.Set("tilesize", 32, "com.apple.dock", CurrentUserAnyHost); err != nil {
+		t.Fatal(err)
+	}
+	if got, _ := c.Get("tilesize", "com.apple.dock", CurrentUserAnyHost); got != 32 {
+		t.Errorf("Get() after Set = %#v, want 32", got)
+	}
+	tx := c.Begin()
+	if err := tx.Set("tilesize", 16, "com.apple.dock", CurrentUserAnyHost); err != nil {
+		t.Fatal(err)
+	}
+	if err := tx.Commit(); err != nil {
+		t.Fatal(err)
+	}
+	if got, _ := c.Get("tilesize", "com.apple.dock", CurrentUserAnyHost); got != 16 {
+		t.Errorf("Get() after Commit = %#v, want 16", got)
+	}
+}
+
+func TestClientCacheGetMany(t *testing.T) {
+	store := NewMemoryStore()
+	c := NewClient(WithStore(store), WithCache(CacheOptions{}))
+	defer c.Close()
+	if err := store.Set("tilesize", 48, "com.apple.dock", CurrentUserAnyHost); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := c.Get("tilesize", "com.apple.dock", CurrentUserAnyHost); err != nil {
+		t.Fatal(err)
+	}
+	keys := []string{"tilesize", "autohide"}
+	want := map[string]interface{}{"tilesize": 48}
+	for i := 0; i < 2; i++ {
+		if got, err := c.GetMany(keys, "com.apple.dock", CurrentUserAnyHost); err != nil || !reflect.DeepEqual(got, want) {
+			t.Fatalf("GetMany() = %#v, %v, want %#v", got, err, want)
+		}
+	}
+	if got, want := c.CacheStats(), (CacheStats{Hits: 3, Misses: 2, Entries: 2}); got != want {
+		t.Errorf("CacheStats() = %+v, want %+v", got, want)
+	}
+
+	if err := c.Set("autohide", true, "com.apple.dock", CurrentUserAnyHost); err != nil {
+		t.Fatal(err)
+	}
+	want["autohide"] = true
+	if got, _ := c.GetMany(keys, "com.apple.dock", CurrentUserAnyHost); !reflect.DeepEqual(got, want) {
+		t.Errorf("GetMany() after Set = %#v, want %#v", got, want)
+	}
+}
+
+func TestClientCacheTTL(t *testing.T) {
+	store := NewMemoryStore()
+	c := NewClient(WithStore(store), WithCache(CacheOptions{TTL: 20 * time.Millisecond}))
+	if _, err := c.Get("tilesize", "com.apple.dock", CurrentUserAnyHost); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.Set("tilesize", 48, "com.apple.dock", CurrentUserAnyHost); err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(30 * time.Millisecond)
+	if got, _ := c.Get("tilesize", "com.apple.dock", CurrentUserAnyHost); got != 48 {
+		t.Errorf("Get() after the TTL = %#v, want 48", got)
+	}
+}
+
+func TestClientCacheWatch(t *testing.T) {
+	store := NewMemoryStore()
+	c := NewClient(WithStore(store), WithCache(CacheOptions{Watch: true}))
+	defer c.Close()
+	if _, err := c.Get("tilesize", "com.apple.dock", CurrentUserAnyHost); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.Set("tilesize", 48, "com.apple.dock", CurrentUserAnyHost); err != nil {
+		t.Fatal(err)
+	}
+
+	deadline := time.Now().Add(time.Second)
+	for {
+		got, err := c.Get("tilesize", "com.apple.dock", CurrentUserAnyHost)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got == 48 {
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("Get() = %#v, the watcher did not invalidate the cached value", got)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestClientCacheDisabled(t *testing.T) {
+	c := NewClient(WithStore(NewMemoryStore()))
+	if _, err := c.Get("tilesize", "com.apple.dock", CurrentUserAnyHost); err != nil {
+		t.Fatal(err)
+	}
+	if got := c.CacheStats(); got != (CacheStats{}) {
+		t.Errorf("CacheStats() = %+v without a cache", got)
+	}
+	if err := c.Close(); err != nil {
+		t.Errorf("Close() error = %v", err)
+	}
+}
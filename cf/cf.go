@@ -0,0 +1,655 @@
+//go:build darwin && cgo
+
+package cf
+
+/*
+#cgo LDFLAGS: -framework CoreFoundation
+
+#include <stdlib.h>
+#include <string.h>
+#include <CoreFoundation/CoreFoundation.h>
+
+// cStringPtr returns the internal UTF-8 buffer of a string of length UTF-16 units, or NULL if
+// CoreFoundation does not store it as a C string or it contains non-ASCII or NUL characters,
+// so that its byte length is not length.
+static const char *cStringPtr(CFStringRef str, CFIndex length) {
+	const char *p = CFStringGetCStringPtr(str, kCFStringEncodingUTF8);
+	if (p == NULL || strlen(p) != (size_t)length) {
+		return NULL;
+	}
+	return p;
+}
+*/
+import "C"
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"net/url"
+	"reflect"
+	"sync"
+	"time"
+	"unsafe"
+
+	"github.com/weswhet/mac_prefs/internal/prefstag"
+)
+
+// absoluteTimeEpoch is the reference date of CFAbsoluteTime.
+var absoluteTimeEpoch = time.Date(2001, time.January, 1, 0, 0, 0, 0, time.UTC)
+
+// NewString creates a CFString.
+func NewString(s string) (*Ref, error) {
+	ref, err := newString(s)
+	if err != nil {
+		return nil, err
+	}
+	return Own(ref), nil
+}
+
+// GoString converts a CFString to a Go string.
+func GoString(ref *Ref) string {
+	return goString(ref.Raw())
+}
+
+// NewData creates a CFData holding a copy of b.
+func NewData(b []byte) (*Ref, error) {
+	ref, err := newData(b)
+	if err != nil {
+		return nil, err
+	}
+	return Own(ref), nil
+}
+
+// GoBytes copies the bytes of a CFData.
+func GoBytes(ref *Ref) []byte {
+	return goBytes(ref.Raw())
+}
+
+// NewDate creates a CFDate.
+func NewDate(t time.Time) *Ref {
+	return Own(newDate(t))
+}
+
+// GoTime converts a CFDate to a UTC time.Time, rounded to the nearest nanosecond.
+func GoTime(ref *Ref) time.Time {
+	return goTime(ref.Raw())
+}
+
+// Describe returns the CFCopyDescription output for an object, which shows its true
+// CoreFoundation type and structure. A nil Ref is described as "(null)".
+func Describe(ref *Ref) string {
+	if ref == nil {
+		return "(null)"
+	}
+	desc := Own(TypeRef(C.CFCopyDescription(C.CFTypeRef(ref.Raw()))))
+	if desc == nil {
+		return ""
+	}
+	defer desc.Close()
+	return GoString(desc)
+}
+
+// GoStrings converts a CFArray of CFStrings to a string slice. Elements that are not strings
+// are skipped.
+func GoStrings(ref *Ref) []string {
+	cfArray := C.CFArrayRef(ref.Raw())
+	count := C.CFArrayGetCount(cfArray)
+	result := make([]string, 0, count)
+	for i := C.CFIndex(0); i < count; i++ {
+		item := C.CFTypeRef(C.CFArrayGetValueAtIndex(cfArray, i))
+		if C.CFGetTypeID(item) != C.CFStringGetTypeID() {
+			continue
+		}
+		result = append(result, goString(TypeRef(item)))
+	}
+	return result
+}
+
+// FromGo converts a Go value to the corresponding CoreFoundation object. A nil value or nil
+// pointer returns a nil Ref and no error. Map entries holding a nil pointer are left out, nil
+// elements inside slices and maps are converted to kCFNull, and nil pointers inside slices are
+// errors.
+//
+// Parameters:
+//   - value: The value to convert.
+//
+// Returns:
+//   - *Ref: The new object, to be closed by the caller.
+//   - error: An error if the value or one of its elements has an unsupported type.
+func FromGo(value interface{}) (*Ref, error) {
+	ref, err := fromGo(value)
+	if err != nil {
+		return nil, err
+	}
+	return Own(ref), nil
+}
+
+// NewArray converts a slice to a CFArray, converting each element with FromGo.
+func NewArray(slice interface{}) (*Ref, error) {
+	ref, err := newArray(slice)
+	if err != nil {
+		return nil, err
+	}
+	return Own(ref), nil
+}
+
+// NewDictionary converts a string-keyed map to a CFDictionary, converting each value with
+// FromGo.
+func NewDictionary(m interface{}) (*Ref, error) {
+	ref, err := newDictionary(m)
+	if err != nil {
+		return nil, err
+	}
+	return Own(ref), nil
+}
+
+// ToGo converts a CoreFoundation property list object to a Go value. The Ref stays open.
+//
+// Parameters:
+//   - ref: The object to convert.
+//
+// Returns:
+//   - interface{}: The converted value.
+//   - error: An error if the object or one of its elements has an unsupported type.
+func ToGo(ref *Ref) (interface{}, error) {
+	return toGo(ref.Raw())
+}
+
+// RoundTrip converts a Go value to CoreFoundation and back, releasing the intermediate
+// object. It is the entry point for fuzzing the converters.
+func RoundTrip(value interface{}) (interface{}, error) {
+	ref, err := FromGo(value)
+	if err != nil {
+		return nil, err
+	}
+	if ref == nil {
+		return nil, nil
+	}
+	defer ref.Close()
+	return ToGo(ref)
+}
+
+// ParsePlist parses XML or binary property list data.
+//
+// Parameters:
+//   - data: The serialized property list.
+//
+// Returns:
+//   - interface{}: The top level value, converted with ToGo.
+//   - error: An error if the data is not a valid property list.
+func ParsePlist(data []byte) (interface{}, error) {
+	cfData, err := NewData(data)
+	if err != nil {
+		return nil, err
+	}
+	defer cfData.Close()
+
+	var cfErr C.CFErrorRef
+	cfPlist := Own(TypeRef(C.CFPropertyListCreateWithData(C.kCFAllocatorDefault, C.CFDataRef(cfData.Raw()), C.kCFPropertyListImmutable, nil, &cfErr)))
+	if cfPlist == nil {
+		return nil, propertyListError("CFPropertyListCreateWithData", cfErr)
+	}
+	defer cfPlist.Close()
+
+	return ToGo(cfPlist)
+}
+
+// MarshalPlist serializes a value as property list data.
+//
+// Parameters:
+//   - value: The top level value, usually a map[string]interface{}.
+//   - format: FormatXML or FormatBinary.
+//
+// Returns:
+//   - []byte: The serialized property list.
+//   - error: An error if the format is unknown or the value cannot be converted.
+func MarshalPlist(value interface{}, format Format) ([]byte, error) {
+	if format != FormatXML && format != FormatBinary {
+		return nil, fmt.Errorf("cf: unsupported plist format %d", int(format))
+	}
+	ref, err := FromGo(value)
+	if err != nil {
+		return nil, err
+	}
+	if ref == nil {
+		return nil, errors.New("cf: cannot marshal a nil value")
+	}
+	defer ref.Close()
+
+	return MarshalRef(ref, format)
+}
+
+// MarshalRef serializes a CoreFoundation property list object as property list data without
+// converting it to Go, so values the Go conversion cannot express are kept exactly.
+//
+// Parameters:
+//   - ref: The property list object.
+//   - format: FormatXML or FormatBinary.
+//
+// Returns:
+//   - []byte: The serialized property list.
+//   - error: An error if the format is unknown or ref is not a property list object.
+func MarshalRef(ref *Ref, format Format) ([]byte, error) {
+	var cfFormat C.CFPropertyListFormat
+	switch format {
+	case FormatXML:
+		cfFormat = C.kCFPropertyListXMLFormat_v1_0
+	case FormatBinary:
+		cfFormat = C.kCFPropertyListBinaryFormat_v1_0
+	default:
+		return nil, fmt.Errorf("cf: unsupported plist format %d", int(format))
+	}
+	if ref == nil {
+		return nil, errors.New("cf: cannot marshal a nil value")
+	}
+
+	var cfErr C.CFErrorRef
+	data := Own(TypeRef(C.CFPropertyListCreateData(C.kCFAllocatorDefault, C.CFPropertyListRef(ref.Raw()), cfFormat, 0, &cfErr)))
+	if data == nil {
+		return nil, propertyListError("CFPropertyListCreateData", cfErr)
+	}
+	defer data.Close()
+
+	return GoBytes(data), nil
+}
+
+// propertyListError describes a failed property list call, consuming cfErr.
+func propertyListError(call string, cfErr C.CFErrorRef) error {
+	owned := Own(TypeRef(cfErr))
+	if owned == nil {
+		return fmt.Errorf("cf: %s failed", call)
+	}
+	defer owned.Close()
+	desc := Own(TypeRef(C.CFErrorCopyDescription(cfErr)))
+	defer desc.Close()
+	return fmt.Errorf("cf: %s failed: %s", call, GoString(desc))
+}
+
+// The functions below work on raw references. Those that create objects return them owned
+// by the caller.
+
+func newString(s string) (TypeRef, error) {
+	cstr := C.CString(s)
+	defer C.free(unsafe.Pointer(cstr))
+	cfStr := C.CFStringCreateWithCString(C.kCFAllocatorDefault, cstr, C.kCFStringEncodingUTF8)
+	if cfStr == 0 {
+		return 0, errors.New("cf: CFStringCreateWithCString failed")
+	}
+	return TypeRef(cfStr), nil
+}
+
+// maxPooledStringBuffer is the size above which goString does not return its buffer to
+// stringBuffers, so one very long string does not pin memory.
+const maxPooledStringBuffer = 64 << 10
+
+// stringBuffers holds the *[]byte buffers goString copies strings into.
+var stringBuffers = sync.Pool{
+	New: func() interface{} {
+		b := make([]byte, 256)
+		return &b
+	},
+}
+
+// goString converts a CFString to UTF-8. ASCII strings are copied straight from the internal
+// buffer of the CFString; other strings are encoded into a pooled buffer with a single
+// CFStringGetBytes call.
+func goString(ref TypeRef) string {
+	cfStr := C.CFStringRef(ref)
+	length := C.CFStringGetLength(cfStr)
+	if length == 0 {
+		return ""
+	}
+	if p := C.cStringPtr(cfStr, length); p != nil {
+		return C.GoStringN(p, C.int(length))
+	}
+
+	size := int(C.CFStringGetMaximumSizeForEncoding(length, C.kCFStringEncodingUTF8))
+	bp := stringBuffers.Get().(*[]byte)
+	if cap(*bp) < size {
+		*bp = make([]byte, size)
+	}
+	buffer := (*bp)[:size]
+
+	var used C.CFIndex
+	var result string
+	cfRange := C.CFRange{location: 0, length: length}
+	if C.CFStringGetBytes(cfStr, cfRange, C.kCFStringEncodingUTF8, 0, C.false, (*C.UInt8)(&buffer[0]), C.CFIndex(size), &used) != 0 {
+		result = string(buffer[:used])
+	}
+	if cap(buffer) <= maxPooledStringBuffer {
+		stringBuffers.Put(bp)
+	}
+	return result
+}
+
+func newData(b []byte) (TypeRef, error) {
+	if uint64(len(b)) > math.MaxUint32 {
+		return 0, errors.New("cf: data is too large")
+	}
+	var p *C.UInt8
+	if len(b) > 0 {
+		p = (*C.UInt8)(&b[0])
+	}
+	cfData := C.CFDataCreate(C.kCFAllocatorDefault, p, C.CFIndex(len(b)))
+	if cfData == 0 {
+		return 0, errors.New("cf: CFDataCreate failed")
+	}
+	return TypeRef(cfData), nil
+}
+
+func goBytes(ref TypeRef) []byte {
+	cfData := C.CFDataRef(ref)
+	return C.GoBytes(unsafe.Pointer(C.CFDataGetBytePtr(cfData)), C.int(C.CFDataGetLength(cfData)))
+}
+
+func newDate(t time.Time) TypeRef {
+	seconds := t.UTC().Sub(absoluteTimeEpoch).Seconds()
+	return TypeRef(C.CFDateCreate(C.kCFAllocatorDefault, C.CFAbsoluteTime(seconds)))
+}
+
+func goTime(ref TypeRef) time.Time {
+	return absoluteTime(float64(C.CFDateGetAbsoluteTime(C.CFDateRef(ref))))
+}
+
+// absoluteTime converts a CFAbsoluteTime to a time.Time.
+func absoluteTime(seconds float64) time.Time {
+	nanos := int64(math.Round(seconds * float64(time.Second)))
+	return absoluteTimeEpoch.Add(time.Duration(nanos))
+}
+
+func newURL(u *url.URL) (TypeRef, error) {
+	s, err := newString(u.String())
+	if err != nil {
+		return 0, err
+	}
+	defer C.CFRelease(C.CFTypeRef(s))
+	ref := C.CFURLCreateWithString(C.kCFAllocatorDefault, C.CFStringRef(s), 0)
+	if ref == 0 {
+		return 0, fmt.Errorf("cf: invalid URL %q", u.String())
+	}
+	return TypeRef(ref), nil
+}
+
+func goURL(ref TypeRef) (*url.URL, error) {
+	abs := C.CFURLCopyAbsoluteURL(C.CFURLRef(ref))
+	if abs == 0 {
+		return nil, errors.New("cf: invalid CFURL")
+	}
+	defer C.CFRelease(C.CFTypeRef(abs))
+	u, err := url.Parse(goString(TypeRef(C.CFURLGetString(abs))))
+	if err != nil {
+		return nil, fmt.Errorf("cf: invalid CFURL: %v", err)
+	}
+	return u, nil
+}
+
+func fromGo(value interface{}) (TypeRef, error) {
+	if value == nil || isNilPointer(value) {
+		return 0, nil
+	}
+	if m, ok := value.(Marshaler); ok {
+		marshaled, err := m.MarshalPrefs()
+		if err != nil {
+			return 0, fmt.Errorf("error marshaling %T: %v", value, err)
+		}
+		return fromGo(marshaled)
+	}
+
+	switch v := value.(type) {
+	case string:
+		return newString(v)
+	case []byte:
+		return newData(v)
+	case bool:
+		if v {
+			return TypeRef(C.CFRetain(C.CFTypeRef(C.kCFBooleanTrue))), nil
+		}
+		return TypeRef(C.CFRetain(C.CFTypeRef(C.kCFBooleanFalse))), nil
+	case time.Time:
+		return newDate(v), nil
+	case Null:
+		return TypeRef(C.CFRetain(C.CFTypeRef(C.kCFNull))), nil
+	case [16]byte:
+		return newString(fmt.Sprintf("%X-%X-%X-%X-%X", v[0:4], v[4:6], v[6:8], v[8:10], v[10:16]))
+	case *url.URL:
+		return newURL(v)
+	case url.URL:
+		return newURL(&v)
+	case json.Number:
+		if i, err := v.Int64(); err == nil {
+			return fromGo(i)
+		}
+		f, err := v.Float64()
+		if err != nil {
+			return 0, fmt.Errorf("cf: invalid json.Number %q", string(v))
+		}
+		return fromGo(f)
+	case time.Duration:
+		seconds := v.Seconds()
+		return TypeRef(C.CFNumberCreate(C.kCFAllocatorDefault, C.kCFNumberDoubleType, unsafe.Pointer(&seconds))), nil
+	case int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64, float32, float64:
+		var numRef C.CFNumberRef
+		numberValue := reflect.ValueOf(v)
+		switch numberValue.Kind() {
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+			int64Value := numberValue.Int()
+			numRef = C.CFNumberCreate(C.kCFAllocatorDefault, C.kCFNumberLongLongType, unsafe.Pointer(&int64Value))
+		case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+			uint64Value := numberValue.Uint()
+			if uint64Value > math.MaxInt64 {
+				return 0, errors.New("cf: unsigned integer overflows signed 64-bit CFNumber")
+			}
+			int64Value := int64(uint64Value)
+			numRef = C.CFNumberCreate(C.kCFAllocatorDefault, C.kCFNumberLongLongType, unsafe.Pointer(&int64Value))
+		case reflect.Float32, reflect.Float64:
+			floatValue := numberValue.Float()
+			numRef = C.CFNumberCreate(C.kCFAllocatorDefault, C.kCFNumberDoubleType, unsafe.Pointer(&floatValue))
+		}
+		if numRef == 0 {
+			return 0, errors.New("cf: CFNumberCreate failed")
+		}
+		return TypeRef(numRef), nil
+	}
+
+	rv := reflect.ValueOf(value)
+	switch {
+	case rv.Kind() == reflect.Slice:
+		return newArray(value)
+	case rv.Kind() == reflect.Map && rv.Type().Key().Kind() == reflect.String:
+		return newDictionary(value)
+	case rv.Kind() == reflect.Struct:
+		return newDictionary(prefstag.Values(rv))
+	case rv.Kind() == reflect.Ptr:
+		return fromGo(rv.Elem().Interface())
+	}
+	return 0, fmt.Errorf("cf: unsupported type: %T", value)
+}
+
+// fromElement converts an element of an array or dictionary. A nil element is kCFNull, which
+// ToGo returns as nil, so values read from a domain can be written back unchanged.
+func fromElement(value interface{}) (TypeRef, error) {
+	if value == nil {
+		return fromGo(Null{})
+	}
+	return fromGo(value)
+}
+
+func newArray(slice interface{}) (TypeRef, error) {
+	sliceValue := reflect.ValueOf(slice)
+	if sliceValue.Kind() != reflect.Slice {
+		return 0, fmt.Errorf("cf: unsupported slice type: %T", slice)
+	}
+
+	cfValues := make([]C.CFTypeRef, 0, sliceValue.Len())
+	defer func() {
+		for _, value := range cfValues {
+			C.CFRelease(value)
+		}
+	}()
+	for i := 0; i < sliceValue.Len(); i++ {
+		item, err := fromElement(sliceValue.Index(i).Interface())
+		if err != nil {
+			return 0, fmt.Errorf("error converting array item at index %d: %v", i, err)
+		}
+		if item == 0 {
+			return 0, fmt.Errorf("cf: nil array item at index %d", i)
+		}
+		cfValues = append(cfValues, C.CFTypeRef(item))
+	}
+
+	var valuePtr *unsafe.Pointer
+	if len(cfValues) > 0 {
+		valuePtr = (*unsafe.Pointer)(unsafe.Pointer(&cfValues[0]))
+	}
+	cfArray := C.CFArrayCreate(C.kCFAllocatorDefault, valuePtr, C.CFIndex(len(cfValues)), &C.kCFTypeArrayCallBacks)
+	if cfArray == 0 {
+		return 0, errors.New("cf: CFArrayCreate failed")
+	}
+	return TypeRef(cfArray), nil
+}
+
+func newDictionary(m interface{}) (TypeRef, error) {
+	mapValue := reflect.ValueOf(m)
+	if mapValue.Kind() != reflect.Map || mapValue.Type().Key().Kind() != reflect.String {
+		return 0, fmt.Errorf("cf: unsupported map type: %T", m)
+	}
+
+	keys := make([]C.CFTypeRef, 0, mapValue.Len())
+	values := make([]C.CFTypeRef, 0, mapValue.Len())
+	defer func() {
+		for i := range keys {
+			C.CFRelease(keys[i])
+		}
+		for i := range values {
+			C.CFRelease(values[i])
+		}
+	}()
+	for _, keyValue := range mapValue.MapKeys() {
+		key := keyValue.String()
+		item := mapValue.MapIndex(keyValue).Interface()
+		if isNilPointer(item) {
+			continue
+		}
+		valueRef, err := fromElement(item)
+		if err != nil {
+			return 0, fmt.Errorf("error converting value for key %s: %v", key, err)
+		}
+		if valueRef == 0 {
+			return 0, fmt.Errorf("cf: nil value for key %s", key)
+		}
+		values = append(values, C.CFTypeRef(valueRef))
+
+		keyRef, err := newString(key)
+		if err != nil {
+			return 0, fmt.Errorf("error converting key to CFString: %v", err)
+		}
+		keys = append(keys, C.CFTypeRef(keyRef))
+	}
+
+	var keyPtr, valuePtr *unsafe.Pointer
+	if len(keys) > 0 {
+		keyPtr = (*unsafe.Pointer)(unsafe.Pointer(&keys[0]))
+		valuePtr = (*unsafe.Pointer)(unsafe.Pointer(&values[0]))
+	}
+	cfDict := C.CFDictionaryCreate(C.kCFAllocatorDefault, keyPtr, valuePtr, C.CFIndex(len(keys)), &C.kCFTypeDictionaryKeyCallBacks, &C.kCFTypeDictionaryValueCallBacks)
+	if cfDict == 0 {
+		return 0, errors.New("cf: CFDictionaryCreate failed")
+	}
+	return TypeRef(cfDict), nil
+}
+
+func toGo(ref TypeRef) (interface{}, error) {
+	if ref == 0 {
+		return nil, errors.New("cf: NULL reference")
+	}
+	var count C.CFIndex
+	switch C.CFGetTypeID(C.CFTypeRef(ref)) {
+	case C.CFArrayGetTypeID():
+		count = C.CFArrayGetCount(C.CFArrayRef(ref))
+	case C.CFDictionaryGetTypeID():
+		count = C.CFDictionaryGetCount(C.CFDictionaryRef(ref))
+	}
+	if count >= flattenThreshold {
+		return toGoFlat(ref)
+	}
+	return toGoEach(ref)
+}
+
+// toGoEach converts ref with one cgo call per element, converting the elements of arrays and
+// dictionaries with toGo.
+func toGoEach(ref TypeRef) (interface{}, error) {
+	cfType := C.CFTypeRef(ref)
+	switch C.CFGetTypeID(cfType) {
+	case C.CFStringGetTypeID():
+		return goString(ref), nil
+	case C.CFDataGetTypeID():
+		return goBytes(ref), nil
+	case C.CFBooleanGetTypeID():
+		return C.CFBooleanGetValue(C.CFBooleanRef(cfType)) != 0, nil
+	case C.CFDateGetTypeID():
+		return goTime(ref), nil
+	case C.CFNullGetTypeID():
+		return nil, nil
+	case C.CFURLGetTypeID():
+		return goURL(ref)
+	case C.CFNumberGetTypeID():
+		var intValue int
+		var floatValue float64
+		switch C.CFNumberGetType(C.CFNumberRef(cfType)) {
+		case C.kCFNumberSInt8Type, C.kCFNumberSInt16Type, C.kCFNumberSInt32Type, C.kCFNumberSInt64Type,
+			C.kCFNumberCharType, C.kCFNumberShortType, C.kCFNumberIntType, C.kCFNumberLongType, C.kCFNumberLongLongType,
+			C.kCFNumberCFIndexType, C.kCFNumberNSIntegerType:
+			C.CFNumberGetValue(C.CFNumberRef(cfType), C.kCFNumberLongLongType, unsafe.Pointer(&intValue))
+			return intValue, nil
+		case C.kCFNumberFloat32Type, C.kCFNumberFloat64Type, C.kCFNumberFloatType, C.kCFNumberDoubleType:
+			C.CFNumberGetValue(C.CFNumberRef(cfType), C.kCFNumberDoubleType, unsafe.Pointer(&floatValue))
+			return floatValue, nil
+		default:
+			return nil, errors.New("cf: unsupported CFNumber type")
+		}
+	case C.CFArrayGetTypeID():
+		cfArray := C.CFArrayRef(cfType)
+		count := C.CFArrayGetCount(cfArray)
+		result := make([]interface{}, count)
+		for i := C.CFIndex(0); i < count; i++ {
+			item, err := toGo(TypeRef(C.CFArrayGetValueAtIndex(cfArray, i)))
+			if err != nil {
+				return nil, fmt.Errorf("error converting array item at index %d: %v", i, err)
+			}
+			result[i] = item
+		}
+		return result, nil
+	case C.CFDictionaryGetTypeID():
+		cfDict := C.CFDictionaryRef(cfType)
+		count := C.CFDictionaryGetCount(cfDict)
+		if count == 0 {
+			return map[string]interface{}{}, nil
+		}
+		keys := make([]C.CFTypeRef, count)
+		values := make([]C.CFTypeRef, count)
+		C.CFDictionaryGetKeysAndValues(cfDict, (*unsafe.Pointer)(unsafe.Pointer(&keys[0])), (*unsafe.Pointer)(unsafe.Pointer(&values[0])))
+		result := make(map[string]interface{}, count)
+		for i := C.CFIndex(0); i < count; i++ {
+			if C.CFGetTypeID(keys[i]) != C.CFStringGetTypeID() {
+				return nil, errors.New("cf: dictionary key is not a string")
+			}
+			key := goString(TypeRef(keys[i]))
+			value, err := toGo(TypeRef(values[i]))
+			if err != nil {
+				return nil, fmt.Errorf("error converting dictionary value for key %s: %v", key, err)
+			}
+			result[key] = value
+		}
+		return result, nil
+	default:
+		return nil, errors.New("cf: unsupported CFTypeRef type")
+	}
+}
+
+// isNilPointer reports whether value is a nil pointer. A nil pointer converts like a nil value,
+// except that dictionary entries holding one are left out.
+func isNilPointer(value interface{}) bool {
+	rv := reflect.ValueOf(value)
+	return rv.Kind() == reflect.Ptr && rv.IsNil()
+}
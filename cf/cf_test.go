@@ -0,0 +1,406 @@
+//go:build darwin && cgo
+
+package cf
+
+import (
+	"encoding/json"
+	"errors"
+	"math"
+	"net/url"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+	"unicode/utf8"
+)
+
+func TestRoundTrip(t *testing.T) {
+	now := time.Date(2024, 2, 29, 12, 30, 0, 123456000, time.UTC)
+	tests := []struct {
+		value interface{}
+		want  interface{}
+	}{
+		{"héllo", "héllo"},
+		{"", ""},
+		{[]byte{0, 1, 2}, []byte{0, 1, 2}},
+		{[]byte{}, []byte{}},
+		{true, true},
+		{false, false},
+		{int8(-8), -8},
+		{uint32(math.MaxUint32), math.MaxUint32},
+		{int64(math.MinInt64), math.MinInt64},
+		{float32(1.5), 1.5},
+		{now, now},
+		{[]string{"a", "b"}, []interface{}{"a", "b"}},
+		{[]interface{}{}, []interface{}{}},
+		{map[string]int{"a": 1}, map[string]interface{}{"a": 1}},
+		{
+			map[string]interface{}{"nested": map[string]interface{}{"list": []interface{}{1, "x", map[string]interface{}{}}}},
+			map[string]interface{}{"nested": map[string]interface{}{"list": []interface{}{1, "x", map[string]interface{}{}}}},
+		},
+	}
+	for _, tt := range tests {
+		got, err := RoundTrip(tt.value)
+		if err != nil {
+			t.Errorf("RoundTrip(%#v) error = %v", tt.value, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("RoundTrip(%#v) = %#v, want %#v", tt.value, got, tt.want)
+		}
+	}
+
+	if got, err := RoundTrip(nil); got != nil || err != nil {
+		t.Errorf("RoundTrip(nil) = %v, %v", got, err)
+	}
+}
+
+func TestFromGoErrors(t *testing.T) {
+	for _, value := range []interface{}{
+		make(chan int),
+		uint64(math.MaxUint64),
+		map[int]string{1: "a"},
+		[]*int{nil},
+		map[string]interface{}{"a": []interface{}{make(chan int)}},
+	} {
+		if ref, err := FromGo(value); err == nil {
+			ref.Close()
+			t.Errorf("FromGo(%#v) expected error", value)
+		}
+	}
+}
+
+func TestPlist(t *testing.T) {
+	values := map[string]interface{}{"name": "dock", "size": 48, "list": []interface{}{true, 1.5}}
+	for _, format := range []Format{FormatXML, FormatBinary} {
+		data, err := MarshalPlist(values, format)
+		if err != nil {
+			t.Fatalf("MarshalPlist(%d) error = %v", format, err)
+		}
+		got, err := ParsePlist(data)
+		if err != nil {
+			t.Fatalf("ParsePlist(%d) error = %v", format, err)
+		}
+		if !reflect.DeepEqual(got, values) {
+			t.Errorf("ParsePlist(MarshalPlist(%d)) = %#v, want %#v", format, got, values)
+		}
+	}
+
+	if _, err := MarshalPlist(values, Format(99)); err == nil {
+		t.Error("MarshalPlist() expected error for an unknown format")
+	}
+	if _, err := ParsePlist([]byte("not a plist")); err == nil {
+		t.Error("ParsePlist() expected error for invalid data")
+	}
+}
+
+func TestMarshalRef(t *testing.T) {
+	ref, err := FromGo([]interface{}{"a", 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ref.Close()
+
+	data, err := MarshalRef(ref, FormatBinary)
+	if err != nil {
+		t.Fatalf("MarshalRef() error = %v", err)
+	}
+	if got, err := ParsePlist(data); err != nil || !reflect.DeepEqual(got, []interface{}{"a", 1}) {
+		t.Fatalf("ParsePlist(MarshalRef()) = %#v, %v", got, err)
+	}
+	if _, err := MarshalRef(nil, FormatBinary); err == nil {
+		t.Error("MarshalRef(nil) expected error")
+	}
+	if _, err := MarshalRef(ref, Format(99)); err == nil {
+		t.Error("MarshalRef() expected error for an unknown format")
+	}
+}
+
+func FuzzParsePlist(f *testing.F) {
+	for _, format := range []Format{FormatXML, FormatBinary} {
+		data, err := MarshalPlist(map[string]interface{}{"a": []interface{}{1, "b", true}}, format)
+		if err != nil {
+			f.Fatal(err)
+		}
+		f.Add(data)
+	}
+	f.Fuzz(func(t *testing.T, data []byte) {
+		value, err := ParsePlist(data)
+		if err != nil {
+			return
+		}
+		if _, err := RoundTrip(value); err != nil {
+			t.Fatalf("RoundTrip() of a parsed plist error = %v", err)
+		}
+	})
+}
+
+func FuzzRoundTripString(f *testing.F) {
+	f.Add("")
+	f.Add("héllo")
+	f.Fuzz(func(t *testing.T, s string) {
+		// CFStringCreateWithCString stops at NUL and rejects invalid UTF-8.
+		if !utf8.ValidString(s) || strings.ContainsRune(s, 0) {
+			return
+		}
+		got, err := RoundTrip(s)
+		if err != nil {
+			t.Fatalf("RoundTrip(%q) error = %v", s, err)
+		}
+		if got != s {
+			t.Fatalf("RoundTrip(%q) = %#v", s, got)
+		}
+	})
+}
+
+func TestEmptyCollections(t *testing.T) {
+	tests := []struct {
+		value interface{}
+		want  interface{}
+	}{
+		{[]interface{}{}, []interface{}{}},
+		{[]string(nil), []interface{}{}},
+		{map[string]interface{}{}, map[string]interface{}{}},
+		{map[string]string(nil), map[string]interface{}{}},
+		{
+			map[string]interface{}{"list": []int{}, "dict": map[string]interface{}{"inner": []interface{}{}}},
+			map[string]interface{}{"list": []interface{}{}, "dict": map[string]interface{}{"inner": []interface{}{}}},
+		},
+	}
+	for _, tt := range tests {
+		got, err := RoundTrip(tt.value)
+		if err != nil {
+			t.Errorf("RoundTrip(%#v) error = %v", tt.value, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("RoundTrip(%#v) = %#v, want %#v", tt.value, got, tt.want)
+		}
+	}
+
+	for _, format := range []Format{FormatXML, FormatBinary} {
+		data, err := MarshalPlist(map[string]interface{}{}, format)
+		if err != nil {
+			t.Fatalf("MarshalPlist(%d) of an empty dictionary error = %v", format, err)
+		}
+		if got, err := ParsePlist(data); err != nil || !reflect.DeepEqual(got, map[string]interface{}{}) {
+			t.Fatalf("ParsePlist(%d) = %#v, %v, want an empty dictionary", format, got, err)
+		}
+	}
+}
+
+func TestNumberTypeFidelity(t *testing.T) {
+	tests := []struct {
+		value interface{}
+		want  interface{}
+	}{
+		{float64(0), float64(0)},
+		{float32(0), float64(0)},
+		{-2.0, -2.0},
+		{3.0, 3.0},
+		{-0.25, -0.25},
+		{0, 0},
+		{-1, -1},
+		{int64(0), 0},
+	}
+	for _, tt := range tests {
+		got, err := RoundTrip(tt.value)
+		if err != nil {
+			t.Fatalf("RoundTrip(%#v) error = %v", tt.value, err)
+		}
+		if reflect.TypeOf(got) != reflect.TypeOf(tt.want) || got != tt.want {
+			t.Errorf("RoundTrip(%#v) = %#v (%T), want %#v (%T)", tt.value, got, got, tt.want, tt.want)
+		}
+	}
+}
+
+func TestDatePrecision(t *testing.T) {
+	for _, want := range []time.Time{
+		time.Date(2023, 5, 1, 12, 0, 0, 250000000, time.UTC),
+		time.Date(2023, 5, 1, 12, 0, 0, 1000000, time.UTC),
+		time.Date(2024, 2, 29, 23, 59, 59, 999999000, time.UTC),
+		time.Date(1999, 12, 31, 23, 59, 59, 500000000, time.UTC),
+		time.Date(1970, 1, 1, 0, 0, 0, 123000000, time.UTC),
+		time.Date(2100, 6, 15, 8, 30, 0, 42000, time.FixedZone("PDT", -7*3600)),
+	} {
+		ref := NewDate(want)
+		got := GoTime(ref)
+		ref.Close()
+
+		// A CFAbsoluteTime is a float64 number of seconds, which keeps well below a microsecond
+		// of precision for dates within a few centuries of 2001.
+		if diff := got.Sub(want); diff < -time.Microsecond || diff > time.Microsecond {
+			t.Errorf("GoTime(NewDate(%v)) = %v, off by %v", want, got, diff)
+		}
+		if got.Location() != time.UTC {
+			t.Errorf("GoTime() location = %v, want UTC", got.Location())
+		}
+	}
+}
+
+func TestDuration(t *testing.T) {
+	got, err := RoundTrip(map[string]interface{}{"timeout": 90 * time.Second, "delay": 250 * time.Millisecond})
+	if err != nil {
+		t.Fatalf("RoundTrip() error = %v", err)
+	}
+	want := map[string]interface{}{"timeout": 90.0, "delay": 0.25}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("RoundTrip() = %#v, want %#v", got, want)
+	}
+}
+
+type testServer struct {
+	Host string `prefs:"host"`
+	Port int    `prefs:"port,omitempty"`
+}
+
+type testConfig struct {
+	Name    string
+	Servers []testServer `prefs:"servers"`
+	Token   string       `prefs:"-"`
+	Updated time.Time    `prefs:"updated"`
+}
+
+func TestStruct(t *testing.T) {
+	updated := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	got, err := RoundTrip(testConfig{
+		Name:    "test",
+		Servers: []testServer{{Host: "a", Port: 22}, {Host: "b"}},
+		Token:   "secret",
+		Updated: updated,
+	})
+	if err != nil {
+		t.Fatalf("RoundTrip() error = %v", err)
+	}
+	want := map[string]interface{}{
+		"Name": "test",
+		"servers": []interface{}{
+			map[string]interface{}{"host": "a", "port": 22},
+			map[string]interface{}{"host": "b"},
+		},
+		"updated": updated,
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("RoundTrip() = %#v, want %#v", got, want)
+	}
+}
+
+type testLevel int
+
+func (l testLevel) MarshalPrefs() (interface{}, error) {
+	if l < 0 {
+		return nil, errors.New("negative level")
+	}
+	return []string{"low", "high"}[l], nil
+}
+
+func TestMarshaler(t *testing.T) {
+	got, err := RoundTrip(map[string]interface{}{"level": testLevel(1), "levels": []testLevel{0, 1}})
+	if err != nil {
+		t.Fatalf("RoundTrip() error = %v", err)
+	}
+	want := map[string]interface{}{"level": "high", "levels": []interface{}{"low", "high"}}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("RoundTrip() = %#v, want %#v", got, want)
+	}
+
+	if ref, err := FromGo(testLevel(-1)); err == nil {
+		ref.Close()
+		t.Fatal("FromGo() with a failing Marshaler expected error")
+	}
+}
+
+func TestJSONNumber(t *testing.T) {
+	got, err := RoundTrip([]interface{}{json.Number("42"), json.Number("-7"), json.Number("1.5"), json.Number("18446744073709551615")})
+	if err != nil {
+		t.Fatalf("RoundTrip() error = %v", err)
+	}
+	want := []interface{}{42, -7, 1.5, 18446744073709551615.0}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("RoundTrip() = %#v, want %#v", got, want)
+	}
+
+	for _, n := range []json.Number{"abc", "1e400"} {
+		if ref, err := FromGo(n); err == nil {
+			ref.Close()
+			t.Errorf("FromGo(%q) expected error", n)
+		}
+	}
+}
+
+func TestPointers(t *testing.T) {
+	size, name := 48, "dock"
+	got, err := RoundTrip(map[string]interface{}{
+		"size":   &size,
+		"name":   &name,
+		"unset":  (*bool)(nil),
+		"struct": &struct{ Size *int }{Size: &size},
+	})
+	if err != nil {
+		t.Fatalf("RoundTrip() error = %v", err)
+	}
+	want := map[string]interface{}{"size": 48, "name": "dock", "struct": map[string]interface{}{"Size": 48}}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("RoundTrip() = %#v, want %#v", got, want)
+	}
+
+	if ref, err := FromGo((*int)(nil)); ref != nil || err != nil {
+		t.Fatalf("FromGo(nil pointer) = %v, %v, want nil, nil", ref, err)
+	}
+	if ref, err := FromGo([]*int{nil}); err == nil {
+		ref.Close()
+		t.Fatal("FromGo() with a nil pointer array item expected error")
+	}
+}
+
+func TestNull(t *testing.T) {
+	got, err := RoundTrip(map[string]interface{}{"items": []interface{}{"a", Null{}}, "empty": Null{}})
+	if err != nil {
+		t.Fatalf("RoundTrip() error = %v", err)
+	}
+	want := map[string]interface{}{"items": []interface{}{"a", nil}, "empty": nil}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("RoundTrip() = %#v, want %#v", got, want)
+	}
+	if again, err := RoundTrip(got); err != nil || !reflect.DeepEqual(again, want) {
+		t.Fatalf("RoundTrip() of nil elements = %#v, %v, want %#v", again, err, want)
+	}
+}
+
+func TestURL(t *testing.T) {
+	u, _ := url.Parse("https://example.com/path?q=1")
+	file, _ := url.Parse("file:///Applications/Safari.app/")
+	got, err := RoundTrip(map[string]interface{}{"u": u, "file": *file})
+	if err != nil {
+		t.Fatalf("RoundTrip() error = %v", err)
+	}
+	want := map[string]interface{}{"u": u, "file": file}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("RoundTrip() = %#v, want %#v", got, want)
+	}
+}
+
+func TestUUID(t *testing.T) {
+	u := [16]byte{0x68, 0x75, 0x3a, 0x44, 0x4d, 0x6f, 0x12, 0x26, 0x9c, 0x60, 0x00, 0x50, 0xe4, 0xc0, 0x00, 0x67}
+	got, err := RoundTrip(u)
+	if err != nil {
+		t.Fatalf("RoundTrip() error = %v", err)
+	}
+	if got != "68753A44-4D6F-1226-9C60-0050E4C00067" {
+		t.Fatalf("RoundTrip() = %#v, want the canonical UUID string", got)
+	}
+}
+
+func TestDescribe(t *testing.T) {
+	ref, err := NewString("dock")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ref.Close()
+	if got := Describe(ref); !strings.Contains(got, "dock") {
+		t.Errorf("Describe() = %q, want it to contain the string", got)
+	}
+	if got := Describe(nil); got != "(null)" {
+		t.Errorf("Describe(nil) = %q, want (null)", got)
+	}
+}
@@ -0,0 +1,76 @@
+//go:build darwin && cgo
+
+package cf
+
+/*
+#include <CoreFoundation/CoreFoundation.h>
+
+static CFTypeRef dictionaryValue(CFDictionaryRef dict, CFStringRef key) {
+	return CFDictionaryGetValue(dict, key);
+}
+*/
+import "C"
+import (
+	"sort"
+	"unsafe"
+)
+
+// IsArray reports whether ref is a CFArray.
+func IsArray(ref *Ref) bool {
+	return ref != nil && C.CFGetTypeID(C.CFTypeRef(ref.Raw())) == C.CFArrayGetTypeID()
+}
+
+// IsDictionary reports whether ref is a CFDictionary.
+func IsDictionary(ref *Ref) bool {
+	return ref != nil && C.CFGetTypeID(C.CFTypeRef(ref.Raw())) == C.CFDictionaryGetTypeID()
+}
+
+// Count returns the number of elements of a CFArray or entries of a CFDictionary, or -1 if
+// ref is neither.
+func Count(ref *Ref) int {
+	switch {
+	case IsArray(ref):
+		return int(C.CFArrayGetCount(C.CFArrayRef(ref.Raw())))
+	case IsDictionary(ref):
+		return int(C.CFDictionaryGetCount(C.CFDictionaryRef(ref.Raw())))
+	default:
+		return -1
+	}
+}
+
+// ArrayValue returns the element of a CFArray at index, which must be in range. The Ref must
+// be closed.
+func ArrayValue(ref *Ref, index int) *Ref {
+	return Retain(TypeRef(C.CFArrayGetValueAtIndex(C.CFArrayRef(ref.Raw()), C.CFIndex(index))))
+}
+
+// DictionaryValue returns the value of a CFDictionary for a string key, or nil if the key is
+// not present. The Ref must be closed.
+func DictionaryValue(ref *Ref, key string) (*Ref, error) {
+	cKey, err := newString(key)
+	if err != nil {
+		return nil, err
+	}
+	defer C.CFRelease(C.CFTypeRef(cKey))
+	return Retain(TypeRef(C.dictionaryValue(C.CFDictionaryRef(ref.Raw()), C.CFStringRef(cKey)))), nil
+}
+
+// DictionaryKeys returns the string keys of a CFDictionary, sorted, without converting its
+// values.
+func DictionaryKeys(ref *Ref) []string {
+	cfDict := C.CFDictionaryRef(ref.Raw())
+	count := C.CFDictionaryGetCount(cfDict)
+	if count == 0 {
+		return []string{}
+	}
+	keys := make([]C.CFTypeRef, count)
+	C.CFDictionaryGetKeysAndValues(cfDict, (*unsafe.Pointer)(unsafe.Pointer(&keys[0])), nil)
+	result := make([]string, 0, count)
+	for _, key := range keys {
+		if C.CFGetTypeID(key) == C.CFStringGetTypeID() {
+			result = append(result, goString(TypeRef(key)))
+		}
+	}
+	sort.Strings(result)
+	return result
+}
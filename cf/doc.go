@@ -0,0 +1,44 @@
+// Package cf converts between Go values and CoreFoundation property list objects. It is the
+// conversion layer of mac_prefs, exported for code that calls other CoreFoundation APIs
+// directly. Objects are held by a *Ref, which owns one CoreFoundation reference and releases
+// it on Close; Own and Retain wrap raw references returned by other CF calls.
+//
+// Go values map to CoreFoundation types as follows:
+//
+//	string                  CFString
+//	[]byte                  CFData
+//	[16]byte                CFString, the canonical UUID form
+//	bool                    CFBoolean
+//	int, uint, float types  CFNumber
+//	time.Time               CFDate
+//	url.URL, *url.URL       CFURL
+//	time.Duration           CFNumber, a real number of seconds
+//	json.Number             CFNumber, an integer if it fits in int64, otherwise a real
+//	slices                  CFArray
+//	string-keyed maps       CFDictionary
+//	structs                 CFDictionary of the exported fields
+//	pointers                the value pointed to
+//	Null                    CFNull
+//
+// Struct fields are stored under their Go names; a `prefs:"name"` tag renames a field,
+// `prefs:"name,omitempty"` leaves it out when it is zero, and `prefs:"-"` skips it.
+// Types that implement Marshaler are converted by their MarshalPrefs method instead.
+//
+// A nil pointer converts like nil, except that struct fields and dictionary entries holding
+// one are left out, so *bool or *int fields can express "unset".
+//
+// Converting back yields string, []byte, bool, int, float64, time.Time, *url.URL,
+// []interface{}, and map[string]interface{}, with nil for CFNull. Nil elements of slices and maps
+// are written as CFNull, so converted values can be converted back. The package requires darwin
+// and cgo.
+package cf
+
+// Format is a property list serialization format.
+type Format int
+
+const (
+	// FormatXML is the XML property list format.
+	FormatXML Format = iota
+	// FormatBinary is the binary property list format.
+	FormatBinary
+)
@@ -0,0 +1,288 @@
+//go:build darwin && cgo
+
+package cf
+
+/*
+#include <stdlib.h>
+#include <string.h>
+#include <CoreFoundation/CoreFoundation.h>
+
+enum {
+	flatString,
+	flatData,
+	flatBool,
+	flatDate,
+	flatNull,
+	flatInt,
+	flatFloat,
+	flatArray,
+	flatDict,
+	flatOther
+};
+
+// flatNode is one object of a flattened property list. Nodes are stored in depth-first order:
+// an array is followed by its elements and a dictionary by its key and value pairs.
+typedef struct {
+	int kind;
+	long long n;   // integer value, boolean, element count, or byte length
+	double f;      // floating point value or absolute time
+	size_t off;    // offset of the string or data bytes
+	CFTypeRef ref; // the object itself, for kinds converted in Go
+} flatNode;
+
+typedef struct {
+	flatNode *nodes;
+	size_t count, nodesCap;
+	UInt8 *bytes;
+	size_t len, bytesCap;
+	int failed;
+} flatBuffer;
+
+static flatNode *flatPush(flatBuffer *b, int kind, CFTypeRef ref) {
+	if (b->count == b->nodesCap) {
+		size_t cap = b->nodesCap ? b->nodesCap * 2 : 64;
+		flatNode *nodes = realloc(b->nodes, cap * sizeof(flatNode));
+		if (nodes == NULL) {
+			b->failed = 1;
+			return NULL;
+		}
+		b->nodes = nodes;
+		b->nodesCap = cap;
+	}
+	flatNode *node = &b->nodes[b->count++];
+	memset(node, 0, sizeof(*node));
+	node->kind = kind;
+	node->ref = ref;
+	return node;
+}
+
+static UInt8 *flatReserve(flatBuffer *b, flatNode *node, size_t n) {
+	if (b->len + n > b->bytesCap) {
+		size_t cap = b->bytesCap ? b->bytesCap : 1024;
+		while (cap < b->len + n) {
+			cap *= 2;
+		}
+		UInt8 *bytes = realloc(b->bytes, cap);
+		if (bytes == NULL) {
+			b->failed = 1;
+			return NULL;
+		}
+		b->bytes = bytes;
+		b->bytesCap = cap;
+	}
+	node->off = b->len;
+	node->n = n;
+	b->len += n;
+	return b->bytes + node->off;
+}
+
+static void flatAddString(flatBuffer *b, CFStringRef str) {
+	flatNode *node = flatPush(b, flatString, str);
+	CFIndex length = CFStringGetLength(str);
+	if (node == NULL || length == 0) {
+		return;
+	}
+	const char *cstr = CFStringGetCStringPtr(str, kCFStringEncodingUTF8);
+	if (cstr != NULL && strlen(cstr) == (size_t)length) {
+		UInt8 *p = flatReserve(b, node, length);
+		if (p != NULL) {
+			memcpy(p, cstr, length);
+		}
+		return;
+	}
+	CFIndex size = CFStringGetMaximumSizeForEncoding(length, kCFStringEncodingUTF8);
+	UInt8 *p = flatReserve(b, node, size);
+	if (p == NULL) {
+		return;
+	}
+	CFRange range = {0, length};
+	CFIndex used = 0;
+	if (CFStringGetBytes(str, range, kCFStringEncodingUTF8, 0, false, p, size, &used) == 0) {
+		used = 0;
+	}
+	// Give back the bytes the string did not need.
+	b->len -= size - used;
+	node->n = used;
+}
+
+static void flatWalk(flatBuffer *b, CFTypeRef ref) {
+	if (b->failed) {
+		return;
+	}
+	CFTypeID type = CFGetTypeID(ref);
+	if (type == CFStringGetTypeID()) {
+		flatAddString(b, (CFStringRef)ref);
+	} else if (type == CFDataGetTypeID()) {
+		flatNode *node = flatPush(b, flatData, ref);
+		CFIndex length = CFDataGetLength((CFDataRef)ref);
+		UInt8 *p = node ? flatReserve(b, node, length) : NULL;
+		if (p != NULL && length > 0) {
+			memcpy(p, CFDataGetBytePtr((CFDataRef)ref), length);
+		}
+	} else if (type == CFBooleanGetTypeID()) {
+		flatNode *node = flatPush(b, flatBool, ref);
+		if (node != NULL) {
+			node->n = CFBooleanGetValue((CFBooleanRef)ref);
+		}
+	} else if (type == CFDateGetTypeID()) {
+		flatNode *node = flatPush(b, flatDate, ref);
+		if (node != NULL) {
+			node->f = CFDateGetAbsoluteTime((CFDateRef)ref);
+		}
+	} else if (type == CFNullGetTypeID()) {
+		flatPush(b, flatNull, ref);
+	} else if (type == CFNumberGetTypeID()) {
+		switch (CFNumberGetType((CFNumberRef)ref)) {
+		case kCFNumberSInt8Type: case kCFNumberSInt16Type: case kCFNumberSInt32Type: case kCFNumberSInt64Type:
+		case kCFNumberCharType: case kCFNumberShortType: case kCFNumberIntType: case kCFNumberLongType:
+		case kCFNumberLongLongType: case kCFNumberCFIndexType: case kCFNumberNSIntegerType: {
+			flatNode *node = flatPush(b, flatInt, ref);
+			if (node != NULL) {
+				CFNumberGetValue((CFNumberRef)ref, kCFNumberLongLongType, &node->n);
+			}
+			break;
+		}
+		case kCFNumberFloat32Type: case kCFNumberFloat64Type: case kCFNumberFloatType: case kCFNumberDoubleType: {
+			flatNode *node = flatPush(b, flatFloat, ref);
+			if (node != NULL) {
+				CFNumberGetValue((CFNumberRef)ref, kCFNumberDoubleType, &node->f);
+			}
+			break;
+		}
+		default:
+			flatPush(b, flatOther, ref);
+		}
+	} else if (type == CFArrayGetTypeID()) {
+		CFIndex count = CFArrayGetCount((CFArrayRef)ref);
+		flatNode *node = flatPush(b, flatArray, ref);
+		if (node == NULL) {
+			return;
+		}
+		node->n = count;
+		for (CFIndex i = 0; i < count && !b->failed; i++) {
+			flatWalk(b, CFArrayGetValueAtIndex((CFArrayRef)ref, i));
+		}
+	} else if (type == CFDictionaryGetTypeID()) {
+		CFIndex count = CFDictionaryGetCount((CFDictionaryRef)ref);
+		flatNode *node = flatPush(b, flatDict, ref);
+		if (node == NULL || count == 0) {
+			return;
+		}
+		node->n = count;
+		const void **keys = malloc(2 * count * sizeof(void *));
+		if (keys == NULL) {
+			b->failed = 1;
+			return;
+		}
+		const void **values = keys + count;
+		CFDictionaryGetKeysAndValues((CFDictionaryRef)ref, keys, values);
+		for (CFIndex i = 0; i < count && !b->failed; i++) {
+			if (CFGetTypeID(keys[i]) == CFStringGetTypeID()) {
+				flatAddString(b, (CFStringRef)keys[i]);
+			} else {
+				flatPush(b, flatOther, keys[i]);
+			}
+			flatWalk(b, values[i]);
+		}
+		free(keys);
+	} else {
+		flatPush(b, flatOther, ref);
+	}
+}
+
+static void flatFree(flatBuffer *b) {
+	free(b->nodes);
+	free(b->bytes);
+}
+*/
+import "C"
+import (
+	"errors"
+	"fmt"
+	"unsafe"
+)
+
+// flattenThreshold is the number of elements from which toGo converts an array or dictionary
+// with toGoFlat. Smaller containers are converted element by element, which avoids the
+// allocations of the flat buffer.
+const flattenThreshold = 16
+
+// toGoFlat converts a property list object with a single cgo call that walks the whole tree
+// and copies it into a flat buffer, instead of crossing the cgo boundary once per element.
+// The buffer is then decoded in Go. Objects the walk does not understand, such as URLs, are
+// left to toGo.
+func toGoFlat(ref TypeRef) (interface{}, error) {
+	var b C.flatBuffer
+	defer C.flatFree(&b)
+	C.flatWalk(&b, C.CFTypeRef(ref))
+	if b.failed != 0 {
+		return nil, errors.New("cf: out of memory converting property list")
+	}
+	d := flatDecoder{
+		nodes: unsafe.Slice(b.nodes, b.count),
+		bytes: unsafe.Slice((*byte)(unsafe.Pointer(b.bytes)), b.len),
+	}
+	return d.value()
+}
+
+// flatDecoder converts the nodes of a flat buffer to Go values.
+type flatDecoder struct {
+	nodes []C.flatNode
+	bytes []byte
+	next  int
+}
+
+func (d *flatDecoder) value() (interface{}, error) {
+	node := &d.nodes[d.next]
+	d.next++
+	switch node.kind {
+	case C.flatString:
+		return string(d.bytesOf(node)), nil
+	case C.flatData:
+		b := make([]byte, node.n)
+		copy(b, d.bytesOf(node))
+		return b, nil
+	case C.flatBool:
+		return node.n != 0, nil
+	case C.flatDate:
+		return absoluteTime(float64(node.f)), nil
+	case C.flatNull:
+		return nil, nil
+	case C.flatInt:
+		return int(node.n), nil
+	case C.flatFloat:
+		return float64(node.f), nil
+	case C.flatArray:
+		result := make([]interface{}, node.n)
+		for i := range result {
+			item, err := d.value()
+			if err != nil {
+				return nil, fmt.Errorf("error converting array item at index %d: %v", i, err)
+			}
+			result[i] = item
+		}
+		return result, nil
+	case C.flatDict:
+		result := make(map[string]interface{}, node.n)
+		for i := 0; i < int(node.n); i++ {
+			keyNode := &d.nodes[d.next]
+			d.next++
+			if keyNode.kind != C.flatString {
+				return nil, errors.New("cf: dictionary key is not a string")
+			}
+			key := string(d.bytesOf(keyNode))
+			value, err := d.value()
+			if err != nil {
+				return nil, fmt.Errorf("error converting dictionary value for key %s: %v", key, err)
+			}
+			result[key] = value
+		}
+		return result, nil
+	default:
+		return toGo(TypeRef(node.ref))
+	}
+}
+
+func (d *flatDecoder) bytesOf(node *C.flatNode) []byte {
+	return d.bytes[node.off : node.off+C.size_t(node.n)]
+}
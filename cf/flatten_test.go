@@ -0,0 +1,91 @@
+//go:build darwin && cgo
+
+package cf
+
+import (
+	"fmt"
+	"net/url"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestToGoFlat(t *testing.T) {
+	now := time.Date(2024, 2, 29, 12, 30, 0, 123456000, time.UTC)
+	link, _ := url.Parse("https://example.com/a")
+	list := []interface{}{"héllo", "", []byte{0, 1}, []byte{}, true, false, -8, 1.5, now, link, Null{}}
+	want := []interface{}{"héllo", "", []byte{0, 1}, []byte{}, true, false, -8, 1.5, now, link, nil}
+	for i := len(list); i < 2*flattenThreshold; i++ {
+		list = append(list, i)
+		want = append(want, i)
+	}
+	dict := map[string]interface{}{"list": list, "empty": map[string]interface{}{}, "nested": map[string]interface{}{"a": []interface{}{}}}
+	wantDict := map[string]interface{}{"list": want, "empty": map[string]interface{}{}, "nested": map[string]interface{}{"a": []interface{}{}}}
+	for i := 0; i < flattenThreshold; i++ {
+		dict[fmt.Sprintf("key%d", i)] = i
+		wantDict[fmt.Sprintf("key%d", i)] = i
+	}
+
+	for _, tt := range []struct {
+		value interface{}
+		want  interface{}
+	}{
+		{list, want},
+		{dict, wantDict},
+	} {
+		ref, err := FromGo(tt.value)
+		if err != nil {
+			t.Fatalf("FromGo() error = %v", err)
+		}
+		flat, err := toGoFlat(ref.Raw())
+		if err != nil {
+			t.Fatalf("toGoFlat() error = %v", err)
+		}
+		if !reflect.DeepEqual(flat, tt.want) {
+			t.Errorf("toGoFlat() = %#v, want %#v", flat, tt.want)
+		}
+		got, err := ToGo(ref)
+		if err != nil {
+			t.Fatalf("ToGo() error = %v", err)
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("ToGo() = %#v, want %#v", got, tt.want)
+		}
+		ref.Close()
+	}
+}
+
+// benchmarkContainer builds a dictionary of n small dictionaries, the shape of a domain with
+// many entries.
+func benchmarkContainer(b *testing.B, n int) *Ref {
+	value := make(map[string]interface{}, n)
+	for i := 0; i < n; i++ {
+		value[fmt.Sprintf("key%d", i)] = map[string]interface{}{"name": "value", "count": i, "enabled": true}
+	}
+	ref, err := FromGo(value)
+	if err != nil {
+		b.Fatal(err)
+	}
+	return ref
+}
+
+func BenchmarkToGo(b *testing.B) {
+	for _, n := range []int{10, 1000, 10000} {
+		ref := benchmarkContainer(b, n)
+		b.Run(fmt.Sprintf("PerElement/%d", n), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				if _, err := toGoEach(ref.Raw()); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+		b.Run(fmt.Sprintf("Flat/%d", n), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				if _, err := toGoFlat(ref.Raw()); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+		ref.Close()
+	}
+}
@@ -0,0 +1,19 @@
+package cf
+
+// Marshaler is implemented by types that can convert themselves to a property list value.
+// FromGo consults it before any other conversion, so enums, versions, and similar user types
+// can be stored as a string, number, or dictionary of their choosing. The returned value is
+// converted like any other and may itself contain Marshalers.
+type Marshaler interface {
+	MarshalPrefs() (interface{}, error)
+}
+
+// Unmarshaler is implemented by types that can set themselves from a value returned by ToGo.
+type Unmarshaler interface {
+	UnmarshalPrefs(value interface{}) error
+}
+
+// Null is converted to kCFNull, which some domains store inside arrays and dictionaries. ToGo
+// returns nil for kCFNull, and nil elements of arrays and dictionaries are written as kCFNull
+// too; Null is needed only where nil would mean "no value", such as a top-level value.
+type Null struct{}
@@ -0,0 +1,98 @@
+//go:build darwin && cgo
+
+package cf
+
+/*
+#include <CoreFoundation/CoreFoundation.h>
+*/
+import "C"
+import (
+	"runtime"
+	"sync"
+)
+
+// TypeRef is a raw CoreFoundation object reference (CFTypeRef). The zero TypeRef is NULL. A
+// TypeRef can be converted to and from the CFTypeRef types of other cgo packages with a type
+// conversion. Wrap it with Own or Retain to manage its lifetime.
+type TypeRef uintptr
+
+// Ref owns one reference to a CoreFoundation object. Close releases it exactly once; later
+// calls do nothing, so a Ref can be closed by a defer and by an early error path without a
+// double free. A nil *Ref stands for NULL. A Ref is safe for concurrent use.
+type Ref struct {
+	mu  sync.Mutex
+	ref TypeRef
+}
+
+// Own wraps a reference the caller already owns, such as the result of a CF Create or Copy
+// function. It returns nil for NULL.
+func Own(ref TypeRef) *Ref {
+	if ref == 0 {
+		return nil
+	}
+	return &Ref{ref: ref}
+}
+
+// Retain wraps a reference the caller does not own, such as the result of a CF Get function
+// or an element of a collection, retaining it first. It returns nil for NULL.
+func Retain(ref TypeRef) *Ref {
+	if ref == 0 {
+		return nil
+	}
+	C.CFRetain(C.CFTypeRef(ref))
+	return &Ref{ref: ref}
+}
+
+// Raw returns the underlying reference for passing to CF functions. It stays valid until the
+// Ref is closed, and must not be released by the caller. Raw returns NULL for a nil Ref and
+// panics if the Ref is closed.
+func (r *Ref) Raw() TypeRef {
+	if r == nil {
+		return 0
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.ref == 0 {
+		panic("cf: use of closed Ref")
+	}
+	return r.ref
+}
+
+// Retain returns a new Ref to the same object, which must be closed separately.
+func (r *Ref) Retain() *Ref {
+	if r == nil {
+		return nil
+	}
+	return Retain(r.Raw())
+}
+
+// Release releases the reference. It does nothing if the Ref is nil or already released.
+func (r *Ref) Release() {
+	if r == nil {
+		return
+	}
+	r.mu.Lock()
+	ref := r.ref
+	r.ref = 0
+	r.mu.Unlock()
+	if ref != 0 {
+		runtime.SetFinalizer(r, nil)
+		C.CFRelease(C.CFTypeRef(ref))
+	}
+}
+
+// Close releases the reference, like Release. It always returns nil and implements io.Closer.
+func (r *Ref) Close() error {
+	r.Release()
+	return nil
+}
+
+// SetFinalizer releases the reference when the Ref is garbage collected without being
+// closed. It is a safety net for long-lived Refs; Close is still the way to release promptly.
+// It returns r.
+func (r *Ref) SetFinalizer() *Ref {
+	if r != nil {
+		runtime.SetFinalizer(r, (*Ref).Release)
+	}
+	return r
+}
@@ -0,0 +1,79 @@
+//go:build darwin && cgo
+
+package cf
+
+import (
+	"runtime"
+	"testing"
+)
+
+func TestRefClose(t *testing.T) {
+	ref, err := NewString("dock")
+	if err != nil {
+		t.Fatalf("NewString() error = %v", err)
+	}
+	if err := ref.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+	// A second Close and a Release must not free the object again.
+	ref.Close()
+	ref.Release()
+
+	defer func() {
+		if recover() == nil {
+			t.Fatal("Raw() of a closed Ref did not panic")
+		}
+	}()
+	ref.Raw()
+}
+
+func TestRefRetain(t *testing.T) {
+	ref, err := NewString("dock")
+	if err != nil {
+		t.Fatalf("NewString() error = %v", err)
+	}
+	other := ref.Retain()
+	ref.Close()
+	defer other.Close()
+
+	if got := GoString(other); got != "dock" {
+		t.Fatalf("GoString() of a retained Ref = %q, want %q", got, "dock")
+	}
+
+	borrowed := Retain(other.Raw())
+	defer borrowed.Close()
+	if got := GoString(borrowed); got != "dock" {
+		t.Fatalf("GoString() of Retain(Raw()) = %q, want %q", got, "dock")
+	}
+}
+
+func TestNilRef(t *testing.T) {
+	var ref *Ref
+	if ref.Raw() != 0 || ref.Retain() != nil || ref.SetFinalizer() != nil {
+		t.Fatal("nil Ref is not NULL")
+	}
+	ref.Close()
+	if Own(0) != nil || Retain(0) != nil {
+		t.Fatal("Own(0) or Retain(0) is not nil")
+	}
+	if _, err := ToGo(nil); err == nil {
+		t.Fatal("ToGo(nil) expected error")
+	}
+}
+
+func TestRefSetFinalizer(t *testing.T) {
+	for i := 0; i < 100; i++ {
+		ref, err := NewString("collected")
+		if err != nil {
+			t.Fatalf("NewString() error = %v", err)
+		}
+		ref.SetFinalizer()
+	}
+	runtime.GC()
+	runtime.GC()
+
+	// Closing a Ref with a finalizer clears it, so the object is released once.
+	ref, _ := NewString("closed")
+	ref.SetFinalizer().Close()
+	runtime.GC()
+}
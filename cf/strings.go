@@ -0,0 +1,93 @@
+//go:build darwin && cgo
+
+package cf
+
+import (
+	"container/list"
+	"sync"
+)
+
+// StringCache interns CFStrings, so code that repeatedly passes the same keys and application
+// IDs to CoreFoundation reuses one CFString per value instead of creating and releasing a new
+// one on every call. It holds at most a fixed number of strings and releases the least
+// recently used one when it is full. A StringCache is safe for concurrent use. A nil
+// *StringCache creates a new CFString on every call.
+type StringCache struct {
+	mu       sync.Mutex
+	capacity int
+	closed   bool
+	order    *list.List // of *cachedString, most recently used first
+	entries  map[string]*list.Element
+}
+
+type cachedString struct {
+	s   string
+	ref *Ref
+}
+
+// NewStringCache creates a StringCache holding at most capacity strings.
+func NewStringCache(capacity int) *StringCache {
+	return &StringCache{
+		capacity: capacity,
+		order:    list.New(),
+		entries:  make(map[string]*list.Element),
+	}
+}
+
+// String returns a CFString for s, which the caller owns and must close like the result of
+// NewString. Closing it does not release the interned string.
+func (c *StringCache) String(s string) (*Ref, error) {
+	if c == nil {
+		return NewString(s)
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.closed || c.capacity <= 0 {
+		return NewString(s)
+	}
+	if e, ok := c.entries[s]; ok {
+		c.order.MoveToFront(e)
+		return e.Value.(*cachedString).ref.Retain(), nil
+	}
+
+	ref, err := NewString(s)
+	if err != nil {
+		return nil, err
+	}
+	c.entries[s] = c.order.PushFront(&cachedString{s: s, ref: ref})
+	for c.order.Len() > c.capacity {
+		oldest := c.order.Remove(c.order.Back()).(*cachedString)
+		delete(c.entries, oldest.s)
+		oldest.ref.Release()
+	}
+	return ref.Retain(), nil
+}
+
+// Len returns the number of interned strings.
+func (c *StringCache) Len() int {
+	if c == nil {
+		return 0
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.order.Len()
+}
+
+// Close releases the interned strings. Strings returned by String remain valid until they
+// are closed, and later calls to String create a new CFString every time. Close always
+// returns nil and implements io.Closer.
+func (c *StringCache) Close() error {
+	if c == nil {
+		return nil
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.closed = true
+	for e := c.order.Front(); e != nil; e = e.Next() {
+		e.Value.(*cachedString).ref.Release()
+	}
+	c.order.Init()
+	c.entries = make(map[string]*list.Element)
+	return nil
+}
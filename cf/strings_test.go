@@ -0,0 +1,114 @@
+//go:build darwin && cgo
+
+package cf
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestStringCache(t *testing.T) {
+	c := NewStringCache(2)
+	defer c.Close()
+
+	first, err := c.String("com.apple.dock")
+	if err != nil {
+		t.Fatalf("String() error = %v", err)
+	}
+	second, err := c.String("com.apple.dock")
+	if err != nil {
+		t.Fatalf("String() error = %v", err)
+	}
+	if first.Raw() != second.Raw() {
+		t.Error("String() did not reuse the interned CFString")
+	}
+	// Closing the returned Refs must not release the interned string.
+	first.Close()
+	second.Close()
+	third, _ := c.String("com.apple.dock")
+	if got := GoString(third); got != "com.apple.dock" {
+		t.Errorf("GoString() = %q after closing earlier Refs", got)
+	}
+	third.Close()
+
+	for _, s := range []string{"a", "b", "c"} {
+		ref, err := c.String(s)
+		if err != nil {
+			t.Fatal(err)
+		}
+		ref.Close()
+	}
+	if n := c.Len(); n != 2 {
+		t.Errorf("Len() = %d, want the capacity 2", n)
+	}
+
+	kept, _ := c.String("c")
+	c.Close()
+	if n := c.Len(); n != 0 {
+		t.Errorf("Len() after Close = %d", n)
+	}
+	if got := GoString(kept); got != "c" {
+		t.Errorf("GoString() = %q of a Ref returned before Close", got)
+	}
+	kept.Close()
+	after, err := c.String("d")
+	if err != nil || GoString(after) != "d" || c.Len() != 0 {
+		t.Errorf("String() after Close = %v, %v, Len() = %d", after, err, c.Len())
+	}
+	after.Close()
+}
+
+func TestNilStringCache(t *testing.T) {
+	var c *StringCache
+	ref, err := c.String("dock")
+	if err != nil || GoString(ref) != "dock" {
+		t.Fatalf("String() = %v, %v", ref, err)
+	}
+	ref.Close()
+	if c.Len() != 0 || c.Close() != nil {
+		t.Error("nil StringCache is not empty")
+	}
+}
+
+func TestGoString(t *testing.T) {
+	for _, s := range []string{
+		"a",
+		"AppleInterfaceStyle",
+		"héllo wörld",
+		"emoji 🍎",
+		strings.Repeat("long ", 20000),
+		strings.Repeat("lång ", 20000),
+	} {
+		ref, err := NewString(s)
+		if err != nil {
+			t.Fatalf("NewString() error = %v", err)
+		}
+		if got := GoString(ref); got != s {
+			t.Errorf("GoString() = %.40q, want %.40q", got, s)
+		}
+		ref.Close()
+	}
+}
+
+func BenchmarkGoString(b *testing.B) {
+	for _, bm := range []struct {
+		name string
+		s    string
+	}{
+		{"ASCII", "com.apple.dock.persistent-apps"},
+		{"NonASCII", "Bildschirmschoner für Präsentationen"},
+		{"Long", strings.Repeat("com.example.app ", 256)},
+	} {
+		ref, err := NewString(bm.s)
+		if err != nil {
+			b.Fatal(err)
+		}
+		b.Run(bm.name, func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				GoString(ref)
+			}
+		})
+		ref.Close()
+	}
+}
@@ -0,0 +1,298 @@
+package mac_prefs
+
+import (
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+)
+
+// Client reads and writes preferences with optional behavior configured through Options.
+// The zero configuration behaves exactly like the package level functions.
+//
+// A Client is safe for concurrent use by multiple goroutines. Read-modify-write operations,
+// such as Increment, Update, and Tx.Commit, hold a lock of the Client, so they are atomic with
+// respect to each other and to Set and Delete through the same Client. The package level
+// read-modify-write functions share one such lock. Every write to
+// CFPreferences in the process, through any Client or package level function, is serialized
+// with the synchronize that flushes it per domain slot. Reads are not serialized, and nothing
+// excludes other processes.
+type Client struct {
+	mu    sync.Mutex
+	store Store
+
+	undoEnabled bool
+	undoDepth   int
+	undoLog     []mutation
+
+	auditHooks []func(AuditEvent)
+	redactor   *Redactor
+	dryRun     bool
+	readOnly   bool
+
+	numberMode       NumberMode
+	location         *time.Location
+	unsignedAsString bool
+	uuidAsData       bool
+	nestedPlists     bool
+
+	defaultsMu sync.RWMutex
+	defaults   map[string]interface{}
+
+	cacheOptions *CacheOptions
+	cache        *cacheStore
+
+	stringCacheSize int
+	names           *stringCache
+
+	dedicatedThread bool
+	thread          *threadStore
+
+	retryPolicy *RetryPolicy
+	backupDir   string
+	root        string
+
+	logger          *slog.Logger
+	instrumentation Instrumentation
+
+	applyHooks map[string][]string
+	restarts   map[Restart]bool
+}
+
+// defaultClient runs the package level read-modify-write functions, such as Increment, so
+// they share one lock and are atomic with respect to each other within the process.
+var defaultClient = NewClient()
+
+// Option configures a Client.
+type Option func(*Client)
+
+// mutation records the value a key had before it was written and the value written.
+type mutation struct {
+	key   string
+	appID string
+	scope PreferenceScope
+	old   interface{}
+	new   interface{}
+}
+
+// NewClient creates a Client configured by opts.
+func NewClient(opts ...Option) *Client {
+	c := &Client{store: CFStore{}, redactor: DefaultRedactor}
+	for _, opt := range opts {
+		opt(c)
+	}
+	if _, ok := c.store.(CFStore); ok && c.stringCacheSize > 0 {
+		c.names = newStringCache(c.stringCacheSize)
+		c.store = CFStore{names: c.names}
+	}
+	if c.instrumentation != nil {
+		c.store = &observedStore{Store: c.store, observe: c.instrumentation.Observe}
+	}
+	if c.dedicatedThread {
+		c.thread = newThreadStore(c.store)
+		c.store = c.thread
+	}
+	if c.retryPolicy != nil {
+		c.store = newRetryStore(c.store, *c.retryPolicy)
+	}
+	if c.backupDir != "" {
+		c.store = newBackupStore(c.store, c.backupDir)
+	}
+	if c.cacheOptions != nil {
+		c.cache = newCacheStore(c.store, *c.cacheOptions)
+		c.store = c.cache
+	}
+	if c.logger != nil {
+		c.store = &observedStore{Store: c.store, observe: logOperation(c.logger)}
+	}
+	if c.readOnly {
+		c.store = readOnlyStore{c.store}
+	}
+	if c.dryRun {
+		c.store = newDryRunStore(c.store)
+	}
+	return c
+}
+
+// WithUndo enables the undo log. Before each Set or Delete the previous value of the key is
+// recorded so the mutation can be rolled back with Undo. At most depth mutations are kept;
+// a depth of zero or less keeps every mutation.
+func WithUndo(depth int) Option {
+	return func(c *Client) {
+		c.undoEnabled = true
+		c.undoDepth = depth
+	}
+}
+
+// WithStringCache makes the Client reuse the CFStrings it creates for keys and application
+// IDs, keeping at most size of them, instead of creating and releasing new ones on every
+// call. It cuts allocations and cgo calls in loops over the same keys. Call Close to release
+// the strings. It has no effect if the Client uses a Store other than CFStore.
+func WithStringCache(size int) Option {
+	return func(c *Client) {
+		c.stringCacheSize = size
+	}
+}
+
+// WithStore makes the Client read and write store instead of the real preferences, e.g. a
+// MemoryStore in unit tests.
+func WithStore(store Store) Option {
+	return func(c *Client) {
+		c.store = store
+	}
+}
+
+// Get retrieves a preference value from one exact (user, host) slot. See Get.
+func (c *Client) Get(key string, applicationID string, scope PreferenceScope) (interface{}, error) {
+	value, err := c.lookup(key, applicationID, scope)
+	if err != nil {
+		return nil, err
+	}
+	return c.readValue(value), nil
+}
+
+// readValue applies the read options of the Client to a value from its store.
+func (c *Client) readValue(value interface{}) interface{} {
+	if c.nestedPlists {
+		value, _ = parseNestedPlists(value)
+	}
+	return normalizeRead(value, c.numberMode, c.location)
+}
+
+// GetAll retrieves every key and value of one exact (user, host) slot. See GetAll.
+func (c *Client) GetAll(applicationID string, scope PreferenceScope) (map[string]interface{}, error) {
+	values, err := c.store.List(applicationID, scope)
+	if err != nil {
+		return nil, err
+	}
+	return c.readValues(values), nil
+}
+
+// readValues applies the read options of the Client to the values of a domain.
+func (c *Client) readValues(values map[string]interface{}) map[string]interface{} {
+	if c.nestedPlists {
+		nested, _ := parseNestedPlists(values)
+		values = nested.(map[string]interface{})
+	}
+	return normalizeRead(values, c.numberMode, c.location).(map[string]interface{})
+}
+
+// GetMany retrieves several keys from one exact (user, host) slot, in one read if the store
+// of the Client is a MultiGetStore. Keys that are not set fall back to the registered
+// defaults. See GetMany.
+func (c *Client) GetMany(keys []string, applicationID string, scope PreferenceScope) (map[string]interface{}, error) {
+	values, err := readSlot(c.store, prefSlot{applicationID, scope}, keys)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make(map[string]interface{}, len(keys))
+	c.defaultsMu.RLock()
+	for _, key := range keys {
+		value, ok := values[key]
+		if !ok || value == nil {
+			value = c.defaults[key]
+		}
+		if value != nil {
+			result[key] = c.readValue(value)
+		}
+	}
+	c.defaultsMu.RUnlock()
+	return result, nil
+}
+
+// Set sets a preference value. See Set.
+func (c *Client) Set(key string, value interface{}, applicationID string, scope PreferenceScope) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.set(key, value, applicationID, scope)
+}
+
+// Delete removes a preference key. See Delete.
+func (c *Client) Delete(key string, applicationID string, scope PreferenceScope) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.set(key, nil, applicationID, scope)
+}
+
+func (c *Client) set(key string, value interface{}, applicationID string, scope PreferenceScope) error {
+	value = c.writeValue(value)
+	var old interface{}
+	if c.undoEnabled || len(c.auditHooks) > 0 {
+		var err error
+		if old, err = c.store.Get(key, applicationID, scope); err != nil {
+			return fmt.Errorf("error reading previous value of %s: %v", key, err)
+		}
+	}
+	if err := c.store.Set(key, value, applicationID, scope); err != nil {
+		return err
+	}
+	c.record(mutation{key: key, appID: applicationID, scope: scope, old: old, new: value})
+	return nil
+}
+
+// writeValue applies the write options of the Client to a value before it is stored.
+func (c *Client) writeValue(value interface{}) interface{} {
+	if c.unsignedAsString {
+		value = unsignedToString(value)
+	}
+	if c.uuidAsData {
+		value = rewriteLeaves(value, uuidToData)
+	}
+	return value
+}
+
+// record passes mutations to the audit hooks, notes the restarts of the apply hooks, and, if
+// undo is enabled, appends them to the undo log, dropping the oldest beyond the undo depth.
+func (c *Client) record(mutations ...mutation) {
+	if c.dryRun {
+		return
+	}
+	c.audit(mutations...)
+	c.markRestarts(mutations...)
+	if !c.undoEnabled {
+		return
+	}
+	c.undoLog = append(c.undoLog, mutations...)
+	if c.undoDepth > 0 && len(c.undoLog) > c.undoDepth {
+		c.undoLog = c.undoLog[len(c.undoLog)-c.undoDepth:]
+	}
+}
+
+// Undo rolls back the last n mutations made through the Client, most recent first. The undo
+// log must be enabled with WithUndo.
+//
+// Parameters:
+//   - n: The number of mutations to roll back.
+//
+// Returns:
+//   - error: An error if undo is not enabled, fewer than n mutations are recorded, or a value cannot be written back.
+func (c *Client) Undo(n int) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if !c.undoEnabled {
+		return fmt.Errorf("undo is not enabled")
+	}
+	if n > len(c.undoLog) {
+		return fmt.Errorf("cannot undo %d mutations, only %d recorded", n, len(c.undoLog))
+	}
+	for i := 0; i < n; i++ {
+		last := c.undoLog[len(c.undoLog)-1]
+		if err := c.store.Set(last.key, last.old, last.appID, last.scope); err != nil {
+			return fmt.Errorf("error undoing %s: %v", last.key, err)
+		}
+		undone := mutation{key: last.key, appID: last.appID, scope: last.scope, old: last.new, new: last.old}
+		c.audit(undone)
+		c.markRestarts(undone)
+		c.undoLog = c.undoLog[:len(c.undoLog)-1]
+	}
+	return nil
+}
+
+// UndoLen returns the number of mutations that can be rolled back with Undo.
+func (c *Client) UndoLen() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return len(c.undoLog)
+}
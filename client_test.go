@@ -0,0 +1,144 @@
+//go:build darwin && cgo
+
+package mac_prefs
+
+import (
+	"bytes"
+	"context"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestClientUndo(t *testing.T) {
+	const appID = testAppID + ".undo"
+	scope := CurrentUserAnyHost
+
+	if err := Set("UndoKey", "original", appID, scope); err != nil {
+		t.Fatalf("Set() error = %v", err)
+	}
+	defer Delete("UndoKey", appID, scope)
+	defer Delete("UndoNew", appID, scope)
+
+	c := NewClient(WithUndo(0))
+	if err := c.Set("UndoKey", "first", appID, scope); err != nil {
+		t.Fatalf("Client.Set() error = %v", err)
+	}
+	if err := c.Set("UndoNew", 1, appID, scope); err != nil {
+		t.Fatalf("Client.Set() error = %v", err)
+	}
+	if err := c.Delete("UndoKey", appID, scope); err != nil {
+		t.Fatalf("Client.Delete() error = %v", err)
+	}
+	if got := c.UndoLen(); got != 3 {
+		t.Fatalf("UndoLen() got = %d, want 3", got)
+	}
+
+	if err := c.Undo(1); err != nil {
+		t.Fatalf("Undo(1) error = %v", err)
+	}
+	if got, _ := Get("UndoKey", appID, scope); got != "first" {
+		t.Fatalf("after Undo(1) UndoKey = %v, want first", got)
+	}
+
+	if err := c.Undo(2); err != nil {
+		t.Fatalf("Undo(2) error = %v", err)
+	}
+	if got, _ := Get("UndoKey", appID, scope); got != "original" {
+		t.Fatalf("after Undo(2) UndoKey = %v, want original", got)
+	}
+	if got, _ := Get("UndoNew", appID, scope); got != nil {
+		t.Fatalf("after Undo(2) UndoNew = %v, want nil", got)
+	}
+
+	if err := c.Undo(1); err == nil {
+		t.Fatal("Undo() expected error with empty log")
+	}
+}
+
+func TestClientUndoDepth(t *testing.T) {
+	const appID = testAppID + ".undo"
+	scope := CurrentUserAnyHost
+	defer Delete("UndoDepth", appID, scope)
+
+	c := NewClient(WithUndo(2))
+	for i := 0; i < 5; i++ {
+		if err := c.Set("UndoDepth", i, appID, scope); err != nil {
+			t.Fatalf("Client.Set() error = %v", err)
+		}
+	}
+	if got := c.UndoLen(); got != 2 {
+		t.Fatalf("UndoLen() got = %d, want 2", got)
+	}
+	if err := c.Undo(2); err != nil {
+		t.Fatalf("Undo() error = %v", err)
+	}
+	if got, _ := Get("UndoDepth", appID, scope); got != 2 {
+		t.Fatalf("after Undo(2) UndoDepth = %v, want 2", got)
+	}
+}
+
+func TestClientUndoDisabled(t *testing.T) {
+	if err := NewClient().Undo(1); err == nil {
+		t.Fatal("Undo() expected error when undo is not enabled")
+	}
+}
+
+func TestClientDedicatedThreadCFStore(t *testing.T) {
+	const key = "TestDedicatedThreadKey"
+	c := NewClient(WithDedicatedThread(), WithStringCache(4))
+	defer c.Close()
+	defer c.Delete(key, testAppID, CurrentUserAnyHost)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	events, err := c.Watch(ctx, testAppID, CurrentUserAnyHost, 10*time.Millisecond)
+	if err != nil {
+		t.Fatalf("Watch() error = %v", err)
+	}
+	if err := c.Set(key, "value", testAppID, CurrentUserAnyHost); err != nil {
+		t.Fatalf("Set() error = %v", err)
+	}
+	if got, err := c.Get(key, testAppID, CurrentUserAnyHost); err != nil || got != "value" {
+		t.Fatalf("Get() = %#v, %v, want value", got, err)
+	}
+	select {
+	case e := <-events:
+		if e.Key != key {
+			t.Errorf("Watch() event for %s, want %s", e.Key, key)
+		}
+	case <-time.After(time.Second):
+		t.Error("Watch() reported no change")
+	}
+}
+
+func TestClientExportRedacts(t *testing.T) {
+	store := NewMemoryStore()
+	store.Set("AuthToken", "abc", "com.example", CurrentUserAnyHost)
+	store.Set("Name", "app", "com.example", CurrentUserAnyHost)
+	c := NewClient(WithStore(store))
+
+	var buf bytes.Buffer
+	if err := c.Export("com.example", CurrentUserAnyHost, &buf, FormatXML); err != nil {
+		t.Fatalf("Export() error = %v", err)
+	}
+	value, err := parsePlistData(buf.Bytes())
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]interface{}{"AuthToken": Redacted, "Name": "app"}
+	if !reflect.DeepEqual(value, want) {
+		t.Errorf("Export() = %v, want %v", value, want)
+	}
+
+	buf.Reset()
+	if err := NewClient(WithStore(store), WithRedactor(nil)).Export("com.example", CurrentUserAnyHost, &buf, FormatXML); err != nil {
+		t.Fatalf("Export() error = %v", err)
+	}
+	if value, err = parsePlistData(buf.Bytes()); err != nil {
+		t.Fatal(err)
+	}
+	if token := value.(map[string]interface{})["AuthToken"]; token != "abc" {
+		t.Errorf("Export() with WithRedactor(nil) AuthToken = %v, want abc", token)
+	}
+}
@@ -0,0 +1,57 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"os/user"
+	"strconv"
+)
+
+// launchctl is the path of launchctl(1), used to enter a user's bootstrap namespace.
+const launchctl = "/bin/launchctl"
+
+// lookupUser finds a user account by name or numeric uid.
+func lookupUser(nameOrUID string) (*user.User, error) {
+	if _, err := strconv.Atoi(nameOrUID); err == nil {
+		u, err := user.LookupId(nameOrUID)
+		if err != nil {
+			return nil, fmt.Errorf("unknown user id %s", nameOrUID)
+		}
+		return u, nil
+	}
+	u, err := user.Lookup(nameOrUID)
+	if err != nil {
+		return nil, fmt.Errorf("unknown user %s", nameOrUID)
+	}
+	return u, nil
+}
+
+// asUserArgs returns the launchctl arguments that run exe with args as u, inside u's
+// bootstrap namespace so cfprefsd writes the user's own preferences with the right owner.
+func asUserArgs(exe string, u *user.User, args []string) []string {
+	return append([]string{"asuser", u.Uid, "sudo", "-H", "-u", u.Username, "--", exe}, args...)
+}
+
+// runAsUser re-executes macprefs with args as u and returns its exit status.
+func runAsUser(u *user.User, args []string, stdin io.Reader, stdout, stderr io.Writer) int {
+	exe, err := os.Executable()
+	if err != nil {
+		fmt.Fprintf(stderr, "macprefs: error locating executable: %v\n", err)
+		return 1
+	}
+
+	cmd := exec.Command(launchctl, asUserArgs(exe, u, args)...)
+	cmd.Stdin, cmd.Stdout, cmd.Stderr = stdin, stdout, stderr
+	if err := cmd.Run(); err != nil {
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) {
+			return exitErr.ExitCode()
+		}
+		fmt.Fprintf(stderr, "macprefs: error running as %s: %v\n", u.Username, err)
+		return 1
+	}
+	return 0
+}
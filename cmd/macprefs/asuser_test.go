@@ -0,0 +1,37 @@
+package main
+
+import (
+	"os/user"
+	"reflect"
+	"testing"
+)
+
+func TestLookupUser(t *testing.T) {
+	current, err := user.Current()
+	if err != nil {
+		t.Skipf("user.Current() error = %v", err)
+	}
+	for _, nameOrUID := range []string{current.Username, current.Uid} {
+		u, err := lookupUser(nameOrUID)
+		if err != nil {
+			t.Fatalf("lookupUser(%q) error = %v", nameOrUID, err)
+		}
+		if u.Uid != current.Uid {
+			t.Errorf("lookupUser(%q) uid = %s, want %s", nameOrUID, u.Uid, current.Uid)
+		}
+	}
+	for _, nameOrUID := range []string{"no-such-user-mac-prefs", "987654321"} {
+		if _, err := lookupUser(nameOrUID); err == nil {
+			t.Errorf("lookupUser(%q) expected error", nameOrUID)
+		}
+	}
+}
+
+func TestAsUserArgs(t *testing.T) {
+	u := &user.User{Uid: "501", Username: "alice"}
+	got := asUserArgs("/usr/local/bin/macprefs", u, []string{"write", "-user", "alice", "com.foo", "Key", "1"})
+	want := []string{"asuser", "501", "sudo", "-H", "-u", "alice", "--", "/usr/local/bin/macprefs", "write", "-user", "alice", "com.foo", "Key", "1"}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("asUserArgs() = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,113 @@
+//go:build darwin
+
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/weswhet/mac_prefs"
+)
+
+func cmdDaemon(c *command) error {
+	if len(c.args) != 0 {
+		return errUsage("daemon takes no arguments")
+	}
+	var domains []string
+	for _, d := range strings.Split(c.domains, ",") {
+		if d = strings.TrimSpace(d); d != "" {
+			domains = append(domains, d)
+		}
+	}
+	if len(domains) == 0 {
+		return errUsage("daemon requires -domains")
+	}
+	if c.interval <= 0 {
+		return errUsage(fmt.Sprintf("invalid -interval %v: must be positive", c.interval))
+	}
+
+	if c.launchdPlist {
+		exe, err := os.Executable()
+		if err != nil {
+			return fmt.Errorf("error locating executable: %v", err)
+		}
+		args := []string{exe, "daemon", "-domains", strings.Join(domains, ","), "-interval", c.interval.String()}
+		args = append(args, c.scopeArgs...)
+		if c.logPath != "" {
+			logPath, err := filepath.Abs(c.logPath)
+			if err != nil {
+				return err
+			}
+			args = append(args, "-log", logPath)
+		}
+		data, err := launchdPlist(c.label, args)
+		if err != nil {
+			return fmt.Errorf("error encoding launchd job: %v", err)
+		}
+		_, err = c.stdout.Write(data)
+		return err
+	}
+
+	out := c.stdout
+	if c.logPath != "" {
+		f, err := os.OpenFile(c.logPath, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0o644)
+		if err != nil {
+			return fmt.Errorf("error opening log: %v", err)
+		}
+		defer f.Close()
+		out = f
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	return logChanges(ctx, out, domains, c.scopes, c.interval)
+}
+
+// logChanges watches every scope of every domain and writes each change as a JSON line to
+// out until ctx is done.
+func logChanges(ctx context.Context, out io.Writer, domains []string, scopes []mac_prefs.PreferenceScope, interval time.Duration) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	merged := make(chan mac_prefs.Event)
+	var wg sync.WaitGroup
+	for _, domain := range domains {
+		for _, scope := range scopes {
+			events, err := mac_prefs.Watch(ctx, domainName(domain), scope, interval)
+			if err != nil {
+				return fmt.Errorf("error watching %s: %v", domain, err)
+			}
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for e := range events {
+					select {
+					case merged <- e:
+					case <-ctx.Done():
+					}
+				}
+			}()
+		}
+	}
+	go func() {
+		wg.Wait()
+		close(merged)
+	}()
+
+	enc := json.NewEncoder(out)
+	for e := range merged {
+		if err := enc.Encode(newWatchRecord(e)); err != nil {
+			return fmt.Errorf("error writing log: %v", err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,73 @@
+//go:build darwin && cgo
+
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/weswhet/mac_prefs"
+)
+
+func TestLogChanges(t *testing.T) {
+	defer runCLI("delete", testDomain)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	r, w := io.Pipe()
+	done := make(chan error, 1)
+	go func() {
+		done <- logChanges(ctx, w, []string{testDomain}, []mac_prefs.PreferenceScope{mac_prefs.CurrentUserAnyHost}, 10*time.Millisecond)
+		w.Close()
+	}()
+
+	// The watcher starts asynchronously, so keep changing the key until a change is logged.
+	writing := make(chan struct{})
+	go func() {
+		defer close(writing)
+		for i := 1; ctx.Err() == nil; i++ {
+			mac_prefs.Set("Logged", i, testDomain, mac_prefs.CurrentUserAnyHost)
+			time.Sleep(20 * time.Millisecond)
+		}
+	}()
+
+	lines := bufio.NewScanner(r)
+	if !lines.Scan() {
+		t.Fatalf("logChanges() wrote nothing: %v", lines.Err())
+	}
+	var record map[string]interface{}
+	if err := json.Unmarshal(lines.Bytes(), &record); err != nil {
+		t.Fatalf("log line is not JSON: %v\n%s", err, lines.Text())
+	}
+	if record["domain"] != testDomain || record["key"] != "Logged" || record["new"] == nil {
+		t.Fatalf("log line = %s", lines.Text())
+	}
+
+	cancel()
+	go io.Copy(io.Discard, r)
+	if err := <-done; err != nil {
+		t.Fatalf("logChanges() error = %v", err)
+	}
+	<-writing
+}
+
+func TestDaemonLaunchdPlist(t *testing.T) {
+	code, stdout, stderr := runCLI("daemon", "-domains", "com.apple.dock, com.apple.finder", "-log", "/var/log/prefs.jsonl", "-host", "current", "-launchd-plist")
+	if code != 0 {
+		t.Fatalf("daemon -launchd-plist exit = %d: %s", code, stderr)
+	}
+	for _, want := range []string{"<string>" + defaultDaemonLabel + "</string>", "<string>com.apple.dock,com.apple.finder</string>", "<string>/var/log/prefs.jsonl</string>", "<string>-host</string>\n\t\t<string>current</string>"} {
+		if !strings.Contains(stdout, want) {
+			t.Errorf("daemon -launchd-plist output is missing %s:\n%s", want, stdout)
+		}
+	}
+
+	if code, _, _ := runCLI("daemon"); code != 2 {
+		t.Fatalf("daemon without -domains exit = %d, want 2", code)
+	}
+}
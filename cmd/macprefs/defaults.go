@@ -0,0 +1,124 @@
+package main
+
+import (
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// defaultsTypes maps the type flags of defaults write to those of macprefs write.
+var defaultsTypes = map[string]string{
+	"-string":    "-string",
+	"-bool":      "-bool",
+	"-boolean":   "-bool",
+	"-int":       "-int",
+	"-integer":   "-int",
+	"-float":     "-float",
+	"-date":      "-date",
+	"-data":      "-data",
+	"-array":     "-array",
+	"-array-add": "-array-add",
+	"-dict":      "-dict",
+	"-dict-add":  "-dict-add",
+}
+
+// translateDefaultsArgs rewrites a defaults(1) command line, such as
+// `write com.foo Key -bool TRUE`, into the equivalent macprefs arguments so existing scripts
+// can switch binaries without changes.
+func translateDefaultsArgs(args []string) ([]string, error) {
+	var flags []string
+	for len(args) > 0 && strings.HasPrefix(args[0], "-") {
+		switch args[0] {
+		case "-currentHost":
+			flags = append(flags, "-host", "current")
+			args = args[1:]
+		case "-host":
+			return nil, fmt.Errorf("-host <hostname> is not supported; use -currentHost")
+		default:
+			return nil, fmt.Errorf("unknown option %s", args[0])
+		}
+	}
+	if len(args) == 0 {
+		return nil, fmt.Errorf("missing command")
+	}
+
+	name, args := args[0], args[1:]
+	switch name {
+	case "help":
+		return []string{"help"}, nil
+	case "domains":
+		if len(args) != 0 {
+			return nil, fmt.Errorf("domains takes no arguments")
+		}
+		return append([]string{name}, flags...), nil
+	case "find":
+		if len(args) != 1 {
+			return nil, fmt.Errorf("find takes a word")
+		}
+		return append(append([]string{name}, flags...), "-regexp", "-i", regexp.QuoteMeta(args[0])), nil
+	}
+
+	if len(args) == 0 {
+		if name == "read" {
+			return nil, fmt.Errorf("reading every domain is not supported; use domains and read <domain>")
+		}
+		return nil, fmt.Errorf("missing domain")
+	}
+	domain, args, err := defaultsDomain(args)
+	if err != nil {
+		return nil, err
+	}
+
+	switch name {
+	case "read", "read-type", "delete":
+		return append(append(append([]string{name}, flags...), domain), args...), nil
+	case "write":
+		if len(args) == 1 {
+			return nil, fmt.Errorf("writing a domain from a plist string is not supported; use import")
+		}
+		if len(args) < 2 {
+			return nil, fmt.Errorf("write takes a domain, a key, and a value")
+		}
+		out := append(append([]string{name}, flags...), domain, args[0])
+		if t, ok := defaultsTypes[args[1]]; ok {
+			out = append(out, t)
+			args = args[1:]
+		} else if strings.HasPrefix(args[1], "-") && len(args) > 2 {
+			return nil, fmt.Errorf("unknown type %s", args[1])
+		}
+		return append(out, args[1:]...), nil
+	case "export":
+		if len(args) != 1 {
+			return nil, fmt.Errorf("export takes a domain and a path")
+		}
+		return append(append([]string{name}, flags...), "-o", args[0], domain), nil
+	case "import":
+		if len(args) != 1 {
+			return nil, fmt.Errorf("import takes a domain and a path")
+		}
+		return append(append([]string{name}, flags...), "-format", formatPlist, "-replace", domain, args[0]), nil
+	default:
+		return nil, fmt.Errorf("unsupported command %q", name)
+	}
+}
+
+// defaultsDomain parses the domain of a defaults command line: a bundle identifier, -g, or
+// the path of a plist in a Preferences directory.
+func defaultsDomain(args []string) (string, []string, error) {
+	domain := args[0]
+	switch {
+	case domain == "-g" || domain == "-globalDomain":
+		return "NSGlobalDomain", args[1:], nil
+	case domain == "-app":
+		return "", nil, fmt.Errorf("-app is not supported; use the application's bundle identifier")
+	case strings.HasPrefix(domain, "-"):
+		return "", nil, fmt.Errorf("unknown domain option %s", domain)
+	case strings.ContainsRune(domain, '/'):
+		if filepath.Base(filepath.Dir(domain)) != "Preferences" {
+			return "", nil, fmt.Errorf("domain path %s is not in a Preferences directory", domain)
+		}
+		return strings.TrimSuffix(filepath.Base(domain), ".plist"), args[1:], nil
+	}
+	return domain, args[1:], nil
+}
@@ -0,0 +1,56 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestTranslateDefaultsArgs(t *testing.T) {
+	tests := []struct {
+		args []string
+		want []string
+	}{
+		{[]string{"read", "com.foo"}, []string{"read", "com.foo"}},
+		{[]string{"-currentHost", "read", "-g", "Key"}, []string{"read", "-host", "current", "NSGlobalDomain", "Key"}},
+		{[]string{"write", "com.foo", "Key", "-bool", "TRUE"}, []string{"write", "com.foo", "Key", "-bool", "TRUE"}},
+		{[]string{"write", "com.foo", "Key", "-integer", "5"}, []string{"write", "com.foo", "Key", "-int", "5"}},
+		{[]string{"write", "com.foo", "Key", "-array-add", "a"}, []string{"write", "com.foo", "Key", "-array-add", "a"}},
+		{[]string{"write", "com.foo", "Key", "value"}, []string{"write", "com.foo", "Key", "value"}},
+		{[]string{"write", "/Library/Preferences/com.foo.plist", "Key", "value"}, []string{"write", "com.foo", "Key", "value"}},
+		{[]string{"delete", "com.foo"}, []string{"delete", "com.foo"}},
+		{[]string{"read-type", "com.foo", "Key"}, []string{"read-type", "com.foo", "Key"}},
+		{[]string{"domains"}, []string{"domains"}},
+		{[]string{"find", "a.b"}, []string{"find", "-regexp", "-i", `a\.b`}},
+		{[]string{"export", "com.foo", "-"}, []string{"export", "-o", "-", "com.foo"}},
+		{[]string{"import", "com.foo", "foo.plist"}, []string{"import", "-format", "plist", "-replace", "com.foo", "foo.plist"}},
+		{[]string{"help"}, []string{"help"}},
+	}
+	for _, tt := range tests {
+		got, err := translateDefaultsArgs(tt.args)
+		if err != nil {
+			t.Errorf("translateDefaultsArgs(%q) error = %v", tt.args, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("translateDefaultsArgs(%q) = %q, want %q", tt.args, got, tt.want)
+		}
+	}
+}
+
+func TestTranslateDefaultsArgsErrors(t *testing.T) {
+	for _, args := range [][]string{
+		{},
+		{"-host", "other", "read", "com.foo"},
+		{"read"},
+		{"rename", "com.foo", "a", "b"},
+		{"write", "com.foo", "{ Key = 1; }"},
+		{"write", "com.foo", "Key", "-number", "1"},
+		{"write", "-app", "Safari", "Key", "1"},
+		{"write", "/tmp/com.foo.plist", "Key", "1"},
+		{"find"},
+	} {
+		if got, err := translateDefaultsArgs(args); err == nil {
+			t.Errorf("translateDefaultsArgs(%q) = %q, expected error", args, got)
+		}
+	}
+}
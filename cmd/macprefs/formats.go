@@ -0,0 +1,207 @@
+package main
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Formats understood by export and import.
+const (
+	formatXML    = "xml"
+	formatBinary = "binary"
+	formatPlist  = "plist"
+	formatJSON   = "json"
+	formatYAML   = "yaml"
+)
+
+// Keys of the single-key dictionaries that stand in for dates and data in JSON and YAML.
+const (
+	dateTag = "$date"
+	dataTag = "$data"
+)
+
+// formatFromPath infers an import format from a file extension, defaulting to plist.
+func formatFromPath(path string) string {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".json":
+		return formatJSON
+	case ".yaml", ".yml":
+		return formatYAML
+	default:
+		return formatPlist
+	}
+}
+
+// encodeValues encodes a domain as JSON or YAML. Neither format has a native date or data
+// type, so dates are written as {"$date": "<RFC 3339>"} and data as {"$data": "<base64>"},
+// which decodeValues turns back into time.Time and []byte.
+func encodeValues(values map[string]interface{}, format string) ([]byte, error) {
+	values = tagValue(values).(map[string]interface{})
+	switch format {
+	case formatJSON:
+		data, err := json.MarshalIndent(values, "", "  ")
+		if err != nil {
+			return nil, err
+		}
+		return append(data, '\n'), nil
+	case formatYAML:
+		var buf bytes.Buffer
+		enc := yaml.NewEncoder(&buf)
+		enc.SetIndent(2)
+		if err := enc.Encode(values); err != nil {
+			return nil, err
+		}
+		if err := enc.Close(); err != nil {
+			return nil, err
+		}
+		return buf.Bytes(), nil
+	default:
+		return nil, fmt.Errorf("unsupported format %q", format)
+	}
+}
+
+// decodeValues decodes a JSON or YAML document whose root is a dictionary. Whole numbers are
+// returned as int and other numbers as float64, matching the values read from CoreFoundation,
+// and the $date and $data dictionaries written by encodeValues as time.Time and []byte.
+func decodeValues(data []byte, format string) (map[string]interface{}, error) {
+	var root interface{}
+	switch format {
+	case formatJSON:
+		dec := json.NewDecoder(bytes.NewReader(data))
+		dec.UseNumber()
+		if err := dec.Decode(&root); err != nil {
+			return nil, fmt.Errorf("error parsing JSON: %v", err)
+		}
+	case formatYAML:
+		if err := yaml.Unmarshal(data, &root); err != nil {
+			return nil, fmt.Errorf("error parsing YAML: %v", err)
+		}
+	default:
+		return nil, fmt.Errorf("unsupported format %q", format)
+	}
+
+	normalized, err := normalizeValue(root, "")
+	if err != nil {
+		return nil, err
+	}
+	values, ok := normalized.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("%s document does not contain a dictionary", format)
+	}
+	return values, nil
+}
+
+// normalizeValue converts decoded JSON and YAML values to the types accepted by mac_prefs.
+func normalizeValue(v interface{}, path string) (interface{}, error) {
+	switch v := v.(type) {
+	case json.Number:
+		if i, err := v.Int64(); err == nil {
+			return int(i), nil
+		}
+		return v.Float64()
+	case []interface{}:
+		out := make([]interface{}, len(v))
+		for i, item := range v {
+			n, err := normalizeValue(item, fmt.Sprintf("%s[%d]", path, i))
+			if err != nil {
+				return nil, err
+			}
+			out[i] = n
+		}
+		return out, nil
+	case map[string]interface{}:
+		if len(v) == 1 {
+			if tagged, ok, err := untagValue(v, path); ok {
+				return tagged, err
+			}
+		}
+		out := make(map[string]interface{}, len(v))
+		for k, item := range v {
+			n, err := normalizeValue(item, joinPath(path, k))
+			if err != nil {
+				return nil, err
+			}
+			out[k] = n
+		}
+		return out, nil
+	case map[interface{}]interface{}:
+		out := make(map[string]interface{}, len(v))
+		for k, item := range v {
+			out[fmt.Sprint(k)] = item
+		}
+		return normalizeValue(out, path)
+	case nil:
+		if path == "" {
+			return nil, fmt.Errorf("document is empty")
+		}
+		return nil, fmt.Errorf("null value at %s is not supported", path)
+	default:
+		return v, nil
+	}
+}
+
+// tagValue replaces the dates and data in v with $date and $data dictionaries.
+func tagValue(v interface{}) interface{} {
+	switch v := v.(type) {
+	case time.Time:
+		return map[string]interface{}{dateTag: v.UTC().Format(time.RFC3339Nano)}
+	case []byte:
+		return map[string]interface{}{dataTag: base64.StdEncoding.EncodeToString(v)}
+	case []interface{}:
+		out := make([]interface{}, len(v))
+		for i, item := range v {
+			out[i] = tagValue(item)
+		}
+		return out
+	case map[string]interface{}:
+		out := make(map[string]interface{}, len(v))
+		for k, item := range v {
+			out[k] = tagValue(item)
+		}
+		return out
+	default:
+		return v
+	}
+}
+
+// untagValue decodes a $date or $data dictionary. It returns false if v is not one.
+func untagValue(v map[string]interface{}, path string) (interface{}, bool, error) {
+	if s, ok := v[dateTag]; ok {
+		switch s := s.(type) {
+		case time.Time:
+			return s, true, nil
+		case string:
+			t, err := time.Parse(time.RFC3339Nano, s)
+			if err != nil {
+				return nil, true, fmt.Errorf("invalid %s at %s: %v", dateTag, path, err)
+			}
+			return t, true, nil
+		}
+		return nil, true, fmt.Errorf("invalid %s at %s: must be an RFC 3339 string", dateTag, path)
+	}
+	if s, ok := v[dataTag]; ok {
+		if s, ok := s.(string); ok {
+			data, err := base64.StdEncoding.DecodeString(s)
+			if err != nil {
+				return nil, true, fmt.Errorf("invalid %s at %s: %v", dataTag, path, err)
+			}
+			return data, true, nil
+		}
+		return nil, true, fmt.Errorf("invalid %s at %s: must be a base64 string", dataTag, path)
+	}
+	return nil, false, nil
+}
+
+func joinPath(path, key string) string {
+	if path == "" {
+		return key
+	}
+	return path + "." + key
+}
@@ -0,0 +1,72 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestEncodeDecodeValues(t *testing.T) {
+	values := map[string]interface{}{
+		"autohide": true,
+		"tilesize": 48,
+		"scale":    1.5,
+		"name":     "dock",
+		"apps":     []interface{}{"Safari", map[string]interface{}{"label": "Mail"}},
+		"modified": time.Date(2024, 5, 1, 12, 30, 0, 500000000, time.UTC),
+		"data":     []byte{0, 1, 0xff},
+		"bookmark": map[string]interface{}{"data": []byte("bookmark"), "dates": []interface{}{time.Date(1999, 12, 31, 0, 0, 0, 0, time.UTC)}},
+	}
+
+	for _, format := range []string{formatJSON, formatYAML} {
+		data, err := encodeValues(values, format)
+		if err != nil {
+			t.Fatalf("encodeValues(%s) error = %v", format, err)
+		}
+		got, err := decodeValues(data, format)
+		if err != nil {
+			t.Fatalf("decodeValues(%s) error = %v\n%s", format, err, data)
+		}
+		if !reflect.DeepEqual(got, values) {
+			t.Errorf("%s round trip got = %#v, want %#v", format, got, values)
+		}
+	}
+}
+
+func TestDecodeValuesErrors(t *testing.T) {
+	tests := []struct {
+		data   string
+		format string
+	}{
+		{`[1, 2]`, formatJSON},
+		{`{"a": null}`, formatJSON},
+		{`{`, formatJSON},
+		{`- a`, formatYAML},
+		{``, formatYAML},
+		{`{}`, "toml"},
+		{`{"a": {"$date": "yesterday"}}`, formatJSON},
+		{`{"a": {"$data": "!!"}}`, formatJSON},
+		{`{"a": {"$data": 1}}`, formatJSON},
+		{"a:\n  $date: 12", formatYAML},
+	}
+	for _, tt := range tests {
+		if _, err := decodeValues([]byte(tt.data), tt.format); err == nil {
+			t.Errorf("decodeValues(%q, %s) expected error", tt.data, tt.format)
+		}
+	}
+}
+
+func TestFormatFromPath(t *testing.T) {
+	tests := map[string]string{
+		"dock.json":  formatJSON,
+		"dock.YAML":  formatYAML,
+		"dock.yml":   formatYAML,
+		"dock.plist": formatPlist,
+		"-":          formatPlist,
+	}
+	for path, want := range tests {
+		if got := formatFromPath(path); got != want {
+			t.Errorf("formatFromPath(%q) = %q, want %q", path, got, want)
+		}
+	}
+}
@@ -0,0 +1,18 @@
+package main
+
+import "github.com/weswhet/mac_prefs/internal/plist"
+
+// defaultDaemonLabel is the launchd label of the job printed by daemon -launchd-plist.
+const defaultDaemonLabel = "com.github.weswhet.macprefs.daemon"
+
+// launchdPlist returns a launchd job definition that keeps args running, suitable for
+// /Library/LaunchDaemons or ~/Library/LaunchAgents.
+func launchdPlist(label string, args []string) ([]byte, error) {
+	return plist.MarshalXML(map[string]interface{}{
+		"Label":            label,
+		"ProgramArguments": args,
+		"RunAtLoad":        true,
+		"KeepAlive":        true,
+		"ProcessType":      "Background",
+	})
+}
@@ -0,0 +1,40 @@
+package main
+
+import "testing"
+
+func TestLaunchdPlist(t *testing.T) {
+	data, err := launchdPlist("com.example.prefs", []string{"/usr/local/bin/macprefs", "daemon", "-domains", "com.apple.dock,a&b", "-user", "alice", "-host", "current"})
+	if err != nil {
+		t.Fatalf("launchdPlist() error = %v", err)
+	}
+	got := string(data)
+	want := `<?xml version="1.0" encoding="UTF-8"?>
+<!DOCTYPE plist PUBLIC "-//Apple//DTD PLIST 1.0//EN" "http://www.apple.com/DTDs/PropertyList-1.0.dtd">
+<plist version="1.0">
+<dict>
+	<key>KeepAlive</key>
+	<true/>
+	<key>Label</key>
+	<string>com.example.prefs</string>
+	<key>ProcessType</key>
+	<string>Background</string>
+	<key>ProgramArguments</key>
+	<array>
+		<string>/usr/local/bin/macprefs</string>
+		<string>daemon</string>
+		<string>-domains</string>
+		<string>com.apple.dock,a&amp;b</string>
+		<string>-user</string>
+		<string>alice</string>
+		<string>-host</string>
+		<string>current</string>
+	</array>
+	<key>RunAtLoad</key>
+	<true/>
+</dict>
+</plist>
+`
+	if got != want {
+		t.Fatalf("launchdPlist() =\n%s\nwant\n%s", got, want)
+	}
+}
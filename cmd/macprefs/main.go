@@ -0,0 +1,718 @@
+//go:build darwin
+
+// Command macprefs reads and writes macOS preferences. It is a scriptable alternative to
+// `defaults` built on github.com/weswhet/mac_prefs.
+//
+// Usage:
+//
+//	macprefs read [-host current|any] [-user current|any|<name|uid>] <domain> [key]
+//	macprefs read-type [flags] <domain> <key>
+//	macprefs write [flags] <domain> <key> [-string|-bool|-int|-float|-array|-array-add|-dict|-dict-add|-date|-data] <value>...
+//	macprefs delete [flags] <domain> [key]
+//	macprefs export [flags] [-format xml|binary|json|yaml] [-o file] [-no-redact] <domain>
+//	macprefs import [flags] [-format plist|json|yaml] [-replace] <domain> <file|->
+//	macprefs watch [flags] [-interval duration] <domain>
+//	macprefs domains [flags] [-json]
+//	macprefs find [flags] [-regexp] [-i] [-keys] [-values] [-json] <pattern>
+//	macprefs serve [flags] [-addr 127.0.0.1:8398]
+//	macprefs exporter [flags] [-addr 127.0.0.1:9398] [-config file] [-key domain:key]...
+//	macprefs daemon [flags] -domains a,b,c [-log file] [-interval duration] [-launchd-plist [-label label]]
+//
+// The domain NSGlobalDomain (or -g) refers to the global preferences domain.
+//
+// Run as root, -user <name|uid> re-executes the command as that user with
+// `launchctl asuser <uid> sudo -u <name>`, so the user's cfprefsd writes the preferences and
+// the files keep the user's ownership.
+//
+// Scripts written for defaults can run unchanged with `macprefs defaults <arguments>`, or by
+// invoking macprefs through a symlink named defaults.
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"os/signal"
+	"os/user"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/weswhet/mac_prefs"
+	"github.com/weswhet/mac_prefs/httpapi"
+	"github.com/weswhet/mac_prefs/metrics"
+)
+
+const usage = `usage: macprefs <command> [flags] <domain> [key] [value]
+
+commands:
+  read       print a domain or a single key
+  read-type  print the type of a key
+  write      write a key: write <domain> <key> [-string|-bool|-int|-float|-array|-array-add|-dict|-dict-add|-date|-data] <value>...
+  delete     delete a key, or every key of a domain
+  export     write a domain to stdout or a file: export [-format xml|binary|json|yaml] [-o file] [-no-redact] <domain>
+             the values of keys that look like tokens, passwords, and serial numbers are masked
+             unless -no-redact is given
+  import     apply a file (or - for stdin) to a domain: import [-format plist|json|yaml] [-replace] <domain> <file>
+  watch      print changes to a domain as JSON lines until interrupted: watch [-interval 1s] <domain>
+  domains    list every preference domain: domains [-json]
+  find       search keys and string values of every domain: find [-regexp] [-i] [-keys] [-values] [-json] <pattern>
+  serve      serve preferences read-only as JSON over HTTP on localhost: serve [-addr 127.0.0.1:8398]
+  exporter   serve selected keys as Prometheus metrics on /metrics:
+             exporter [-addr 127.0.0.1:9398] [-config file] [-key domain:key]...
+  daemon     append changes to several domains to a JSON lines log until stopped:
+             daemon -domains a,b,c [-log file] [-interval 1s]; -launchd-plist prints a launchd job instead
+  defaults   run a defaults(1) command line, e.g. defaults -currentHost write com.foo Key -bool TRUE
+
+flags:
+  -host current|any         host scope (default any)
+  -user current|any|<name|uid>
+                            user scope (default current); as root, a named user's
+                            command runs as that user through launchctl asuser
+`
+
+// errUsage reports a usage error, which exits with status 2.
+type errUsage string
+
+func (e errUsage) Error() string { return string(e) }
+
+func main() {
+	args := os.Args[1:]
+	if filepath.Base(os.Args[0]) == "defaults" {
+		args = append([]string{"defaults"}, args...)
+	}
+	os.Exit(run(args, os.Stdin, os.Stdout, os.Stderr))
+}
+
+// run executes a command and returns the process exit status.
+func run(args []string, stdin io.Reader, stdout, stderr io.Writer) int {
+	if len(args) == 0 || args[0] == "-h" || args[0] == "-help" || args[0] == "help" {
+		fmt.Fprint(stderr, usage)
+		return 2
+	}
+
+	if args[0] == "defaults" {
+		translated, err := translateDefaultsArgs(args[1:])
+		if err != nil {
+			fmt.Fprintf(stderr, "macprefs defaults: %v\n\n%s", err, usage)
+			return 2
+		}
+		args = translated
+		if args[0] == "help" {
+			fmt.Fprint(stderr, usage)
+			return 2
+		}
+	}
+
+	commands := map[string]func(*command) error{
+		"read":      cmdRead,
+		"read-type": cmdReadType,
+		"write":     cmdWrite,
+		"delete":    cmdDelete,
+		"export":    cmdExport,
+		"import":    cmdImport,
+		"watch":     cmdWatch,
+		"domains":   cmdDomains,
+		"find":      cmdFind,
+		"daemon":    cmdDaemon,
+		"serve":     cmdServe,
+		"exporter":  cmdExporter,
+	}
+	fn, ok := commands[args[0]]
+	if !ok {
+		fmt.Fprintf(stderr, "macprefs: unknown command %q\n\n%s", args[0], usage)
+		return 2
+	}
+
+	cmd, err := parseCommand(args[0], args[1:], stdin, stdout, stderr)
+	if err == nil && cmd.asUser != nil {
+		return runAsUser(cmd.asUser, args, stdin, stdout, stderr)
+	}
+	if err == nil {
+		err = fn(cmd)
+	}
+	switch err.(type) {
+	case nil:
+		return 0
+	case errUsage:
+		fmt.Fprintf(stderr, "macprefs %s: %v\n\n%s", args[0], err, usage)
+		return 2
+	default:
+		fmt.Fprintf(stderr, "macprefs %s: %v\n", args[0], err)
+		return 1
+	}
+}
+
+// command is a parsed command line.
+type command struct {
+	name   string
+	scope  mac_prefs.PreferenceScope
+	domain string
+	args   []string
+	asUser *user.User
+	stdin  io.Reader
+	stdout io.Writer
+	stderr io.Writer
+
+	// scopeArgs are the -user and -host flags as given, for commands that print a command
+	// line running macprefs again.
+	scopeArgs []string
+
+	// Flags of export and import.
+	format   string
+	output   string
+	noRedact bool
+	replace  bool
+
+	// Flags of watch and daemon.
+	interval time.Duration
+
+	// Flags of daemon.
+	domains      string
+	logPath      string
+	launchdPlist bool
+	label        string
+
+	// Flags of serve and exporter.
+	addr string
+
+	// Flags of exporter.
+	config  string
+	targets stringList
+
+	// Flags of domains and find. scopes are the scopes to walk: the one selected by -host, or
+	// both hosts of the user when -host is not given.
+	scopes     []mac_prefs.PreferenceScope
+	json       bool
+	regexp     bool
+	ignoreCase bool
+	keys       bool
+	values     bool
+}
+
+// stringList is a flag that may be repeated.
+type stringList []string
+
+func (l *stringList) String() string { return strings.Join(*l, ",") }
+
+func (l *stringList) Set(s string) error {
+	*l = append(*l, s)
+	return nil
+}
+
+// domainless lists the commands that do not take a domain argument.
+var domainless = map[string]bool{"domains": true, "find": true, "daemon": true, "serve": true, "exporter": true}
+
+func parseCommand(name string, args []string, stdin io.Reader, stdout, stderr io.Writer) (*command, error) {
+	cmd := &command{name: name, stdin: stdin, stdout: stdout, stderr: stderr}
+
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	host := fs.String("host", "any", "")
+	userName := fs.String("user", "current", "")
+	switch name {
+	case "export":
+		fs.StringVar(&cmd.format, "format", formatXML, "")
+		fs.StringVar(&cmd.output, "o", "", "")
+		fs.BoolVar(&cmd.noRedact, "no-redact", false, "")
+	case "import":
+		fs.StringVar(&cmd.format, "format", "", "")
+		fs.BoolVar(&cmd.replace, "replace", false, "")
+	case "watch":
+		fs.DurationVar(&cmd.interval, "interval", mac_prefs.DefaultWatchInterval, "")
+	case "daemon":
+		fs.DurationVar(&cmd.interval, "interval", mac_prefs.DefaultWatchInterval, "")
+		fs.StringVar(&cmd.domains, "domains", "", "")
+		fs.StringVar(&cmd.logPath, "log", "", "")
+		fs.BoolVar(&cmd.launchdPlist, "launchd-plist", false, "")
+		fs.StringVar(&cmd.label, "label", defaultDaemonLabel, "")
+	case "serve":
+		fs.StringVar(&cmd.addr, "addr", httpapi.DefaultAddr, "")
+	case "exporter":
+		fs.StringVar(&cmd.addr, "addr", metrics.DefaultAddr, "")
+		fs.StringVar(&cmd.config, "config", "", "")
+		fs.Var(&cmd.targets, "key", "")
+	case "domains":
+		fs.BoolVar(&cmd.json, "json", false, "")
+	case "find":
+		fs.BoolVar(&cmd.json, "json", false, "")
+		fs.BoolVar(&cmd.regexp, "regexp", false, "")
+		fs.BoolVar(&cmd.ignoreCase, "i", false, "")
+		fs.BoolVar(&cmd.keys, "keys", false, "")
+		fs.BoolVar(&cmd.values, "values", false, "")
+	}
+	if err := fs.Parse(args); err != nil {
+		return nil, errUsage(err.Error())
+	}
+
+	switch *host {
+	case "any":
+		cmd.scope.Host = mac_prefs.AnyHost
+	case "current":
+		cmd.scope.Host = mac_prefs.CurrentHost
+	default:
+		return nil, errUsage(fmt.Sprintf("invalid -host %q: must be current or any", *host))
+	}
+	switch *userName {
+	case "current":
+		cmd.scope.User = mac_prefs.CurrentUser
+	case "any":
+		cmd.scope.User = mac_prefs.AnyUser
+	default:
+		u, err := lookupUser(*userName)
+		if err != nil {
+			return nil, err
+		}
+		switch {
+		case u.Uid == strconv.Itoa(os.Getuid()):
+			cmd.scope.User = mac_prefs.CurrentUser
+		case os.Geteuid() == 0:
+			// Writing another user's domain as root leaves root-owned files behind and
+			// bypasses the user's cfprefsd, so run the command as that user instead.
+			cmd.asUser = u
+			cmd.scope.User = mac_prefs.CurrentUser
+		default:
+			cmd.scope.User = mac_prefs.UserType(u.Username)
+		}
+	}
+
+	cmd.scopes = []mac_prefs.PreferenceScope{cmd.scope}
+	hostSet := false
+	fs.Visit(func(f *flag.Flag) {
+		if f.Name == "user" || f.Name == "host" {
+			cmd.scopeArgs = append(cmd.scopeArgs, "-"+f.Name, f.Value.String())
+		}
+		hostSet = hostSet || f.Name == "host"
+	})
+	if !hostSet {
+		cmd.scopes = []mac_prefs.PreferenceScope{
+			{User: cmd.scope.User, Host: mac_prefs.AnyHost},
+			{User: cmd.scope.User, Host: mac_prefs.CurrentHost},
+		}
+	}
+
+	if domainless[name] {
+		cmd.args = fs.Args()
+		return cmd, nil
+	}
+	if fs.NArg() == 0 {
+		return nil, errUsage("missing domain")
+	}
+	cmd.domain = domainName(fs.Arg(0))
+	cmd.args = fs.Args()[1:]
+	return cmd, nil
+}
+
+// domainName maps the defaults spellings of the global domain to AnyApplication.
+func domainName(domain string) string {
+	switch domain {
+	case "NSGlobalDomain", "-g", "-globalDomain", mac_prefs.GlobalDomain:
+		return mac_prefs.AnyApplication
+	}
+	return domain
+}
+
+func cmdRead(c *command) error {
+	if len(c.args) > 1 {
+		return errUsage("read takes a domain and an optional key")
+	}
+	if len(c.args) == 0 {
+		values, err := mac_prefs.GetAll(c.domain, c.scope)
+		if err != nil {
+			return err
+		}
+		if len(values) == 0 {
+			return fmt.Errorf("domain %s does not exist", c.domain)
+		}
+		return printValue(c.stdout, values)
+	}
+
+	value, err := readKey(c)
+	if err != nil {
+		return err
+	}
+	return printValue(c.stdout, value)
+}
+
+func cmdReadType(c *command) error {
+	if len(c.args) != 1 {
+		return errUsage("read-type takes a domain and a key")
+	}
+	value, err := readKey(c)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(c.stdout, "Type is %s\n", typeName(value))
+	return nil
+}
+
+func cmdWrite(c *command) error {
+	if len(c.args) < 2 {
+		return errUsage("write takes a domain, a key, and a value")
+	}
+	if flag := c.args[1]; flag == "-array-add" || flag == "-dict-add" {
+		existing, err := mac_prefs.Get(c.args[0], c.domain, c.scope)
+		if err != nil {
+			return err
+		}
+		value, err := addValue(existing, flag, c.args[2:])
+		if err != nil {
+			return errUsage(err.Error())
+		}
+		return mac_prefs.Set(c.args[0], value, c.domain, c.scope)
+	}
+
+	value, err := parseValue(c.args[1:])
+	if err != nil {
+		return errUsage(err.Error())
+	}
+	return mac_prefs.Set(c.args[0], value, c.domain, c.scope)
+}
+
+func cmdDelete(c *command) error {
+	switch len(c.args) {
+	case 0:
+		keys, err := mac_prefs.Keys(c.domain, c.scope)
+		if err != nil {
+			return err
+		}
+		if len(keys) == 0 {
+			return fmt.Errorf("domain %s does not exist", c.domain)
+		}
+		sort.Strings(keys)
+		return mac_prefs.SetMultiple(nil, keys, c.domain, c.scope)
+	case 1:
+		if _, err := readKey(c); err != nil {
+			return err
+		}
+		return mac_prefs.Delete(c.args[0], c.domain, c.scope)
+	default:
+		return errUsage("delete takes a domain and an optional key")
+	}
+}
+
+func cmdExport(c *command) error {
+	if len(c.args) != 0 {
+		return errUsage("export takes a domain")
+	}
+
+	var data []byte
+	switch c.format {
+	case formatXML, formatBinary:
+		format := mac_prefs.FormatXML
+		if c.format == formatBinary {
+			format = mac_prefs.FormatBinary
+		}
+		var buf bytes.Buffer
+		export := mac_prefs.Export
+		if c.noRedact {
+			export = mac_prefs.NewClient(mac_prefs.WithRedactor(nil)).Export
+		}
+		if err := export(c.domain, c.scope, &buf, format); err != nil {
+			return err
+		}
+		data = buf.Bytes()
+	case formatJSON, formatYAML:
+		values, err := mac_prefs.GetAll(c.domain, c.scope)
+		if err != nil {
+			return err
+		}
+		if !c.noRedact {
+			values = mac_prefs.DefaultRedactor.RedactValues(values)
+		}
+		if data, err = encodeValues(values, c.format); err != nil {
+			return err
+		}
+	default:
+		return errUsage(fmt.Sprintf("invalid -format %q: must be xml, binary, json, or yaml", c.format))
+	}
+
+	if c.output == "" || c.output == "-" {
+		_, err := c.stdout.Write(data)
+		return err
+	}
+	return os.WriteFile(c.output, data, 0o644)
+}
+
+func cmdImport(c *command) error {
+	if len(c.args) != 1 {
+		return errUsage("import takes a domain and a file")
+	}
+	path := c.args[0]
+	format := c.format
+	if format == "" {
+		format = formatFromPath(path)
+	}
+	mode := mac_prefs.ImportMerge
+	if c.replace {
+		mode = mac_prefs.ImportReplace
+	}
+
+	var data []byte
+	var err error
+	if path == "-" {
+		data, err = io.ReadAll(c.stdin)
+	} else {
+		data, err = os.ReadFile(path)
+	}
+	if err != nil {
+		return err
+	}
+
+	switch format {
+	case formatPlist, formatXML, formatBinary:
+		return mac_prefs.Import(c.domain, c.scope, bytes.NewReader(data), mode)
+	case formatJSON, formatYAML:
+		values, err := decodeValues(data, format)
+		if err != nil {
+			return err
+		}
+		return mac_prefs.ImportValues(c.domain, c.scope, values, mode)
+	default:
+		return errUsage(fmt.Sprintf("invalid -format %q: must be plist, json, or yaml", format))
+	}
+}
+
+func cmdWatch(c *command) error {
+	if len(c.args) != 0 {
+		return errUsage("watch takes a domain")
+	}
+	if c.interval <= 0 {
+		return errUsage(fmt.Sprintf("invalid -interval %v: must be positive", c.interval))
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	events, err := mac_prefs.Watch(ctx, c.domain, c.scope, c.interval)
+	if err != nil {
+		return err
+	}
+
+	enc := json.NewEncoder(c.stdout)
+	for e := range events {
+		if err := enc.Encode(newWatchRecord(e)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// watchRecord is the JSON form of a change event printed by watch and logged by daemon. The
+// values of sensitive keys are masked by mac_prefs.DefaultRedactor.
+type watchRecord struct {
+	Time   time.Time   `json:"time"`
+	Domain string      `json:"domain"`
+	Scope  scopeRecord `json:"scope"`
+	Key    string      `json:"key"`
+	Old    interface{} `json:"old"`
+	New    interface{} `json:"new"`
+}
+
+// scopeRecord spells a scope the way the -user and -host flags do.
+type scopeRecord struct {
+	User string `json:"user"`
+	Host string `json:"host"`
+}
+
+func newWatchRecord(e mac_prefs.Event) watchRecord {
+	domain := e.ApplicationID
+	if domain == mac_prefs.AnyApplication {
+		domain = "NSGlobalDomain"
+	}
+	redact := mac_prefs.DefaultRedactor.Redact
+	return watchRecord{Time: e.Time, Domain: domain, Scope: newScopeRecord(e.Scope), Key: e.Key, Old: redact(e.Key, e.Old), New: redact(e.Key, e.New)}
+}
+
+func newScopeRecord(scope mac_prefs.PreferenceScope) scopeRecord {
+	r := scopeRecord{User: string(scope.User), Host: "any"}
+	switch scope.User {
+	case mac_prefs.CurrentUser:
+		r.User = "current"
+	case mac_prefs.AnyUser:
+		r.User = "any"
+	}
+	if scope.Host == mac_prefs.CurrentHost {
+		r.Host = "current"
+	}
+	return r
+}
+
+func cmdDomains(c *command) error {
+	if len(c.args) != 0 {
+		return errUsage("domains takes no arguments")
+	}
+
+	seen := make(map[string]bool)
+	domains := make([]string, 0)
+	for _, scope := range c.scopes {
+		list, err := mac_prefs.ListDomains(scope)
+		if err != nil {
+			return err
+		}
+		for _, domain := range list {
+			if !seen[domain] {
+				seen[domain] = true
+				domains = append(domains, domain)
+			}
+		}
+	}
+	sort.Strings(domains)
+
+	if c.json {
+		return writeJSON(c.stdout, domains)
+	}
+	for _, domain := range domains {
+		fmt.Fprintln(c.stdout, domain)
+	}
+	return nil
+}
+
+func cmdFind(c *command) error {
+	if len(c.args) != 1 {
+		return errUsage("find takes a pattern")
+	}
+
+	matches, err := mac_prefs.Search(c.args[0], mac_prefs.SearchOptions{
+		Regexp:     c.regexp,
+		IgnoreCase: c.ignoreCase,
+		Keys:       c.keys,
+		Values:     c.values,
+		Scopes:     c.scopes,
+	})
+	if err != nil {
+		return err
+	}
+
+	if c.json {
+		records := make([]findRecord, 0, len(matches))
+		for _, m := range matches {
+			records = append(records, findRecord{
+				Domain:     m.Domain,
+				Scope:      newScopeRecord(m.Scope),
+				Key:        m.Key,
+				Path:       m.Path,
+				Value:      m.Value,
+				MatchedKey: m.MatchedKey,
+			})
+		}
+		return writeJSON(c.stdout, records)
+	}
+	for _, m := range matches {
+		fmt.Fprintf(c.stdout, "%s (%s host): %s = %s\n", m.Domain, newScopeRecord(m.Scope).Host, m.Path, summarizeValue(m.Value))
+	}
+	return nil
+}
+
+func cmdServe(c *command) error {
+	if len(c.args) != 0 {
+		return errUsage("serve takes no arguments")
+	}
+	l, err := httpapi.Listen(c.addr)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(c.stderr, "macprefs: serving on http://%s\n", l.Addr())
+	return http.Serve(l, httpapi.NewHandler(httpapi.Prefs{Scope: c.scope}))
+}
+
+func cmdExporter(c *command) error {
+	if len(c.args) != 0 {
+		return errUsage("exporter takes no arguments")
+	}
+
+	var targets []metrics.Target
+	if c.config != "" {
+		f, err := os.Open(c.config)
+		if err != nil {
+			return err
+		}
+		targets, err = metrics.ParseTargets(f)
+		f.Close()
+		if err != nil {
+			return err
+		}
+	}
+	for _, key := range c.targets {
+		t, err := metrics.ParseTarget(key)
+		if err != nil {
+			return errUsage(err.Error())
+		}
+		targets = append(targets, t)
+	}
+	if len(targets) == 0 {
+		return errUsage("exporter requires -config or -key")
+	}
+
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", metrics.Handler(targets, c.scope))
+	fmt.Fprintf(c.stderr, "macprefs: exporting %d keys on http://%s/metrics\n", len(targets), c.addr)
+	return http.ListenAndServe(c.addr, mux)
+}
+
+// findRecord is the JSON form of a match printed by find -json.
+type findRecord struct {
+	Domain     string      `json:"domain"`
+	Scope      scopeRecord `json:"scope"`
+	Key        string      `json:"key"`
+	Path       string      `json:"path"`
+	Value      interface{} `json:"value"`
+	MatchedKey bool        `json:"matchedKey"`
+}
+
+// summarizeValue renders a value on one line: scalars in OpenStep format, collections by
+// their type and size.
+func summarizeValue(v interface{}) string {
+	switch v := v.(type) {
+	case []interface{}:
+		return fmt.Sprintf("array (%d items)", len(v))
+	case map[string]interface{}:
+		return fmt.Sprintf("dictionary (%d keys)", len(v))
+	}
+	text, err := mac_prefs.FormatDefaults(v)
+	if err != nil {
+		return fmt.Sprint(v)
+	}
+	return text
+}
+
+// writeJSON writes v as indented JSON.
+func writeJSON(w io.Writer, v interface{}) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(v)
+}
+
+// readKey reads the key named by the first argument, failing if it does not exist.
+func readKey(c *command) (interface{}, error) {
+	value, err := mac_prefs.Get(c.args[0], c.domain, c.scope)
+	if err != nil {
+		return nil, err
+	}
+	if value == nil {
+		return nil, fmt.Errorf("the domain/default pair of (%s, %s) does not exist", c.domain, c.args[0])
+	}
+	return value, nil
+}
+
+// printValue prints a value the way defaults read does: strings bare, everything else in
+// OpenStep format.
+func printValue(w io.Writer, value interface{}) error {
+	if s, ok := value.(string); ok {
+		_, err := fmt.Fprintln(w, s)
+		return err
+	}
+	text, err := mac_prefs.FormatDefaults(value)
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintln(w, text)
+	return err
+}
@@ -0,0 +1,13 @@
+//go:build !darwin
+
+package main
+
+import (
+	"fmt"
+	"os"
+)
+
+func main() {
+	fmt.Fprintln(os.Stderr, "macprefs: only supported on macOS")
+	os.Exit(1)
+}
@@ -0,0 +1,255 @@
+//go:build darwin && cgo
+
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"os/user"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/weswhet/mac_prefs"
+)
+
+const testDomain = "com.github.weswhet.mac_prefs.test.cli"
+
+// runCLI runs the command line and returns its exit status and output.
+func runCLI(args ...string) (int, string, string) {
+	var stdout, stderr bytes.Buffer
+	code := run(args, strings.NewReader(""), &stdout, &stderr)
+	return code, stdout.String(), stderr.String()
+}
+
+func TestWriteReadDelete(t *testing.T) {
+	defer runCLI("delete", testDomain)
+
+	if code, _, stderr := runCLI("write", testDomain, "Enabled", "-bool", "yes"); code != 0 {
+		t.Fatalf("write exit = %d: %s", code, stderr)
+	}
+	if code, _, stderr := runCLI("write", testDomain, "Name", "hello world"); code != 0 {
+		t.Fatalf("write exit = %d: %s", code, stderr)
+	}
+
+	if _, stdout, _ := runCLI("read", testDomain, "Name"); stdout != "hello world\n" {
+		t.Fatalf("read Name = %q", stdout)
+	}
+	if _, stdout, _ := runCLI("read", testDomain, "Enabled"); stdout != "1\n" {
+		t.Fatalf("read Enabled = %q", stdout)
+	}
+	if _, stdout, _ := runCLI("read-type", testDomain, "Enabled"); stdout != "Type is boolean\n" {
+		t.Fatalf("read-type Enabled = %q", stdout)
+	}
+	if _, stdout, _ := runCLI("read", testDomain); !strings.Contains(stdout, "Name = \"hello world\";") {
+		t.Fatalf("read domain = %q", stdout)
+	}
+
+	if code, _, stderr := runCLI("delete", testDomain, "Name"); code != 0 {
+		t.Fatalf("delete exit = %d: %s", code, stderr)
+	}
+	if code, _, stderr := runCLI("read", testDomain, "Name"); code != 1 || !strings.Contains(stderr, "does not exist") {
+		t.Fatalf("read deleted key exit = %d: %s", code, stderr)
+	}
+}
+
+func TestUsageErrors(t *testing.T) {
+	for _, args := range [][]string{
+		{},
+		{"bogus"},
+		{"read"},
+		{"write", testDomain, "Key"},
+		{"write", testDomain, "Key", "-int", "x"},
+		{"read", "-host", "elsewhere", testDomain},
+	} {
+		if code, _, _ := runCLI(args...); code != 2 {
+			t.Errorf("run(%q) exit = %d, want 2", args, code)
+		}
+	}
+}
+
+func TestExportImport(t *testing.T) {
+	const other = testDomain + ".import"
+	defer runCLI("delete", testDomain)
+	defer runCLI("delete", other)
+
+	if code, _, stderr := runCLI("write", testDomain, "Size", "-int", "48"); code != 0 {
+		t.Fatalf("write exit = %d: %s", code, stderr)
+	}
+
+	for _, format := range []string{formatXML, formatJSON, formatYAML} {
+		t.Run(format, func(t *testing.T) {
+			file := filepath.Join(t.TempDir(), "export."+format)
+			if code, _, stderr := runCLI("export", "-no-redact", "-format", format, "-o", file, testDomain); code != 0 {
+				t.Fatalf("export exit = %d: %s", code, stderr)
+			}
+			if code, _, stderr := runCLI("write", other, "Stale", "x"); code != 0 {
+				t.Fatalf("write exit = %d: %s", code, stderr)
+			}
+			if code, _, stderr := runCLI("import", "-replace", other, file); code != 0 {
+				t.Fatalf("import exit = %d: %s", code, stderr)
+			}
+			if _, stdout, _ := runCLI("read", other); stdout != "{\n    Size = 48;\n}\n" {
+				t.Fatalf("read after import = %q", stdout)
+			}
+		})
+	}
+
+	if code, _, stderr := runCLI("write", testDomain, "AuthToken", "abc"); code != 0 {
+		t.Fatalf("write exit = %d: %s", code, stderr)
+	}
+	for _, format := range []string{formatXML, formatJSON} {
+		code, stdout, stderr := runCLI("export", "-format", format, testDomain)
+		if code != 0 {
+			t.Fatalf("export exit = %d: %s", code, stderr)
+		}
+		if !strings.Contains(stdout, "redacted") || strings.Contains(stdout, "abc") {
+			t.Errorf("export -format %s did not mask AuthToken:\n%s", format, stdout)
+		}
+	}
+
+	if code, _, _ := runCLI("export", "-format", "toml", testDomain); code != 2 {
+		t.Fatalf("export -format toml exit = %d, want 2", code)
+	}
+}
+
+func TestWatchRecord(t *testing.T) {
+	e := mac_prefs.Event{
+		Change:        mac_prefs.Change{Key: "autohide", Old: false, New: true},
+		ApplicationID: mac_prefs.AnyApplication,
+		Scope:         mac_prefs.CurrentUserCurrentHost,
+		Time:          time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC),
+	}
+	data, err := json.Marshal(newWatchRecord(e))
+	if err != nil {
+		t.Fatalf("json.Marshal() error = %v", err)
+	}
+	want := `{"time":"2024-01-02T03:04:05Z","domain":"NSGlobalDomain","scope":{"user":"current","host":"current"},"key":"autohide","old":false,"new":true}`
+	if string(data) != want {
+		t.Fatalf("newWatchRecord() = %s, want %s", data, want)
+	}
+
+	if code, _, _ := runCLI("watch", "-interval", "0s", testDomain); code != 2 {
+		t.Fatalf("watch -interval 0s exit = %d, want 2", code)
+	}
+}
+
+func TestDomainsFind(t *testing.T) {
+	defer runCLI("delete", testDomain)
+	if code, _, stderr := runCLI("write", testDomain, "MacprefsFindKey", "needle value"); code != 0 {
+		t.Fatalf("write exit = %d: %s", code, stderr)
+	}
+
+	code, stdout, stderr := runCLI("domains")
+	if code != 0 {
+		t.Fatalf("domains exit = %d: %s", code, stderr)
+	}
+	if !strings.Contains(stdout, testDomain+"\n") {
+		t.Fatalf("domains output does not list %s:\n%s", testDomain, stdout)
+	}
+
+	code, stdout, stderr = runCLI("domains", "-json", "-host", "any")
+	if code != 0 {
+		t.Fatalf("domains -json exit = %d: %s", code, stderr)
+	}
+	var domains []string
+	if err := json.Unmarshal([]byte(stdout), &domains); err != nil {
+		t.Fatalf("domains -json output is not JSON: %v\n%s", err, stdout)
+	}
+
+	code, stdout, stderr = runCLI("find", "-values", "needle*")
+	if code != 0 {
+		t.Fatalf("find exit = %d: %s", code, stderr)
+	}
+	if want := testDomain + " (any host): MacprefsFindKey = \"needle value\"\n"; stdout != want {
+		t.Fatalf("find output = %q, want %q", stdout, want)
+	}
+
+	code, stdout, stderr = runCLI("find", "-json", "-i", "macprefsfindkey")
+	if code != 0 {
+		t.Fatalf("find -json exit = %d: %s", code, stderr)
+	}
+	var records []findRecord
+	if err := json.Unmarshal([]byte(stdout), &records); err != nil {
+		t.Fatalf("find -json output is not JSON: %v\n%s", err, stdout)
+	}
+	want := []findRecord{{
+		Domain:     testDomain,
+		Scope:      scopeRecord{User: "current", Host: "any"},
+		Key:        "MacprefsFindKey",
+		Path:       "MacprefsFindKey",
+		Value:      "needle value",
+		MatchedKey: true,
+	}}
+	if !reflect.DeepEqual(records, want) {
+		t.Fatalf("find -json got = %+v, want %+v", records, want)
+	}
+
+	if code, _, _ := runCLI("find"); code != 2 {
+		t.Fatalf("find without pattern exit = %d, want 2", code)
+	}
+}
+
+func TestDefaultsMode(t *testing.T) {
+	defer runCLI("delete", testDomain)
+
+	if code, _, stderr := runCLI("defaults", "write", testDomain, "Enabled", "-boolean", "TRUE"); code != 0 {
+		t.Fatalf("defaults write exit = %d: %s", code, stderr)
+	}
+	if code, _, stderr := runCLI("defaults", "write", testDomain, "Apps", "-array-add", "Safari"); code != 0 {
+		t.Fatalf("defaults write -array-add exit = %d: %s", code, stderr)
+	}
+	if code, _, stderr := runCLI("defaults", "write", testDomain, "Apps", "-array-add", "Mail"); code != 0 {
+		t.Fatalf("defaults write -array-add exit = %d: %s", code, stderr)
+	}
+
+	code, stdout, stderr := runCLI("defaults", "read", testDomain)
+	if code != 0 {
+		t.Fatalf("defaults read exit = %d: %s", code, stderr)
+	}
+	if want := "{\n    Apps =     (\n        Safari,\n        Mail\n    );\n    Enabled = 1;\n}\n"; stdout != want {
+		t.Fatalf("defaults read = %q, want %q", stdout, want)
+	}
+
+	if code, _, _ := runCLI("defaults", "rename", testDomain, "Apps", "Other"); code != 2 {
+		t.Fatalf("defaults rename exit = %d, want 2", code)
+	}
+}
+
+func TestUserFlag(t *testing.T) {
+	current, err := user.Current()
+	if err != nil {
+		t.Skipf("user.Current() error = %v", err)
+	}
+	defer runCLI("delete", testDomain)
+
+	// Naming the user the command already runs as selects the current user directly.
+	if code, _, stderr := runCLI("write", "-user", current.Uid, testDomain, "Name", "value"); code != 0 {
+		t.Fatalf("write -user %s exit = %d: %s", current.Uid, code, stderr)
+	}
+	if code, stdout, stderr := runCLI("read", testDomain, "Name"); code != 0 || stdout != "value\n" {
+		t.Fatalf("read exit = %d, stdout = %q: %s", code, stdout, stderr)
+	}
+
+	if code, _, _ := runCLI("read", "-user", "no-such-user-mac-prefs", testDomain); code != 1 {
+		t.Fatalf("read -user no-such-user exit = %d, want 1", code)
+	}
+}
+
+func TestServeRejectsRemoteAddr(t *testing.T) {
+	code, _, stderr := runCLI("serve", "-addr", "0.0.0.0:8398")
+	if code != 1 || !strings.Contains(stderr, "not a loopback address") {
+		t.Fatalf("serve -addr 0.0.0.0:8398 exit = %d: %s", code, stderr)
+	}
+}
+
+func TestExporterUsage(t *testing.T) {
+	if code, _, _ := runCLI("exporter"); code != 2 {
+		t.Fatalf("exporter without keys exit = %d, want 2", code)
+	}
+	if code, _, _ := runCLI("exporter", "-key", "com.apple.alf"); code != 2 {
+		t.Fatalf("exporter -key without a key name exit = %d, want 2", code)
+	}
+}
@@ -0,0 +1,179 @@
+package main
+
+import (
+	"encoding/hex"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// valueTypes maps the type flags accepted by write to their plist type names.
+var valueTypes = map[string]string{
+	"-string": "string",
+	"-bool":   "boolean",
+	"-int":    "integer",
+	"-float":  "float",
+	"-array":  "array",
+	"-dict":   "dictionary",
+	"-date":   "date",
+	"-data":   "data",
+}
+
+// parseValue converts the value arguments of a write command into a Go value. args may start
+// with a type flag such as -bool or -array; without one a single string value is expected.
+func parseValue(args []string) (interface{}, error) {
+	if len(args) == 0 {
+		return nil, fmt.Errorf("missing value")
+	}
+
+	typ := "string"
+	if t, ok := valueTypes[args[0]]; ok {
+		typ = t
+		args = args[1:]
+	} else if strings.HasPrefix(args[0], "-") && len(args) > 1 {
+		return nil, fmt.Errorf("unknown type flag %s", args[0])
+	}
+
+	switch typ {
+	case "array":
+		values := make([]interface{}, 0, len(args))
+		for _, a := range args {
+			values = append(values, a)
+		}
+		return values, nil
+	case "dictionary":
+		if len(args)%2 != 0 {
+			return nil, fmt.Errorf("-dict requires key/value pairs, got %d arguments", len(args))
+		}
+		values := make(map[string]interface{}, len(args)/2)
+		for i := 0; i < len(args); i += 2 {
+			values[args[i]] = args[i+1]
+		}
+		return values, nil
+	}
+
+	if len(args) != 1 {
+		return nil, fmt.Errorf("-%s takes exactly one value, got %d", shortType(typ), len(args))
+	}
+	return parseScalar(typ, args[0])
+}
+
+// parseScalar parses a single value of the given plist type.
+func parseScalar(typ, s string) (interface{}, error) {
+	switch typ {
+	case "string":
+		return s, nil
+	case "boolean":
+		switch strings.ToLower(s) {
+		case "true", "yes", "1":
+			return true, nil
+		case "false", "no", "0":
+			return false, nil
+		}
+		return nil, fmt.Errorf("invalid boolean %q: use true/false or yes/no", s)
+	case "integer":
+		i, err := strconv.Atoi(s)
+		if err != nil {
+			return nil, fmt.Errorf("invalid integer %q", s)
+		}
+		return i, nil
+	case "float":
+		f, err := strconv.ParseFloat(s, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid float %q", s)
+		}
+		return f, nil
+	case "date":
+		for _, layout := range []string{time.RFC3339Nano, "2006-01-02 15:04:05 -0700", "2006-01-02"} {
+			if t, err := time.Parse(layout, s); err == nil {
+				return t, nil
+			}
+		}
+		return nil, fmt.Errorf("invalid date %q: use RFC 3339, e.g. 2024-01-02T15:04:05Z", s)
+	case "data":
+		b, err := hex.DecodeString(strings.TrimPrefix(strings.ReplaceAll(s, " ", ""), "0x"))
+		if err != nil {
+			return nil, fmt.Errorf("invalid data %q: use hexadecimal bytes", s)
+		}
+		return b, nil
+	default:
+		return nil, fmt.Errorf("unsupported type %s", typ)
+	}
+}
+
+// addValue implements the -array-add and -dict-add write flags: args are parsed like -array
+// or -dict values and appended to, or merged into, the existing value, which may be nil.
+func addValue(existing interface{}, flag string, args []string) (interface{}, error) {
+	switch flag {
+	case "-array-add":
+		added, err := parseValue(append([]string{"-array"}, args...))
+		if err != nil {
+			return nil, err
+		}
+		switch existing := existing.(type) {
+		case nil:
+			return added, nil
+		case []interface{}:
+			return append(existing, added.([]interface{})...), nil
+		default:
+			return nil, fmt.Errorf("-array-add requires an existing array, found %s", typeName(existing))
+		}
+	case "-dict-add":
+		added, err := parseValue(append([]string{"-dict"}, args...))
+		if err != nil {
+			return nil, err
+		}
+		switch existing := existing.(type) {
+		case nil:
+			return added, nil
+		case map[string]interface{}:
+			merged := make(map[string]interface{}, len(existing))
+			for k, v := range existing {
+				merged[k] = v
+			}
+			for k, v := range added.(map[string]interface{}) {
+				merged[k] = v
+			}
+			return merged, nil
+		default:
+			return nil, fmt.Errorf("-dict-add requires an existing dictionary, found %s", typeName(existing))
+		}
+	default:
+		return nil, fmt.Errorf("unknown add flag %s", flag)
+	}
+}
+
+// shortType returns the type flag name for a plist type name.
+func shortType(typ string) string {
+	for flag, t := range valueTypes {
+		if t == typ {
+			return strings.TrimPrefix(flag, "-")
+		}
+	}
+	return typ
+}
+
+// typeName returns the plist type name of a value, as printed by read-type.
+func typeName(v interface{}) string {
+	switch v.(type) {
+	case string:
+		return "string"
+	case bool:
+		return "boolean"
+	case int, int64, uint64:
+		return "integer"
+	case float64:
+		return "float"
+	case []interface{}:
+		return "array"
+	case map[string]interface{}:
+		return "dictionary"
+	case time.Time:
+		return "date"
+	case []byte:
+		return "data"
+	default:
+		return fmt.Sprintf("%T", v)
+	}
+}
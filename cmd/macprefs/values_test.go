@@ -0,0 +1,91 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestParseValue(t *testing.T) {
+	tests := []struct {
+		args    []string
+		want    interface{}
+		wantErr bool
+	}{
+		{args: []string{"hello"}, want: "hello"},
+		{args: []string{"-string", "-5"}, want: "-5"},
+		{args: []string{"-bool", "TRUE"}, want: true},
+		{args: []string{"-bool", "no"}, want: false},
+		{args: []string{"-bool", "maybe"}, wantErr: true},
+		{args: []string{"-int", "42"}, want: 42},
+		{args: []string{"-int", "4.2"}, wantErr: true},
+		{args: []string{"-float", "0.5"}, want: 0.5},
+		{args: []string{"-array", "a", "b"}, want: []interface{}{"a", "b"}},
+		{args: []string{"-array"}, want: []interface{}{}},
+		{args: []string{"-dict", "k", "v"}, want: map[string]interface{}{"k": "v"}},
+		{args: []string{"-dict", "k"}, wantErr: true},
+		{args: []string{"-date", "2024-01-02T03:04:05Z"}, want: time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)},
+		{args: []string{"-data", "dead"}, want: []byte{0xde, 0xad}},
+		{args: []string{"-data", "xyz"}, wantErr: true},
+		{args: []string{"-int", "1", "2"}, wantErr: true},
+		{args: []string{"-nope", "1"}, wantErr: true},
+		{args: nil, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		got, err := parseValue(tt.args)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("parseValue(%q) error = %v, wantErr %v", tt.args, err, tt.wantErr)
+			continue
+		}
+		if !tt.wantErr && !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("parseValue(%q) = %#v, want %#v", tt.args, got, tt.want)
+		}
+	}
+}
+
+func TestTypeName(t *testing.T) {
+	tests := map[string]interface{}{
+		"string":     "x",
+		"boolean":    true,
+		"integer":    1,
+		"float":      1.5,
+		"array":      []interface{}{},
+		"dictionary": map[string]interface{}{},
+		"date":       time.Time{},
+		"data":       []byte{},
+	}
+	for want, v := range tests {
+		if got := typeName(v); got != want {
+			t.Errorf("typeName(%#v) = %q, want %q", v, got, want)
+		}
+	}
+}
+
+func TestAddValue(t *testing.T) {
+	tests := []struct {
+		existing interface{}
+		flag     string
+		args     []string
+		want     interface{}
+		wantErr  bool
+	}{
+		{existing: nil, flag: "-array-add", args: []string{"a"}, want: []interface{}{"a"}},
+		{existing: []interface{}{"a"}, flag: "-array-add", args: []string{"b", "c"}, want: []interface{}{"a", "b", "c"}},
+		{existing: "a", flag: "-array-add", args: []string{"b"}, wantErr: true},
+		{existing: nil, flag: "-dict-add", args: []string{"k", "v"}, want: map[string]interface{}{"k": "v"}},
+		{existing: map[string]interface{}{"k": "v", "x": 1}, flag: "-dict-add", args: []string{"k", "w"}, want: map[string]interface{}{"k": "w", "x": 1}},
+		{existing: map[string]interface{}{}, flag: "-dict-add", args: []string{"k"}, wantErr: true},
+		{existing: 1, flag: "-dict-add", args: []string{"k", "v"}, wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := addValue(tt.existing, tt.flag, tt.args)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("addValue(%v, %s, %q) error = %v, wantErr %v", tt.existing, tt.flag, tt.args, err, tt.wantErr)
+			continue
+		}
+		if !tt.wantErr && !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("addValue(%v, %s, %q) = %#v, want %#v", tt.existing, tt.flag, tt.args, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,248 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"go/format"
+	"sort"
+	"strings"
+	"text/template"
+	"time"
+	"unicode"
+
+	"github.com/weswhet/mac_prefs/manifest"
+)
+
+// spec describes the file to generate.
+type spec struct {
+	Package string
+	Type    string
+	Domain  string
+	Keys    []genKey
+}
+
+// genKey is a single preference key of the generated file.
+type genKey struct {
+	Name   string
+	Field  string
+	GoType string
+}
+
+func (s *spec) needsFmt() bool {
+	for _, k := range s.Keys {
+		if k.GoType != "interface{}" {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *spec) needsTime() bool {
+	for _, k := range s.Keys {
+		if k.GoType == "time.Time" {
+			return true
+		}
+	}
+	return false
+}
+
+// specFromValues builds a spec from sample values, such as the contents of an exported domain.
+func specFromValues(domain string, values map[string]interface{}) *spec {
+	s := &spec{Domain: domain}
+	for name, value := range values {
+		s.Keys = append(s.Keys, genKey{Name: name, GoType: goTypeOfValue(value)})
+	}
+	s.finish()
+	return s
+}
+
+// specFromManifest builds a spec from the top level keys of a ProfileManifests manifest.
+func specFromManifest(m *manifest.Manifest) *spec {
+	s := &spec{Domain: m.Domain}
+	for _, k := range m.Subkeys {
+		if k.Name == "" || strings.HasPrefix(k.Name, "Payload") {
+			continue
+		}
+		s.Keys = append(s.Keys, genKey{Name: k.Name, GoType: goTypeOfManifest(k.Type)})
+	}
+	s.finish()
+	return s
+}
+
+// finish sorts the keys and assigns unique Go identifiers.
+func (s *spec) finish() {
+	sort.Slice(s.Keys, func(i, j int) bool { return s.Keys[i].Name < s.Keys[j].Name })
+	// Scope is the field of the generated struct, so a method may not use the name.
+	used := map[string]bool{"Scope": true}
+	for i := range s.Keys {
+		base := exportedName(s.Keys[i].Name)
+		field := base
+		for n := 2; used[field]; n++ {
+			field = fmt.Sprintf("%s%d", base, n)
+		}
+		used[field] = true
+		s.Keys[i].Field = field
+	}
+}
+
+func goTypeOfValue(v interface{}) string {
+	switch v.(type) {
+	case bool:
+		return "bool"
+	case string:
+		return "string"
+	case int, int64, uint64:
+		return "int"
+	case float64:
+		return "float64"
+	case time.Time:
+		return "time.Time"
+	case []byte:
+		return "[]byte"
+	case []interface{}:
+		return "[]interface{}"
+	case map[string]interface{}:
+		return "map[string]interface{}"
+	default:
+		return "interface{}"
+	}
+}
+
+func goTypeOfManifest(t string) string {
+	switch t {
+	case "boolean":
+		return "bool"
+	case "string", "url":
+		return "string"
+	case "integer":
+		return "int"
+	case "real", "float":
+		return "float64"
+	case "date":
+		return "time.Time"
+	case "data":
+		return "[]byte"
+	case "array":
+		return "[]interface{}"
+	case "dictionary":
+		return "map[string]interface{}"
+	default:
+		return "interface{}"
+	}
+}
+
+// exportedName converts a preference key such as "show-recents" into an exported Go
+// identifier such as "ShowRecents".
+func exportedName(key string) string {
+	var b strings.Builder
+	upper := true
+	for _, r := range key {
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) {
+			upper = true
+			continue
+		}
+		if upper {
+			r = unicode.ToUpper(r)
+			upper = false
+		}
+		b.WriteRune(r)
+	}
+	name := b.String()
+	if name == "" || unicode.IsDigit(rune(name[0])) {
+		name = "Key" + name
+	}
+	return name
+}
+
+// typeNameForDomain derives a type name from the last component of a domain, e.g.
+// "com.apple.dock" becomes "Dock".
+func typeNameForDomain(domain string) string {
+	parts := strings.Split(domain, ".")
+	return exportedName(parts[len(parts)-1])
+}
+
+var fileTemplate = template.Must(template.New("file").Parse(`// Code generated by prefsgen. DO NOT EDIT.
+
+package {{.Package}}
+
+import (
+{{- if .NeedsFmt}}
+	"fmt"
+{{- end}}
+{{- if .NeedsTime}}
+	"time"
+{{- end}}
+
+	"github.com/weswhet/mac_prefs"
+)
+
+// Domain is the preference domain accessed by {{.Type}}.
+const Domain = {{printf "%q" .Domain}}
+
+// Preference keys of {{.Domain}}.
+const (
+{{- range .Keys}}
+	Key{{.Field}} = {{printf "%q" .Name}}
+{{- end}}
+)
+
+// {{.Type}} provides typed access to the {{.Domain}} preference domain.
+type {{.Type}} struct {
+	// Scope is the scope values are read from and written to.
+	Scope mac_prefs.PreferenceScope
+}
+{{range .Keys}}
+// {{.Field}} returns the value of {{.Name}}, or the zero value if it is not set.
+func (p {{$.Type}}) {{.Field}}() ({{.GoType}}, error) {
+	var zero {{.GoType}}
+	v, err := mac_prefs.Get(Key{{.Field}}, Domain, p.Scope)
+	if err != nil || v == nil {
+		return zero, err
+	}
+{{- if eq .GoType "float64"}}
+	switch n := v.(type) {
+	case float64:
+		return n, nil
+	case int:
+		return float64(n), nil
+	}
+	return zero, fmt.Errorf("%s: unexpected type %T", Key{{.Field}}, v)
+{{- else if eq .GoType "interface{}"}}
+	return v, nil
+{{- else}}
+	value, ok := v.({{.GoType}})
+	if !ok {
+		return zero, fmt.Errorf("%s: unexpected type %T", Key{{.Field}}, v)
+	}
+	return value, nil
+{{- end}}
+}
+
+// Set{{.Field}} sets the value of {{.Name}}.
+func (p {{$.Type}}) Set{{.Field}}(value {{.GoType}}) error {
+	return mac_prefs.Set(Key{{.Field}}, value, Domain, p.Scope)
+}
+
+// Reset{{.Field}} removes {{.Name}}.
+func (p {{$.Type}}) Reset{{.Field}}() error {
+	return mac_prefs.Set(Key{{.Field}}, nil, Domain, p.Scope)
+}
+{{end}}`))
+
+// generate renders the Go source for s.
+func generate(s *spec) ([]byte, error) {
+	var buf bytes.Buffer
+	data := struct {
+		*spec
+		NeedsFmt  bool
+		NeedsTime bool
+	}{s, s.needsFmt(), s.needsTime()}
+	if err := fileTemplate.Execute(&buf, data); err != nil {
+		return nil, err
+	}
+	src, err := format.Source(buf.Bytes())
+	if err != nil {
+		return nil, fmt.Errorf("error formatting generated source: %v", err)
+	}
+	return src, nil
+}
@@ -0,0 +1,91 @@
+package main
+
+import (
+	"go/parser"
+	"go/token"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestExportedName(t *testing.T) {
+	tests := map[string]string{
+		"autohide":          "Autohide",
+		"show-recents":      "ShowRecents",
+		"AppleShowAllFiles": "AppleShowAllFiles",
+		"com.apple.sound":   "ComAppleSound",
+		"3DTouch":           "Key3DTouch",
+		"_":                 "Key",
+	}
+	for in, want := range tests {
+		if got := exportedName(in); got != want {
+			t.Errorf("exportedName(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+func TestGenerateFromValues(t *testing.T) {
+	s := specFromValues("com.apple.dock", map[string]interface{}{
+		"autohide":     true,
+		"tilesize":     48,
+		"magnify-size": 1.5,
+		"persistent":   []interface{}{},
+		"updated":      time.Now(),
+	})
+	s.Package = "dock"
+	s.Type = typeNameForDomain(s.Domain)
+
+	src, err := generate(s)
+	if err != nil {
+		t.Fatalf("generate() error = %v", err)
+	}
+	if _, err := parser.ParseFile(token.NewFileSet(), "dock.go", src, 0); err != nil {
+		t.Fatalf("generated source does not parse: %v\n%s", err, src)
+	}
+
+	for _, want := range []string{
+		"package dock",
+		`const Domain = "com.apple.dock"`,
+		`KeyMagnifySize = "magnify-size"`,
+		"type Dock struct",
+		"func (p Dock) Autohide() (bool, error)",
+		"func (p Dock) SetTilesize(value int) error",
+		"func (p Dock) MagnifySize() (float64, error)",
+		"func (p Dock) Updated() (time.Time, error)",
+		"func (p Dock) ResetPersistent() error",
+	} {
+		if !strings.Contains(string(src), want) {
+			t.Errorf("generated source missing %q:\n%s", want, src)
+		}
+	}
+}
+
+func TestGenerateFromManifest(t *testing.T) {
+	const input = `<plist version="1.0"><dict>
+	<key>pfm_domain</key><string>com.example.app</string>
+	<key>pfm_subkeys</key><array>
+		<dict><key>pfm_name</key><string>PayloadUUID</string><key>pfm_type</key><string>string</string></dict>
+		<dict><key>pfm_name</key><string>Interval</string><key>pfm_type</key><string>integer</string></dict>
+	</array>
+</dict></plist>`
+
+	s, err := parseInput([]byte(input), "com.example.app.plist", "")
+	if err != nil {
+		t.Fatalf("parseInput() error = %v", err)
+	}
+	if s.Domain != "com.example.app" || len(s.Keys) != 1 || s.Keys[0].GoType != "int" {
+		t.Fatalf("parseInput() got = %+v", s)
+	}
+}
+
+func TestGenerateUntypedKeysOnly(t *testing.T) {
+	s := &spec{Package: "empty", Type: "Empty", Domain: "com.example.empty"}
+	s.finish()
+	src, err := generate(s)
+	if err != nil {
+		t.Fatalf("generate() error = %v", err)
+	}
+	if strings.Contains(string(src), `"fmt"`) {
+		t.Errorf("generated source imports fmt without using it:\n%s", src)
+	}
+}
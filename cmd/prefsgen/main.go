@@ -0,0 +1,97 @@
+// Command prefsgen generates typed Go accessors for a preference domain.
+//
+// The input is either a sample plist of the domain (for example the output of
+// `defaults export com.apple.dock -`, converted to XML) or a ProfileManifests manifest:
+//
+//	prefsgen -package dock -o dock_prefs.go com.apple.dock.plist
+//
+// The generated file contains a constant for every key and a struct with Get, Set, and Reset
+// methods built on github.com/weswhet/mac_prefs.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/weswhet/mac_prefs/internal/plist"
+	"github.com/weswhet/mac_prefs/manifest"
+)
+
+func main() {
+	pkg := flag.String("package", "", "package name of the generated file (default: derived from the domain)")
+	typeName := flag.String("type", "", "name of the generated struct (default: derived from the domain)")
+	domain := flag.String("domain", "", "preference domain of a sample plist (default: the file name without .plist)")
+	output := flag.String("o", "", "output file (default: stdout)")
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "usage: prefsgen [flags] <plist or manifest>\n")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+	if flag.NArg() != 1 {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	if err := run(flag.Arg(0), *pkg, *typeName, *domain, *output); err != nil {
+		fmt.Fprintf(os.Stderr, "prefsgen: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+func run(input, pkg, typeName, domain, output string) error {
+	data, err := os.ReadFile(input)
+	if err != nil {
+		return err
+	}
+	s, err := parseInput(data, input, domain)
+	if err != nil {
+		return err
+	}
+
+	s.Type = typeName
+	if s.Type == "" {
+		s.Type = typeNameForDomain(s.Domain)
+	}
+	s.Package = pkg
+	if s.Package == "" {
+		s.Package = strings.ToLower(s.Type)
+	}
+
+	src, err := generate(s)
+	if err != nil {
+		return err
+	}
+	if output == "" {
+		_, err = os.Stdout.Write(src)
+		return err
+	}
+	return os.WriteFile(output, src, 0o644)
+}
+
+// parseInput builds a spec from a manifest or, if the plist is not a manifest, from sample values.
+func parseInput(data []byte, path, domain string) (*spec, error) {
+	parsed, err := plist.Unmarshal(data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", path, err)
+	}
+	values, ok := parsed.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("%s: top level value is not a dictionary", path)
+	}
+
+	if _, ok := values["pfm_domain"]; ok {
+		m, err := manifest.Parse(data)
+		if err != nil {
+			return nil, err
+		}
+		return specFromManifest(m), nil
+	}
+
+	if domain == "" {
+		domain = strings.TrimSuffix(filepath.Base(path), ".plist")
+	}
+	return specFromValues(domain, values), nil
+}
@@ -0,0 +1,104 @@
+package mac_prefs
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+)
+
+// TestClientConcurrentUse exercises a Client from many goroutines at once; run it with -race.
+func TestClientConcurrentUse(t *testing.T) {
+	const appID = "com.example.concurrent"
+	store := NewMemoryStore()
+	c := NewClient(WithStore(store), WithUndo(10), WithCache(CacheOptions{Watch: true, WatchInterval: time.Millisecond}))
+	defer c.Close()
+	c.RegisterDefaults(map[string]interface{}{"fallback": true})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	events, err := c.Watch(ctx, appID, CurrentUserAnyHost, time.Millisecond)
+	if err != nil {
+		t.Fatal(err)
+	}
+	go func() {
+		for range events {
+		}
+	}()
+
+	const workers, iterations = 8, 50
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func(w int) {
+			defer wg.Done()
+			key := fmt.Sprintf("key%d", w)
+			for i := 0; i < iterations; i++ {
+				if err := c.Set(key, i, appID, CurrentUserAnyHost); err != nil {
+					t.Error(err)
+					return
+				}
+				if _, err := c.Get(key, appID, CurrentUserAnyHost); err != nil {
+					t.Error(err)
+					return
+				}
+				if _, err := c.Increment("counter", appID, CurrentUserAnyHost, 1); err != nil {
+					t.Error(err)
+					return
+				}
+				if _, err := c.GetMany([]string{key, "counter", "fallback"}, appID, CurrentUserAnyHost); err != nil {
+					t.Error(err)
+					return
+				}
+				tx := c.Begin()
+				if err := tx.Set("tx", w, appID, CurrentUserAnyHost); err != nil {
+					t.Error(err)
+					return
+				}
+				if err := tx.Commit(); err != nil {
+					t.Error(err)
+					return
+				}
+			}
+		}(w)
+	}
+	wg.Wait()
+
+	if got, _ := c.Get("counter", appID, CurrentUserAnyHost); got != int64(workers*iterations) {
+		t.Errorf("counter = %v, want %d; concurrent increments were lost", got, workers*iterations)
+	}
+	for w := 0; w < workers; w++ {
+		if got, _ := c.Get(fmt.Sprintf("key%d", w), appID, CurrentUserAnyHost); got != iterations-1 {
+			t.Errorf("key%d = %v, want %d", w, got, iterations-1)
+		}
+	}
+}
+
+func TestLockSlot(t *testing.T) {
+	var wg sync.WaitGroup
+	var inside, total int
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			unlock := lockSlot("com.example.lock", CurrentUserAnyHost)
+			defer unlock()
+			inside++
+			if inside != 1 {
+				t.Error("two writers held the same slot")
+			}
+			total++
+			inside--
+		}()
+	}
+	wg.Wait()
+	if total != 20 {
+		t.Errorf("total = %d, want 20", total)
+	}
+
+	// Different slots do not block each other.
+	unlock := lockSlot("com.example.lock", CurrentUserAnyHost)
+	defer unlock()
+	lockSlot("com.example.lock", CurrentUserCurrentHost)()
+}
@@ -0,0 +1,73 @@
+package mac_prefs
+
+// SetIfAbsent sets a preference value only if the key is not already set in the slot, so
+// provisioning can seed defaults without overwriting values users have since changed. The
+// check and the write happen under a lock shared by the package level read-modify-write
+// functions, so of several concurrent seeders in the process, only one writes.
+//
+// Parameters:
+//   - key: The preference key to set.
+//   - value: The value to set.
+//   - applicationID: The bundle identifier of the application for which to set the preference.
+//   - scope: The PreferenceScope to write to.
+//
+// Returns:
+//   - bool: Whether the value was written.
+//   - error: An error if the preference cannot be read or written.
+func SetIfAbsent(key string, value interface{}, applicationID string, scope PreferenceScope) (bool, error) {
+	return defaultClient.SetIfAbsent(key, value, applicationID, scope)
+}
+
+// SetIfAbsent sets a preference value only if the key is not set. The check and the write
+// happen while the Client is locked. See SetIfAbsent.
+func (c *Client) SetIfAbsent(key string, value interface{}, applicationID string, scope PreferenceScope) (bool, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	current, err := c.store.Get(key, applicationID, scope)
+	if err != nil || current != nil {
+		return false, err
+	}
+	if err := c.set(key, value, applicationID, scope); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// CompareAndSwap sets a preference value only if its current value equals old, so several
+// management agents can update a key without overwriting each other's changes. Values are
+// compared deeply, treating numbers of different Go types as equal, as in Diff. A nil old
+// value matches a key that is not set, and a nil new value removes the key. The comparison
+// and the write happen under a lock shared by the package level read-modify-write
+// functions, so of several concurrent swaps from the same old value in the process, only one
+// succeeds.
+//
+// Parameters:
+//   - key: The preference key to set.
+//   - old: The value the key is expected to have.
+//   - new: The value to set.
+//   - applicationID: The bundle identifier of the application for which to set the preference.
+//   - scope: The PreferenceScope to write to.
+//
+// Returns:
+//   - bool: Whether the value was written.
+//   - error: An error if the preference cannot be read or written.
+func CompareAndSwap(key string, old, new interface{}, applicationID string, scope PreferenceScope) (bool, error) {
+	return defaultClient.CompareAndSwap(key, old, new, applicationID, scope)
+}
+
+// CompareAndSwap sets a preference value only if it equals old. The comparison and the write
+// happen while the Client is locked. See CompareAndSwap.
+func (c *Client) CompareAndSwap(key string, old, new interface{}, applicationID string, scope PreferenceScope) (bool, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	current, err := c.store.Get(key, applicationID, scope)
+	if err != nil || !valuesEqual(current, old) {
+		return false, err
+	}
+	if err := c.set(key, new, applicationID, scope); err != nil {
+		return false, err
+	}
+	return true, nil
+}
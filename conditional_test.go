@@ -0,0 +1,100 @@
+package mac_prefs
+
+import (
+	"sync"
+	"sync/atomic"
+	"testing"
+)
+
+func TestClientSetIfAbsent(t *testing.T) {
+	c := NewClient(WithStore(NewMemoryStore()))
+	if wrote, err := c.SetIfAbsent("tilesize", 48, "com.apple.dock", CurrentUserAnyHost); err != nil || !wrote {
+		t.Fatalf("SetIfAbsent() of a missing key = %v, %v, want true", wrote, err)
+	}
+	if wrote, err := c.SetIfAbsent("tilesize", 64, "com.apple.dock", CurrentUserAnyHost); err != nil || wrote {
+		t.Errorf("SetIfAbsent() of a set key = %v, %v, want false", wrote, err)
+	}
+	if got, _ := c.Get("tilesize", "com.apple.dock", CurrentUserAnyHost); got != 48 {
+		t.Errorf("tilesize = %#v, want 48", got)
+	}
+	if _, err := c.SetIfAbsent("invalid", make(chan int), "com.apple.dock", CurrentUserAnyHost); err == nil {
+		t.Error("SetIfAbsent() of an invalid value expected error")
+	}
+}
+
+// TestCompareAndSwapConcurrent checks that only one of several package level swaps from the
+// same old value succeeds; run it with -race.
+func TestCompareAndSwapConcurrent(t *testing.T) {
+	useDefaultStore(t, NewMemoryStore())
+
+	var swaps int32
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			swapped, err := CompareAndSwap("owner", nil, i, "com.example", CurrentUserAnyHost)
+			if err != nil {
+				t.Error(err)
+			}
+			if swapped {
+				atomic.AddInt32(&swaps, 1)
+			}
+		}(i)
+	}
+	wg.Wait()
+	if swaps != 1 {
+		t.Errorf("%d concurrent CompareAndSwap() calls succeeded, want 1", swaps)
+	}
+}
+
+// TestSetIfAbsentConcurrent checks that only one of several package level seeders writes;
+// run it with -race.
+func TestSetIfAbsentConcurrent(t *testing.T) {
+	useDefaultStore(t, NewMemoryStore())
+
+	var writes int32
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			wrote, err := SetIfAbsent("tilesize", i, "com.apple.dock", CurrentUserAnyHost)
+			if err != nil {
+				t.Error(err)
+			}
+			if wrote {
+				atomic.AddInt32(&writes, 1)
+			}
+		}(i)
+	}
+	wg.Wait()
+	if writes != 1 {
+		t.Errorf("%d concurrent SetIfAbsent() calls wrote, want 1", writes)
+	}
+}
+
+func TestClientCompareAndSwap(t *testing.T) {
+	c := NewClient(WithStore(NewMemoryStore()))
+	swaps := []struct {
+		old, new interface{}
+		want     bool
+	}{
+		{nil, []string{"a"}, true},
+		{nil, []string{"b"}, false},
+		{[]interface{}{"a"}, []string{"a", "b"}, true},
+		{[]string{"a"}, []string{"c"}, false},
+		{[]interface{}{"a", "b"}, nil, true},
+		{nil, int64(1), true},
+		{1.0, 2, true},
+	}
+	for i, s := range swaps {
+		swapped, err := c.CompareAndSwap("items", s.old, s.new, "com.example", CurrentUserAnyHost)
+		if err != nil || swapped != s.want {
+			t.Fatalf("swap %d: CompareAndSwap(%#v, %#v) = %v, %v, want %v", i, s.old, s.new, swapped, err, s.want)
+		}
+	}
+	if got, _ := c.Get("items", "com.example", CurrentUserAnyHost); got != 2 {
+		t.Errorf("items = %#v, want 2", got)
+	}
+}
@@ -0,0 +1,91 @@
+package mac_prefs
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+)
+
+// Discrepancy is a key whose value differs between cfprefsd and the plist file backing its
+// domain.
+type Discrepancy struct {
+	// Key is the preference key.
+	Key string
+	// Cached is the value cfprefsd returns, or nil if it does not have the key.
+	Cached interface{}
+	// OnDisk is the value in the plist file, or nil if the file does not have the key.
+	OnDisk interface{}
+}
+
+// String describes the discrepancy, with the values of sensitive keys masked by
+// DefaultRedactor.
+func (d Discrepancy) String() string {
+	cached, onDisk := DefaultRedactor.Redact(d.Key, d.Cached), DefaultRedactor.Redact(d.Key, d.OnDisk)
+	switch {
+	case d.Cached == nil:
+		return fmt.Sprintf("%s: only on disk (%v)", d.Key, onDisk)
+	case d.OnDisk == nil:
+		return fmt.Sprintf("%s: only in cfprefsd (%v)", d.Key, cached)
+	default:
+		return fmt.Sprintf("%s: cfprefsd has %v, file has %v", d.Key, cached, onDisk)
+	}
+}
+
+// VerifyConsistency reads one exact (user, host) slot of a domain both through cfprefsd and by
+// parsing the plist file backing it, and reports every key on which they disagree. A
+// discrepancy usually means the file was edited behind the back of cfprefsd, which keeps
+// serving its cached values and overwrites the file on the next write; FlushDomainCache makes
+// cfprefsd read the file again. A missing file is an empty domain.
+//
+// Parameters:
+//   - appID: The bundle identifier of the application to verify.
+//   - scope: The PreferenceScope defining the user and host scope to verify.
+//
+// Returns:
+//   - []Discrepancy: The keys that differ, sorted by key. Empty if both views agree.
+//   - error: An error if the domain or its file cannot be read.
+func VerifyConsistency(appID string, scope PreferenceScope) ([]Discrepancy, error) {
+	return NewClient().VerifyConsistency(appID, scope)
+}
+
+// VerifyConsistency compares the view of cfprefsd with the plist file of a slot, through the
+// store of the Client and on the booted system even if WithRoot is set. See VerifyConsistency.
+func (c *Client) VerifyConsistency(appID string, scope PreferenceScope) ([]Discrepancy, error) {
+	cached, err := c.GetAll(appID, scope)
+	if err != nil {
+		return nil, err
+	}
+	path, err := plistPath("", appID, scope)
+	if err != nil {
+		return nil, err
+	}
+	onDisk, err := readPlistFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		onDisk, err = map[string]interface{}{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	onDisk = c.readValues(onDisk)
+
+	keys := make([]string, 0, len(cached)+len(onDisk))
+	for key := range cached {
+		keys = append(keys, key)
+	}
+	for key := range onDisk {
+		if _, ok := cached[key]; !ok {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+
+	discrepancies := make([]Discrepancy, 0)
+	for _, key := range keys {
+		have, want := cached[key], onDisk[key]
+		if have == nil || want == nil || !valuesEqual(have, want) {
+			discrepancies = append(discrepancies, Discrepancy{Key: key, Cached: have, OnDisk: want})
+		}
+	}
+	return discrepancies, nil
+}
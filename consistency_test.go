@@ -0,0 +1,50 @@
+//go:build darwin && cgo
+
+package mac_prefs
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestVerifyConsistency(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	store := NewMemoryStore()
+	c := NewClient(WithStore(store))
+
+	got, err := c.VerifyConsistency(testAppID, CurrentUserAnyHost)
+	if err != nil || len(got) != 0 {
+		t.Fatalf("VerifyConsistency() of an empty domain = %v, %v", got, err)
+	}
+
+	file := filepath.Join(home, "Library", "Preferences", testAppID+".plist")
+	if err := os.MkdirAll(filepath.Dir(file), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(file, []byte(testPlistXML), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	for key, value := range map[string]interface{}{"Name": "cached", "Count": 3.0, "Extra": true} {
+		if err := store.Set(key, value, testAppID, CurrentUserAnyHost); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	got, err = c.VerifyConsistency(testAppID, CurrentUserAnyHost)
+	if err != nil {
+		t.Fatalf("VerifyConsistency() error = %v", err)
+	}
+	want := []Discrepancy{
+		{Key: "Extra", Cached: true},
+		{Key: "Name", Cached: "cached", OnDisk: "container"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("VerifyConsistency() got = %v, want %v", got, want)
+	}
+	if s := got[1].String(); s != "Name: cfprefsd has cached, file has container" {
+		t.Errorf("String() = %q", s)
+	}
+}
@@ -0,0 +1,235 @@
+package mac_prefs
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+)
+
+// Change is a single modification of a preference key.
+type Change struct {
+	// Key is the preference key that changed.
+	Key string
+	// Old is the previous value, or nil if the key was added.
+	Old interface{}
+	// New is the new value, or nil if the key was removed.
+	New interface{}
+}
+
+// String describes the change, with the values of sensitive keys masked by DefaultRedactor.
+func (c Change) String() string {
+	from, to := DefaultRedactor.Redact(c.Key, c.Old), DefaultRedactor.Redact(c.Key, c.New)
+	switch {
+	case c.Old == nil:
+		return fmt.Sprintf("add %s = %v", c.Key, to)
+	case c.New == nil:
+		return fmt.Sprintf("remove %s (was %v)", c.Key, from)
+	default:
+		return fmt.Sprintf("change %s: %v -> %v", c.Key, from, to)
+	}
+}
+
+// Changes lists the modifications made to a domain, sorted by key.
+type Changes []Change
+
+// Converge brings one exact (user, host) slot of a domain to a desired state. Only keys whose
+// current value differs from the desired value are written, so calling Converge again with
+// the same desired state makes no changes. Keys that are not in desired are left untouched;
+// a nil desired value removes the key.
+//
+// Parameters:
+//   - appID: The bundle identifier of the application to converge.
+//   - scope: The PreferenceScope defining the user and host scope to converge.
+//   - desired: The desired keys and values.
+//
+// Returns:
+//   - Changes: The modifications made, sorted by key. Empty if the domain already matched.
+//   - error: An error if the domain cannot be read or written. No changes are made if a value cannot be converted.
+func Converge(appID string, scope PreferenceScope, desired map[string]interface{}) (Changes, error) {
+	current, err := GetAll(appID, scope)
+	if err != nil {
+		return nil, err
+	}
+
+	changes := convergeChanges(current, desired)
+	if len(changes) == 0 {
+		return changes, nil
+	}
+
+	toSet := make(map[string]interface{}, len(changes))
+	var toRemove []string
+	for _, c := range changes {
+		if c.New == nil {
+			toRemove = append(toRemove, c.Key)
+			continue
+		}
+		toSet[c.Key] = c.New
+	}
+	if err := SetMultiple(toSet, toRemove, appID, scope); err != nil {
+		return nil, err
+	}
+	return changes, nil
+}
+
+// Converge brings a slot to a desired state. Values are compared as the Client reads them and
+// written with its write options. With WithDryRun, the changes are computed and returned but
+// not written. See Converge.
+func (c *Client) Converge(appID string, scope PreferenceScope, desired map[string]interface{}) (Changes, error) {
+	for key, value := range desired {
+		if err := ValidateValue(value); err != nil {
+			return nil, fmt.Errorf("invalid value for key %s: %w", key, err)
+		}
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	current, err := c.store.List(appID, scope)
+	if err != nil {
+		return nil, err
+	}
+	read := make(map[string]interface{}, len(current))
+	for key, value := range current {
+		read[key] = c.readValue(value)
+	}
+	changes := convergeChanges(read, desired)
+	if len(changes) == 0 {
+		return changes, nil
+	}
+
+	values := make(map[string]interface{}, len(changes))
+	for _, change := range changes {
+		values[change.Key] = c.writeValue(change.New)
+	}
+	if err := writeSlot(c.store, prefSlot{appID, scope}, values); err != nil {
+		return nil, err
+	}
+	for key, value := range values {
+		c.record(mutation{key: key, appID: appID, scope: scope, old: current[key], new: value})
+	}
+	return changes, nil
+}
+
+// convergeChanges computes the changes needed to bring current to desired.
+func convergeChanges(current, desired map[string]interface{}) Changes {
+	keys := make([]string, 0, len(desired))
+	for key := range desired {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	changes := make(Changes, 0)
+	for _, key := range keys {
+		want := desired[key]
+		have, ok := current[key]
+		switch {
+		case want == nil && !ok:
+		case want == nil:
+			changes = append(changes, Change{Key: key, Old: have})
+		case !ok:
+			changes = append(changes, Change{Key: key, New: want})
+		case !valuesEqual(have, want):
+			changes = append(changes, Change{Key: key, Old: have, New: want})
+		}
+	}
+	return changes
+}
+
+// Diff reports how one exact (user, host) slot of a domain has drifted from a desired state,
+// without modifying anything. The comparison is deep and treats numbers of different Go types
+// as equal, so an int and a float64 with the same value are not reported as drift. Keys that are
+// not in desired are ignored; a nil desired value means the key should be absent.
+//
+// Parameters:
+//   - appID: The bundle identifier of the application to compare.
+//   - scope: The PreferenceScope defining the user and host scope to compare.
+//   - desired: The desired keys and values.
+//
+// Returns:
+//   - added: Desired keys that are missing from the domain.
+//   - changed: Keys whose current value differs from the desired value.
+//   - removed: Keys that are present but should be absent.
+//   - err: An error if the domain cannot be read.
+func Diff(appID string, scope PreferenceScope, desired map[string]interface{}) (added, changed, removed Changes, err error) {
+	return NewClient().Diff(appID, scope, desired)
+}
+
+// Diff reports how a slot has drifted from a desired state. With WithRoot, the slot is read
+// from its plist file under the root, and a missing file is an empty domain. See Diff.
+func (c *Client) Diff(appID string, scope PreferenceScope, desired map[string]interface{}) (added, changed, removed Changes, err error) {
+	var current map[string]interface{}
+	if c.root != "" {
+		current, err = c.ReadDomainFile(appID, scope)
+		if errors.Is(err, os.ErrNotExist) {
+			current, err = map[string]interface{}{}, nil
+		}
+	} else {
+		current, err = c.GetAll(appID, scope)
+	}
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	added, changed, removed = make(Changes, 0), make(Changes, 0), make(Changes, 0)
+	for _, c := range convergeChanges(current, desired) {
+		switch {
+		case c.Old == nil:
+			added = append(added, c)
+		case c.New == nil:
+			removed = append(removed, c)
+		default:
+			changed = append(changed, c)
+		}
+	}
+	return added, changed, removed, nil
+}
+
+// ReplaceAll makes one exact (user, host) slot of a domain hold exactly the desired keys: the
+// desired values are written and every other key is removed, in a single batch write if the
+// store is a BatchStore, e.g. one CFPreferencesSetMultiple call. A nil desired value means the
+// key should be absent.
+//
+// Parameters:
+//   - appID: The bundle identifier of the application to replace.
+//   - scope: The PreferenceScope defining the user and host scope to replace.
+//   - desired: The complete set of keys and values.
+//
+// Returns:
+//   - error: An error if the domain cannot be read or written. Nothing is written if a value
+//     is invalid.
+func ReplaceAll(appID string, scope PreferenceScope, desired map[string]interface{}) error {
+	return NewClient().ReplaceAll(appID, scope, desired)
+}
+
+// ReplaceAll makes a slot hold exactly the desired keys. See ReplaceAll.
+func (c *Client) ReplaceAll(appID string, scope PreferenceScope, desired map[string]interface{}) error {
+	for key, value := range desired {
+		if err := ValidateValue(value); err != nil {
+			return fmt.Errorf("invalid value for key %s: %w", key, err)
+		}
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	current, err := c.store.List(appID, scope)
+	if err != nil {
+		return err
+	}
+	values := make(map[string]interface{}, len(current)+len(desired))
+	for key := range current {
+		values[key] = nil
+	}
+	for key, value := range desired {
+		values[key] = c.writeValue(value)
+	}
+	if err := writeSlot(c.store, prefSlot{appID, scope}, values); err != nil {
+		return err
+	}
+
+	for _, change := range convergeChanges(current, values) {
+		c.record(mutation{key: change.Key, appID: appID, scope: scope, old: change.Old, new: change.New})
+	}
+	return nil
+}
@@ -0,0 +1,25 @@
+package mac_prefs
+
+import "testing"
+
+func TestValuesEqual(t *testing.T) {
+	tests := []struct {
+		a, b interface{}
+		want bool
+	}{
+		{1, 1.0, true},
+		{1, 1.5, false},
+		{uint64(3), 3, true},
+		{"a", "a", true},
+		{[]string{"a"}, []interface{}{"a"}, true},
+		{map[string]int{"a": 1}, map[string]interface{}{"a": 1.0}, true},
+		{[]interface{}{map[string]interface{}{"a": 1}}, []interface{}{map[string]interface{}{"a": 2}}, false},
+		{[]byte("x"), []byte("x"), true},
+		{"1", 1, false},
+	}
+	for _, tt := range tests {
+		if got := valuesEqual(tt.a, tt.b); got != tt.want {
+			t.Errorf("valuesEqual(%#v, %#v) = %t, want %t", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
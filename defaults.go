@@ -0,0 +1,41 @@
+package mac_prefs
+
+// RegisterDefaults registers fallback values that the Client's reads return for keys that are
+// not set, like NSUserDefaults registerDefaults, so an application's default configuration
+// lives in one place instead of at every call site. The defaults apply to every application
+// ID and scope read through the Client and are never written. Registering a key again
+// replaces its default, and a nil value unregisters it.
+//
+// Get, GetValue, GetPath, GetInto, GetDuration, and the typed getters such as GetStrings fall
+// back to the registered defaults, and the typed getters report a default as set. GetAll and
+// the read-modify-write helpers see only stored values.
+//
+// Parameters:
+//   - defaults: The fallback values by key.
+func (c *Client) RegisterDefaults(defaults map[string]interface{}) {
+	c.defaultsMu.Lock()
+	defer c.defaultsMu.Unlock()
+
+	if c.defaults == nil {
+		c.defaults = make(map[string]interface{}, len(defaults))
+	}
+	for key, value := range defaults {
+		if value == nil {
+			delete(c.defaults, key)
+		} else {
+			c.defaults[key] = value
+		}
+	}
+}
+
+// lookup retrieves a value from the store, or the registered default if the key is not set.
+func (c *Client) lookup(key string, applicationID string, scope PreferenceScope) (interface{}, error) {
+	value, err := c.store.Get(key, applicationID, scope)
+	if err != nil || value != nil {
+		return value, err
+	}
+
+	c.defaultsMu.RLock()
+	defer c.defaultsMu.RUnlock()
+	return c.defaults[key], nil
+}
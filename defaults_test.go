@@ -0,0 +1,40 @@
+package mac_prefs
+
+import (
+	"testing"
+	"time"
+)
+
+func TestClientRegisterDefaults(t *testing.T) {
+	c := NewClient(WithStore(NewMemoryStore()))
+	c.RegisterDefaults(map[string]interface{}{
+		"tilesize": 48,
+		"apps":     []interface{}{"Safari"},
+		"delay":    0.5,
+		"removed":  true,
+	})
+	c.RegisterDefaults(map[string]interface{}{"removed": nil})
+
+	if got, err := c.Get("tilesize", "com.apple.dock", CurrentUserAnyHost); err != nil || got != 48 {
+		t.Errorf("Get() = %#v, %v, want the default 48", got, err)
+	}
+	if err := c.Set("tilesize", 64, "com.apple.dock", CurrentUserAnyHost); err != nil {
+		t.Fatal(err)
+	}
+	if got, err := c.Get("tilesize", "com.apple.dock", CurrentUserAnyHost); err != nil || got != 64 {
+		t.Errorf("Get() = %#v, %v, want the stored 64", got, err)
+	}
+	if got, _ := c.Get("removed", "com.apple.dock", CurrentUserAnyHost); got != nil {
+		t.Errorf("Get() of an unregistered default = %#v", got)
+	}
+
+	if apps, ok, err := c.GetStrings("apps", "com.apple.dock", CurrentUserAnyHost); err != nil || !ok || len(apps) != 1 {
+		t.Errorf("GetStrings() = %v, %v, %v, want the default", apps, ok, err)
+	}
+	if d, ok, err := c.GetDuration("delay", "com.apple.dock", CurrentUserAnyHost); err != nil || !ok || d != 500*time.Millisecond {
+		t.Errorf("GetDuration() = %v, %v, %v, want the default", d, ok, err)
+	}
+	if all, _ := c.GetAll("com.apple.dock", CurrentUserAnyHost); len(all) != 1 {
+		t.Errorf("GetAll() = %#v, want only stored values", all)
+	}
+}
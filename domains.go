@@ -0,0 +1,408 @@
+package mac_prefs
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+// GlobalDomain is the name the global preferences domain (AnyApplication) is stored and
+// listed under.
+const GlobalDomain = ".GlobalPreferences"
+
+// DumpAllOptions controls which domains DumpAll reads.
+type DumpAllOptions struct {
+	// Scopes are the scopes to walk. Defaults to CurrentUserAnyHost and CurrentUserCurrentHost.
+	Scopes []PreferenceScope
+	// Include, when not empty, limits the dump to domains matching one of these glob patterns
+	// (as understood by path.Match), e.g. "com.apple.*".
+	Include []string
+	// Exclude skips domains matching one of these glob patterns.
+	Exclude []string
+}
+
+// DumpAll reads the full contents of every preference domain visible in the given scopes,
+// including the global domain. When a domain has values in several scopes they are combined
+// with the ByHost values taking precedence, as in the search list.
+//
+// Parameters:
+//   - opts: Options selecting scopes and filtering domains.
+//
+// Returns:
+//   - map[string]map[string]interface{}: The keys and values of each domain, keyed by application ID.
+//   - error: An error if a pattern is malformed or a domain cannot be read.
+func DumpAll(opts DumpAllOptions) (map[string]map[string]interface{}, error) {
+	scopes := opts.Scopes
+	if len(scopes) == 0 {
+		scopes = []PreferenceScope{CurrentUserAnyHost, CurrentUserCurrentHost}
+	}
+	// Read any-host scopes first so ByHost values override them.
+	sorted := append([]PreferenceScope(nil), scopes...)
+	sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].Host == AnyHost && sorted[j].Host != AnyHost })
+
+	result := make(map[string]map[string]interface{})
+	err := walkDomains(sorted, opts.Include, opts.Exclude, func(domain string, scope PreferenceScope, values map[string]interface{}) {
+		if result[domain] == nil {
+			result[domain] = make(map[string]interface{}, len(values))
+		}
+		for k, v := range values {
+			result[domain][k] = v
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// walkDomains calls fn with the contents of every non-empty domain, including the global
+// domain, in each scope in order. Domains are filtered with matchDomain.
+func walkDomains(scopes []PreferenceScope, include, exclude []string, fn func(domain string, scope PreferenceScope, values map[string]interface{})) error {
+	for _, scope := range scopes {
+		domains, err := ListDomains(scope)
+		if err != nil {
+			return err
+		}
+		if !containsString(domains, GlobalDomain) {
+			domains = append(domains, GlobalDomain)
+		}
+
+		for _, domain := range domains {
+			ok, err := matchDomain(domain, include, exclude)
+			if err != nil {
+				return err
+			}
+			if !ok {
+				continue
+			}
+
+			appID := domain
+			if domain == GlobalDomain {
+				appID = AnyApplication
+			}
+			values, err := GetAll(appID, scope)
+			if err != nil {
+				return fmt.Errorf("error reading %s: %v", domain, err)
+			}
+			if len(values) > 0 {
+				fn(domain, scope, values)
+			}
+		}
+	}
+	return nil
+}
+
+// CollectDomains reads one exact (user, host) slot of many domains concurrently, e.g. for an
+// inventory of hundreds of domains. Every worker creates and releases its own CoreFoundation
+// objects, so no CF object is shared between goroutines; CFPreferences itself is safe to call
+// from several threads.
+//
+// Parameters:
+//   - appIDs: The bundle identifiers of the applications to read. Duplicates are read once.
+//   - scope: The PreferenceScope defining the user and host scope to read.
+//   - concurrency: The maximum number of domains read at once. Zero or less uses GOMAXPROCS.
+//
+// Returns:
+//   - map[string]map[string]interface{}: The keys and values of each domain, keyed by application
+//     ID. Domains without values map to an empty map.
+//   - error: An error naming the first domain, in the order of appIDs, that could not be read.
+func CollectDomains(appIDs []string, scope PreferenceScope, concurrency int) (map[string]map[string]interface{}, error) {
+	return NewClient().CollectDomains(appIDs, scope, concurrency)
+}
+
+// CollectDomains reads one slot of many domains concurrently. See CollectDomains.
+func (c *Client) CollectDomains(appIDs []string, scope PreferenceScope, concurrency int) (map[string]map[string]interface{}, error) {
+	if concurrency <= 0 {
+		concurrency = runtime.GOMAXPROCS(0)
+	}
+	if concurrency > len(appIDs) {
+		concurrency = len(appIDs)
+	}
+
+	values := make([]map[string]interface{}, len(appIDs))
+	errs := make([]error, len(appIDs))
+	var failed int32
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < concurrency; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				values[i], errs[i] = c.GetAll(appIDs[i], scope)
+				if errs[i] != nil {
+					atomic.StoreInt32(&failed, 1)
+				}
+			}
+		}()
+	}
+	seen := make(map[string]bool, len(appIDs))
+	for i, appID := range appIDs {
+		if atomic.LoadInt32(&failed) != 0 {
+			break
+		}
+		if !seen[appID] {
+			seen[appID] = true
+			jobs <- i
+		}
+	}
+	close(jobs)
+	wg.Wait()
+
+	result := make(map[string]map[string]interface{}, len(seen))
+	for i, appID := range appIDs {
+		if errs[i] != nil {
+			return nil, fmt.Errorf("error reading %s: %v", appID, errs[i])
+		}
+		if values[i] != nil {
+			result[appID] = values[i]
+		}
+	}
+	return result, nil
+}
+
+func containsString(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
+
+// matchDomain reports whether domain passes the include and exclude glob patterns.
+func matchDomain(domain string, include, exclude []string) (bool, error) {
+	for _, pattern := range exclude {
+		matched, err := path.Match(pattern, domain)
+		if err != nil {
+			return false, fmt.Errorf("invalid exclude pattern %q: %v", pattern, err)
+		}
+		if matched {
+			return false, nil
+		}
+	}
+	if len(include) == 0 {
+		return true, nil
+	}
+	for _, pattern := range include {
+		matched, err := path.Match(pattern, domain)
+		if err != nil {
+			return false, fmt.Errorf("invalid include pattern %q: %v", pattern, err)
+		}
+		if matched {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// CopyDomain makes one exact (user, host) slot of dstAppID an exact copy of the same slot of
+// srcAppID: every key of the source is written and every other key of the destination is
+// removed, as with ReplaceAll. Use it when an application changes its bundle identifier or to
+// seed a test domain from a real one.
+//
+// Parameters:
+//   - srcAppID: The bundle identifier of the application to copy from.
+//   - dstAppID: The bundle identifier of the application to copy to.
+//   - scope: The PreferenceScope defining the user and host scope to copy.
+//
+// Returns:
+//   - error: An error if either domain cannot be read or the destination cannot be written.
+func CopyDomain(srcAppID, dstAppID string, scope PreferenceScope) error {
+	return NewClient().CopyDomain(srcAppID, dstAppID, scope)
+}
+
+// CopyDomain copies one slot of a domain to another application ID. See CopyDomain.
+func (c *Client) CopyDomain(srcAppID, dstAppID string, scope PreferenceScope) error {
+	if srcAppID == dstAppID {
+		return fmt.Errorf("cannot copy %s to itself", srcAppID)
+	}
+	values, err := c.store.List(srcAppID, scope)
+	if err != nil {
+		return fmt.Errorf("error reading %s: %v", srcAppID, err)
+	}
+	return c.ReplaceAll(dstAppID, scope, values)
+}
+
+// MigrateOptions controls how MigrateDomain moves values.
+type MigrateOptions struct {
+	// RenameKeys maps keys of the old domain to their names in the new domain. Keys mapped to
+	// the empty string are dropped; other keys keep their names.
+	RenameKeys map[string]string
+	// KeepExisting leaves keys that are already set in the new domain unchanged instead of
+	// overwriting them with the migrated values.
+	KeepExisting bool
+}
+
+// MigrateDomain moves one exact (user, host) slot of a domain to a new application ID, e.g.
+// after a vendor renames its bundle identifier. The values are written to the new domain,
+// with keys renamed as opts specifies, and only then removed from the old domain. Keys of the
+// new domain that the old one does not have are left untouched.
+//
+// Parameters:
+//   - oldAppID: The bundle identifier to migrate from.
+//   - newAppID: The bundle identifier to migrate to.
+//   - scope: The PreferenceScope defining the user and host scope to migrate.
+//   - opts: How keys are renamed and whether existing values are kept.
+//
+// Returns:
+//   - error: An error if either domain cannot be read or written. The old domain is left
+//     intact if the new one cannot be written.
+func MigrateDomain(oldAppID, newAppID string, scope PreferenceScope, opts MigrateOptions) error {
+	return NewClient().MigrateDomain(oldAppID, newAppID, scope, opts)
+}
+
+// MigrateDomain moves one slot of a domain to a new application ID. See MigrateDomain.
+func (c *Client) MigrateDomain(oldAppID, newAppID string, scope PreferenceScope, opts MigrateOptions) error {
+	if oldAppID == newAppID {
+		return fmt.Errorf("cannot migrate %s to itself", oldAppID)
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	values, err := c.store.List(oldAppID, scope)
+	if err != nil {
+		return fmt.Errorf("error reading %s: %v", oldAppID, err)
+	}
+	existing, err := c.store.List(newAppID, scope)
+	if err != nil {
+		return fmt.Errorf("error reading %s: %v", newAppID, err)
+	}
+
+	migrated := make(map[string]interface{}, len(values))
+	removed := make(map[string]interface{}, len(values))
+	for key, value := range values {
+		removed[key] = nil
+		if name, ok := opts.RenameKeys[key]; ok {
+			if name == "" {
+				continue
+			}
+			key = name
+		}
+		if _, ok := existing[key]; ok && opts.KeepExisting {
+			continue
+		}
+		migrated[key] = c.writeValue(value)
+	}
+
+	if err := writeSlot(c.store, prefSlot{newAppID, scope}, migrated); err != nil {
+		return fmt.Errorf("error writing %s: %w", newAppID, err)
+	}
+	if err := writeSlot(c.store, prefSlot{oldAppID, scope}, removed); err != nil {
+		return fmt.Errorf("error removing %s: %w", oldAppID, err)
+	}
+
+	for key, value := range migrated {
+		c.record(mutation{key: key, appID: newAppID, scope: scope, old: existing[key], new: value})
+	}
+	for key := range removed {
+		c.record(mutation{key: key, appID: oldAppID, scope: scope, old: values[key]})
+	}
+	return nil
+}
+
+// ListDomains enumerates the application IDs that have preferences stored in a scope. The list
+// comes from CFPreferencesCopyApplicationList; if that returns nothing, which happens on
+// releases where the deprecated API is no longer populated, the Preferences directories of the
+// scope (and, for the current user, sandbox containers) are scanned instead.
+//
+// Parameters:
+//   - scope: The PreferenceScope defining the user and host scope to enumerate.
+//
+// Returns:
+//   - []string: The application IDs, sorted alphabetically.
+//   - error: An error if the scope is invalid or the directories cannot be read.
+func ListDomains(scope PreferenceScope) ([]string, error) {
+	return NewClient().ListDomains(scope)
+}
+
+// ListDomains enumerates the application IDs of a scope. With WithRoot, only the Preferences
+// directories under the root are scanned. See ListDomains.
+func (c *Client) ListDomains(scope PreferenceScope) ([]string, error) {
+	var domains []string
+	if c.root == "" {
+		var err error
+		if domains, err = applicationList(scope); err != nil {
+			return nil, err
+		}
+	}
+	if len(domains) == 0 {
+		var err error
+		if domains, err = scanDomains(c.root, scope); err != nil {
+			return nil, err
+		}
+	}
+
+	sort.Strings(domains)
+	unique := domains[:0]
+	for i, d := range domains {
+		if i == 0 || d != domains[i-1] {
+			unique = append(unique, d)
+		}
+	}
+	return unique, nil
+}
+
+// scanDomains lists the domains of a scope by scanning its Preferences directories on the
+// volume mounted at root for plist files.
+func scanDomains(root string, scope PreferenceScope) ([]string, error) {
+	var dirs []string
+	if scope.User == AnyUser {
+		dirs = append(dirs, filepath.Join(root, systemPrefsDir))
+	} else {
+		home, err := rootHomeDir(root, scope.User)
+		if err != nil {
+			return nil, err
+		}
+		dirs = append(dirs, filepath.Join(home, "Library", "Preferences"))
+		containers, _ := filepath.Glob(containerPrefsDir(home, "*"))
+		dirs = append(dirs, containers...)
+	}
+
+	byHost := false
+	switch scope.Host {
+	case AnyHost:
+	case CurrentHost:
+		byHost = true
+		for i := range dirs {
+			dirs[i] = filepath.Join(dirs[i], byHostDir)
+		}
+	default:
+		return nil, fmt.Errorf("invalid host type in scope: must be CurrentHost or AnyHost")
+	}
+
+	domains := make([]string, 0)
+	for _, dir := range dirs {
+		entries, err := os.ReadDir(dir)
+		if err != nil {
+			if os.IsNotExist(err) || os.IsPermission(err) {
+				continue
+			}
+			return nil, fmt.Errorf("error reading %s: %v", dir, err)
+		}
+		for _, e := range entries {
+			name := e.Name()
+			if e.IsDir() || !strings.HasSuffix(name, ".plist") {
+				continue
+			}
+			domain := strings.TrimSuffix(name, ".plist")
+			if byHost {
+				// ByHost files are named <domain>.<host identifier>.plist.
+				i := strings.LastIndex(domain, ".")
+				if i <= 0 {
+					continue
+				}
+				domain = domain[:i]
+			}
+			domains = append(domains, domain)
+		}
+	}
+	return domains, nil
+}
@@ -0,0 +1,143 @@
+//go:build darwin && cgo
+
+package mac_prefs
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"testing"
+)
+
+func TestDumpAll(t *testing.T) {
+	const appID = testAppID + ".dumpall"
+
+	if err := Set("DumpShared", "anyhost", appID, CurrentUserAnyHost); err != nil {
+		t.Fatalf("Set() error = %v", err)
+	}
+	defer Delete("DumpShared", appID, CurrentUserAnyHost)
+	if err := Set("DumpShared", "byhost", appID, CurrentUserCurrentHost); err != nil {
+		t.Fatalf("Set() error = %v", err)
+	}
+	defer Delete("DumpShared", appID, CurrentUserCurrentHost)
+	if err := Set("DumpOnly", 1, appID, CurrentUserAnyHost); err != nil {
+		t.Fatalf("Set() error = %v", err)
+	}
+	defer Delete("DumpOnly", appID, CurrentUserAnyHost)
+
+	all, err := DumpAll(DumpAllOptions{Include: []string{testAppID + ".dump*"}})
+	if err != nil {
+		t.Fatalf("DumpAll() error = %v", err)
+	}
+	want := map[string]map[string]interface{}{
+		appID: {"DumpShared": "byhost", "DumpOnly": 1},
+	}
+	if !reflect.DeepEqual(all, want) {
+		t.Fatalf("DumpAll() got = %v, want %v", all, want)
+	}
+
+	excluded, err := DumpAll(DumpAllOptions{Include: []string{testAppID + ".*"}, Exclude: []string{"*.dumpall"}})
+	if err != nil {
+		t.Fatalf("DumpAll() error = %v", err)
+	}
+	if _, ok := excluded[appID]; ok {
+		t.Fatalf("DumpAll() included excluded domain %s", appID)
+	}
+
+	if _, err := DumpAll(DumpAllOptions{Include: []string{"["}}); err == nil {
+		t.Fatal("DumpAll() expected error for malformed pattern")
+	}
+}
+
+func TestDumpAllIncludesGlobalDomain(t *testing.T) {
+	if err := Set("DumpGlobalKey", "global", AnyApplication, CurrentUserAnyHost); err != nil {
+		t.Fatalf("Set() error = %v", err)
+	}
+	defer Delete("DumpGlobalKey", AnyApplication, CurrentUserAnyHost)
+
+	all, err := DumpAll(DumpAllOptions{Include: []string{GlobalDomain}})
+	if err != nil {
+		t.Fatalf("DumpAll() error = %v", err)
+	}
+	if got := all[GlobalDomain]["DumpGlobalKey"]; got != "global" {
+		t.Fatalf("DumpAll() global DumpGlobalKey = %v, want global", got)
+	}
+}
+
+func TestCollectDomains(t *testing.T) {
+	var appIDs []string
+	want := make(map[string]map[string]interface{})
+	for i := 0; i < 20; i++ {
+		appID := fmt.Sprintf("%s.collect%d", testAppID, i)
+		if err := Set("CollectIndex", i, appID, CurrentUserAnyHost); err != nil {
+			t.Fatalf("Set() error = %v", err)
+		}
+		defer Delete("CollectIndex", appID, CurrentUserAnyHost)
+		appIDs = append(appIDs, appID)
+		want[appID] = map[string]interface{}{"CollectIndex": i}
+	}
+
+	got, err := CollectDomains(appIDs, CurrentUserAnyHost, 8)
+	if err != nil {
+		t.Fatalf("CollectDomains() error = %v", err)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("CollectDomains() got = %v, want %v", got, want)
+	}
+}
+
+func TestListDomains(t *testing.T) {
+	const appID = testAppID + ".listdomains"
+	if err := Set("ListKey", true, appID, CurrentUserAnyHost); err != nil {
+		t.Fatalf("Set() error = %v", err)
+	}
+	defer Delete("ListKey", appID, CurrentUserAnyHost)
+
+	domains, err := ListDomains(CurrentUserAnyHost)
+	if err != nil {
+		t.Fatalf("ListDomains() error = %v", err)
+	}
+	if !containsString(domains, appID) {
+		t.Fatalf("ListDomains() got = %v, want it to contain %s", domains, appID)
+	}
+}
+
+func TestScanDomains(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+
+	prefs := filepath.Join(home, "Library", "Preferences")
+	container := containerPrefsDir(home, "com.example.sandboxed")
+	for _, file := range []string{
+		filepath.Join(prefs, "com.example.a.plist"),
+		filepath.Join(prefs, "notes.txt"),
+		filepath.Join(prefs, byHostDir, "com.example.b.0123-4567.plist"),
+		filepath.Join(container, "com.example.sandboxed.plist"),
+	} {
+		if err := os.MkdirAll(filepath.Dir(file), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(file, []byte(testPlistXML), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	got, err := scanDomains("", CurrentUserAnyHost)
+	if err != nil {
+		t.Fatalf("scanDomains() error = %v", err)
+	}
+	sort.Strings(got)
+	if want := []string{"com.example.a", "com.example.sandboxed"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("scanDomains(CurrentUserAnyHost) got = %v, want %v", got, want)
+	}
+
+	got, err = scanDomains("", CurrentUserCurrentHost)
+	if err != nil {
+		t.Fatalf("scanDomains() error = %v", err)
+	}
+	if want := []string{"com.example.b"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("scanDomains(CurrentUserCurrentHost) got = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,158 @@
+package mac_prefs
+
+import (
+	"fmt"
+	"sync"
+)
+
+// PlannedChange is a change a Client created with WithDryRun would have written.
+type PlannedChange struct {
+	Change
+	// ApplicationID is the domain of the key.
+	ApplicationID string
+	// Scope is the (user, host) slot of the key.
+	Scope PreferenceScope
+}
+
+func (p PlannedChange) String() string {
+	return fmt.Sprintf("%s: %v", p.ApplicationID, p.Change)
+}
+
+// WithDryRun makes the Client plan its writes instead of making them. Set, Delete, Converge,
+// transactions, and every other write validate their values and compute what would change,
+// but nothing is written; Planned returns the changes, in the order they were planned. Reads
+// through the Client see the planned values, so a sequence of writes is planned as it would
+// run. Undo and the OnMutation hooks do not record planned changes.
+func WithDryRun() Option {
+	return func(c *Client) {
+		c.dryRun = true
+	}
+}
+
+// Planned returns the changes a Client created with WithDryRun has planned so far, or nil for
+// other Clients. Writes that would not change a value are not included.
+func (c *Client) Planned() []PlannedChange {
+	s, ok := c.store.(*dryRunStore)
+	if !ok {
+		return nil
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]PlannedChange(nil), s.planned...)
+}
+
+// dryRunStore is a Store that records the writes to the Store it wraps instead of making
+// them, and serves reads from the recorded values.
+type dryRunStore struct {
+	Store
+
+	mu      sync.Mutex
+	values  map[prefSlot]map[string]interface{} // planned values; nil removes the key
+	planned []PlannedChange
+}
+
+func newDryRunStore(store Store) *dryRunStore {
+	return &dryRunStore{Store: store, values: make(map[prefSlot]map[string]interface{})}
+}
+
+// Get retrieves the planned value of a key, or the value in the wrapped Store.
+func (s *dryRunStore) Get(key string, applicationID string, scope PreferenceScope) (interface{}, error) {
+	s.mu.Lock()
+	value, ok := s.values[prefSlot{applicationID, scope}][key]
+	s.mu.Unlock()
+	if ok {
+		return value, nil
+	}
+	return s.Store.Get(key, applicationID, scope)
+}
+
+// GetMultiple retrieves several keys, with their planned values.
+func (s *dryRunStore) GetMultiple(keys []string, applicationID string, scope PreferenceScope) (map[string]interface{}, error) {
+	values, err := readSlot(s.Store, prefSlot{applicationID, scope}, keys)
+	if err != nil {
+		return nil, err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	planned := s.values[prefSlot{applicationID, scope}]
+	for _, key := range keys {
+		if value, ok := planned[key]; ok {
+			if value == nil {
+				delete(values, key)
+			} else {
+				values[key] = value
+			}
+		}
+	}
+	return values, nil
+}
+
+// List retrieves a domain with its planned values.
+func (s *dryRunStore) List(applicationID string, scope PreferenceScope) (map[string]interface{}, error) {
+	current, err := s.Store.List(applicationID, scope)
+	if err != nil {
+		return nil, err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	planned := s.values[prefSlot{applicationID, scope}]
+	values := make(map[string]interface{}, len(current)+len(planned))
+	for key, value := range current {
+		values[key] = value
+	}
+	for key, value := range planned {
+		if value == nil {
+			delete(values, key)
+		} else {
+			values[key] = value
+		}
+	}
+	return values, nil
+}
+
+// Set plans a value. A nil value plans the removal of the key.
+func (s *dryRunStore) Set(key string, value interface{}, applicationID string, scope PreferenceScope) error {
+	return s.SetMultiple(map[string]interface{}{key: value}, nil, applicationID, scope)
+}
+
+// Delete plans the removal of a key.
+func (s *dryRunStore) Delete(key string, applicationID string, scope PreferenceScope) error {
+	return s.SetMultiple(nil, []string{key}, applicationID, scope)
+}
+
+// SetMultiple plans several values. Nothing is planned if any value is invalid. Values are
+// planned in the shape CFPreferences stores them, as MemoryStore stores them, so reading a
+// planned value returns what a real write would read back.
+func (s *dryRunStore) SetMultiple(keysToSet map[string]interface{}, keysToRemove []string, applicationID string, scope PreferenceScope) error {
+	values := batchValues(keysToSet, keysToRemove)
+	for key, value := range values {
+		if err := ValidateValue(value); err != nil {
+			return fmt.Errorf("invalid value for key %s: %w", key, err)
+		}
+		stored, _ := storedValue(value)
+		values[key] = cloneStoredValue(stored)
+	}
+
+	slot := prefSlot{applicationID, scope}
+	current := make(map[string]interface{}, len(values))
+	for key := range values {
+		value, err := s.Get(key, applicationID, scope)
+		if err != nil {
+			return err
+		}
+		if value != nil {
+			current[key] = value
+		}
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.values[slot] == nil {
+		s.values[slot] = make(map[string]interface{})
+	}
+	for _, change := range convergeChanges(current, values) {
+		s.values[slot][change.Key] = change.New
+		s.planned = append(s.planned, PlannedChange{Change: change, ApplicationID: applicationID, Scope: scope})
+	}
+	return nil
+}
@@ -0,0 +1,139 @@
+package mac_prefs
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestClientDryRun(t *testing.T) {
+	store := NewMemoryStore()
+	if err := store.Set("keep", "same", "com.example", CurrentUserAnyHost); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.Set("old", 1, "com.example", CurrentUserAnyHost); err != nil {
+		t.Fatal(err)
+	}
+	c := NewClient(WithStore(store), WithDryRun(), WithUndo(0))
+	var audited int
+	c.OnMutation(func(AuditEvent) { audited++ })
+
+	if err := c.Set("new", true, "com.example", CurrentUserAnyHost); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.Set("keep", "same", "com.example", CurrentUserAnyHost); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := c.Increment("old", "com.example", CurrentUserAnyHost, 1); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := c.Increment("old", "com.example", CurrentUserAnyHost, 1); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.Delete("missing", "com.example", CurrentUserAnyHost); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.Set("invalid", make(chan int), "com.example", CurrentUserAnyHost); err == nil {
+		t.Error("Set() of an invalid value expected error")
+	}
+
+	changes, err := c.Converge("com.example", CurrentUserAnyHost, map[string]interface{}{"keep": nil, "new": true, "other": "x"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := (Changes{{Key: "keep", Old: "same"}, {Key: "other", New: "x"}}); !reflect.DeepEqual(changes, want) {
+		t.Errorf("Converge() = %v, want %v", changes, want)
+	}
+
+	var got []string
+	for _, p := range c.Planned() {
+		got = append(got, p.String())
+	}
+	want := []string{
+		"com.example: add new = true",
+		"com.example: change old: 1 -> 2",
+		"com.example: change old: 2 -> 3",
+		"com.example: remove keep (was same)",
+		"com.example: add other = x",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Planned() = %q, want %q", got, want)
+	}
+
+	if value, _ := c.Get("old", "com.example", CurrentUserAnyHost); value != int64(3) {
+		t.Errorf("Client.Get() = %#v, want the planned value 3", value)
+	}
+	values, err := c.GetAll("com.example", CurrentUserAnyHost)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := values["keep"]; ok || values["other"] != "x" {
+		t.Errorf("Client.GetAll() = %v, want the planned values", values)
+	}
+
+	stored, _ := store.List("com.example", CurrentUserAnyHost)
+	if !reflect.DeepEqual(stored, map[string]interface{}{"keep": "same", "old": 1}) {
+		t.Errorf("store = %v, want it unchanged", stored)
+	}
+	if audited != 0 || c.UndoLen() != 0 {
+		t.Errorf("dry run recorded %d audit events and %d undo entries", audited, c.UndoLen())
+	}
+}
+
+func TestClientDryRunStoredValues(t *testing.T) {
+	type dock struct {
+		Autohide bool `prefs:"autohide"`
+	}
+	values := map[string]interface{}{
+		"struct":   dock{Autohide: true},
+		"duration": 1500 * time.Millisecond,
+		"uuid":     [16]byte{0x12, 0x34},
+		"number":   json.Number("42"),
+	}
+
+	memory := NewMemoryStore()
+	if err := memory.SetMultiple(values, nil, "com.example", CurrentUserAnyHost); err != nil {
+		t.Fatalf("SetMultiple() error = %v", err)
+	}
+	want, _ := memory.List("com.example", CurrentUserAnyHost)
+
+	c := NewClient(WithStore(NewMemoryStore()), WithDryRun())
+	for key, value := range values {
+		if err := c.Set(key, value, "com.example", CurrentUserAnyHost); err != nil {
+			t.Fatalf("Set(%s) error = %v", key, err)
+		}
+	}
+	for key := range values {
+		got, err := c.store.Get(key, "com.example", CurrentUserAnyHost)
+		if err != nil {
+			t.Fatalf("Get(%s) error = %v", key, err)
+		}
+		if !reflect.DeepEqual(got, want[key]) {
+			t.Errorf("planned %s = %#v, want %#v", key, got, want[key])
+		}
+	}
+}
+
+func TestClientConverge(t *testing.T) {
+	store := NewMemoryStore()
+	if err := store.Set("old", "value", "com.example", CurrentUserAnyHost); err != nil {
+		t.Fatal(err)
+	}
+	c := NewClient(WithStore(store), WithUndo(0))
+	desired := map[string]interface{}{"old": nil, "count": 2.0}
+
+	changes, err := c.Converge("com.example", CurrentUserAnyHost, desired)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(changes) != 2 || c.UndoLen() != 2 {
+		t.Errorf("Converge() = %v with %d undo entries", changes, c.UndoLen())
+	}
+	if changes, err := c.Converge("com.example", CurrentUserAnyHost, desired); err != nil || len(changes) != 0 {
+		t.Errorf("second Converge() = %v, %v, want no changes", changes, err)
+	}
+	if c.Planned() != nil {
+		t.Errorf("Planned() = %v without dry run", c.Planned())
+	}
+}
@@ -0,0 +1,92 @@
+package mac_prefs
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Dump renders a value, such as one returned by Get or GetAll, as an indented tree annotated
+// with plist types. Dictionary keys are printed in sorted order so output is stable.
+//
+// Example output:
+//
+//	dict (2 keys) {
+//	  autohide: bool true
+//	  persistent-apps: array (1) [
+//	    [0]: string "Safari"
+//	  ]
+//	}
+func Dump(v interface{}) string {
+	var b strings.Builder
+	dumpValue(&b, reflect.ValueOf(v), 0)
+	b.WriteByte('\n')
+	return b.String()
+}
+
+func dumpValue(b *strings.Builder, v reflect.Value, depth int) {
+	for v.IsValid() && (v.Kind() == reflect.Interface || v.Kind() == reflect.Pointer) {
+		if v.IsNil() {
+			b.WriteString("nil")
+			return
+		}
+		v = v.Elem()
+	}
+	if !v.IsValid() {
+		b.WriteString("nil")
+		return
+	}
+	if t, ok := v.Interface().(time.Time); ok {
+		b.WriteString("date " + t.UTC().Format(time.RFC3339Nano))
+		return
+	}
+
+	indent := strings.Repeat("  ", depth+1)
+	switch v.Kind() {
+	case reflect.String:
+		b.WriteString("string " + strconv.Quote(v.String()))
+	case reflect.Bool:
+		b.WriteString("bool " + strconv.FormatBool(v.Bool()))
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		b.WriteString("int " + strconv.FormatInt(v.Int(), 10))
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		b.WriteString("int " + strconv.FormatUint(v.Uint(), 10))
+	case reflect.Float32, reflect.Float64:
+		b.WriteString("real " + strconv.FormatFloat(v.Float(), 'g', -1, 64))
+	case reflect.Slice, reflect.Array:
+		if v.Type().Elem().Kind() == reflect.Uint8 {
+			fmt.Fprintf(b, "data (%d bytes)", v.Len())
+			return
+		}
+		if v.Len() == 0 {
+			b.WriteString("array (0) []")
+			return
+		}
+		fmt.Fprintf(b, "array (%d) [\n", v.Len())
+		for i := 0; i < v.Len(); i++ {
+			fmt.Fprintf(b, "%s[%d]: ", indent, i)
+			dumpValue(b, v.Index(i), depth+1)
+			b.WriteByte('\n')
+		}
+		b.WriteString(indent[2:] + "]")
+	case reflect.Map:
+		if v.Len() == 0 {
+			b.WriteString("dict (0 keys) {}")
+			return
+		}
+		keys := v.MapKeys()
+		sort.Slice(keys, func(i, j int) bool { return fmt.Sprint(keys[i]) < fmt.Sprint(keys[j]) })
+		fmt.Fprintf(b, "dict (%d keys) {\n", v.Len())
+		for _, k := range keys {
+			fmt.Fprintf(b, "%s%v: ", indent, k)
+			dumpValue(b, v.MapIndex(k), depth+1)
+			b.WriteByte('\n')
+		}
+		b.WriteString(indent[2:] + "}")
+	default:
+		fmt.Fprintf(b, "%s %v", v.Type(), v)
+	}
+}
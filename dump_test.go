@@ -0,0 +1,44 @@
+package mac_prefs
+
+import (
+	"testing"
+	"time"
+)
+
+func TestDump(t *testing.T) {
+	got := Dump(map[string]interface{}{
+		"autohide": true,
+		"tilesize": 48,
+		"scale":    1.5,
+		"name":     "dock",
+		"icon":     []byte{1, 2, 3},
+		"updated":  time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC),
+		"apps": []interface{}{
+			map[string]interface{}{"label": "Safari"},
+			[]interface{}{},
+		},
+		"empty": map[string]interface{}{},
+		"unset": nil,
+	})
+
+	want := `dict (9 keys) {
+  apps: array (2) [
+    [0]: dict (1 keys) {
+      label: string "Safari"
+    }
+    [1]: array (0) []
+  ]
+  autohide: bool true
+  empty: dict (0 keys) {}
+  icon: data (3 bytes)
+  name: string "dock"
+  scale: real 1.5
+  tilesize: int 48
+  unset: nil
+  updated: date 2024-01-02T03:04:05Z
+}
+`
+	if got != want {
+		t.Errorf("Dump() got:\n%s\nwant:\n%s", got, want)
+	}
+}
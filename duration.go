@@ -0,0 +1,73 @@
+package mac_prefs
+
+import (
+	"fmt"
+	"math"
+	"reflect"
+	"time"
+)
+
+var durationType = reflect.TypeOf(time.Duration(0))
+
+// DurationValue converts a value read from preferences to a time.Duration. Durations are
+// written as a real number of seconds, the usual plist representation, so they read back as
+// float64; integer seconds are accepted as well.
+//
+// Parameters:
+//   - value: The value returned by Get or a Client.
+//
+// Returns:
+//   - time.Duration: The duration.
+//   - error: An error if value is not a number or is out of range.
+func DurationValue(value interface{}) (time.Duration, error) {
+	var seconds float64
+	rv := reflect.ValueOf(value)
+	switch {
+	case value == nil:
+		return 0, fmt.Errorf("cannot convert nil to a duration")
+	case rv.Type() == durationType:
+		return time.Duration(rv.Int()), nil
+	case rv.CanInt():
+		seconds = float64(rv.Int())
+	case rv.CanUint():
+		seconds = float64(rv.Uint())
+	case rv.CanFloat():
+		seconds = rv.Float()
+	default:
+		return 0, fmt.Errorf("cannot convert %T to a duration", value)
+	}
+
+	nanos := math.Round(seconds * float64(time.Second))
+	if math.IsNaN(nanos) || nanos > math.MaxInt64 || nanos < math.MinInt64 {
+		return 0, fmt.Errorf("%v seconds is out of range for a duration", seconds)
+	}
+	return time.Duration(nanos), nil
+}
+
+// GetDuration retrieves a duration stored as a number of seconds. See Get and DurationValue.
+//
+// Parameters:
+//   - key: The preference key to retrieve.
+//   - applicationID: The bundle identifier of the application for which to retrieve the preference.
+//   - scope: The PreferenceScope defining the user and host scope for the preference.
+//
+// Returns:
+//   - time.Duration: The duration, or zero if the key is not set.
+//   - bool: Whether the key is set.
+//   - error: An error if the value cannot be read or is not a number.
+func GetDuration(key string, applicationID string, scope PreferenceScope) (time.Duration, bool, error) {
+	return NewClient().GetDuration(key, applicationID, scope)
+}
+
+// GetDuration retrieves a duration stored as a number of seconds. See GetDuration.
+func (c *Client) GetDuration(key string, applicationID string, scope PreferenceScope) (time.Duration, bool, error) {
+	value, err := c.lookup(key, applicationID, scope)
+	if err != nil || value == nil {
+		return 0, false, err
+	}
+	d, err := DurationValue(value)
+	if err != nil {
+		return 0, true, fmt.Errorf("error reading %s: %v", key, err)
+	}
+	return d, true, nil
+}
@@ -0,0 +1,75 @@
+package mac_prefs
+
+import (
+	"math"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestDurationValue(t *testing.T) {
+	tests := []struct {
+		value interface{}
+		want  time.Duration
+	}{
+		{90.0, 90 * time.Second},
+		{0.25, 250 * time.Millisecond},
+		{-1.5, -1500 * time.Millisecond},
+		{30, 30 * time.Second},
+		{int64(2), 2 * time.Second},
+		{uint8(3), 3 * time.Second},
+		{5 * time.Minute, 5 * time.Minute},
+	}
+	for _, tt := range tests {
+		got, err := DurationValue(tt.value)
+		if err != nil {
+			t.Errorf("DurationValue(%#v) error = %v", tt.value, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("DurationValue(%#v) = %v, want %v", tt.value, got, tt.want)
+		}
+	}
+
+	for _, value := range []interface{}{nil, "90", true, math.Inf(1), math.NaN(), 1e300} {
+		if _, err := DurationValue(value); err == nil {
+			t.Errorf("DurationValue(%#v) expected error", value)
+		}
+	}
+}
+
+func TestMemoryStoreDurations(t *testing.T) {
+	store := NewMemoryStore()
+	if err := store.Set("Timeout", 90*time.Second, "com.example", CurrentUserAnyHost); err != nil {
+		t.Fatalf("Set() error = %v", err)
+	}
+	if err := store.Set("Backoff", []time.Duration{time.Second, 1500 * time.Millisecond}, "com.example", CurrentUserAnyHost); err != nil {
+		t.Fatalf("Set() error = %v", err)
+	}
+	if err := store.Set("Sizes", []int{1, 2}, "com.example", CurrentUserAnyHost); err != nil {
+		t.Fatalf("Set() error = %v", err)
+	}
+
+	values, _ := store.List("com.example", CurrentUserAnyHost)
+	want := map[string]interface{}{
+		"Timeout": 90.0,
+		"Backoff": []interface{}{1.0, 1.5},
+		"Sizes":   []int{1, 2},
+	}
+	if !reflect.DeepEqual(values, want) {
+		t.Fatalf("List() = %#v, want %#v", values, want)
+	}
+
+	c := NewClient(WithStore(store))
+	d, ok, err := c.GetDuration("Timeout", "com.example", CurrentUserAnyHost)
+	if err != nil || !ok || d != 90*time.Second {
+		t.Fatalf("GetDuration() = %v, %v, %v, want 1m30s", d, ok, err)
+	}
+	if d, ok, err := c.GetDuration("Missing", "com.example", CurrentUserAnyHost); d != 0 || ok || err != nil {
+		t.Fatalf("GetDuration() of a missing key = %v, %v, %v", d, ok, err)
+	}
+	store.Set("Name", "dock", "com.example", CurrentUserAnyHost)
+	if _, ok, err := c.GetDuration("Name", "com.example", CurrentUserAnyHost); !ok || err == nil {
+		t.Fatalf("GetDuration() of a string = %v, %v, want an error", ok, err)
+	}
+}
@@ -0,0 +1,85 @@
+package mac_prefs
+
+import (
+	"bytes"
+	"fmt"
+	"os/exec"
+)
+
+// killallCommand is the tool used to restart cfprefsd when it cannot be asked to drop a domain.
+var killallCommand = "/usr/bin/killall"
+
+// FlushDomainCache makes cfprefsd forget what it has cached for one slot of a domain, so the
+// next read loads the plist file from disk. Use it after editing or replacing a plist file
+// directly, e.g. with WritePlistFile or a restore from backup; otherwise cfprefsd keeps
+// serving its cached values and writes them over the file on the next change.
+//
+// The slot is synchronized first, which flushes the pending writes of this process. cfprefsd
+// is then asked to drop the domain with _CFPreferencesFlushCachesForIdentifier, a private
+// CoreFoundation function looked up at run time. On releases without it, the cfprefsd of the
+// scope's user is terminated with killall instead, as Apple recommends after editing
+// preference files by hand; launchd restarts it on the next request, and it writes its
+// pending changes before exiting. Terminating the cfprefsd of another user, or of AnyUser,
+// requires root privileges.
+//
+// Parameters:
+//   - appID: The bundle identifier of the application whose cache to flush.
+//   - scope: The PreferenceScope defining the user and host scope to flush.
+//
+// Returns:
+//   - error: An error if the domain cannot be synchronized or cfprefsd cannot be restarted.
+func FlushDomainCache(appID string, scope PreferenceScope) error {
+	return NewClient().FlushDomainCache(appID, scope)
+}
+
+// FlushDomainCache makes cfprefsd forget a slot of a domain and drops the values the Client
+// has cached for it. A read-only Client returns ErrReadOnly, since restarting cfprefsd
+// changes the state of the machine, and a dry-run Client does nothing. See FlushDomainCache.
+func (c *Client) FlushDomainCache(appID string, scope PreferenceScope) error {
+	if c.readOnly {
+		return ErrReadOnly
+	}
+	if c.dryRun {
+		return nil
+	}
+	if c.cache != nil {
+		c.cache.invalidateSlot(prefSlot{appID, scope})
+	}
+
+	flushed, err := flushCaches(appID, scope)
+	if err != nil || flushed {
+		return err
+	}
+	return restartCfprefsd(scope.User)
+}
+
+// restartCfprefsd terminates the cfprefsd serving userName. It is not an error if none is
+// running.
+func restartCfprefsd(userName UserType) error {
+	name := string(userName)
+	switch userName {
+	case CurrentUser:
+		name = processUser()
+	case AnyUser:
+		name = "root"
+	}
+
+	return killall(name, "cfprefsd")
+}
+
+// killall terminates the processes named process that belong to userName, or to every user
+// if userName is empty. It is not an error if none is running.
+func killall(userName, process string) error {
+	args := []string{process}
+	if userName != "" {
+		args = []string{"-u", userName, process}
+	}
+	out, err := exec.Command(killallCommand, args...).CombinedOutput()
+	if err != nil && !bytes.Contains(out, []byte("No matching processes")) {
+		if userName == "" {
+			return fmt.Errorf("error restarting %s: %v: %s", process, err, bytes.TrimSpace(out))
+		}
+		return fmt.Errorf("error restarting %s for %s: %v: %s", process, userName, err, bytes.TrimSpace(out))
+	}
+	return nil
+}
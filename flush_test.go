@@ -0,0 +1,52 @@
+package mac_prefs
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestClientFlushDomainCacheGuards(t *testing.T) {
+	c := NewClient(WithStore(NewMemoryStore()), WithReadOnly())
+	if err := c.FlushDomainCache("com.example", CurrentUserAnyHost); !errors.Is(err, ErrReadOnly) {
+		t.Errorf("FlushDomainCache() error = %v, want ErrReadOnly", err)
+	}
+	c = NewClient(WithStore(NewMemoryStore()), WithDryRun())
+	if err := c.FlushDomainCache("com.example", CurrentUserAnyHost); err != nil {
+		t.Errorf("FlushDomainCache() in dry run error = %v", err)
+	}
+}
+
+func TestRestartCfprefsd(t *testing.T) {
+	dir := t.TempDir()
+	args := filepath.Join(dir, "args")
+	killall := filepath.Join(dir, "killall")
+	script := "#!/bin/sh\necho \"$@\" >> " + args + "\n" +
+		"if [ \"$2\" = nobody ]; then echo 'No matching processes belonging to you were found' >&2; exit 1; fi\n" +
+		"if [ \"$2\" = root ]; then echo 'Operation not permitted' >&2; exit 1; fi\n"
+	if err := os.WriteFile(killall, []byte(script), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	defer func(old string) { killallCommand = old }(killallCommand)
+	killallCommand = killall
+
+	if err := restartCfprefsd("alice"); err != nil {
+		t.Errorf("restartCfprefsd(alice) error = %v", err)
+	}
+	if err := restartCfprefsd("nobody"); err != nil {
+		t.Errorf("restartCfprefsd() with no cfprefsd running error = %v", err)
+	}
+	if err := restartCfprefsd(AnyUser); err == nil || !strings.Contains(err.Error(), "Operation not permitted") {
+		t.Errorf("restartCfprefsd(AnyUser) error = %v, want the killall error", err)
+	}
+
+	data, err := os.ReadFile(args)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "-u alice cfprefsd\n-u nobody cfprefsd\n-u root cfprefsd\n"; string(data) != want {
+		t.Errorf("killall calls = %q, want %q", data, want)
+	}
+}
@@ -0,0 +1,161 @@
+// Package httpapi serves preferences read-only over HTTP on the loopback interface, for local
+// agents and UIs that cannot link the cgo package directly.
+//
+// The API has three endpoints, all returning JSON:
+//
+//	GET /domains                     the sorted list of domains
+//	GET /domains/{domain}            every key and value of a domain
+//	GET /domains/{domain}/keys/{key} {"domain": ..., "key": ..., "value": ...}
+//
+// Errors are returned as {"error": "..."} with an appropriate status code. Dates are encoded
+// as RFC 3339 strings and data as base64.
+package httpapi
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+)
+
+// DefaultAddr is the address the server listens on when none is given.
+const DefaultAddr = "127.0.0.1:8398"
+
+// Source provides the preferences served by the API.
+type Source interface {
+	// Domains lists the preference domains.
+	Domains() ([]string, error)
+	// Values returns every key and value of a domain, or an empty map if it has none.
+	Values(domain string) (map[string]interface{}, error)
+}
+
+// ErrNotLoopback is returned by Listen for addresses outside the loopback interface.
+var ErrNotLoopback = errors.New("httpapi: address is not a loopback address")
+
+// NewHandler returns a handler serving src. Requests whose Host header does not name the
+// loopback interface are rejected, so web pages cannot reach the API through DNS rebinding.
+func NewHandler(src Source) http.Handler {
+	return &handler{src: src}
+}
+
+// Listen listens on addr, which must be a loopback address such as 127.0.0.1:8398 or
+// localhost:8398. An empty addr listens on DefaultAddr.
+func Listen(addr string) (net.Listener, error) {
+	if addr == "" {
+		addr = DefaultAddr
+	}
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		return nil, fmt.Errorf("httpapi: invalid address %q: %v", addr, err)
+	}
+	if !isLoopbackHost(host) {
+		return nil, ErrNotLoopback
+	}
+	return net.Listen("tcp", addr)
+}
+
+type handler struct {
+	src Source
+}
+
+func (h *handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	host := r.Host
+	if hostname, _, err := net.SplitHostPort(host); err == nil {
+		host = hostname
+	}
+	if !isLoopbackHost(host) {
+		writeError(w, http.StatusForbidden, "host %q is not allowed", r.Host)
+		return
+	}
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		w.Header().Set("Allow", "GET, HEAD")
+		writeError(w, http.StatusMethodNotAllowed, "method %s is not allowed", r.Method)
+		return
+	}
+
+	path := strings.TrimPrefix(r.URL.Path, "/")
+	parts := strings.SplitN(path, "/", 4)
+	switch {
+	case path == "domains":
+		h.serveDomains(w)
+	case len(parts) == 2 && parts[0] == "domains" && parts[1] != "":
+		h.serveDomain(w, parts[1])
+	case len(parts) == 4 && parts[0] == "domains" && parts[1] != "" && parts[2] == "keys" && parts[3] != "":
+		h.serveKey(w, parts[1], parts[3])
+	default:
+		writeError(w, http.StatusNotFound, "no such endpoint %s", r.URL.Path)
+	}
+}
+
+func (h *handler) serveDomains(w http.ResponseWriter) {
+	domains, err := h.src.Domains()
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "%v", err)
+		return
+	}
+	writeJSON(w, http.StatusOK, domains)
+}
+
+func (h *handler) serveDomain(w http.ResponseWriter, domain string) {
+	values, ok := h.values(w, domain)
+	if !ok {
+		return
+	}
+	writeJSON(w, http.StatusOK, values)
+}
+
+func (h *handler) serveKey(w http.ResponseWriter, domain, key string) {
+	values, ok := h.values(w, domain)
+	if !ok {
+		return
+	}
+	value, ok := values[key]
+	if !ok {
+		writeError(w, http.StatusNotFound, "key %s does not exist in domain %s", key, domain)
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{"domain": domain, "key": key, "value": value})
+}
+
+// values reads a domain, writing a 404 if it has no keys.
+func (h *handler) values(w http.ResponseWriter, domain string) (map[string]interface{}, bool) {
+	values, err := h.src.Values(domain)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "%v", err)
+		return nil, false
+	}
+	if len(values) == 0 {
+		writeError(w, http.StatusNotFound, "domain %s does not exist", domain)
+		return nil, false
+	}
+	return values, true
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "error encoding response: %v", err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	w.Write(append(data, '\n'))
+}
+
+func writeError(w http.ResponseWriter, status int, format string, args ...interface{}) {
+	data, _ := json.Marshal(map[string]string{"error": fmt.Sprintf(format, args...)})
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	w.Write(append(data, '\n'))
+}
+
+// isLoopbackHost reports whether host is localhost or a loopback IP address.
+func isLoopbackHost(host string) bool {
+	if strings.EqualFold(host, "localhost") {
+		return true
+	}
+	ip := net.ParseIP(strings.Trim(host, "[]"))
+	return ip != nil && ip.IsLoopback()
+}
@@ -0,0 +1,85 @@
+package httpapi
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+type fakeSource map[string]map[string]interface{}
+
+func (f fakeSource) Domains() ([]string, error) {
+	return []string{"com.example.a", "com.example.b"}, nil
+}
+
+func (f fakeSource) Values(domain string) (map[string]interface{}, error) {
+	if domain == "com.example.broken" {
+		return nil, errors.New("broken")
+	}
+	if values, ok := f[domain]; ok {
+		return values, nil
+	}
+	return map[string]interface{}{}, nil
+}
+
+func TestHandler(t *testing.T) {
+	h := NewHandler(fakeSource{
+		"com.example.a": {"Name": "a", "Size": 48, "Path/With/Slash": true},
+	})
+
+	tests := []struct {
+		method, host, path string
+		status             int
+		body               string
+	}{
+		{"GET", "127.0.0.1:8398", "/domains", 200, "[\n  \"com.example.a\",\n  \"com.example.b\"\n]\n"},
+		{"GET", "localhost", "/domains/com.example.a", 200, "{\n  \"Name\": \"a\",\n  \"Path/With/Slash\": true,\n  \"Size\": 48\n}\n"},
+		{"GET", "[::1]:8398", "/domains/com.example.a/keys/Size", 200, "{\n  \"domain\": \"com.example.a\",\n  \"key\": \"Size\",\n  \"value\": 48\n}\n"},
+		{"GET", "localhost", "/domains/com.example.a/keys/Path%2FWith%2FSlash", 200, "{\n  \"domain\": \"com.example.a\",\n  \"key\": \"Path/With/Slash\",\n  \"value\": true\n}\n"},
+		{"GET", "localhost", "/domains/com.example.a/keys/Missing", 404, "{\"error\":\"key Missing does not exist in domain com.example.a\"}\n"},
+		{"GET", "localhost", "/domains/com.example.b", 404, "{\"error\":\"domain com.example.b does not exist\"}\n"},
+		{"GET", "localhost", "/domains/com.example.broken", 500, "{\"error\":\"broken\"}\n"},
+		{"GET", "localhost", "/other", 404, "{\"error\":\"no such endpoint /other\"}\n"},
+		{"POST", "localhost", "/domains", 405, "{\"error\":\"method POST is not allowed\"}\n"},
+		{"GET", "evil.example.com", "/domains", 403, "{\"error\":\"host \\\"evil.example.com\\\" is not allowed\"}\n"},
+	}
+	for _, tt := range tests {
+		r := httptest.NewRequest(tt.method, "http://localhost"+tt.path, nil)
+		r.Host = tt.host
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, r)
+		if w.Code != tt.status || w.Body.String() != tt.body {
+			t.Errorf("%s %s (Host %s) = %d %q, want %d %q", tt.method, tt.path, tt.host, w.Code, w.Body.String(), tt.status, tt.body)
+		}
+		if ct := w.Header().Get("Content-Type"); ct != "application/json" {
+			t.Errorf("%s %s Content-Type = %q", tt.method, tt.path, ct)
+		}
+	}
+}
+
+func TestListen(t *testing.T) {
+	for _, addr := range []string{"0.0.0.0:0", "192.0.2.1:8398", ":8398", "example.com:80", "nonsense"} {
+		if l, err := Listen(addr); err == nil {
+			l.Close()
+			t.Errorf("Listen(%q) expected error", addr)
+		}
+	}
+
+	l, err := Listen("127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Listen() error = %v", err)
+	}
+	defer l.Close()
+	go http.Serve(l, NewHandler(fakeSource{}))
+
+	resp, err := http.Get("http://" + l.Addr().String() + "/domains")
+	if err != nil {
+		t.Fatalf("GET /domains error = %v", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK || !strings.HasPrefix(resp.Header.Get("Content-Type"), "application/json") {
+		t.Fatalf("GET /domains = %s", resp.Status)
+	}
+}
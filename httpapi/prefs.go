@@ -0,0 +1,43 @@
+package httpapi
+
+import (
+	"net/http"
+
+	"github.com/weswhet/mac_prefs"
+)
+
+// Prefs is a Source reading one exact (user, host) slot of every domain.
+type Prefs struct {
+	Scope mac_prefs.PreferenceScope
+}
+
+// Domains lists the domains of the scope. See mac_prefs.ListDomains.
+func (p Prefs) Domains() ([]string, error) {
+	return mac_prefs.ListDomains(p.Scope)
+}
+
+// Values reads a domain. The names NSGlobalDomain and .GlobalPreferences refer to the global
+// domain.
+func (p Prefs) Values(domain string) (map[string]interface{}, error) {
+	if domain == "NSGlobalDomain" || domain == mac_prefs.GlobalDomain {
+		domain = mac_prefs.AnyApplication
+	}
+	return mac_prefs.GetAll(domain, p.Scope)
+}
+
+// ListenAndServe serves the preferences of scope on addr, which must be a loopback address.
+// An empty addr listens on DefaultAddr.
+//
+// Parameters:
+//   - addr: The loopback address to listen on, e.g. "127.0.0.1:8398".
+//   - scope: The PreferenceScope to serve.
+//
+// Returns:
+//   - error: ErrNotLoopback for other addresses, or the error that stopped the server.
+func ListenAndServe(addr string, scope mac_prefs.PreferenceScope) error {
+	l, err := Listen(addr)
+	if err != nil {
+		return err
+	}
+	return http.Serve(l, NewHandler(Prefs{Scope: scope}))
+}
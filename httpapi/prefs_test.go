@@ -0,0 +1,24 @@
+//go:build darwin && cgo
+
+package httpapi
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	"github.com/weswhet/mac_prefs/prefstest"
+)
+
+func TestPrefs(t *testing.T) {
+	d := prefstest.New(t)
+	d.Set("ServedKey", "served")
+	appID := d.ID
+
+	h := NewHandler(Prefs{Scope: d.Scope})
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest("GET", "http://localhost/domains/"+appID+"/keys/ServedKey", nil))
+	want := "{\n  \"domain\": \"" + appID + "\",\n  \"key\": \"ServedKey\",\n  \"value\": \"served\"\n}\n"
+	if w.Code != 200 || w.Body.String() != want {
+		t.Fatalf("GET key = %d %q, want %q", w.Code, w.Body.String(), want)
+	}
+}
@@ -0,0 +1,53 @@
+package mac_prefs
+
+import (
+	"fmt"
+	"math"
+)
+
+// Increment adds delta to the integer stored under key and returns the new value, e.g. for
+// launch or nag counters. A key that is not set counts as zero. The value is read, incremented,
+// and written back under a lock shared by the package level read-modify-write functions, so
+// concurrent increments in the process are not lost; other processes writing the key at the
+// same time are not excluded.
+//
+// Parameters:
+//   - key: The preference key holding the counter.
+//   - applicationID: The bundle identifier of the application for which to update the preference.
+//   - scope: The PreferenceScope to write to.
+//   - delta: The amount to add, which may be negative.
+//
+// Returns:
+//   - int64: The new value.
+//   - error: An error if the stored value is not an integer, the result overflows, or the
+//     preference cannot be read or written.
+func Increment(key string, applicationID string, scope PreferenceScope, delta int64) (int64, error) {
+	return defaultClient.Increment(key, applicationID, scope, delta)
+}
+
+// Increment adds delta to an integer preference while the Client is locked, so concurrent
+// increments through the same Client are not lost. See Increment.
+func (c *Client) Increment(key string, applicationID string, scope PreferenceScope, delta int64) (int64, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	current, err := c.store.Get(key, applicationID, scope)
+	if err != nil {
+		return 0, err
+	}
+	var n int64
+	if current != nil {
+		var ok bool
+		if n, ok = normalizeRead(current, NumberInt64, nil).(int64); !ok {
+			return 0, fmt.Errorf("error incrementing %s: value of type %T is not an integer", key, current)
+		}
+	}
+	if (delta > 0 && n > math.MaxInt64-delta) || (delta < 0 && n < math.MinInt64-delta) {
+		return 0, fmt.Errorf("error incrementing %s: %d + %d overflows int64", key, n, delta)
+	}
+	n += delta
+	if err := c.set(key, n, applicationID, scope); err != nil {
+		return 0, err
+	}
+	return n, nil
+}
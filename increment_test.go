@@ -0,0 +1,74 @@
+package mac_prefs
+
+import (
+	"math"
+	"sync"
+	"testing"
+)
+
+// useDefaultStore makes the package level read-modify-write functions use store until the
+// test ends.
+func useDefaultStore(t *testing.T, store Store) {
+	saved := defaultClient
+	defaultClient = NewClient(WithStore(store))
+	t.Cleanup(func() { defaultClient = saved })
+}
+
+// TestIncrementConcurrent checks that package level increments are serialized; run it
+// with -race.
+func TestIncrementConcurrent(t *testing.T) {
+	useDefaultStore(t, NewMemoryStore())
+
+	var wg sync.WaitGroup
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := Increment("launches", "com.example", CurrentUserAnyHost, 1); err != nil {
+				t.Error(err)
+			}
+		}()
+	}
+	wg.Wait()
+	if got, _ := defaultClient.Get("launches", "com.example", CurrentUserAnyHost); got != int64(50) {
+		t.Errorf("launches = %#v after concurrent increments, want 50", got)
+	}
+}
+
+func TestClientIncrement(t *testing.T) {
+	c := NewClient(WithStore(NewMemoryStore()))
+	if n, err := c.Increment("launches", "com.example", CurrentUserAnyHost, 1); err != nil || n != 1 {
+		t.Fatalf("Increment() of a missing key = %d, %v, want 1", n, err)
+	}
+	if n, err := c.Increment("launches", "com.example", CurrentUserAnyHost, -3); err != nil || n != -2 {
+		t.Errorf("Increment(-3) = %d, %v, want -2", n, err)
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := c.Increment("launches", "com.example", CurrentUserAnyHost, 1); err != nil {
+				t.Error(err)
+			}
+		}()
+	}
+	wg.Wait()
+	if got, _ := c.Get("launches", "com.example", CurrentUserAnyHost); got != int64(48) {
+		t.Errorf("launches = %#v after concurrent increments, want 48", got)
+	}
+
+	if err := c.Set("max", int64(math.MaxInt64), "com.example", CurrentUserAnyHost); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := c.Increment("max", "com.example", CurrentUserAnyHost, 1); err == nil {
+		t.Error("Increment() overflow expected error")
+	}
+	if err := c.Set("name", "x", "com.example", CurrentUserAnyHost); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := c.Increment("name", "com.example", CurrentUserAnyHost, 1); err == nil {
+		t.Error("Increment() of a string expected error")
+	}
+}
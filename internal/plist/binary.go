@@ -0,0 +1,260 @@
+package plist
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+	"time"
+	"unicode/utf16"
+)
+
+// binaryMagic starts every binary property list.
+const binaryMagic = "bplist00"
+
+// binaryEpoch is the reference date of binary plist dates.
+var binaryEpoch = time.Date(2001, time.January, 1, 0, 0, 0, 0, time.UTC)
+
+// maxBinaryDepth bounds the nesting of containers, so malformed files with reference cycles
+// fail instead of recursing forever.
+const maxBinaryDepth = 512
+
+// binaryDecoder decodes the objects of a binary property list.
+type binaryDecoder struct {
+	data    []byte
+	offsets []uint64
+	refSize int
+}
+
+// unmarshalBinary decodes a binary property list (bplist00).
+func unmarshalBinary(data []byte) (interface{}, error) {
+	if len(data) < len(binaryMagic)+32 {
+		return nil, errors.New("plist: binary property list is truncated")
+	}
+	trailer := data[len(data)-32:]
+	offsetSize := int(trailer[6])
+	refSize := int(trailer[7])
+	numObjects := binary.BigEndian.Uint64(trailer[8:])
+	topObject := binary.BigEndian.Uint64(trailer[16:])
+	tableOffset := binary.BigEndian.Uint64(trailer[24:])
+
+	if offsetSize < 1 || offsetSize > 8 || refSize < 1 || refSize > 8 {
+		return nil, fmt.Errorf("plist: invalid binary trailer sizes %d and %d", offsetSize, refSize)
+	}
+	tableEnd := uint64(len(data) - 32)
+	if numObjects == 0 || tableOffset > tableEnd || numObjects > (tableEnd-tableOffset)/uint64(offsetSize) {
+		return nil, errors.New("plist: invalid binary offset table")
+	}
+	if topObject >= numObjects {
+		return nil, errors.New("plist: invalid binary top object")
+	}
+
+	d := &binaryDecoder{data: data[:tableOffset], offsets: make([]uint64, numObjects), refSize: refSize}
+	for i := range d.offsets {
+		start := tableOffset + uint64(i*offsetSize)
+		d.offsets[i] = readUint(data[start : start+uint64(offsetSize)])
+	}
+	return d.object(topObject, 0)
+}
+
+func (d *binaryDecoder) object(ref uint64, depth int) (interface{}, error) {
+	if depth > maxBinaryDepth {
+		return nil, errors.New("plist: binary property list is nested too deeply")
+	}
+	if ref >= uint64(len(d.offsets)) {
+		return nil, fmt.Errorf("plist: invalid object reference %d", ref)
+	}
+	off := d.offsets[ref]
+	if off < uint64(len(binaryMagic)) || off >= uint64(len(d.data)) {
+		return nil, fmt.Errorf("plist: invalid object offset %d", off)
+	}
+
+	marker := d.data[off]
+	kind, info := marker>>4, int(marker&0x0f)
+	off++
+	switch kind {
+	case 0x0:
+		switch marker {
+		case 0x08:
+			return false, nil
+		case 0x09:
+			return true, nil
+		}
+		return nil, fmt.Errorf("plist: unsupported binary object 0x%02x", marker)
+	case 0x1:
+		b, err := d.bytes(off, 1<<uint(info))
+		if err != nil {
+			return nil, err
+		}
+		return binaryInteger(b)
+	case 0x2:
+		b, err := d.bytes(off, 1<<uint(info))
+		if err != nil {
+			return nil, err
+		}
+		switch len(b) {
+		case 4:
+			return float64(math.Float32frombits(binary.BigEndian.Uint32(b))), nil
+		case 8:
+			return math.Float64frombits(binary.BigEndian.Uint64(b)), nil
+		}
+		return nil, fmt.Errorf("plist: invalid binary real of %d bytes", len(b))
+	case 0x3:
+		b, err := d.bytes(off, 8)
+		if err != nil {
+			return nil, err
+		}
+		// Whole seconds are added to the Unix time of the epoch rather than as a time.Duration,
+		// which overflows for dates such as distantPast and distantFuture.
+		secs := math.Float64frombits(binary.BigEndian.Uint64(b))
+		whole := math.Floor(secs)
+		nanos := int64(math.Round((secs - whole) * 1e9))
+		return time.Unix(binaryEpoch.Unix()+int64(whole), nanos).UTC(), nil
+	case 0x4, 0x5, 0x6:
+		n, off, err := d.length(off, info)
+		if err != nil {
+			return nil, err
+		}
+		if kind == 0x6 {
+			b, err := d.bytes(off, 2*n)
+			if err != nil {
+				return nil, err
+			}
+			units := make([]uint16, n)
+			for i := range units {
+				units[i] = binary.BigEndian.Uint16(b[2*i:])
+			}
+			return string(utf16.Decode(units)), nil
+		}
+		b, err := d.bytes(off, n)
+		if err != nil {
+			return nil, err
+		}
+		if kind == 0x4 {
+			return append([]byte(nil), b...), nil
+		}
+		return string(b), nil
+	case 0x8:
+		b, err := d.bytes(off, info+1)
+		if err != nil {
+			return nil, err
+		}
+		return UID(readUint(b)), nil
+	case 0xA, 0xC:
+		n, off, err := d.length(off, info)
+		if err != nil {
+			return nil, err
+		}
+		refs, err := d.refs(off, n)
+		if err != nil {
+			return nil, err
+		}
+		result := make([]interface{}, 0, n)
+		for _, r := range refs {
+			v, err := d.object(r, depth+1)
+			if err != nil {
+				return nil, err
+			}
+			result = append(result, v)
+		}
+		return result, nil
+	case 0xD:
+		n, off, err := d.length(off, info)
+		if err != nil {
+			return nil, err
+		}
+		refs, err := d.refs(off, 2*n)
+		if err != nil {
+			return nil, err
+		}
+		result := make(map[string]interface{}, n)
+		for i := 0; i < n; i++ {
+			k, err := d.object(refs[i], depth+1)
+			if err != nil {
+				return nil, err
+			}
+			key, ok := k.(string)
+			if !ok {
+				return nil, fmt.Errorf("plist: binary dictionary key of type %T", k)
+			}
+			v, err := d.object(refs[n+i], depth+1)
+			if err != nil {
+				return nil, err
+			}
+			result[key] = v
+		}
+		return result, nil
+	default:
+		return nil, fmt.Errorf("plist: unsupported binary object 0x%02x", marker)
+	}
+}
+
+// length reads the element count of a data, string, or container object. Counts of 15 or
+// more are stored as an integer object following the marker.
+func (d *binaryDecoder) length(off uint64, info int) (int, uint64, error) {
+	if info != 0x0f {
+		return info, off, nil
+	}
+	if off >= uint64(len(d.data)) || d.data[off]>>4 != 0x1 {
+		return 0, 0, errors.New("plist: invalid binary length")
+	}
+	size := 1 << uint(d.data[off]&0x0f)
+	b, err := d.bytes(off+1, size)
+	if err != nil {
+		return 0, 0, err
+	}
+	n := readUint(b)
+	if n > uint64(len(d.data)) {
+		return 0, 0, fmt.Errorf("plist: invalid binary length %d", n)
+	}
+	return int(n), off + 1 + uint64(size), nil
+}
+
+func (d *binaryDecoder) refs(off uint64, n int) ([]uint64, error) {
+	b, err := d.bytes(off, n*d.refSize)
+	if err != nil {
+		return nil, err
+	}
+	refs := make([]uint64, n)
+	for i := range refs {
+		refs[i] = readUint(b[i*d.refSize : (i+1)*d.refSize])
+	}
+	return refs, nil
+}
+
+func (d *binaryDecoder) bytes(off uint64, n int) ([]byte, error) {
+	if n < 0 || off > uint64(len(d.data)) || uint64(n) > uint64(len(d.data))-off {
+		return nil, errors.New("plist: binary object extends past the object table")
+	}
+	return d.data[off : off+uint64(n)], nil
+}
+
+// binaryInteger decodes a big-endian integer. 8-byte integers are signed; 16-byte integers
+// hold unsigned values above math.MaxInt64 in their low 8 bytes.
+func binaryInteger(b []byte) (interface{}, error) {
+	switch len(b) {
+	case 1, 2, 4:
+		return int(readUint(b)), nil
+	case 8:
+		i := int64(binary.BigEndian.Uint64(b))
+		if i >= math.MinInt && i <= math.MaxInt {
+			return int(i), nil
+		}
+		return i, nil
+	case 16:
+		u := binary.BigEndian.Uint64(b[8:])
+		if u <= math.MaxInt {
+			return int(u), nil
+		}
+		return u, nil
+	}
+	return nil, fmt.Errorf("plist: invalid binary integer of %d bytes", len(b))
+}
+
+func readUint(b []byte) uint64 {
+	var n uint64
+	for _, c := range b {
+		n = n<<8 | uint64(c)
+	}
+	return n
+}
@@ -0,0 +1,201 @@
+package plist
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// UID is a reference to another object in an NSKeyedArchiver archive. Binary property lists
+// have a UID type; XML property lists write one as a dictionary with a single CF$UID key.
+type UID uint64
+
+// Unmarshal decodes an XML or binary property list. Values are returned as string, bool, int, float64,
+// time.Time, []byte, []interface{}, and map[string]interface{}, matching the types produced by
+// the CoreFoundation converters. Integers that do not fit in an int are returned as uint64,
+// and keyed archiver references as UID.
+func Unmarshal(data []byte) (interface{}, error) {
+	if bytes.HasPrefix(data, []byte(binaryMagic)) {
+		return unmarshalBinary(data)
+	}
+	dec := xml.NewDecoder(bytes.NewReader(data))
+	dec.Strict = false
+	for {
+		tok, err := dec.Token()
+		if err == io.EOF {
+			return nil, errors.New("plist: no property list found")
+		}
+		if err != nil {
+			return nil, fmt.Errorf("plist: %v", err)
+		}
+		start, ok := tok.(xml.StartElement)
+		if !ok {
+			continue
+		}
+		if start.Name.Local == "plist" {
+			continue
+		}
+		return decodeXMLElement(dec, start)
+	}
+}
+
+func decodeXMLElement(dec *xml.Decoder, start xml.StartElement) (interface{}, error) {
+	switch start.Name.Local {
+	case "dict":
+		return decodeXMLDict(dec)
+	case "array":
+		return decodeXMLArray(dec)
+	case "true", "false":
+		if err := dec.Skip(); err != nil {
+			return nil, fmt.Errorf("plist: %v", err)
+		}
+		return start.Name.Local == "true", nil
+	}
+
+	text, err := xmlText(dec)
+	if err != nil {
+		return nil, err
+	}
+	switch start.Name.Local {
+	case "string":
+		return text, nil
+	case "integer":
+		return parseInteger(strings.TrimSpace(text))
+	case "real":
+		return parseReal(strings.TrimSpace(text))
+	case "date":
+		t, err := time.Parse(time.RFC3339, strings.TrimSpace(text))
+		if err != nil {
+			return nil, fmt.Errorf("plist: invalid date %q", text)
+		}
+		return t.UTC(), nil
+	case "data":
+		clean := strings.Map(func(r rune) rune {
+			if r == ' ' || r == '\t' || r == '\n' || r == '\r' {
+				return -1
+			}
+			return r
+		}, text)
+		b, err := base64.StdEncoding.DecodeString(clean)
+		if err != nil {
+			return nil, fmt.Errorf("plist: invalid data: %v", err)
+		}
+		return b, nil
+	default:
+		return nil, fmt.Errorf("plist: unknown element <%s>", start.Name.Local)
+	}
+}
+
+func decodeXMLDict(dec *xml.Decoder) (interface{}, error) {
+	result := make(map[string]interface{})
+	var key *string
+	for {
+		tok, err := dec.Token()
+		if err != nil {
+			return nil, fmt.Errorf("plist: %v", err)
+		}
+		switch t := tok.(type) {
+		case xml.StartElement:
+			if t.Name.Local == "key" {
+				k, err := xmlText(dec)
+				if err != nil {
+					return nil, err
+				}
+				key = &k
+				continue
+			}
+			if key == nil {
+				return nil, fmt.Errorf("plist: <%s> without <key> in <dict>", t.Name.Local)
+			}
+			value, err := decodeXMLElement(dec, t)
+			if err != nil {
+				return nil, err
+			}
+			result[*key] = value
+			key = nil
+		case xml.EndElement:
+			if key != nil {
+				return nil, fmt.Errorf("plist: <key>%s</key> without value", *key)
+			}
+			if uid, ok := result["CF$UID"].(int); ok && len(result) == 1 && uid >= 0 {
+				return UID(uid), nil
+			}
+			return result, nil
+		}
+	}
+}
+
+func decodeXMLArray(dec *xml.Decoder) (interface{}, error) {
+	result := make([]interface{}, 0)
+	for {
+		tok, err := dec.Token()
+		if err != nil {
+			return nil, fmt.Errorf("plist: %v", err)
+		}
+		switch t := tok.(type) {
+		case xml.StartElement:
+			value, err := decodeXMLElement(dec, t)
+			if err != nil {
+				return nil, err
+			}
+			result = append(result, value)
+		case xml.EndElement:
+			return result, nil
+		}
+	}
+}
+
+// xmlText reads character data up to the end of the current element.
+func xmlText(dec *xml.Decoder) (string, error) {
+	var b strings.Builder
+	for {
+		tok, err := dec.Token()
+		if err != nil {
+			return "", fmt.Errorf("plist: %v", err)
+		}
+		switch t := tok.(type) {
+		case xml.CharData:
+			b.Write(t)
+		case xml.EndElement:
+			return b.String(), nil
+		case xml.StartElement:
+			return "", fmt.Errorf("plist: unexpected <%s> in text element", t.Name.Local)
+		}
+	}
+}
+
+func parseInteger(s string) (interface{}, error) {
+	if i, err := strconv.ParseInt(s, 0, 64); err == nil {
+		if i >= math.MinInt && i <= math.MaxInt {
+			return int(i), nil
+		}
+		return i, nil
+	}
+	if u, err := strconv.ParseUint(s, 0, 64); err == nil {
+		return u, nil
+	}
+	return nil, fmt.Errorf("plist: invalid integer %q", s)
+}
+
+func parseReal(s string) (interface{}, error) {
+	switch strings.ToLower(s) {
+	case "+infinity", "infinity", "inf":
+		return math.Inf(1), nil
+	case "-infinity", "-inf":
+		return math.Inf(-1), nil
+	case "nan":
+		return math.NaN(), nil
+	}
+	f, err := strconv.ParseFloat(s, 64)
+	if err != nil {
+		return nil, fmt.Errorf("plist: invalid real %q", s)
+	}
+	return f, nil
+}
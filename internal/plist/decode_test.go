@@ -0,0 +1,148 @@
+package plist
+
+import (
+	"os"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestUnmarshalRoundTrip(t *testing.T) {
+	want := map[string]interface{}{
+		"string": "a < b",
+		"int":    -3,
+		"real":   1.5,
+		"bool":   true,
+		"date":   time.Date(2023, 5, 1, 12, 0, 0, 0, time.UTC),
+		"data":   []byte("hi"),
+		"array":  []interface{}{"x", 1},
+		"empty":  map[string]interface{}{},
+		"none":   []interface{}{},
+	}
+	data, err := MarshalXML(want)
+	if err != nil {
+		t.Fatalf("MarshalXML() error = %v", err)
+	}
+
+	got, err := Unmarshal(data)
+	if err != nil {
+		t.Fatalf("Unmarshal() error = %v", err)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("Unmarshal() got = %#v, want %#v", got, want)
+	}
+}
+
+func TestUnmarshalErrors(t *testing.T) {
+	for _, data := range []string{
+		"",
+		"<plist><integer>abc</integer></plist>",
+		"<plist><dict><string>x</string></dict></plist>",
+		"<plist><unknown/></plist>",
+	} {
+		if _, err := Unmarshal([]byte(data)); err == nil {
+			t.Errorf("Unmarshal(%q) expected error", data)
+		}
+	}
+}
+
+func TestUnmarshalBinary(t *testing.T) {
+	data, err := os.ReadFile("testdata/binary.plist")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]interface{}{
+		"string":  "a < b",
+		"unicode": "caf\u00e9 \u2603",
+		"long":    "xxxxxxxxxxxxxxxxxxxx",
+		"int":     -3,
+		"big":     1 << 40,
+		"real":    1.5,
+		"bool":    true,
+		"off":     false,
+		"date":    time.Date(2023, 5, 1, 12, 0, 0, 0, time.UTC),
+		"millis":  time.Date(2023, 5, 1, 12, 0, 0, 250000000, time.UTC),
+		"old":     time.Date(1999, 12, 31, 23, 59, 59, 500000000, time.UTC),
+		"data":    []byte("hi"),
+		"array":   []interface{}{"x", 1},
+		"empty":   map[string]interface{}{},
+		"none":    []interface{}{},
+	}
+
+	got, err := Unmarshal(data)
+	if err != nil {
+		t.Fatalf("Unmarshal() error = %v", err)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("Unmarshal() got = %#v, want %#v", got, want)
+	}
+}
+
+func TestUnmarshalBinaryDistantDates(t *testing.T) {
+	data, err := os.ReadFile("testdata/dates.plist")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]interface{}{
+		"distantPast":   time.Date(0, 12, 30, 0, 0, 0, 0, time.UTC),
+		"distantFuture": time.Date(4001, 1, 1, 0, 0, 0, 0, time.UTC),
+	}
+
+	got, err := Unmarshal(data)
+	if err != nil {
+		t.Fatalf("Unmarshal() error = %v", err)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("Unmarshal() got = %#v, want %#v", got, want)
+	}
+}
+
+func TestUnmarshalBinaryErrors(t *testing.T) {
+	data, err := os.ReadFile("testdata/binary.plist")
+	if err != nil {
+		t.Fatal(err)
+	}
+	truncated := append([]byte(nil), data[:len(data)-40]...)
+	badTop := append([]byte(nil), data...)
+	badTop[len(badTop)-9] = 0xff
+
+	for name, data := range map[string][]byte{
+		"header":    []byte("bplist00"),
+		"truncated": append(truncated, data[len(data)-32:]...),
+		"top":       badTop,
+	} {
+		if _, err := Unmarshal(data); err == nil {
+			t.Errorf("Unmarshal(%s) expected error", name)
+		}
+	}
+}
+
+func TestUnmarshalKeepsZeroReal(t *testing.T) {
+	data, err := MarshalXML(map[string]interface{}{"zero": 0.0, "negative": -2.0, "int": 0})
+	if err != nil {
+		t.Fatalf("MarshalXML() error = %v", err)
+	}
+	got, err := Unmarshal(data)
+	if err != nil {
+		t.Fatalf("Unmarshal() error = %v", err)
+	}
+	want := map[string]interface{}{"zero": 0.0, "negative": -2.0, "int": 0}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("Unmarshal() got = %#v, want %#v", got, want)
+	}
+}
+
+func TestUIDRoundTrip(t *testing.T) {
+	data, err := MarshalXML(map[string]interface{}{"ref": UID(3), "plain": map[string]interface{}{"CF$UID": "x"}})
+	if err != nil {
+		t.Fatalf("MarshalXML() error = %v", err)
+	}
+	got, err := Unmarshal(data)
+	if err != nil {
+		t.Fatalf("Unmarshal() error = %v", err)
+	}
+	want := map[string]interface{}{"ref": UID(3), "plain": map[string]interface{}{"CF$UID": "x"}}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("Unmarshal() = %#v, want %#v", got, want)
+	}
+}
@@ -0,0 +1,147 @@
+package plist
+
+import (
+	"encoding/hex"
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// FormatDefaults renders v in the OpenStep ("old style") text format printed by `defaults read`,
+// including its indentation quirks, so output can be diffed against existing defaults-based
+// scripts. Booleans are printed as 1 and 0, as defaults does.
+func FormatDefaults(v interface{}) (string, error) {
+	var b strings.Builder
+	if err := writeDefaults(&b, reflect.ValueOf(v), 0, ""); err != nil {
+		return "", err
+	}
+	return b.String(), nil
+}
+
+func writeDefaults(b *strings.Builder, v reflect.Value, level int, path string) error {
+	for v.IsValid() && (v.Kind() == reflect.Interface || v.Kind() == reflect.Pointer) {
+		if v.IsNil() {
+			return fmt.Errorf("plist: nil value at %s", pathOrRoot(path))
+		}
+		v = v.Elem()
+	}
+	if !v.IsValid() {
+		return fmt.Errorf("plist: nil value at %s", pathOrRoot(path))
+	}
+
+	if t, ok := v.Interface().(time.Time); ok {
+		b.WriteString(quoteDefaults(t.UTC().Format("2006-01-02 15:04:05 -0700")))
+		return nil
+	}
+
+	indent := strings.Repeat("    ", level)
+	inner := strings.Repeat("    ", level+1)
+
+	switch v.Kind() {
+	case reflect.String:
+		b.WriteString(quoteDefaults(v.String()))
+	case reflect.Bool:
+		if v.Bool() {
+			b.WriteString("1")
+		} else {
+			b.WriteString("0")
+		}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		b.WriteString(strconv.FormatInt(v.Int(), 10))
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		b.WriteString(strconv.FormatUint(v.Uint(), 10))
+	case reflect.Float32, reflect.Float64:
+		b.WriteString(strconv.FormatFloat(v.Float(), 'g', -1, 64))
+	case reflect.Slice, reflect.Array:
+		if v.Type().Elem().Kind() == reflect.Uint8 {
+			data := make([]byte, v.Len())
+			reflect.Copy(reflect.ValueOf(data), v)
+			fmt.Fprintf(b, "{length = %d, bytes = 0x%s}", len(data), hex.EncodeToString(data))
+			return nil
+		}
+		b.WriteString(indent + "(\n")
+		for i := 0; i < v.Len(); i++ {
+			b.WriteString(inner)
+			if err := writeDefaults(b, v.Index(i), level+1, fmt.Sprintf("%s[%d]", path, i)); err != nil {
+				return err
+			}
+			if i < v.Len()-1 {
+				b.WriteString(",")
+			}
+			b.WriteString("\n")
+		}
+		b.WriteString(indent + ")")
+	case reflect.Map:
+		if v.Type().Key().Kind() != reflect.String {
+			return fmt.Errorf("plist: unsupported map key type %s at %s", v.Type().Key(), pathOrRoot(path))
+		}
+		keys := make([]string, 0, v.Len())
+		for _, k := range v.MapKeys() {
+			keys = append(keys, k.String())
+		}
+		sort.Strings(keys)
+		b.WriteString(indent + "{\n")
+		for _, k := range keys {
+			b.WriteString(inner + quoteDefaults(k) + " = ")
+			kv := reflect.ValueOf(k).Convert(v.Type().Key())
+			if err := writeDefaults(b, v.MapIndex(kv), level+1, joinPath(path, k)); err != nil {
+				return err
+			}
+			b.WriteString(";\n")
+		}
+		b.WriteString(indent + "}")
+	default:
+		return fmt.Errorf("plist: unsupported type %s at %s", v.Type(), pathOrRoot(path))
+	}
+	return nil
+}
+
+// quoteDefaults quotes s the way NSString descriptions do: strings made only of letters,
+// digits, and _$:./ are printed bare, anything else is quoted and escaped.
+func quoteDefaults(s string) string {
+	bare := s != ""
+	for _, r := range s {
+		if !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || strings.ContainsRune("_$:./", r)) {
+			bare = false
+			break
+		}
+	}
+	if bare {
+		return s
+	}
+
+	var b strings.Builder
+	b.WriteByte('"')
+	for _, r := range s {
+		switch {
+		case r == '"':
+			b.WriteString(`\"`)
+		case r == '\\':
+			b.WriteString(`\\`)
+		case r == '\n':
+			b.WriteString(`\n`)
+		case r == '\t':
+			b.WriteString(`\t`)
+		case r < 0x20 || r > 0x7e:
+			if r > 0xffff {
+				for _, u := range utf16Surrogates(r) {
+					fmt.Fprintf(&b, `\U%04x`, u)
+				}
+			} else {
+				fmt.Fprintf(&b, `\U%04x`, r)
+			}
+		default:
+			b.WriteRune(r)
+		}
+	}
+	b.WriteByte('"')
+	return b.String()
+}
+
+func utf16Surrogates(r rune) [2]rune {
+	r -= 0x10000
+	return [2]rune{0xd800 + (r>>10)&0x3ff, 0xdc00 + r&0x3ff}
+}
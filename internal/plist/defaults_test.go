@@ -0,0 +1,80 @@
+package plist
+
+import (
+	"testing"
+	"time"
+)
+
+func TestFormatDefaults(t *testing.T) {
+	v := map[string]interface{}{
+		"autohide":        true,
+		"tilesize":        48,
+		"magnification":   1.5,
+		"orientation":     "bottom",
+		"persistent-apps": []interface{}{map[string]interface{}{"GUID": 123, "tile-type": "file-tile"}},
+		"recent":          []interface{}{"a b", "c"},
+		"empty":           []interface{}{},
+		"quote":           `say "hi"`,
+		"unicode":         "café",
+		"updated":         time.Date(2023, 4, 5, 6, 7, 8, 0, time.UTC),
+		"blob":            []byte{0xde, 0xad},
+	}
+
+	want := `{
+    autohide = 1;
+    blob = {length = 2, bytes = 0xdead};
+    empty =     (
+    );
+    magnification = 1.5;
+    orientation = bottom;
+    "persistent-apps" =     (
+                {
+            GUID = 123;
+            "tile-type" = "file-tile";
+        }
+    );
+    quote = "say \"hi\"";
+    recent =     (
+        "a b",
+        c
+    );
+    tilesize = 48;
+    unicode = "caf\U00e9";
+    updated = "2023-04-05 06:07:08 +0000";
+}`
+
+	got, err := FormatDefaults(v)
+	if err != nil {
+		t.Fatalf("FormatDefaults() error = %v", err)
+	}
+	if got != want {
+		t.Errorf("FormatDefaults() got:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestFormatDefaultsScalars(t *testing.T) {
+	tests := []struct {
+		v    interface{}
+		want string
+	}{
+		{"", `""`},
+		{"com.apple.dock", "com.apple.dock"},
+		{"/usr/bin", "/usr/bin"},
+		{false, "0"},
+		{-3, "-3"},
+		{2.0, "2"},
+	}
+	for _, tt := range tests {
+		got, err := FormatDefaults(tt.v)
+		if err != nil {
+			t.Fatalf("FormatDefaults(%v) error = %v", tt.v, err)
+		}
+		if got != tt.want {
+			t.Errorf("FormatDefaults(%v) = %q, want %q", tt.v, got, tt.want)
+		}
+	}
+
+	if _, err := FormatDefaults(map[string]interface{}{"a": nil}); err == nil {
+		t.Error("FormatDefaults() expected error for nil value")
+	}
+}
@@ -0,0 +1,166 @@
+// Package plist implements a pure Go encoder and decoder for Apple property lists.
+// It is used by the subpackages that must build and run without CoreFoundation.
+package plist
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/xml"
+	"fmt"
+	"math"
+	"reflect"
+	"sort"
+	"strconv"
+	"time"
+)
+
+const xmlHeader = `<?xml version="1.0" encoding="UTF-8"?>
+<!DOCTYPE plist PUBLIC "-//Apple//DTD PLIST 1.0//EN" "http://www.apple.com/DTDs/PropertyList-1.0.dtd">
+<plist version="1.0">
+`
+
+// MarshalXML encodes v as an XML property list. Supported values are strings, booleans,
+// integers, floats, time.Time, []byte, slices, and maps with string keys. Dictionary keys are
+// written in sorted order so the output is stable.
+func MarshalXML(v interface{}) ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteString(xmlHeader)
+	if err := writeXMLValue(&buf, reflect.ValueOf(v), 0, ""); err != nil {
+		return nil, err
+	}
+	buf.WriteString("</plist>\n")
+	return buf.Bytes(), nil
+}
+
+func writeXMLValue(buf *bytes.Buffer, v reflect.Value, depth int, path string) error {
+	for v.IsValid() && (v.Kind() == reflect.Interface || v.Kind() == reflect.Pointer) {
+		if v.IsNil() {
+			return fmt.Errorf("plist: nil value at %s", pathOrRoot(path))
+		}
+		v = v.Elem()
+	}
+	if !v.IsValid() {
+		return fmt.Errorf("plist: nil value at %s", pathOrRoot(path))
+	}
+
+	indent := func(d int) {
+		for i := 0; i < d; i++ {
+			buf.WriteByte('\t')
+		}
+	}
+	indent(depth)
+
+	if t, ok := v.Interface().(time.Time); ok {
+		fmt.Fprintf(buf, "<date>%s</date>\n", t.UTC().Format(time.RFC3339))
+		return nil
+	}
+	if uid, ok := v.Interface().(UID); ok {
+		buf.WriteString("<dict>\n")
+		indent(depth + 1)
+		buf.WriteString("<key>CF$UID</key>\n")
+		indent(depth + 1)
+		fmt.Fprintf(buf, "<integer>%d</integer>\n", uint64(uid))
+		indent(depth)
+		buf.WriteString("</dict>\n")
+		return nil
+	}
+	if d, ok := v.Interface().(time.Duration); ok {
+		fmt.Fprintf(buf, "<real>%s</real>\n", strconv.FormatFloat(d.Seconds(), 'g', -1, 64))
+		return nil
+	}
+
+	switch v.Kind() {
+	case reflect.String:
+		buf.WriteString("<string>")
+		if err := xml.EscapeText(buf, []byte(v.String())); err != nil {
+			return err
+		}
+		buf.WriteString("</string>\n")
+	case reflect.Bool:
+		if v.Bool() {
+			buf.WriteString("<true/>\n")
+		} else {
+			buf.WriteString("<false/>\n")
+		}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		fmt.Fprintf(buf, "<integer>%d</integer>\n", v.Int())
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		fmt.Fprintf(buf, "<integer>%d</integer>\n", v.Uint())
+	case reflect.Float32, reflect.Float64:
+		f := v.Float()
+		switch {
+		case math.IsInf(f, 1):
+			buf.WriteString("<real>+infinity</real>\n")
+		case math.IsInf(f, -1):
+			buf.WriteString("<real>-infinity</real>\n")
+		case math.IsNaN(f):
+			buf.WriteString("<real>nan</real>\n")
+		default:
+			fmt.Fprintf(buf, "<real>%s</real>\n", strconv.FormatFloat(f, 'g', -1, 64))
+		}
+	case reflect.Slice, reflect.Array:
+		if v.Type().Elem().Kind() == reflect.Uint8 {
+			b := make([]byte, v.Len())
+			reflect.Copy(reflect.ValueOf(b), v)
+			fmt.Fprintf(buf, "<data>%s</data>\n", base64.StdEncoding.EncodeToString(b))
+			return nil
+		}
+		if v.Len() == 0 {
+			buf.WriteString("<array/>\n")
+			return nil
+		}
+		buf.WriteString("<array>\n")
+		for i := 0; i < v.Len(); i++ {
+			if err := writeXMLValue(buf, v.Index(i), depth+1, fmt.Sprintf("%s[%d]", path, i)); err != nil {
+				return err
+			}
+		}
+		indent(depth)
+		buf.WriteString("</array>\n")
+	case reflect.Map:
+		if v.Type().Key().Kind() != reflect.String {
+			return fmt.Errorf("plist: unsupported map key type %s at %s", v.Type().Key(), pathOrRoot(path))
+		}
+		if v.Len() == 0 {
+			buf.WriteString("<dict/>\n")
+			return nil
+		}
+		keys := make([]string, 0, v.Len())
+		for _, k := range v.MapKeys() {
+			keys = append(keys, k.String())
+		}
+		sort.Strings(keys)
+		buf.WriteString("<dict>\n")
+		for _, k := range keys {
+			indent(depth + 1)
+			buf.WriteString("<key>")
+			if err := xml.EscapeText(buf, []byte(k)); err != nil {
+				return err
+			}
+			buf.WriteString("</key>\n")
+			kv := reflect.ValueOf(k).Convert(v.Type().Key())
+			if err := writeXMLValue(buf, v.MapIndex(kv), depth+1, joinPath(path, k)); err != nil {
+				return err
+			}
+		}
+		indent(depth)
+		buf.WriteString("</dict>\n")
+	default:
+		return fmt.Errorf("plist: unsupported type %s at %s", v.Type(), pathOrRoot(path))
+	}
+	return nil
+}
+
+func joinPath(path, key string) string {
+	if path == "" {
+		return key
+	}
+	return path + "." + key
+}
+
+func pathOrRoot(path string) string {
+	if path == "" {
+		return "root"
+	}
+	return path
+}
@@ -0,0 +1,59 @@
+package plist
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestMarshalXML(t *testing.T) {
+	data, err := MarshalXML(map[string]interface{}{
+		"string": "a < b",
+		"int":    -3,
+		"real":   1.5,
+		"bool":   false,
+		"date":   time.Date(2023, 5, 1, 12, 0, 0, 0, time.UTC),
+		"delay":  1500 * time.Millisecond,
+		"data":   []byte("hi"),
+		"array":  []string{"x"},
+		"empty":  map[string]int{},
+	})
+	if err != nil {
+		t.Fatalf("MarshalXML() error = %v", err)
+	}
+
+	want := xmlHeader + `<dict>
+	<key>array</key>
+	<array>
+		<string>x</string>
+	</array>
+	<key>bool</key>
+	<false/>
+	<key>data</key>
+	<data>aGk=</data>
+	<key>date</key>
+	<date>2023-05-01T12:00:00Z</date>
+	<key>delay</key>
+	<real>1.5</real>
+	<key>empty</key>
+	<dict/>
+	<key>int</key>
+	<integer>-3</integer>
+	<key>real</key>
+	<real>1.5</real>
+	<key>string</key>
+	<string>a &lt; b</string>
+</dict>
+</plist>
+`
+	if string(data) != want {
+		t.Fatalf("MarshalXML() got:\n%s\nwant:\n%s", data, want)
+	}
+}
+
+func TestMarshalXMLReportsPath(t *testing.T) {
+	_, err := MarshalXML(map[string]interface{}{"outer": []interface{}{1, nil}})
+	if err == nil || !strings.Contains(err.Error(), "outer[1]") {
+		t.Fatalf("MarshalXML() error = %v, want error naming outer[1]", err)
+	}
+}
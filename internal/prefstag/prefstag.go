@@ -0,0 +1,90 @@
+// Package prefstag reads the `prefs` struct tags that control how a Go struct is stored as a
+// preference dictionary. It is shared by the CoreFoundation and pure Go conversions.
+//
+// Every exported field is stored under its Go name unless a tag renames it:
+//
+//	Name    string `prefs:"name"`           // stored as "name"
+//	Port    int    `prefs:"port,omitempty"` // not stored if zero
+//	Secret  string `prefs:"-"`              // never stored
+//
+// Untagged embedded structs have their fields promoted into the outer dictionary, and nil
+// pointer fields are left out.
+package prefstag
+
+import (
+	"reflect"
+	"strings"
+	"sync"
+)
+
+// Field is a struct field stored in a preference dictionary.
+type Field struct {
+	Name      string
+	Index     []int
+	OmitEmpty bool
+}
+
+var cache sync.Map // reflect.Type -> []Field
+
+// Fields returns the stored fields of the struct type t. Fields of embedded structs are
+// promoted unless a field of the same name is declared at a shallower depth.
+func Fields(t reflect.Type) []Field {
+	if fields, ok := cache.Load(t); ok {
+		return fields.([]Field)
+	}
+	var fields []Field
+	seen := make(map[string]bool)
+	collect(t, nil, seen, &fields)
+	cache.Store(t, fields)
+	return fields
+}
+
+func collect(t reflect.Type, index []int, seen map[string]bool, fields *[]Field) {
+	var embedded []reflect.StructField
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		tag, tagged := f.Tag.Lookup("prefs")
+		if tag == "-" {
+			continue
+		}
+		if f.Anonymous && !tagged && f.Type.Kind() == reflect.Struct {
+			embedded = append(embedded, f)
+			continue
+		}
+		if !f.IsExported() {
+			continue
+		}
+
+		name, opts, _ := strings.Cut(tag, ",")
+		if name == "" {
+			name = f.Name
+		}
+		if seen[name] {
+			continue
+		}
+		seen[name] = true
+		*fields = append(*fields, Field{
+			Name:      name,
+			Index:     append(append([]int(nil), index...), i),
+			OmitEmpty: opts == "omitempty",
+		})
+	}
+	for _, f := range embedded {
+		collect(f.Type, append(append([]int(nil), index...), f.Index...), seen, fields)
+	}
+}
+
+// Values returns the stored fields of the struct value v by name, leaving out nil pointers
+// and empty omitempty fields.
+func Values(v reflect.Value) map[string]interface{} {
+	fields := Fields(v.Type())
+	values := make(map[string]interface{}, len(fields))
+	for _, f := range fields {
+		fv := v.FieldByIndex(f.Index)
+		if (f.OmitEmpty || fv.Kind() == reflect.Ptr) && fv.IsZero() {
+			continue
+		}
+		values[f.Name] = fv.Interface()
+	}
+	return values
+}
@@ -0,0 +1,52 @@
+package prefstag
+
+import (
+	"reflect"
+	"testing"
+)
+
+type base struct {
+	ID   string `prefs:"id"`
+	Name string
+}
+
+type config struct {
+	base
+	Name    string `prefs:"name"`
+	Port    int    `prefs:"port,omitempty"`
+	Secret  string `prefs:"-"`
+	Enabled bool
+	hidden  int
+}
+
+func TestFields(t *testing.T) {
+	var names []string
+	for _, f := range Fields(reflect.TypeOf(config{})) {
+		names = append(names, f.Name)
+	}
+	want := []string{"name", "port", "Enabled", "id", "Name"}
+	if !reflect.DeepEqual(names, want) {
+		t.Errorf("Fields() names = %v, want %v", names, want)
+	}
+}
+
+func TestValues(t *testing.T) {
+	c := config{base: base{ID: "x", Name: "inner"}, Name: "outer", Secret: "s", hidden: 1}
+	got := Values(reflect.ValueOf(c))
+	want := map[string]interface{}{"id": "x", "Name": "inner", "name": "outer", "Enabled": false}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Values() = %v, want %v", got, want)
+	}
+
+	c.Port = 8080
+	if got := Values(reflect.ValueOf(c)); got["port"] != 8080 {
+		t.Errorf("Values()[port] = %v, want 8080", got["port"])
+	}
+
+	enabled := true
+	type flags struct{ Enabled, Visible *bool }
+	got = Values(reflect.ValueOf(flags{Enabled: &enabled}))
+	if len(got) != 1 || got["Enabled"] != &enabled {
+		t.Errorf("Values() = %v, want only Enabled", got)
+	}
+}
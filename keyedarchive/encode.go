@@ -0,0 +1,124 @@
+package keyedarchive
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"time"
+
+	"github.com/weswhet/mac_prefs/internal/plist"
+)
+
+// Encode encodes a value as an NSKeyedArchiver archive in the XML property list format, the
+// reverse of Decode for simple values: strings, booleans, numbers, []byte, time.Time, nil,
+// and slices and string-keyed maps of those, which become NSArray and NSDictionary.
+//
+// Parameters:
+//   - value: The root object.
+//
+// Returns:
+//   - []byte: The archive, which can be stored in preferences as data.
+//   - error: An error if the value or one of its elements has an unsupported type.
+func Encode(value interface{}) ([]byte, error) {
+	e := &encoder{objects: []interface{}{"$null"}, classes: make(map[string]plist.UID)}
+	root, err := e.encode(reflect.ValueOf(value))
+	if err != nil {
+		return nil, err
+	}
+	return plist.MarshalXML(map[string]interface{}{
+		"$version":  100000,
+		"$archiver": archiverName,
+		"$top":      map[string]interface{}{"root": root},
+		"$objects":  e.objects,
+	})
+}
+
+type encoder struct {
+	objects []interface{}
+	classes map[string]plist.UID
+}
+
+func (e *encoder) add(obj interface{}) plist.UID {
+	e.objects = append(e.objects, obj)
+	return plist.UID(len(e.objects) - 1)
+}
+
+// class returns the reference to the class description of name, adding it once.
+func (e *encoder) class(name string) plist.UID {
+	if uid, ok := e.classes[name]; ok {
+		return uid
+	}
+	uid := e.add(map[string]interface{}{"$classname": name, "$classes": []interface{}{name, "NSObject"}})
+	e.classes[name] = uid
+	return uid
+}
+
+func (e *encoder) encode(v reflect.Value) (plist.UID, error) {
+	for v.IsValid() && (v.Kind() == reflect.Interface || v.Kind() == reflect.Pointer) {
+		if v.IsNil() {
+			return 0, nil
+		}
+		v = v.Elem()
+	}
+	if !v.IsValid() {
+		return 0, nil
+	}
+	if t, ok := v.Interface().(time.Time); ok {
+		// Reserve the object before the class, as NSKeyedArchiver does.
+		uid := e.add(nil)
+		seconds := float64(t.Unix()-referenceDate.Unix()) + float64(t.Nanosecond())/float64(time.Second)
+		e.objects[uid] = map[string]interface{}{"NS.time": seconds, "$class": e.class("NSDate")}
+		return uid, nil
+	}
+
+	switch v.Kind() {
+	case reflect.String:
+		return e.add(v.String()), nil
+	case reflect.Bool:
+		return e.add(v.Bool()), nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return e.add(v.Int()), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return e.add(v.Uint()), nil
+	case reflect.Float32, reflect.Float64:
+		return e.add(v.Float()), nil
+	case reflect.Slice:
+		if v.Type().Elem().Kind() == reflect.Uint8 {
+			return e.add(append([]byte(nil), v.Bytes()...)), nil
+		}
+		uid := e.add(nil)
+		refs := make([]interface{}, v.Len())
+		for i := range refs {
+			ref, err := e.encode(v.Index(i))
+			if err != nil {
+				return 0, fmt.Errorf("error encoding item at index %d: %v", i, err)
+			}
+			refs[i] = ref
+		}
+		e.objects[uid] = map[string]interface{}{"NS.objects": refs, "$class": e.class("NSArray")}
+		return uid, nil
+	case reflect.Map:
+		if v.Type().Key().Kind() != reflect.String {
+			return 0, fmt.Errorf("keyedarchive: unsupported map key type %s", v.Type().Key())
+		}
+		uid := e.add(nil)
+		names := make([]string, 0, v.Len())
+		for _, key := range v.MapKeys() {
+			names = append(names, key.String())
+		}
+		sort.Strings(names)
+		keys := make([]interface{}, len(names))
+		values := make([]interface{}, len(names))
+		for i, name := range names {
+			keys[i] = e.add(name)
+			ref, err := e.encode(v.MapIndex(reflect.ValueOf(name).Convert(v.Type().Key())))
+			if err != nil {
+				return 0, fmt.Errorf("error encoding value for key %s: %v", name, err)
+			}
+			values[i] = ref
+		}
+		e.objects[uid] = map[string]interface{}{"NS.keys": keys, "NS.objects": values, "$class": e.class("NSDictionary")}
+		return uid, nil
+	}
+	return 0, fmt.Errorf("keyedarchive: unsupported type %s", v.Type())
+}
@@ -0,0 +1,251 @@
+// Package keyedarchive decodes NSKeyedArchiver archives, which many applications store in
+// their preferences as data, into plain Go values, and encodes simple values as archives.
+//
+// Foundation classes are decoded to the types the CoreFoundation converters return:
+//
+//	NSString, NSMutableString              string
+//	NSNumber                               bool, int, or float64
+//	NSData, NSMutableData                  []byte
+//	NSDate                                 time.Time
+//	NSURL                                  *url.URL
+//	NSUUID                                 [16]byte
+//	NSArray, NSSet, NSOrderedSet (mutable) []interface{}
+//	NSDictionary (mutable)                 map[string]interface{}
+//	NSNull, $null                          nil
+//
+// Objects of any other class are decoded to a map[string]interface{} of their encoded fields
+// with the class name under the "$class" key.
+package keyedarchive
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"net/url"
+	"time"
+
+	"github.com/weswhet/mac_prefs/internal/plist"
+)
+
+// archiverName is the $archiver value of NSKeyedArchiver archives.
+const archiverName = "NSKeyedArchiver"
+
+// maxDepth bounds the nesting of decoded objects, so archives with reference cycles fail
+// instead of recursing forever.
+const maxDepth = 512
+
+// referenceDate is the reference date of NSDate.
+var referenceDate = time.Date(2001, time.January, 1, 0, 0, 0, 0, time.UTC)
+
+// IsArchive reports whether data is an NSKeyedArchiver archive.
+func IsArchive(data []byte) bool {
+	archive, ok := parse(data)
+	return ok && archive["$archiver"] == archiverName
+}
+
+// Decode decodes an NSKeyedArchiver archive in the XML or binary property list format.
+//
+// Parameters:
+//   - data: The archive, e.g. a []byte value read from preferences.
+//
+// Returns:
+//   - interface{}: The root object, or a map of every top-level object by key if the archive
+//     has no "root" key.
+//   - error: An error if data is not a keyed archive or references objects that do not exist.
+func Decode(data []byte) (interface{}, error) {
+	archive, ok := parse(data)
+	if !ok || archive["$archiver"] != archiverName {
+		return nil, errors.New("keyedarchive: data is not an NSKeyedArchiver archive")
+	}
+	objects, ok := archive["$objects"].([]interface{})
+	if !ok {
+		return nil, errors.New("keyedarchive: archive has no $objects array")
+	}
+	top, ok := archive["$top"].(map[string]interface{})
+	if !ok {
+		return nil, errors.New("keyedarchive: archive has no $top dictionary")
+	}
+
+	d := &decoder{objects: objects}
+	if root, ok := top["root"]; ok {
+		return d.value(root, 0)
+	}
+	result := make(map[string]interface{}, len(top))
+	for key, value := range top {
+		decoded, err := d.value(value, 0)
+		if err != nil {
+			return nil, err
+		}
+		result[key] = decoded
+	}
+	return result, nil
+}
+
+func parse(data []byte) (map[string]interface{}, bool) {
+	value, err := plist.Unmarshal(data)
+	if err != nil {
+		return nil, false
+	}
+	archive, ok := value.(map[string]interface{})
+	return archive, ok
+}
+
+type decoder struct {
+	objects []interface{}
+}
+
+// value decodes a field value, following it if it is a reference.
+func (d *decoder) value(v interface{}, depth int) (interface{}, error) {
+	if uid, ok := v.(plist.UID); ok {
+		return d.object(uid, depth)
+	}
+	return v, nil
+}
+
+func (d *decoder) object(uid plist.UID, depth int) (interface{}, error) {
+	if depth > maxDepth {
+		return nil, errors.New("keyedarchive: archive is nested too deeply")
+	}
+	if uint64(uid) >= uint64(len(d.objects)) {
+		return nil, fmt.Errorf("keyedarchive: invalid object reference %d", uid)
+	}
+	obj := d.objects[uid]
+	if obj == "$null" {
+		return nil, nil
+	}
+	fields, ok := obj.(map[string]interface{})
+	if !ok {
+		return obj, nil
+	}
+	classRef, ok := fields["$class"].(plist.UID)
+	if !ok {
+		return fields, nil
+	}
+	class, err := d.className(classRef)
+	if err != nil {
+		return nil, err
+	}
+
+	switch class {
+	case "NSString", "NSMutableString":
+		return d.value(fields["NS.string"], depth+1)
+	case "NSData", "NSMutableData":
+		return d.value(fields["NS.data"], depth+1)
+	case "NSNull":
+		return nil, nil
+	case "NSDate":
+		seconds, ok := fields["NS.time"].(float64)
+		if !ok {
+			return nil, errors.New("keyedarchive: NSDate without NS.time")
+		}
+		// time.Duration overflows for dates such as distantPast and distantFuture, so whole
+		// seconds are added to the Unix time of the reference date.
+		whole := math.Floor(seconds)
+		nanos := int64(math.Round((seconds - whole) * float64(time.Second)))
+		return time.Unix(referenceDate.Unix()+int64(whole), nanos).UTC(), nil
+	case "NSUUID":
+		b, ok := fields["NS.uuidbytes"].([]byte)
+		if !ok || len(b) != 16 {
+			return nil, errors.New("keyedarchive: NSUUID without 16 NS.uuidbytes")
+		}
+		var u [16]byte
+		copy(u[:], b)
+		return u, nil
+	case "NSURL":
+		return d.url(fields, depth)
+	case "NSArray", "NSMutableArray", "NSSet", "NSMutableSet", "NSOrderedSet", "NSMutableOrderedSet":
+		return d.array(fields["NS.objects"], depth)
+	case "NSDictionary", "NSMutableDictionary":
+		return d.dictionary(fields, depth)
+	}
+
+	result := map[string]interface{}{"$class": class}
+	for key, field := range fields {
+		if key == "$class" {
+			continue
+		}
+		decoded, err := d.value(field, depth+1)
+		if err != nil {
+			return nil, fmt.Errorf("error decoding %s.%s: %v", class, key, err)
+		}
+		result[key] = decoded
+	}
+	return result, nil
+}
+
+func (d *decoder) className(uid plist.UID) (string, error) {
+	if uint64(uid) >= uint64(len(d.objects)) {
+		return "", fmt.Errorf("keyedarchive: invalid class reference %d", uid)
+	}
+	class, ok := d.objects[uid].(map[string]interface{})
+	if !ok {
+		return "", fmt.Errorf("keyedarchive: object %d is not a class", uid)
+	}
+	name, ok := class["$classname"].(string)
+	if !ok {
+		return "", fmt.Errorf("keyedarchive: class %d has no $classname", uid)
+	}
+	return name, nil
+}
+
+func (d *decoder) array(v interface{}, depth int) ([]interface{}, error) {
+	refs, ok := v.([]interface{})
+	if !ok {
+		return nil, errors.New("keyedarchive: collection without NS.objects")
+	}
+	result := make([]interface{}, len(refs))
+	for i, ref := range refs {
+		item, err := d.value(ref, depth+1)
+		if err != nil {
+			return nil, fmt.Errorf("error decoding item at index %d: %v", i, err)
+		}
+		result[i] = item
+	}
+	return result, nil
+}
+
+func (d *decoder) dictionary(fields map[string]interface{}, depth int) (map[string]interface{}, error) {
+	keys, err := d.array(fields["NS.keys"], depth)
+	if err != nil {
+		return nil, err
+	}
+	values, err := d.array(fields["NS.objects"], depth)
+	if err != nil {
+		return nil, err
+	}
+	if len(keys) != len(values) {
+		return nil, fmt.Errorf("keyedarchive: dictionary has %d keys and %d values", len(keys), len(values))
+	}
+	result := make(map[string]interface{}, len(keys))
+	for i, key := range keys {
+		s, ok := key.(string)
+		if !ok {
+			return nil, fmt.Errorf("keyedarchive: dictionary key of type %T is not a string", key)
+		}
+		result[s] = values[i]
+	}
+	return result, nil
+}
+
+func (d *decoder) url(fields map[string]interface{}, depth int) (*url.URL, error) {
+	relative, err := d.value(fields["NS.relative"], depth+1)
+	if err != nil {
+		return nil, err
+	}
+	s, ok := relative.(string)
+	if !ok {
+		return nil, errors.New("keyedarchive: NSURL without NS.relative")
+	}
+	u, err := url.Parse(s)
+	if err != nil {
+		return nil, fmt.Errorf("keyedarchive: invalid NSURL: %v", err)
+	}
+	base, err := d.value(fields["NS.base"], depth+1)
+	if err != nil {
+		return nil, err
+	}
+	if base, ok := base.(*url.URL); ok {
+		return base.ResolveReference(u), nil
+	}
+	return u, nil
+}
@@ -0,0 +1,72 @@
+package keyedarchive
+
+import (
+	"os"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestDecode(t *testing.T) {
+	data, err := os.ReadFile("testdata/dictionary.bplist")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !IsArchive(data) {
+		t.Fatal("IsArchive() = false, want true")
+	}
+
+	got, err := Decode(data)
+	if err != nil {
+		t.Fatalf("Decode() error = %v", err)
+	}
+	want := map[string]interface{}{
+		"name":     "Dock",
+		"items":    []interface{}{"a", 42},
+		"modified": referenceDate.Add(700000000*time.Second + 500*time.Millisecond),
+		"color":    map[string]interface{}{"$class": "NSColor", "NSRed": 1.0, "NSColorSpace": 1},
+		"missing":  nil,
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("Decode() = %#v, want %#v", got, want)
+	}
+}
+
+func TestDecodeErrors(t *testing.T) {
+	for _, data := range []string{
+		"",
+		"not a plist",
+		`<plist><dict><key>$archiver</key><string>Other</string></dict></plist>`,
+		`<plist><dict><key>$archiver</key><string>NSKeyedArchiver</string><key>$top</key><dict><key>root</key><dict><key>CF$UID</key><integer>5</integer></dict></dict><key>$objects</key><array><string>$null</string></array></dict></plist>`,
+	} {
+		if _, err := Decode([]byte(data)); err == nil {
+			t.Errorf("Decode(%q) expected error", data)
+		}
+	}
+}
+
+func TestEncodeRoundTrip(t *testing.T) {
+	value := map[string]interface{}{
+		"name":    "Dock",
+		"tiles":   []interface{}{"a", 1, 2.5, true, nil},
+		"nested":  map[string]interface{}{"data": []byte("hi")},
+		"created": time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC),
+		"past":    time.Date(0, 12, 30, 0, 0, 0, 0, time.UTC),
+		"future":  time.Date(4001, 1, 1, 0, 0, 0, 0, time.UTC),
+	}
+	data, err := Encode(value)
+	if err != nil {
+		t.Fatalf("Encode() error = %v", err)
+	}
+	got, err := Decode(data)
+	if err != nil {
+		t.Fatalf("Decode() error = %v", err)
+	}
+	if !reflect.DeepEqual(got, value) {
+		t.Fatalf("Decode(Encode()) = %#v, want %#v", got, value)
+	}
+
+	if _, err := Encode(map[string]interface{}{"c": make(chan int)}); err == nil {
+		t.Fatal("Encode() with an unsupported type expected error")
+	}
+}
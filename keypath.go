@@ -0,0 +1,241 @@
+package mac_prefs
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// GetPath retrieves one value nested inside a preference. The path is the preference key
+// followed by dictionary keys and array indexes separated by dots, e.g.
+// "persistent-apps.3.tile-data.file-label" for the file label of the fourth item of the
+// Dock's persistent-apps array. A dot or backslash that is part of a key is escaped with a
+// backslash, e.g. `NSNavPanelExpandedStateForSaveMode\.v2`.
+//
+// Parameters:
+//   - applicationID: The bundle identifier of the application for which to retrieve the preference.
+//   - path: The key path to the value.
+//   - scope: The PreferenceScope to read from.
+//
+// Returns:
+//   - interface{}: The value, or nil if a key along the path is not set or an index is out of
+//     range.
+//   - error: An error if the path is invalid, the preference cannot be read, or the path
+//     descends into a value that is not an array or dictionary.
+func GetPath(applicationID string, path string, scope PreferenceScope) (interface{}, error) {
+	return NewClient().GetPath(applicationID, path, scope)
+}
+
+// GetPath retrieves one value nested inside a preference. See GetPath.
+func (c *Client) GetPath(applicationID string, path string, scope PreferenceScope) (interface{}, error) {
+	segments, err := splitPath(path)
+	if err != nil {
+		return nil, err
+	}
+	value, err := c.Get(segments[0], applicationID, scope)
+	if err != nil {
+		return nil, err
+	}
+	value, err = lookupPath(value, segments)
+	if err != nil {
+		return nil, fmt.Errorf("error reading %s: %v", path, err)
+	}
+	return value, nil
+}
+
+// SetPath sets one value nested inside a preference, e.g. "NSWindow Frames.Main.x". The
+// current value of the key is read, the nested value replaced, and the key written back while
+// the Client is locked. Missing dictionaries along the path are created, and an index equal to
+// the length of an array appends to it. A nil value removes the value, like DeletePath. See
+// GetPath for the path syntax.
+//
+// Parameters:
+//   - applicationID: The bundle identifier of the application for which to set the preference.
+//   - path: The key path to the value.
+//   - value: The value to set.
+//   - scope: The PreferenceScope to write to.
+//
+// Returns:
+//   - error: An error if the path is invalid, descends into a value that is not an array or
+//     dictionary, or the preference cannot be read or written.
+func SetPath(applicationID string, path string, value interface{}, scope PreferenceScope) error {
+	return NewClient().SetPath(applicationID, path, value, scope)
+}
+
+// SetPath sets one value nested inside a preference. See SetPath.
+func (c *Client) SetPath(applicationID string, path string, value interface{}, scope PreferenceScope) error {
+	segments, err := splitPath(path)
+	if err != nil {
+		return err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	root, err := c.store.Get(segments[0], applicationID, scope)
+	if err != nil {
+		return err
+	}
+	root, err = replacePath(root, segments, 1, value)
+	if err != nil {
+		return fmt.Errorf("error writing %s: %v", path, err)
+	}
+	return c.set(segments[0], root, applicationID, scope)
+}
+
+// DeletePath removes one value nested inside a preference, e.g. a single Dock tile with
+// "persistent-apps.3", and writes the key back while the Client is locked. Removing an array
+// element shifts the elements after it. See GetPath for the path syntax.
+//
+// Parameters:
+//   - applicationID: The bundle identifier of the application for which to remove the preference.
+//   - path: The key path to the value.
+//   - scope: The PreferenceScope to write to.
+//
+// Returns:
+//   - error: An error if the path is invalid, descends into a value that is not an array or
+//     dictionary, or the preference cannot be read or written. Removing a value that is not
+//     set is not an error.
+func DeletePath(applicationID string, path string, scope PreferenceScope) error {
+	return NewClient().DeletePath(applicationID, path, scope)
+}
+
+// DeletePath removes one value nested inside a preference. See DeletePath.
+func (c *Client) DeletePath(applicationID string, path string, scope PreferenceScope) error {
+	return c.SetPath(applicationID, path, nil, scope)
+}
+
+// splitPath splits a key path into its unescaped segments.
+func splitPath(path string) ([]string, error) {
+	var segments []string
+	var segment strings.Builder
+	for i := 0; i < len(path); i++ {
+		switch path[i] {
+		case '\\':
+			i++
+			if i == len(path) {
+				return nil, fmt.Errorf("invalid key path %q: trailing backslash", path)
+			}
+			segment.WriteByte(path[i])
+		case '.':
+			segments = append(segments, segment.String())
+			segment.Reset()
+		default:
+			segment.WriteByte(path[i])
+		}
+	}
+	segments = append(segments, segment.String())
+	for _, s := range segments {
+		if s == "" {
+			return nil, fmt.Errorf("invalid key path %q: empty segment", path)
+		}
+	}
+	return segments, nil
+}
+
+// lookupPath walks value, the value of segments[0], along the remaining segments.
+func lookupPath(value interface{}, segments []string) (interface{}, error) {
+	for i := 1; i < len(segments) && value != nil; i++ {
+		switch v := value.(type) {
+		case map[string]interface{}:
+			value = v[segments[i]]
+		case []interface{}:
+			index, err := pathIndex(segments, i)
+			if err != nil {
+				return nil, err
+			}
+			if index >= len(v) {
+				return nil, nil
+			}
+			value = v[index]
+		default:
+			return nil, notContainerError(segments, i, value)
+		}
+	}
+	return value, nil
+}
+
+// replacePath returns a copy of value, the value of segments[:i], with the value at the
+// remaining segments replaced by leaf, or removed if leaf is nil. Only the arrays and
+// dictionaries along the path are copied.
+func replacePath(value interface{}, segments []string, i int, leaf interface{}) (interface{}, error) {
+	if i == len(segments) {
+		return leaf, nil
+	}
+	if value == nil {
+		if leaf == nil {
+			return nil, nil
+		}
+		value = map[string]interface{}{}
+	}
+
+	switch v := value.(type) {
+	case map[string]interface{}:
+		result := make(map[string]interface{}, len(v)+1)
+		for key, item := range v {
+			result[key] = item
+		}
+		item, err := replacePath(v[segments[i]], segments, i+1, leaf)
+		if err != nil {
+			return nil, err
+		}
+		if item == nil {
+			delete(result, segments[i])
+		} else {
+			result[segments[i]] = item
+		}
+		return result, nil
+	case []interface{}:
+		index, err := pathIndex(segments, i)
+		if err != nil {
+			return nil, err
+		}
+		if leaf == nil && index >= len(v) {
+			return v, nil
+		}
+		if index > len(v) {
+			return nil, fmt.Errorf("index %d of %s is out of range for %d elements", index, joinSegments(segments[:i]), len(v))
+		}
+		result := append(make([]interface{}, 0, len(v)+1), v...)
+		var current interface{}
+		if index < len(v) {
+			current = v[index]
+		}
+		item, err := replacePath(current, segments, i+1, leaf)
+		if err != nil {
+			return nil, err
+		}
+		switch {
+		case item == nil:
+			return append(result[:index], result[index+1:]...), nil
+		case index == len(v):
+			return append(result, item), nil
+		}
+		result[index] = item
+		return result, nil
+	}
+	return nil, notContainerError(segments, i, value)
+}
+
+// pathIndex parses segments[i] as an index into an array.
+func pathIndex(segments []string, i int) (int, error) {
+	index, err := strconv.Atoi(segments[i])
+	if err != nil || index < 0 {
+		return 0, fmt.Errorf("%s is an array, and %q is not an index", joinSegments(segments[:i]), segments[i])
+	}
+	return index, nil
+}
+
+func notContainerError(segments []string, i int, value interface{}) error {
+	return fmt.Errorf("%s is a %T, not an array or dictionary", joinSegments(segments[:i]), value)
+}
+
+// joinSegments joins segments into a key path, escaping them as splitPath expects.
+func joinSegments(segments []string) string {
+	escaped := make([]string, len(segments))
+	for i, s := range segments {
+		s = strings.ReplaceAll(s, `\`, `\\`)
+		escaped[i] = strings.ReplaceAll(s, ".", `\.`)
+	}
+	return strings.Join(escaped, ".")
+}
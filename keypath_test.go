@@ -0,0 +1,178 @@
+package mac_prefs
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestSplitPath(t *testing.T) {
+	tests := []struct {
+		path string
+		want []string
+	}{
+		{"autohide", []string{"autohide"}},
+		{"persistent-apps.3.tile-data.file-label", []string{"persistent-apps", "3", "tile-data", "file-label"}},
+		{`NSWindow Frame\.Main.x`, []string{"NSWindow Frame.Main", "x"}},
+		{`a\\.b`, []string{`a\`, "b"}},
+	}
+	for _, tt := range tests {
+		got, err := splitPath(tt.path)
+		if err != nil {
+			t.Errorf("splitPath(%q) error = %v", tt.path, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("splitPath(%q) = %q, want %q", tt.path, got, tt.want)
+		}
+		if joined := joinSegments(got); joined != tt.path {
+			t.Errorf("joinSegments(%q) = %q, want %q", got, joined, tt.path)
+		}
+	}
+
+	for _, path := range []string{"", "a..b", "a.", `a\`} {
+		if _, err := splitPath(path); err == nil {
+			t.Errorf("splitPath(%q) expected error", path)
+		}
+	}
+}
+
+func TestClientGetPath(t *testing.T) {
+	c := NewClient(WithStore(NewMemoryStore()))
+	apps := []interface{}{
+		map[string]interface{}{"tile-data": map[string]interface{}{"file-label": "Safari"}},
+		map[string]interface{}{"tile-data": map[string]interface{}{"file-label": "Mail"}},
+	}
+	if err := c.Set("persistent-apps", apps, "com.apple.dock", CurrentUserAnyHost); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		path string
+		want interface{}
+	}{
+		{"persistent-apps.1.tile-data.file-label", "Mail"},
+		{"persistent-apps.0.tile-data", map[string]interface{}{"file-label": "Safari"}},
+		{"persistent-apps.5.tile-data", nil},
+		{"persistent-apps.0.missing.file-label", nil},
+		{"missing.0", nil},
+	}
+	for _, tt := range tests {
+		got, err := c.GetPath("com.apple.dock", tt.path, CurrentUserAnyHost)
+		if err != nil {
+			t.Errorf("GetPath(%q) error = %v", tt.path, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("GetPath(%q) = %#v, want %#v", tt.path, got, tt.want)
+		}
+	}
+
+	for _, path := range []string{"persistent-apps.first", "persistent-apps.-1", "persistent-apps.0.tile-data.file-label.x"} {
+		if _, err := c.GetPath("com.apple.dock", path, CurrentUserAnyHost); err == nil || !strings.Contains(err.Error(), path) {
+			t.Errorf("GetPath(%q) error = %v, want an error naming the path", path, err)
+		}
+	}
+}
+
+func TestClientSetPath(t *testing.T) {
+	c := NewClient(WithStore(NewMemoryStore()))
+	apps := []interface{}{
+		map[string]interface{}{"tile-data": map[string]interface{}{"file-label": "Safari"}},
+	}
+	if err := c.Set("persistent-apps", apps, "com.apple.dock", CurrentUserAnyHost); err != nil {
+		t.Fatal(err)
+	}
+
+	writes := []struct {
+		path  string
+		value interface{}
+	}{
+		{"persistent-apps.0.tile-data.file-label", "Safari Technology Preview"},
+		{"persistent-apps.1.tile-data.file-label", "Mail"},
+		{"NSWindow Frames.Main\\.v2.x", 100},
+		{"autohide", true},
+	}
+	for _, w := range writes {
+		if err := c.SetPath("com.apple.dock", w.path, w.value, CurrentUserAnyHost); err != nil {
+			t.Fatalf("SetPath(%q) error = %v", w.path, err)
+		}
+	}
+
+	got, err := c.GetAll("com.apple.dock", CurrentUserAnyHost)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]interface{}{
+		"persistent-apps": []interface{}{
+			map[string]interface{}{"tile-data": map[string]interface{}{"file-label": "Safari Technology Preview"}},
+			map[string]interface{}{"tile-data": map[string]interface{}{"file-label": "Mail"}},
+		},
+		"NSWindow Frames": map[string]interface{}{"Main.v2": map[string]interface{}{"x": 100}},
+		"autohide":        true,
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("GetAll() = %#v, want %#v", got, want)
+	}
+	if !reflect.DeepEqual(apps[0], map[string]interface{}{"tile-data": map[string]interface{}{"file-label": "Safari"}}) {
+		t.Errorf("SetPath modified the value it read: %#v", apps[0])
+	}
+
+	if err := c.SetPath("com.apple.dock", "NSWindow Frames.Main\\.v2.x", nil, CurrentUserAnyHost); err != nil {
+		t.Fatal(err)
+	}
+	if v, _ := c.GetPath("com.apple.dock", "NSWindow Frames.Main\\.v2", CurrentUserAnyHost); !reflect.DeepEqual(v, map[string]interface{}{}) {
+		t.Errorf("after removing x, Main.v2 = %#v", v)
+	}
+	if err := c.SetPath("com.apple.dock", "missing.x", nil, CurrentUserAnyHost); err != nil {
+		t.Fatal(err)
+	}
+	if v, _ := c.Get("missing", "com.apple.dock", CurrentUserAnyHost); v != nil {
+		t.Errorf("removing from a missing key wrote %#v", v)
+	}
+
+	for _, path := range []string{"persistent-apps.5.x", "persistent-apps.x", "autohide.x"} {
+		if err := c.SetPath("com.apple.dock", path, 1, CurrentUserAnyHost); err == nil || !strings.Contains(err.Error(), path) {
+			t.Errorf("SetPath(%q) error = %v, want an error naming the path", path, err)
+		}
+	}
+}
+
+func TestClientDeletePath(t *testing.T) {
+	c := NewClient(WithStore(NewMemoryStore()))
+	apps := []interface{}{
+		map[string]interface{}{"tile-data": map[string]interface{}{"file-label": "Safari"}},
+		map[string]interface{}{"tile-data": map[string]interface{}{"file-label": "Mail"}},
+		map[string]interface{}{"tile-data": map[string]interface{}{"file-label": "Notes"}},
+	}
+	if err := c.Set("persistent-apps", apps, "com.apple.dock", CurrentUserAnyHost); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, path := range []string{"persistent-apps.1", "persistent-apps.1.tile-data.file-label", "persistent-apps.9", "missing.0.x"} {
+		if err := c.DeletePath("com.apple.dock", path, CurrentUserAnyHost); err != nil {
+			t.Fatalf("DeletePath(%q) error = %v", path, err)
+		}
+	}
+	got, err := c.Get("persistent-apps", "com.apple.dock", CurrentUserAnyHost)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []interface{}{
+		map[string]interface{}{"tile-data": map[string]interface{}{"file-label": "Safari"}},
+		map[string]interface{}{"tile-data": map[string]interface{}{}},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("persistent-apps = %#v, want %#v", got, want)
+	}
+
+	if err := c.DeletePath("com.apple.dock", "persistent-apps", CurrentUserAnyHost); err != nil {
+		t.Fatal(err)
+	}
+	if v, _ := c.Get("persistent-apps", "com.apple.dock", CurrentUserAnyHost); v != nil {
+		t.Errorf("persistent-apps = %#v after deleting it", v)
+	}
+	if err := c.DeletePath("com.apple.dock", "persistent-apps..x", CurrentUserAnyHost); err == nil {
+		t.Error("DeletePath() with an invalid path expected error")
+	}
+}
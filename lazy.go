@@ -0,0 +1,273 @@
+package mac_prefs
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"time"
+)
+
+// LazyValue is a preference value that is converted to Go only as far as it is accessed.
+// Reading one field of a large dictionary, such as a key of com.apple.finder, with Get or Path
+// converts that field alone instead of the whole value.
+//
+// A LazyValue read from CFPreferences holds a reference to the CoreFoundation object. Close
+// releases it; every LazyValue returned by Get, Index, and Path holds its own reference and
+// must be closed as well. A LazyValue must not be used after it is closed.
+type LazyValue struct {
+	node lazyNode
+	read func(interface{}) interface{}
+}
+
+// lazyNode is the value behind a LazyValue: a CoreFoundation object or a Go value.
+type lazyNode interface {
+	// count returns the number of elements of an array or entries of a dictionary, or -1.
+	count() int
+	isDictionary() bool
+	keys() []string
+	// get returns the value of a dictionary for key, or nil if it is not present.
+	get(key string) (lazyNode, error)
+	// index returns the element of an array at i, which is in range.
+	index(i int) lazyNode
+	value() (interface{}, error)
+	close()
+}
+
+// GetLazy retrieves a preference value from one exact (user, host) slot without converting
+// it. Use the methods of the returned LazyValue to convert only the parts that are needed.
+//
+// Parameters:
+//   - key: The preference key to retrieve.
+//   - applicationID: The bundle identifier of the application for which to retrieve the preference.
+//   - scope: The PreferenceScope to read.
+//
+// Returns:
+//   - *LazyValue: The value, which must be closed, or nil if the key is not set.
+//   - error: An error if the operation fails, nil otherwise.
+func GetLazy(key string, applicationID string, scope PreferenceScope) (*LazyValue, error) {
+	return NewClient().GetLazy(key, applicationID, scope)
+}
+
+// GetLazy retrieves a preference value without converting it. Values that are not set fall
+// back to the registered defaults, and the read options of the Client apply to the values the
+// LazyValue converts. Only a Client reading CFPreferences without a cache defers the
+// conversion; other stores return values that are already converted. See GetLazy.
+func (c *Client) GetLazy(key string, applicationID string, scope PreferenceScope) (*LazyValue, error) {
+	// Read past the wrappers that do not change reads so that CFPreferences values stay
+	// unconverted, and report the read to the observers among them.
+	store := c.store
+	var observers []*observedStore
+	for unwrapped := false; !unwrapped; {
+		switch s := store.(type) {
+		case *observedStore:
+			observers = append(observers, s)
+			store = s.Store
+		case *retryStore:
+			store = s.Store
+		case readOnlyStore:
+			store = s.Store
+		case *backupStore:
+			store = s.Store
+		default:
+			unwrapped = true
+		}
+	}
+	start := time.Now()
+	node, err := copyLazy(store, key, applicationID, scope)
+	for _, s := range observers {
+		s.report("get lazy", key, applicationID, scope, start, node != nil, err)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if node == nil {
+		c.defaultsMu.RLock()
+		value := c.defaults[key]
+		c.defaultsMu.RUnlock()
+		if value == nil {
+			return nil, nil
+		}
+		node = goNode{value}
+	}
+	return &LazyValue{node: node, read: c.readValue}, nil
+}
+
+// Len returns the number of elements of an array or entries of a dictionary, or -1 for other
+// values.
+func (v *LazyValue) Len() int {
+	return v.node.count()
+}
+
+// IsDictionary reports whether the value is a dictionary.
+func (v *LazyValue) IsDictionary() bool {
+	return v.node.isDictionary()
+}
+
+// IsArray reports whether the value is an array.
+func (v *LazyValue) IsArray() bool {
+	return !v.node.isDictionary() && v.node.count() >= 0
+}
+
+// Keys returns the keys of a dictionary, sorted, or nil for other values.
+func (v *LazyValue) Keys() []string {
+	if !v.node.isDictionary() {
+		return nil
+	}
+	return v.node.keys()
+}
+
+// Get returns the value of a dictionary for key.
+//
+// Returns:
+//   - *LazyValue: The value, which must be closed, or nil if the key is not present.
+//   - error: An error if the value is not a dictionary.
+func (v *LazyValue) Get(key string) (child *LazyValue, err error) {
+	defer recoverPanic(&err, "LazyValue.Get", "", key)
+
+	if !v.node.isDictionary() {
+		return nil, fmt.Errorf("cannot get %q: value is not a dictionary", key)
+	}
+	node, err := v.node.get(key)
+	if err != nil || node == nil {
+		return nil, err
+	}
+	return &LazyValue{node: node, read: v.read}, nil
+}
+
+// Index returns the element of an array at i.
+//
+// Returns:
+//   - *LazyValue: The element, which must be closed.
+//   - error: An error if the value is not an array or i is out of range.
+func (v *LazyValue) Index(i int) (child *LazyValue, err error) {
+	defer recoverPanic(&err, "LazyValue.Index", "", strconv.Itoa(i))
+
+	if !v.IsArray() {
+		return nil, fmt.Errorf("cannot get index %d: value is not an array", i)
+	}
+	if n := v.node.count(); i < 0 || i >= n {
+		return nil, fmt.Errorf("index %d out of range for array of length %d", i, n)
+	}
+	return &LazyValue{node: v.node.index(i), read: v.read}, nil
+}
+
+// Path returns the value at a dot-separated path inside the value, such as
+// "persistent-apps.0.tile-data", with the syntax of GetPath. Only the value at the path is
+// converted.
+//
+// Returns:
+//   - *LazyValue: The value, which must be closed, or nil if a dictionary key or array index
+//     on the path is not present.
+//   - error: An error if the path is malformed or goes through a value that is not a
+//     container.
+func (v *LazyValue) Path(path string) (child *LazyValue, err error) {
+	defer recoverPanic(&err, "LazyValue.Path", "", path)
+
+	segments, err := splitPath(path)
+	if err != nil {
+		return nil, err
+	}
+
+	node := v.node
+	for i := range segments {
+		var next lazyNode
+		switch {
+		case node.isDictionary():
+			next, err = node.get(segments[i])
+		case node.count() >= 0:
+			var index int
+			if index, err = pathIndex(segments, i); err == nil && index < node.count() {
+				next = node.index(index)
+			}
+		default:
+			value, _ := node.value()
+			err = notContainerError(segments, i, value)
+		}
+		if i > 0 {
+			node.close()
+		}
+		if err != nil || next == nil {
+			return nil, err
+		}
+		node = next
+	}
+	return &LazyValue{node: node, read: v.read}, nil
+}
+
+// Value converts the whole value to Go, as Client.Get would return it.
+func (v *LazyValue) Value() (result interface{}, err error) {
+	defer recoverPanic(&err, "LazyValue.Value", "", "")
+
+	value, err := v.node.value()
+	if err != nil {
+		return nil, err
+	}
+	return v.read(value), nil
+}
+
+// Close releases the reference the LazyValue holds, if any. It always returns nil.
+func (v *LazyValue) Close() error {
+	if v != nil {
+		v.node.close()
+	}
+	return nil
+}
+
+// storeNode reads a value from store and wraps it in a goNode, or returns nil if it is not
+// set.
+func storeNode(store Store, key string, applicationID string, scope PreferenceScope) (lazyNode, error) {
+	value, err := store.Get(key, applicationID, scope)
+	if err != nil || value == nil {
+		return nil, err
+	}
+	return goNode{value}, nil
+}
+
+// goNode is a lazyNode holding a value that is already converted.
+type goNode struct {
+	v interface{}
+}
+
+func (n goNode) count() int {
+	switch v := n.v.(type) {
+	case map[string]interface{}:
+		return len(v)
+	case []interface{}:
+		return len(v)
+	default:
+		return -1
+	}
+}
+
+func (n goNode) isDictionary() bool {
+	_, ok := n.v.(map[string]interface{})
+	return ok
+}
+
+func (n goNode) keys() []string {
+	m := n.v.(map[string]interface{})
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func (n goNode) get(key string) (lazyNode, error) {
+	value, ok := n.v.(map[string]interface{})[key]
+	if !ok {
+		return nil, nil
+	}
+	return goNode{value}, nil
+}
+
+func (n goNode) index(i int) lazyNode {
+	return goNode{n.v.([]interface{})[i]}
+}
+
+func (n goNode) value() (interface{}, error) {
+	return n.v, nil
+}
+
+func (goNode) close() {}
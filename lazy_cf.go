@@ -0,0 +1,58 @@
+//go:build darwin && cgo
+
+package mac_prefs
+
+import "github.com/weswhet/mac_prefs/cf"
+
+// copyLazy reads a value for GetLazy. Values from a CFStore are left unconverted.
+func copyLazy(store Store, key string, applicationID string, scope PreferenceScope) (node lazyNode, err error) {
+	defer recoverPanic(&err, "GetLazy", applicationID, key)
+
+	s, ok := store.(CFStore)
+	if !ok {
+		return storeNode(store, key, applicationID, scope)
+	}
+	ref, err := copyValue(s.names, key, applicationID, scope)
+	if ref == nil {
+		return nil, err
+	}
+	return cfNode{ref.SetFinalizer()}, nil
+}
+
+// cfNode is a lazyNode holding a CoreFoundation object. The finalizer of its Ref releases it
+// if the LazyValue is not closed.
+type cfNode struct {
+	ref *cf.Ref
+}
+
+func (n cfNode) count() int {
+	return cf.Count(n.ref)
+}
+
+func (n cfNode) isDictionary() bool {
+	return cf.IsDictionary(n.ref)
+}
+
+func (n cfNode) keys() []string {
+	return cf.DictionaryKeys(n.ref)
+}
+
+func (n cfNode) get(key string) (lazyNode, error) {
+	ref, err := cf.DictionaryValue(n.ref, key)
+	if ref == nil {
+		return nil, err
+	}
+	return cfNode{ref.SetFinalizer()}, nil
+}
+
+func (n cfNode) index(i int) lazyNode {
+	return cfNode{cf.ArrayValue(n.ref, i).SetFinalizer()}
+}
+
+func (n cfNode) value() (interface{}, error) {
+	return cf.ToGo(n.ref)
+}
+
+func (n cfNode) close() {
+	n.ref.Release()
+}
@@ -0,0 +1,118 @@
+package mac_prefs
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestClientGetLazy(t *testing.T) {
+	c := NewClient(WithStore(NewMemoryStore()), WithNumberMode(NumberInt64))
+	value := map[string]interface{}{
+		"persistent-apps": []interface{}{
+			map[string]interface{}{"tile-data": map[string]interface{}{"file-label": "Safari"}},
+		},
+		"tilesize": 48,
+	}
+	if err := c.Set("dock", value, "com.example", CurrentUserAnyHost); err != nil {
+		t.Fatal(err)
+	}
+	testLazyValue(t, c, "dock", "com.example")
+
+	if v, err := c.GetLazy("missing", "com.example", CurrentUserAnyHost); v != nil || err != nil {
+		t.Errorf("GetLazy() of a missing key = %v, %v, want nil", v, err)
+	}
+	c.RegisterDefaults(map[string]interface{}{"missing": 1})
+	v, err := c.GetLazy("missing", "com.example", CurrentUserAnyHost)
+	if err != nil || v == nil {
+		t.Fatalf("GetLazy() of a registered default = %v, %v", v, err)
+	}
+	defer v.Close()
+	if got, _ := v.Value(); got != int64(1) {
+		t.Errorf("Value() = %#v, want the default", got)
+	}
+}
+
+// testLazyValue checks a LazyValue read from the value set by TestClientGetLazy.
+func testLazyValue(t *testing.T, c *Client, key, appID string) {
+	t.Helper()
+	v, err := c.GetLazy(key, appID, CurrentUserAnyHost)
+	if err != nil || v == nil {
+		t.Fatalf("GetLazy() = %v, %v", v, err)
+	}
+	defer v.Close()
+
+	if !v.IsDictionary() || v.IsArray() || v.Len() != 2 {
+		t.Errorf("IsDictionary() = %v, IsArray() = %v, Len() = %d", v.IsDictionary(), v.IsArray(), v.Len())
+	}
+	if got, want := v.Keys(), []string{"persistent-apps", "tilesize"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Keys() = %v, want %v", got, want)
+	}
+
+	size, err := v.Get("tilesize")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer size.Close()
+	if got, _ := size.Value(); got != int64(48) {
+		t.Errorf("Get(tilesize).Value() = %#v, want int64(48)", got)
+	}
+	if size.Len() != -1 || size.Keys() != nil {
+		t.Errorf("Len() = %d, Keys() = %v for a number", size.Len(), size.Keys())
+	}
+	if _, err := size.Get("x"); err == nil {
+		t.Error("Get() on a number expected error")
+	}
+	if _, err := size.Index(0); err == nil {
+		t.Error("Index() on a number expected error")
+	}
+
+	apps, err := v.Get("persistent-apps")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer apps.Close()
+	if !apps.IsArray() || apps.Len() != 1 {
+		t.Errorf("IsArray() = %v, Len() = %d", apps.IsArray(), apps.Len())
+	}
+	if _, err := apps.Index(1); err == nil {
+		t.Error("Index() out of range expected error")
+	}
+	first, err := apps.Index(0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer first.Close()
+	if !first.IsDictionary() {
+		t.Error("Index(0) is not a dictionary")
+	}
+
+	label, err := v.Path("persistent-apps.0.tile-data.file-label")
+	if err != nil || label == nil {
+		t.Fatalf("Path() = %v, %v", label, err)
+	}
+	defer label.Close()
+	if got, _ := label.Value(); got != "Safari" {
+		t.Errorf("Path().Value() = %#v, want Safari", got)
+	}
+	for _, path := range []string{"missing", "persistent-apps.5", "persistent-apps.0.missing.deeper"} {
+		if got, err := v.Path(path); got != nil || err != nil {
+			t.Errorf("Path(%q) = %v, %v, want nil", path, got, err)
+		}
+	}
+	for _, path := range []string{"tilesize.x", "persistent-apps.x", "a..b"} {
+		if _, err := v.Path(path); err == nil {
+			t.Errorf("Path(%q) expected error", path)
+		}
+	}
+
+	if got, missing := v.Get("missing"); got != nil || missing != nil {
+		t.Errorf("Get(missing) = %v, %v, want nil", got, missing)
+	}
+	all, err := v.Value()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := all.(map[string]interface{})["tilesize"]; got != int64(48) {
+		t.Errorf("Value() tilesize = %#v, want int64(48)", got)
+	}
+}
@@ -0,0 +1,17 @@
+package mac_prefs
+
+import "sync"
+
+// slotLocks serializes the writes this process makes to each domain slot. CFPreferences may
+// be called from several threads, but a write and the CFPreferencesSynchronize that flushes
+// it must not interleave with another write and synchronize of the same slot, or one
+// synchronize can flush the other's changes half applied. Reads are not serialized.
+var slotLocks sync.Map // of prefSlot to *sync.Mutex
+
+// lockSlot locks one slot of a domain for writing and returns the function that unlocks it.
+func lockSlot(applicationID string, scope PreferenceScope) (unlock func()) {
+	m, _ := slotLocks.LoadOrStore(prefSlot{applicationID, scope}, new(sync.Mutex))
+	mu := m.(*sync.Mutex)
+	mu.Lock()
+	return mu.Unlock
+}
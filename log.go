@@ -0,0 +1,53 @@
+package mac_prefs
+
+import (
+	"context"
+	"log/slog"
+	"strings"
+)
+
+// LevelTrace is the level WithLogger logs reads at, below slog.LevelDebug so that enabling
+// debug logging shows writes without the volume of every read.
+const LevelTrace = slog.LevelDebug - 4
+
+// WithLogger makes the Client log every read and list at LevelTrace, and every write, delete,
+// and watcher event at slog.LevelDebug. Records carry the domain, key, scope, duration, and
+// outcome of the operation, and the error if it failed. Values are not logged.
+func WithLogger(logger *slog.Logger) Option {
+	return func(c *Client) {
+		c.logger = logger
+	}
+}
+
+// logOperation returns a function that logs operations to logger.
+func logOperation(logger *slog.Logger) func(Operation) {
+	return func(op Operation) {
+		level := slog.LevelDebug
+		if strings.HasPrefix(op.Name, "get") || op.Name == "list" {
+			level = LevelTrace
+		}
+		ctx := context.Background()
+		if !logger.Enabled(ctx, level) {
+			return
+		}
+		attrs := make([]slog.Attr, 0, 8)
+		attrs = append(attrs, slog.String("op", op.Name), slog.String("domain", op.ApplicationID))
+		if op.Key != "" {
+			attrs = append(attrs, slog.String("key", op.Key))
+		}
+		attrs = append(attrs,
+			slog.String("user", string(op.Scope.User)),
+			slog.String("host", string(op.Scope.Host)),
+			slog.Duration("duration", op.Duration),
+		)
+		switch {
+		case op.Err != nil:
+			attrs = append(attrs, slog.String("outcome", "error"), slog.Any("error", op.Err))
+		case !op.Found:
+			attrs = append(attrs, slog.String("outcome", "not set"))
+		default:
+			attrs = append(attrs, slog.String("outcome", "ok"))
+		}
+		logger.LogAttrs(ctx, level, "mac_prefs: "+op.Name, attrs...)
+	}
+}
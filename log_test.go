@@ -0,0 +1,125 @@
+package mac_prefs
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"testing"
+	"time"
+)
+
+// logRecords decodes the records a JSON handler wrote to buf.
+func logRecords(t *testing.T, buf *bytes.Buffer) []map[string]interface{} {
+	t.Helper()
+	var records []map[string]interface{}
+	dec := json.NewDecoder(buf)
+	for dec.More() {
+		var record map[string]interface{}
+		if err := dec.Decode(&record); err != nil {
+			t.Fatal(err)
+		}
+		records = append(records, record)
+	}
+	return records
+}
+
+func TestClientLogger(t *testing.T) {
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewJSONHandler(&buf, &slog.HandlerOptions{Level: LevelTrace}))
+	store := failingStore{MemoryStore: NewMemoryStore(), failAppID: "com.example.fail"}
+	c := NewClient(WithStore(store), WithLogger(logger))
+
+	if err := c.Set("key", "secret", "com.example", CurrentUserAnyHost); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := c.Get("missing", "com.example", CurrentUserAnyHost); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.Delete("key", "com.example", CurrentUserAnyHost); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := c.GetAll("com.example.fail", CurrentUserAnyHost); err == nil {
+		t.Fatal("GetAll() error = nil, want an error")
+	}
+
+	want := []struct{ level, op, key, domain, outcome string }{
+		{"DEBUG", "set", "key", "com.example", "ok"},
+		{"DEBUG-4", "get", "missing", "com.example", "not set"},
+		{"DEBUG", "delete", "key", "com.example", "ok"},
+		{"DEBUG-4", "list", "", "com.example.fail", "error"},
+	}
+	got := logRecords(t, &buf)
+	if len(got) != len(want) {
+		t.Fatalf("got records %v, want %d", got, len(want))
+	}
+	for i, w := range want {
+		r := got[i]
+		key, _ := r["key"].(string)
+		if r["level"] != w.level || r["op"] != w.op || key != w.key || r["domain"] != w.domain || r["outcome"] != w.outcome {
+			t.Errorf("record %d = %v, want %+v", i, r, w)
+		}
+		if r["user"] != string(CurrentUser) || r["host"] != string(AnyHost) {
+			t.Errorf("record %d scope = %v/%v", i, r["user"], r["host"])
+		}
+		if _, ok := r["duration"]; !ok {
+			t.Errorf("record %d has no duration", i)
+		}
+	}
+	if bytes.Contains(buf.Bytes(), []byte("secret")) {
+		t.Error("log contains a preference value")
+	}
+	if got[3]["error"] != "permission denied" {
+		t.Errorf("error = %v, want permission denied", got[3]["error"])
+	}
+}
+
+func TestClientLoggerLevel(t *testing.T) {
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewJSONHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug}))
+	c := NewClient(WithStore(NewMemoryStore()), WithLogger(logger))
+
+	if err := c.Set("key", 1, "com.example", CurrentUserAnyHost); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := c.Get("key", "com.example", CurrentUserAnyHost); err != nil {
+		t.Fatal(err)
+	}
+	records := logRecords(t, &buf)
+	if len(records) != 1 || records[0]["op"] != "set" {
+		t.Errorf("records = %v, want only the set", records)
+	}
+}
+
+func TestClientLoggerWatch(t *testing.T) {
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewJSONHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug}))
+	store := NewMemoryStore()
+	c := NewClient(WithStore(store), WithLogger(logger))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	events, err := c.Watch(ctx, "com.example", CurrentUserAnyHost, time.Millisecond)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := store.Set("watched", true, "com.example", CurrentUserAnyHost); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case event := <-events:
+		if event.Key != "watched" {
+			t.Errorf("event = %+v", event)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("no event")
+	}
+	cancel()
+	for range events {
+	}
+
+	records := logRecords(t, &buf)
+	if len(records) != 2 || records[0]["op"] != "watch" || records[1]["op"] != "watch add" || records[1]["key"] != "watched" {
+		t.Errorf("records = %v, want the watch and its event", records)
+	}
+}
@@ -5,44 +5,27 @@ package mac_prefs
 /*
 #cgo LDFLAGS: -framework CoreFoundation
 #include <CoreFoundation/CoreFoundation.h>
+#include <dlfcn.h>
+
+typedef void (*flushCachesFunc)(CFStringRef, CFStringRef);
+
+// flushCachesForIdentifier calls the private _CFPreferencesFlushCachesForIdentifier, which
+// makes cfprefsd drop its cached copy of a domain. It returns false if the function is not
+// available.
+static Boolean flushCachesForIdentifier(CFStringRef appID, CFStringRef userName) {
+	flushCachesFunc flush = (flushCachesFunc)dlsym(RTLD_DEFAULT, "_CFPreferencesFlushCachesForIdentifier");
+	if (flush == NULL) {
+		return false;
+	}
+	flush(appID, userName);
+	return true;
+}
 */
 import "C"
 import (
 	"fmt"
-)
-
-// UserType represents the user scope for preferences.
-// Use the predefined constants or a literal username.
-type UserType string
-
-// HostType represents the type of host for preferences
-type HostType string
-
-// PreferenceScope defines the scope for preferences.
-// User may be a predefined constant or a literal username.
-type PreferenceScope struct {
-	User UserType
-	Host HostType
-}
 
-var (
-	// CurrentUser represents the current user's preferences
-	CurrentUser UserType = "kCFPreferencesCurrentUser"
-	// AnyUser represents preferences for any user
-	AnyUser UserType = "kCFPreferencesAnyUser"
-	// CurrentHost represents the current host's preferences
-	CurrentHost HostType = "kCFPreferencesCurrentHost"
-	// AnyHost represents preferences for any host
-	AnyHost HostType = "kCFPreferencesAnyHost"
-
-	// CurrentUserCurrentHost represents preferences for the current user on the current host
-	CurrentUserCurrentHost = PreferenceScope{User: CurrentUser, Host: CurrentHost}
-	// CurrentUserAnyHost represents preferences for the current user on any host
-	CurrentUserAnyHost = PreferenceScope{User: CurrentUser, Host: AnyHost}
-	// AnyUserCurrentHost represents preferences for any user on the current host
-	AnyUserCurrentHost = PreferenceScope{User: AnyUser, Host: CurrentHost}
-	// AnyUserAnyHost represents preferences for any user on any host
-	AnyUserAnyHost = PreferenceScope{User: AnyUser, Host: AnyHost}
+	"github.com/weswhet/mac_prefs/cf"
 )
 
 // Set sets a preference value for the given key, application ID, and preference scope.
@@ -54,56 +37,75 @@ var (
 //   - scope: The PreferenceScope defining the user and host scope for the preference.
 //
 // Returns:
-//   - error: An error if the operation fails, nil otherwise.
+//   - error: An error if the operation fails, nil otherwise. A value that ValidateValue rejects fails with a
+//     wrapped *ValueError before any CF objects are created.
 func Set(key string, value interface{}, applicationID string, scope PreferenceScope) error {
-	cKey, err := stringToCFString(key)
+	return setValue(nil, key, value, applicationID, scope)
+}
+
+// setValue sets a value like Set, interning the CFStrings of the key and application ID in
+// names.
+func setValue(names *stringCache, key string, value interface{}, applicationID string, scope PreferenceScope) (err error) {
+	defer recoverPanic(&err, "Set", applicationID, key)
+
+	if err := ValidateValue(value); err != nil {
+		return fmt.Errorf("invalid value for key %s: %w", key, err)
+	}
+
+	cKey, err := names.String(key)
 	if err != nil {
 		return fmt.Errorf("error creating CFString for key: %v", err)
 	}
-	defer release(C.CFTypeRef(cKey))
+	defer cKey.Close()
 
-	cValue, err := convertToCFType(value)
+	cValue, err := cf.FromGo(value)
 	if err != nil {
 		return fmt.Errorf("error converting value to CFType: %v", err)
 	}
-	if cValue != NilCFType {
-		defer release(cValue)
-	}
+	defer cValue.Close()
 
-	cAppID, err := stringToCFString(applicationID)
+	cAppID, err := names.String(applicationID)
 	if err != nil {
 		return fmt.Errorf("error creating CFString for applicationID: %v", err)
 	}
-	defer release(C.CFTypeRef(cAppID))
+	defer cAppID.Close()
 
-	cUserName, releaseUserName, err := resolveUserName(scope.User)
+	cUserName, err := resolveUserName(scope.User)
 	if err != nil {
 		return err
 	}
-	if releaseUserName {
-		defer release(C.CFTypeRef(cUserName))
-	}
+	defer cUserName.Close()
 
-	var cHostName C.CFStringRef
-	switch scope.Host {
-	case CurrentHost:
-		cHostName = C.kCFPreferencesCurrentHost
-	case AnyHost:
-		cHostName = C.kCFPreferencesAnyHost
-	default:
-		return fmt.Errorf("invalid host type in scope: must be CurrentHost or AnyHost")
+	cHostName, err := resolveHostName(scope.Host)
+	if err != nil {
+		return err
 	}
 
-	C.CFPreferencesSetValue(cKey, cValue, cAppID, cUserName, cHostName)
+	defer lockSlot(applicationID, scope)()
+	C.CFPreferencesSetValue(stringRef(cKey), typeRef(cValue), stringRef(cAppID), stringRef(cUserName), cHostName)
 
-	success := C.CFPreferencesSynchronize(cAppID, cUserName, cHostName)
+	success := C.CFPreferencesSynchronize(stringRef(cAppID), stringRef(cUserName), cHostName)
 	if success == C.false {
-		return fmt.Errorf("failed to synchronize preferences")
+		return ErrSynchronizeFailed
 	}
 
 	return nil
 }
 
+// Delete removes a preference key for the given application ID and preference scope.
+// It is equivalent to calling Set with a nil value.
+//
+// Parameters:
+//   - key: The preference key to remove.
+//   - applicationID: The bundle identifier of the application for which to remove the preference.
+//   - scope: The PreferenceScope defining the user and host scope for the preference.
+//
+// Returns:
+//   - error: An error if the operation fails, nil otherwise.
+func Delete(key string, applicationID string, scope PreferenceScope) error {
+	return Set(key, nil, applicationID, scope)
+}
+
 // SetApp sets a preference value for the given key and application ID using the CurrentUserAnyHost scope.
 //
 // Parameters:
@@ -113,38 +115,126 @@ func Set(key string, value interface{}, applicationID string, scope PreferenceSc
 //
 // Returns:
 //   - error: An error if the operation fails, nil otherwise.
-func SetApp(key string, value interface{}, appID string) error {
-	cKey, err := stringToCFString(key)
+func SetApp(key string, value interface{}, appID string) (err error) {
+	defer recoverPanic(&err, "SetApp", appID, key)
+
+	if err := ValidateValue(value); err != nil {
+		return fmt.Errorf("invalid value for key %s: %w", key, err)
+	}
+
+	cKey, err := cf.NewString(key)
 	if err != nil {
 		return fmt.Errorf("error creating CFString for key: %v", err)
 	}
-	defer release(C.CFTypeRef(cKey))
+	defer cKey.Close()
 
-	cValue, err := convertToCFType(value)
+	cValue, err := cf.FromGo(value)
 	if err != nil {
 		return fmt.Errorf("error converting value to CFType: %v", err)
 	}
-	if cValue != NilCFType {
-		defer release(cValue)
+	defer cValue.Close()
+
+	cAppID, err := cf.NewString(appID)
+	if err != nil {
+		return fmt.Errorf("error creating CFString for applicationID: %v", err)
+	}
+	defer cAppID.Close()
+
+	defer lockSlot(appID, CurrentUserAnyHost)()
+	C.CFPreferencesSetAppValue(stringRef(cKey), typeRef(cValue), stringRef(cAppID))
+
+	success := C.CFPreferencesAppSynchronize(stringRef(cAppID))
+	if success == C.false {
+		return ErrSynchronizeFailed
 	}
 
-	cAppID, err := stringToCFString(appID)
+	return nil
+}
+
+// SetMultiple sets and removes several preference values for the given application ID and preference scope
+// in a single CFPreferencesSetMultiple call, then synchronizes the domain once.
+//
+// Parameters:
+//   - keysToSet: The preference keys and values to set. A nil value removes the key. May be nil.
+//   - keysToRemove: The preference keys to remove. May be nil.
+//   - applicationID: The bundle identifier of the application for which to set the preferences.
+//   - scope: The PreferenceScope defining the user and host scope for the preferences.
+//
+// Returns:
+//   - error: An error if the operation fails, nil otherwise. No values are written if any value cannot be converted.
+func SetMultiple(keysToSet map[string]interface{}, keysToRemove []string, applicationID string, scope PreferenceScope) error {
+	return setMultiple(nil, keysToSet, keysToRemove, applicationID, scope)
+}
+
+// setMultiple sets and removes values like SetMultiple, interning the CFString of the
+// application ID in names.
+func setMultiple(names *stringCache, keysToSet map[string]interface{}, keysToRemove []string, applicationID string, scope PreferenceScope) (err error) {
+	defer recoverPanic(&err, "SetMultiple", applicationID, "")
+
+	values := make(map[string]interface{}, len(keysToSet))
+	for key, value := range keysToSet {
+		if value == nil || isNilPointer(value) {
+			keysToRemove = append(keysToRemove, key)
+			continue
+		}
+		values[key] = value
+	}
+	if err := ValidateValue(values); err != nil {
+		return fmt.Errorf("invalid value: %w", err)
+	}
+
+	var cKeysToSet *cf.Ref
+	if len(values) > 0 {
+		cDict, err := cf.NewDictionary(values)
+		if err != nil {
+			return fmt.Errorf("error converting values to CFDictionary: %v", err)
+		}
+		defer cDict.Close()
+		cKeysToSet = cDict
+	}
+
+	var cKeysToRemove *cf.Ref
+	if len(keysToRemove) > 0 {
+		cArray, err := cf.NewArray(keysToRemove)
+		if err != nil {
+			return fmt.Errorf("error converting keys to CFArray: %v", err)
+		}
+		defer cArray.Close()
+		cKeysToRemove = cArray
+	}
+
+	cAppID, err := names.String(applicationID)
 	if err != nil {
 		return fmt.Errorf("error creating CFString for applicationID: %v", err)
 	}
-	defer release(C.CFTypeRef(cAppID))
+	defer cAppID.Close()
+
+	cUserName, err := resolveUserName(scope.User)
+	if err != nil {
+		return err
+	}
+	defer cUserName.Close()
+
+	cHostName, err := resolveHostName(scope.Host)
+	if err != nil {
+		return err
+	}
 
-	C.CFPreferencesSetAppValue(cKey, cValue, cAppID)
+	defer lockSlot(applicationID, scope)()
+	C.CFPreferencesSetMultiple(C.CFDictionaryRef(cKeysToSet.Raw()), C.CFArrayRef(cKeysToRemove.Raw()), stringRef(cAppID), stringRef(cUserName), cHostName)
 
-	success := C.CFPreferencesAppSynchronize(cAppID)
+	success := C.CFPreferencesSynchronize(stringRef(cAppID), stringRef(cUserName), cHostName)
 	if success == C.false {
-		return fmt.Errorf("failed to synchronize preferences")
+		return ErrSynchronizeFailed
 	}
 
 	return nil
 }
 
 // Get retrieves a preference value for the given key, application ID, and preference scope.
+// Get reads exactly one (user, host) slot of the domain and does not consult the search list,
+// so values from other scopes, the global domain, or management are not visible. Use
+// GetComposite to read the value an application actually sees.
 //
 // Parameters:
 //   - key: The preference key to retrieve.
@@ -155,46 +245,108 @@ func SetApp(key string, value interface{}, appID string) error {
 //   - interface{}: The retrieved preference value. The type depends on what was originally stored.
 //   - error: An error if the operation fails, nil otherwise. Returns nil, nil if the preference is not found.
 func Get(key string, applicationID string, scope PreferenceScope) (interface{}, error) {
-	cKey, err := stringToCFString(key)
+	return getValue(nil, key, applicationID, scope)
+}
+
+// getValue retrieves a value like Get, interning the CFStrings of the key and application ID
+// in names.
+func getValue(names *stringCache, key string, applicationID string, scope PreferenceScope) (result interface{}, err error) {
+	defer recoverPanic(&err, "Get", applicationID, key)
+
+	value, err := copyValue(names, key, applicationID, scope)
+	if value == nil {
+		return nil, err // Preference not found
+	}
+	defer value.Close()
+
+	return cf.ToGo(value)
+}
+
+// GetRaw retrieves a preference value serialized as a binary property list, straight from the
+// CoreFoundation object without converting it to Go. The data is an exact copy of the stored
+// value, including types the Go conversion cannot express, for backups or forwarding.
+//
+// Parameters:
+//   - key: The preference key to retrieve.
+//   - applicationID: The bundle identifier of the application for which to retrieve the preference.
+//   - scope: The PreferenceScope defining the user and host scope for the preference.
+//
+// Returns:
+//   - []byte: The serialized value, or nil if the preference is not found.
+//   - Format: The format of the data, FormatBinary.
+//   - error: An error if the operation fails, nil otherwise.
+func GetRaw(key string, applicationID string, scope PreferenceScope) (data []byte, format Format, err error) {
+	defer recoverPanic(&err, "GetRaw", applicationID, key)
+
+	value, err := copyValue(nil, key, applicationID, scope)
+	if value == nil {
+		return nil, FormatBinary, err
+	}
+	defer value.Close()
+
+	data, err = cf.MarshalRef(value, cf.FormatBinary)
+	if err != nil {
+		return nil, FormatBinary, fmt.Errorf("error serializing value for key %s: %v", key, err)
+	}
+	return data, FormatBinary, nil
+}
+
+// Describe returns the CFCopyDescription output for a stored value, which shows its true
+// CoreFoundation type and structure. It works for any value, including ones Get cannot
+// convert, so it helps diagnose "unsupported CFTypeRef type" errors.
+//
+// Parameters:
+//   - key: The preference key to describe.
+//   - applicationID: The bundle identifier of the application for which to retrieve the preference.
+//   - scope: The PreferenceScope defining the user and host scope for the preference.
+//
+// Returns:
+//   - string: The description, or "" if the preference is not found.
+//   - error: An error if the operation fails, nil otherwise.
+func Describe(key string, applicationID string, scope PreferenceScope) (description string, err error) {
+	defer recoverPanic(&err, "Describe", applicationID, key)
+
+	value, err := copyValue(nil, key, applicationID, scope)
+	if value == nil {
+		return "", err
+	}
+	defer value.Close()
+
+	return cf.Describe(value), nil
+}
+
+// copyValue copies the CoreFoundation object stored for a key in one (user, host) slot,
+// interning the CFStrings of the key and application ID in names. It returns a nil Ref if the
+// key is not set.
+func copyValue(names *stringCache, key string, applicationID string, scope PreferenceScope) (*cf.Ref, error) {
+	cKey, err := names.String(key)
 	if err != nil {
 		return nil, fmt.Errorf("error creating CFString for key: %v", err)
 	}
-	defer release(C.CFTypeRef(cKey))
+	defer cKey.Close()
 
-	cAppID, err := stringToCFString(applicationID)
+	cAppID, err := names.String(applicationID)
 	if err != nil {
 		return nil, fmt.Errorf("error creating CFString for applicationID: %v", err)
 	}
-	defer release(C.CFTypeRef(cAppID))
+	defer cAppID.Close()
 
-	cUserName, releaseUserName, err := resolveUserName(scope.User)
+	cUserName, err := resolveUserName(scope.User)
 	if err != nil {
 		return nil, err
 	}
-	if releaseUserName {
-		defer release(C.CFTypeRef(cUserName))
-	}
-
-	var cHostName C.CFStringRef
-	switch scope.Host {
-	case CurrentHost:
-		cHostName = C.kCFPreferencesCurrentHost
-	case AnyHost:
-		cHostName = C.kCFPreferencesAnyHost
-	default:
-		return nil, fmt.Errorf("invalid host type in scope: must be CurrentHost or AnyHost")
-	}
+	defer cUserName.Close()
 
-	value := C.CFPreferencesCopyValue(cKey, cAppID, cUserName, cHostName)
-	if value == NilCFType {
-		return nil, nil // Preference not found
+	cHostName, err := resolveHostName(scope.Host)
+	if err != nil {
+		return nil, err
 	}
-	defer release(C.CFTypeRef(value))
 
-	return convertFromCFType(value)
+	return cf.Own(cf.TypeRef(C.CFPreferencesCopyValue(stringRef(cKey), stringRef(cAppID), stringRef(cUserName), cHostName))), nil
 }
 
-// GetApp retrieves a preference value for the given key and application ID using the CurrentUserAnyHost scope.
+// GetApp retrieves a preference value for the given key and application ID.
+// It is equivalent to GetComposite and resolves the value through the full search list.
 //
 // Parameters:
 //   - key: The preference key to retrieve.
@@ -204,25 +356,44 @@ func Get(key string, applicationID string, scope PreferenceScope) (interface{},
 //   - interface{}: The retrieved preference value. The type depends on what was originally stored.
 //   - error: An error if the operation fails, nil otherwise. Returns nil, nil if the preference is not found.
 func GetApp(key string, appID string) (interface{}, error) {
-	cKey, err := stringToCFString(key)
+	return GetComposite(key, appID)
+}
+
+// GetComposite retrieves the effective value of a preference as the application sees it.
+// The value is resolved through the CFPreferences search list: forced (managed) values first,
+// then the current user's ByHost and any-host values, the global domain, and finally the
+// AnyUser domains. Use Resolve to see which layer supplied the value, or Get to read a single
+// exact slot.
+//
+// Parameters:
+//   - key: The preference key to retrieve.
+//   - appID: The bundle identifier of the application for which to retrieve the preference.
+//
+// Returns:
+//   - interface{}: The effective preference value. The type depends on what was originally stored.
+//   - error: An error if the operation fails, nil otherwise. Returns nil, nil if no layer defines the preference.
+func GetComposite(key string, appID string) (result interface{}, err error) {
+	defer recoverPanic(&err, "GetComposite", appID, key)
+
+	cKey, err := cf.NewString(key)
 	if err != nil {
 		return nil, fmt.Errorf("error creating CFString for key: %v", err)
 	}
-	defer release(C.CFTypeRef(cKey))
+	defer cKey.Close()
 
-	cAppID, err := stringToCFString(appID)
+	cAppID, err := cf.NewString(appID)
 	if err != nil {
 		return nil, fmt.Errorf("error creating CFString for applicationID: %v", err)
 	}
-	defer release(C.CFTypeRef(cAppID))
+	defer cAppID.Close()
 
-	value := C.CFPreferencesCopyAppValue(cKey, cAppID)
-	if value == NilCFType {
+	value := cf.Own(cf.TypeRef(C.CFPreferencesCopyAppValue(stringRef(cKey), stringRef(cAppID))))
+	if value == nil {
 		return nil, nil // Preference not found
 	}
-	defer release(C.CFTypeRef(value))
+	defer value.Close()
 
-	return convertFromCFType(value)
+	return cf.ToGo(value)
 }
 
 // IsForcedApp reports whether an application preference value is managed or forced.
@@ -234,37 +405,288 @@ func GetApp(key string, appID string) (interface{}, error) {
 // Returns:
 //   - bool: True when the preference is forced by management, false otherwise.
 //   - error: An error if the CoreFoundation string conversion fails.
-func IsForcedApp(key string, appID string) (bool, error) {
-	cKey, err := stringToCFString(key)
+func IsForcedApp(key string, appID string) (forced bool, err error) {
+	defer recoverPanic(&err, "IsForcedApp", appID, key)
+
+	cKey, err := cf.NewString(key)
 	if err != nil {
 		return false, fmt.Errorf("error creating CFString for key: %v", err)
 	}
-	defer release(C.CFTypeRef(cKey))
+	defer cKey.Close()
 
-	cAppID, err := stringToCFString(appID)
+	cAppID, err := cf.NewString(appID)
 	if err != nil {
 		return false, fmt.Errorf("error creating CFString for applicationID: %v", err)
 	}
-	defer release(C.CFTypeRef(cAppID))
+	defer cAppID.Close()
+
+	return C.CFPreferencesAppValueIsForced(stringRef(cKey), stringRef(cAppID)) == C.true, nil
+}
+
+// Keys lists the keys stored in one exact (user, host) slot of an application's preferences.
+//
+// Parameters:
+//   - applicationID: The bundle identifier of the application whose keys to list.
+//   - scope: The PreferenceScope defining the user and host scope to list.
+//
+// Returns:
+//   - []string: The keys defined in the slot, in no particular order. Empty if the domain has no keys.
+//   - error: An error if the operation fails, nil otherwise.
+func Keys(applicationID string, scope PreferenceScope) (keys []string, err error) {
+	defer recoverPanic(&err, "Keys", applicationID, "")
+
+	cAppID, err := cf.NewString(applicationID)
+	if err != nil {
+		return nil, fmt.Errorf("error creating CFString for applicationID: %v", err)
+	}
+	defer cAppID.Close()
+
+	cUserName, err := resolveUserName(scope.User)
+	if err != nil {
+		return nil, err
+	}
+	defer cUserName.Close()
+
+	cHostName, err := resolveHostName(scope.Host)
+	if err != nil {
+		return nil, err
+	}
+
+	keyList := cf.Own(cf.TypeRef(C.CFPreferencesCopyKeyList(stringRef(cAppID), stringRef(cUserName), cHostName)))
+	if keyList == nil {
+		return []string{}, nil
+	}
+	defer keyList.Close()
+
+	return cf.GoStrings(keyList), nil
+}
+
+// GetAll retrieves every key and value defined in one exact (user, host) slot of a domain.
+// Like Get, it does not consult the search list.
+//
+// Parameters:
+//   - applicationID: The bundle identifier of the application whose preferences to read.
+//   - scope: The PreferenceScope defining the user and host scope to read.
+//
+// Returns:
+//   - map[string]interface{}: The keys and values defined in the slot. Empty if the domain has no keys.
+//   - error: An error if the operation fails, nil otherwise.
+func GetAll(applicationID string, scope PreferenceScope) (map[string]interface{}, error) {
+	return getAll(nil, applicationID, scope)
+}
+
+// getAll retrieves every key and value like GetAll, interning the CFString of the application
+// ID in names.
+func getAll(names *stringCache, applicationID string, scope PreferenceScope) (result map[string]interface{}, err error) {
+	defer recoverPanic(&err, "GetAll", applicationID, "")
+
+	cAppID, err := names.String(applicationID)
+	if err != nil {
+		return nil, fmt.Errorf("error creating CFString for applicationID: %v", err)
+	}
+	defer cAppID.Close()
+
+	cUserName, err := resolveUserName(scope.User)
+	if err != nil {
+		return nil, err
+	}
+	defer cUserName.Close()
+
+	cHostName, err := resolveHostName(scope.Host)
+	if err != nil {
+		return nil, err
+	}
+
+	cValues := cf.Own(cf.TypeRef(C.CFPreferencesCopyMultiple(NilCFArray, stringRef(cAppID), stringRef(cUserName), cHostName)))
+	if cValues == nil {
+		return map[string]interface{}{}, nil
+	}
+	defer cValues.Close()
+
+	values, err := cf.ToGo(cValues)
+	if err != nil {
+		return nil, fmt.Errorf("error converting preferences: %v", err)
+	}
+	return values.(map[string]interface{}), nil
+}
+
+// GetMany retrieves several keys from one exact (user, host) slot of a domain with a single
+// CFPreferencesCopyMultiple call, which is much cheaper than calling Get for each key when
+// reading dozens of keys per domain. Like Get, it does not consult the search list.
+//
+// Parameters:
+//   - keys: The preference keys to retrieve.
+//   - applicationID: The bundle identifier of the application whose preferences to read.
+//   - scope: The PreferenceScope defining the user and host scope to read.
+//
+// Returns:
+//   - map[string]interface{}: The values of the keys that are set. Keys that are not set are left out.
+//   - error: An error if the operation fails, nil otherwise.
+func GetMany(keys []string, applicationID string, scope PreferenceScope) (map[string]interface{}, error) {
+	return getMultiple(nil, keys, applicationID, scope)
+}
+
+// getMultiple retrieves several keys like GetMany, interning the CFString of the application
+// ID in names.
+func getMultiple(names *stringCache, keys []string, applicationID string, scope PreferenceScope) (result map[string]interface{}, err error) {
+	defer recoverPanic(&err, "GetMany", applicationID, "")
+
+	if len(keys) == 0 {
+		return map[string]interface{}{}, nil
+	}
+
+	cKeys, err := cf.NewArray(keys)
+	if err != nil {
+		return nil, fmt.Errorf("error creating CFArray for keys: %v", err)
+	}
+	defer cKeys.Close()
+
+	cAppID, err := names.String(applicationID)
+	if err != nil {
+		return nil, fmt.Errorf("error creating CFString for applicationID: %v", err)
+	}
+	defer cAppID.Close()
+
+	cUserName, err := resolveUserName(scope.User)
+	if err != nil {
+		return nil, err
+	}
+	defer cUserName.Close()
+
+	cHostName, err := resolveHostName(scope.Host)
+	if err != nil {
+		return nil, err
+	}
+
+	cValues := cf.Own(cf.TypeRef(C.CFPreferencesCopyMultiple(C.CFArrayRef(cKeys.Raw()), stringRef(cAppID), stringRef(cUserName), cHostName)))
+	if cValues == nil {
+		return map[string]interface{}{}, nil
+	}
+	defer cValues.Close()
+
+	values, err := cf.ToGo(cValues)
+	if err != nil {
+		return nil, fmt.Errorf("error converting preferences: %v", err)
+	}
+	return values.(map[string]interface{}), nil
+}
+
+// stringCache interns the CFStrings of keys and application IDs for a CFStore. A nil
+// stringCache creates new strings on every call.
+type stringCache = cf.StringCache
 
-	return C.CFPreferencesAppValueIsForced(cKey, cAppID) == C.true, nil
+func newStringCache(capacity int) *stringCache {
+	return cf.NewStringCache(capacity)
 }
 
-func resolveUserName(userName UserType) (C.CFStringRef, bool, error) {
+// resolveUserName returns the CFString naming a user for CFPreferences. The Ref must be closed.
+func resolveUserName(userName UserType) (*cf.Ref, error) {
 	switch userName {
 	case CurrentUser:
-		return C.kCFPreferencesCurrentUser, false, nil
+		return cf.Retain(cf.TypeRef(C.kCFPreferencesCurrentUser)), nil
 	case AnyUser:
-		return C.kCFPreferencesAnyUser, false, nil
+		return cf.Retain(cf.TypeRef(C.kCFPreferencesAnyUser)), nil
 	default:
-		cUserName, err := stringToCFString(string(userName))
+		cUserName, err := cf.NewString(string(userName))
 		if err != nil {
-			return NilCFString, false, fmt.Errorf("error creating CFString for userName: %v", err)
+			return nil, fmt.Errorf("error creating CFString for userName: %v", err)
 		}
-		return cUserName, true, nil
+		return cUserName, nil
 	}
 }
 
-func releaseCFString(ref C.CFStringRef) {
-	release(C.CFTypeRef(ref))
+func resolveHostName(hostName HostType) (C.CFStringRef, error) {
+	switch hostName {
+	case CurrentHost:
+		return C.kCFPreferencesCurrentHost, nil
+	case AnyHost:
+		return C.kCFPreferencesAnyHost, nil
+	default:
+		return NilCFString, fmt.Errorf("invalid host type in scope: must be CurrentHost or AnyHost")
+	}
+}
+
+// applicationList returns the application IDs with preferences stored in scope, as reported by
+// CFPreferencesCopyApplicationList.
+func applicationList(scope PreferenceScope) (domains []string, err error) {
+	defer recoverPanic(&err, "ListDomains", "", "")
+
+	cUserName, err := resolveUserName(scope.User)
+	if err != nil {
+		return nil, err
+	}
+	defer cUserName.Close()
+
+	cHostName, err := resolveHostName(scope.Host)
+	if err != nil {
+		return nil, err
+	}
+
+	list := cf.Own(cf.TypeRef(C.CFPreferencesCopyApplicationList(stringRef(cUserName), cHostName)))
+	if list == nil {
+		return []string{}, nil
+	}
+	defer list.Close()
+
+	return cf.GoStrings(list), nil
+}
+
+// flushCaches synchronizes a domain slot and asks cfprefsd to drop its cached copy of the
+// domain. It reports whether cfprefsd could be asked; if not, only the caches of this process
+// were discarded.
+func flushCaches(appID string, scope PreferenceScope) (flushed bool, err error) {
+	defer recoverPanic(&err, "FlushDomainCache", appID, "")
+
+	cAppID, err := cf.NewString(appID)
+	if err != nil {
+		return false, fmt.Errorf("error creating CFString for applicationID: %v", err)
+	}
+	defer cAppID.Close()
+
+	cUserName, err := resolveUserName(scope.User)
+	if err != nil {
+		return false, err
+	}
+	defer cUserName.Close()
+
+	cHostName, err := resolveHostName(scope.Host)
+	if err != nil {
+		return false, err
+	}
+
+	defer lockSlot(appID, scope)()
+	if C.CFPreferencesSynchronize(stringRef(cAppID), stringRef(cUserName), cHostName) == C.false {
+		return false, ErrSynchronizeFailed
+	}
+	return C.flushCachesForIdentifier(stringRef(cAppID), stringRef(cUserName)) != C.false, nil
+}
+
+// readFresh synchronizes a domain, discarding values this process has cached, and reads it.
+func readFresh(appID string, scope PreferenceScope) (result map[string]interface{}, err error) {
+	defer recoverPanic(&err, "Synchronize", appID, "")
+
+	cAppID, err := cf.NewString(appID)
+	if err != nil {
+		return nil, fmt.Errorf("error creating CFString for applicationID: %v", err)
+	}
+	defer cAppID.Close()
+
+	cUserName, err := resolveUserName(scope.User)
+	if err != nil {
+		return nil, err
+	}
+	defer cUserName.Close()
+
+	cHostName, err := resolveHostName(scope.Host)
+	if err != nil {
+		return nil, err
+	}
+
+	unlock := lockSlot(appID, scope)
+	synchronized := C.CFPreferencesSynchronize(stringRef(cAppID), stringRef(cUserName), cHostName)
+	unlock()
+	if synchronized == C.false {
+		return nil, ErrSynchronizeFailed
+	}
+	return GetAll(appID, scope)
 }
@@ -1,12 +1,20 @@
-//go:build darwin
+//go:build darwin && cgo
 
 package mac_prefs
 
 import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
 	"os/user"
 	"reflect"
+	"strings"
+	"sync"
 	"testing"
 	"time"
+
+	"github.com/weswhet/mac_prefs/cf"
 )
 
 const testAppID = "com.github.weswhet.mac_prefs.test"
@@ -254,52 +262,41 @@ func TestGetApp(t *testing.T) {
 
 func TestResolveUserName(t *testing.T) {
 	tests := []struct {
-		name          string
-		user          UserType
-		wantRelease   bool
-		wantValue     string
-		checkCFString bool
+		name      string
+		user      UserType
+		wantValue string
 	}{
 		{
-			name:        "current user constant",
-			user:        CurrentUser,
-			wantRelease: false,
+			name:      "current user constant",
+			user:      CurrentUser,
+			wantValue: "kCFPreferencesCurrentUser",
 		},
 		{
-			name:        "any user constant",
-			user:        AnyUser,
-			wantRelease: false,
+			name:      "any user constant",
+			user:      AnyUser,
+			wantValue: "kCFPreferencesAnyUser",
 		},
 		{
-			name:          "literal username",
-			user:          UserType("alice"),
-			wantRelease:   true,
-			wantValue:     "alice",
-			checkCFString: true,
+			name:      "literal username",
+			user:      UserType("alice"),
+			wantValue: "alice",
 		},
 		{
-			name:          "empty literal username",
-			user:          UserType(""),
-			wantRelease:   true,
-			wantValue:     "",
-			checkCFString: true,
+			name:      "empty literal username",
+			user:      UserType(""),
+			wantValue: "",
 		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			got, releaseRef, err := resolveUserName(tt.user)
+			got, err := resolveUserName(tt.user)
 			if err != nil {
 				t.Fatalf("resolveUserName() error = %v", err)
 			}
-			if releaseRef != tt.wantRelease {
-				t.Fatalf("resolveUserName() releaseRef = %v, want %v", releaseRef, tt.wantRelease)
-			}
-			if tt.checkCFString {
-				defer releaseCFString(got)
-				if gotValue := cfStringToString(got); gotValue != tt.wantValue {
-					t.Fatalf("resolveUserName() CFString = %q, want %q", gotValue, tt.wantValue)
-				}
+			defer got.Close()
+			if gotValue := cf.GoString(got); gotValue != tt.wantValue {
+				t.Fatalf("resolveUserName() CFString = %q, want %q", gotValue, tt.wantValue)
 			}
 		})
 	}
@@ -394,8 +391,11 @@ func TestSetAppSupportsUnsignedIntegers(t *testing.T) {
 func TestSetAppRejectsOverflowingUnsignedIntegers(t *testing.T) {
 	const key = "TestAppUintOverflowKey"
 
-	if err := SetApp(key, uint64(1<<63), testAppID); err == nil {
-		t.Fatal("SetApp() expected overflow error for uint64 value above MaxInt64")
+	if err := SetApp(key, uint64(1<<63), testAppID); !errors.Is(err, ErrUnsignedOverflow) {
+		t.Fatalf("SetApp() error = %v, want ErrUnsignedOverflow for uint64 value above MaxInt64", err)
+	}
+	if got, _ := GetApp(key, testAppID); got != nil {
+		t.Fatalf("GetApp() = %v, want nil after a rejected write", got)
 	}
 }
 
@@ -508,3 +508,757 @@ func TestIsForcedApp(t *testing.T) {
 		})
 	}
 }
+
+func TestGetCompositeUsesSearchList(t *testing.T) {
+	const key = "TestCompositeKey"
+
+	if err := Set(key, "global", AnyApplication, CurrentUserAnyHost); err != nil {
+		t.Fatalf("Set() global error = %v", err)
+	}
+	defer func() {
+		if err := Set(key, nil, AnyApplication, CurrentUserAnyHost); err != nil {
+			t.Fatalf("cleanup Set() global error = %v", err)
+		}
+		if err := Set(key, nil, testAppID, CurrentUserAnyHost); err != nil {
+			t.Fatalf("cleanup Set() app error = %v", err)
+		}
+	}()
+
+	exact, err := Get(key, testAppID, CurrentUserAnyHost)
+	if err != nil {
+		t.Fatalf("Get() error = %v", err)
+	}
+	if exact != nil {
+		t.Fatalf("Get() got = %v, want nil for an exact slot without the key", exact)
+	}
+
+	composite, err := GetComposite(key, testAppID)
+	if err != nil {
+		t.Fatalf("GetComposite() error = %v", err)
+	}
+	if composite != "global" {
+		t.Fatalf("GetComposite() got = %v, want global domain value", composite)
+	}
+
+	if err := Set(key, "app", testAppID, CurrentUserAnyHost); err != nil {
+		t.Fatalf("Set() app error = %v", err)
+	}
+	composite, err = GetComposite(key, testAppID)
+	if err != nil {
+		t.Fatalf("GetComposite() error = %v", err)
+	}
+	if composite != "app" {
+		t.Fatalf("GetComposite() got = %v, want application value to win over global", composite)
+	}
+}
+
+func TestSetMultiple(t *testing.T) {
+	scope := CurrentUserCurrentHost
+
+	if err := Set("TestMultipleRemoveKey", "stale", testAppID, scope); err != nil {
+		t.Fatalf("Set() setup error = %v", err)
+	}
+	if err := Set("TestMultipleNilKey", "stale", testAppID, scope); err != nil {
+		t.Fatalf("Set() setup error = %v", err)
+	}
+
+	err := SetMultiple(map[string]interface{}{
+		"TestMultipleStringKey": "value",
+		"TestMultipleIntKey":    7,
+		"TestMultipleNilKey":    nil,
+	}, []string{"TestMultipleRemoveKey"}, testAppID, scope)
+	if err != nil {
+		t.Fatalf("SetMultiple() error = %v", err)
+	}
+	defer SetMultiple(nil, []string{"TestMultipleStringKey", "TestMultipleIntKey"}, testAppID, scope)
+
+	for key, want := range map[string]interface{}{
+		"TestMultipleStringKey": "value",
+		"TestMultipleIntKey":    7,
+		"TestMultipleNilKey":    nil,
+		"TestMultipleRemoveKey": nil,
+	} {
+		got, err := Get(key, testAppID, scope)
+		if err != nil {
+			t.Fatalf("Get(%s) error = %v", key, err)
+		}
+		if got != want {
+			t.Fatalf("Get(%s) got = %v, want %v", key, got, want)
+		}
+	}
+}
+
+func TestGetMany(t *testing.T) {
+	scope := CurrentUserAnyHost
+	values := map[string]interface{}{"TestGetManyString": "value", "TestGetManyInt": 7}
+	if err := SetMultiple(values, nil, testAppID, scope); err != nil {
+		t.Fatalf("SetMultiple() error = %v", err)
+	}
+	defer SetMultiple(nil, []string{"TestGetManyString", "TestGetManyInt"}, testAppID, scope)
+
+	got, err := GetMany([]string{"TestGetManyString", "TestGetManyInt", "TestGetManyMissing"}, testAppID, scope)
+	if err != nil {
+		t.Fatalf("GetMany() error = %v", err)
+	}
+	if !reflect.DeepEqual(got, values) {
+		t.Errorf("GetMany() = %#v, want %#v", got, values)
+	}
+	if got, err := GetMany(nil, testAppID, scope); err != nil || len(got) != 0 {
+		t.Errorf("GetMany(nil) = %#v, %v, want an empty map", got, err)
+	}
+}
+
+func TestSetMultipleNestedNil(t *testing.T) {
+	const key = "TestMultipleNestedNilKey"
+	if err := SetMultiple(map[string]interface{}{key: []interface{}{"a", nil}}, nil, testAppID, CurrentUserCurrentHost); err != nil {
+		t.Fatalf("SetMultiple() error = %v", err)
+	}
+	defer Delete(key, testAppID, CurrentUserCurrentHost)
+
+	if got, _ := Get(key, testAppID, CurrentUserCurrentHost); !reflect.DeepEqual(got, []interface{}{"a", nil}) {
+		t.Fatalf("Get() = %#v, want the nil element read back", got)
+	}
+}
+
+func TestSetReportsInvalidElementPath(t *testing.T) {
+	value := map[string]interface{}{"Servers": []interface{}{"a", map[string]interface{}{"Port": make(chan int)}}}
+	err := Set("TestInvalidElementKey", value, testAppID, CurrentUserCurrentHost)
+	var valueErr *ValueError
+	if !errors.As(err, &valueErr) || valueErr.Path != "Servers[1].Port" {
+		t.Fatalf("Set() error = %v, want a *ValueError at Servers[1].Port", err)
+	}
+	if got, _ := Get("TestInvalidElementKey", testAppID, CurrentUserCurrentHost); got != nil {
+		t.Fatalf("Get() = %v, want nil after a rejected Set", got)
+	}
+}
+
+func TestSetSupportsEmptyCollections(t *testing.T) {
+	values := map[string]interface{}{
+		"TestEmptySliceKey":  []interface{}{},
+		"TestNilSliceKey":    []string(nil),
+		"TestEmptyMapKey":    map[string]interface{}{},
+		"TestNestedEmptyKey": map[string]interface{}{"list": []interface{}{}, "dict": map[string]interface{}{}},
+	}
+	want := map[string]interface{}{
+		"TestEmptySliceKey":  []interface{}{},
+		"TestNilSliceKey":    []interface{}{},
+		"TestEmptyMapKey":    map[string]interface{}{},
+		"TestNestedEmptyKey": map[string]interface{}{"list": []interface{}{}, "dict": map[string]interface{}{}},
+	}
+
+	for key, value := range values {
+		if err := Set(key, value, testAppID, CurrentUserAnyHost); err != nil {
+			t.Fatalf("Set(%s) error = %v", key, err)
+		}
+		defer Delete(key, testAppID, CurrentUserAnyHost)
+
+		got, err := Get(key, testAppID, CurrentUserAnyHost)
+		if err != nil {
+			t.Fatalf("Get(%s) error = %v", key, err)
+		}
+		if !reflect.DeepEqual(got, want[key]) {
+			t.Fatalf("Get(%s) = %#v, want %#v", key, got, want[key])
+		}
+	}
+
+	if err := SetMultiple(values, nil, testAppID, CurrentUserCurrentHost); err != nil {
+		t.Fatalf("SetMultiple() error = %v", err)
+	}
+	defer SetMultiple(nil, []string{"TestEmptySliceKey", "TestNilSliceKey", "TestEmptyMapKey", "TestNestedEmptyKey"}, testAppID, CurrentUserCurrentHost)
+	got, err := GetAll(testAppID, CurrentUserCurrentHost)
+	if err != nil {
+		t.Fatalf("GetAll() error = %v", err)
+	}
+	for key := range values {
+		if !reflect.DeepEqual(got[key], want[key]) {
+			t.Fatalf("GetAll()[%s] = %#v, want %#v", key, got[key], want[key])
+		}
+	}
+}
+
+func TestSetPreservesFloatType(t *testing.T) {
+	for _, value := range []float64{0, -1, -0.5, 2} {
+		const key = "TestFloatFidelityKey"
+		if err := Set(key, value, testAppID, CurrentUserAnyHost); err != nil {
+			t.Fatalf("Set(%v) error = %v", value, err)
+		}
+		got, err := Get(key, testAppID, CurrentUserAnyHost)
+		if err != nil {
+			t.Fatalf("Get() error = %v", err)
+		}
+		if got != value {
+			t.Fatalf("Get() = %#v (%T), want float64 %v", got, got, value)
+		}
+	}
+	Delete("TestFloatFidelityKey", testAppID, CurrentUserAnyHost)
+}
+
+func TestSetGetDuration(t *testing.T) {
+	const key = "TestDurationKey"
+	if err := Set(key, 1500*time.Millisecond, testAppID, CurrentUserAnyHost); err != nil {
+		t.Fatalf("Set() error = %v", err)
+	}
+	defer Delete(key, testAppID, CurrentUserAnyHost)
+
+	if got, _ := Get(key, testAppID, CurrentUserAnyHost); got != 1.5 {
+		t.Fatalf("Get() = %#v, want 1.5 seconds", got)
+	}
+	d, ok, err := GetDuration(key, testAppID, CurrentUserAnyHost)
+	if err != nil || !ok || d != 1500*time.Millisecond {
+		t.Fatalf("GetDuration() = %v, %v, %v, want 1.5s", d, ok, err)
+	}
+}
+
+func TestSetGetTypedCollections(t *testing.T) {
+	const key = "TestTypedKey"
+	defer Delete(key, testAppID, CurrentUserAnyHost)
+
+	if err := Set(key, []string{"a", "b"}, testAppID, CurrentUserAnyHost); err != nil {
+		t.Fatalf("Set([]string) error = %v", err)
+	}
+	strs, ok, err := GetStrings(key, testAppID, CurrentUserAnyHost)
+	if err != nil || !ok || !reflect.DeepEqual(strs, []string{"a", "b"}) {
+		t.Fatalf("GetStrings() = %v, %v, %v", strs, ok, err)
+	}
+
+	if err := Set(key, []float64{1.5, 2}, testAppID, CurrentUserAnyHost); err != nil {
+		t.Fatalf("Set([]float64) error = %v", err)
+	}
+	floats, ok, err := GetFloats(key, testAppID, CurrentUserAnyHost)
+	if err != nil || !ok || !reflect.DeepEqual(floats, []float64{1.5, 2}) {
+		t.Fatalf("GetFloats() = %v, %v, %v", floats, ok, err)
+	}
+
+	if err := Set(key, map[string]string{"a": "x"}, testAppID, CurrentUserAnyHost); err != nil {
+		t.Fatalf("Set(map[string]string) error = %v", err)
+	}
+	sm, ok, err := GetStringMap(key, testAppID, CurrentUserAnyHost)
+	if err != nil || !ok || !reflect.DeepEqual(sm, map[string]string{"a": "x"}) {
+		t.Fatalf("GetStringMap() = %v, %v, %v", sm, ok, err)
+	}
+}
+
+func TestSetStruct(t *testing.T) {
+	type server struct {
+		Host string `prefs:"host"`
+		Port int    `prefs:"port,omitempty"`
+	}
+	const key = "TestStructKey"
+	value := struct {
+		Name    string
+		Servers []server `prefs:"servers"`
+		Secret  string   `prefs:"-"`
+	}{Name: "test", Servers: []server{{Host: "a", Port: 22}, {Host: "b"}}, Secret: "x"}
+	if err := Set(key, value, testAppID, CurrentUserAnyHost); err != nil {
+		t.Fatalf("Set() error = %v", err)
+	}
+	defer Delete(key, testAppID, CurrentUserAnyHost)
+
+	got, err := Get(key, testAppID, CurrentUserAnyHost)
+	if err != nil {
+		t.Fatalf("Get() error = %v", err)
+	}
+	want := map[string]interface{}{
+		"Name": "test",
+		"servers": []interface{}{
+			map[string]interface{}{"host": "a", "port": 22},
+			map[string]interface{}{"host": "b"},
+		},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("Get() = %#v, want %#v", got, want)
+	}
+}
+
+func TestSetGetMarshaler(t *testing.T) {
+	const key = "TestMarshalerKey"
+	if err := Set(key, testVersion{3, 4}, testAppID, CurrentUserAnyHost); err != nil {
+		t.Fatalf("Set() error = %v", err)
+	}
+	defer Delete(key, testAppID, CurrentUserAnyHost)
+
+	if got, _ := Get(key, testAppID, CurrentUserAnyHost); got != "3.4" {
+		t.Fatalf("Get() = %#v, want \"3.4\"", got)
+	}
+	var v testVersion
+	if ok, err := GetInto(key, testAppID, CurrentUserAnyHost, &v); err != nil || !ok || v != (testVersion{3, 4}) {
+		t.Fatalf("GetInto() = %v, %v, %+v", ok, err, v)
+	}
+}
+
+func TestSetJSONNumber(t *testing.T) {
+	const key = "TestJSONNumberKey"
+	defer Delete(key, testAppID, CurrentUserAnyHost)
+
+	if err := Set(key, json.Number("48"), testAppID, CurrentUserAnyHost); err != nil {
+		t.Fatalf("Set() error = %v", err)
+	}
+	if got, _ := Get(key, testAppID, CurrentUserAnyHost); got != 48 {
+		t.Fatalf("Get() = %#v, want 48", got)
+	}
+	if err := Set(key, json.Number("0.5"), testAppID, CurrentUserAnyHost); err != nil {
+		t.Fatalf("Set() error = %v", err)
+	}
+	if got, _ := Get(key, testAppID, CurrentUserAnyHost); got != 0.5 {
+		t.Fatalf("Get() = %#v, want 0.5", got)
+	}
+}
+
+func TestSetPointers(t *testing.T) {
+	const key = "TestPointerKey"
+	enabled := true
+	if err := Set(key, &enabled, testAppID, CurrentUserAnyHost); err != nil {
+		t.Fatalf("Set() error = %v", err)
+	}
+	if got, _ := Get(key, testAppID, CurrentUserAnyHost); got != true {
+		t.Fatalf("Get() = %#v, want true", got)
+	}
+
+	if err := Set(key, (*bool)(nil), testAppID, CurrentUserAnyHost); err != nil {
+		t.Fatalf("Set(nil pointer) error = %v", err)
+	}
+	if got, _ := Get(key, testAppID, CurrentUserAnyHost); got != nil {
+		t.Fatalf("Get() after setting a nil pointer = %#v, want nil", got)
+	}
+}
+
+func TestGetNullElements(t *testing.T) {
+	const key = "TestNullKey"
+	value := map[string]interface{}{"tiles": []interface{}{"a", Null{}}, "label": Null{}}
+	if err := Set(key, value, testAppID, CurrentUserAnyHost); err != nil {
+		t.Fatalf("Set() error = %v", err)
+	}
+	defer Delete(key, testAppID, CurrentUserAnyHost)
+
+	got, err := Get(key, testAppID, CurrentUserAnyHost)
+	if err != nil {
+		t.Fatalf("Get() error = %v", err)
+	}
+	want := map[string]interface{}{"tiles": []interface{}{"a", nil}, "label": nil}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("Get() = %#v, want %#v", got, want)
+	}
+}
+
+func TestNullElementsRoundTrip(t *testing.T) {
+	const appID = testAppID + ".null"
+	scope := CurrentUserAnyHost
+	value := map[string]interface{}{"tiles": []interface{}{"a", Null{}}, "label": Null{}}
+	if err := Set("persistent-apps", value, appID, scope); err != nil {
+		t.Fatalf("Set() error = %v", err)
+	}
+	defer Delete("persistent-apps", appID, scope)
+	defer Delete("copy", appID, scope)
+
+	if err := SetPath(appID, "persistent-apps.tiles.0", "b", scope); err != nil {
+		t.Fatalf("SetPath() on a value holding a null error = %v", err)
+	}
+	snapshot, err := TakeSnapshot(appID, scope)
+	if err != nil {
+		t.Fatalf("TakeSnapshot() error = %v", err)
+	}
+	if err := Set("copy", snapshot.Values["persistent-apps"], appID, scope); err != nil {
+		t.Fatalf("Set() of a read value holding a null error = %v", err)
+	}
+	if err := snapshot.Restore(); err != nil {
+		t.Fatalf("Restore() error = %v", err)
+	}
+
+	got, err := GetAll(appID, scope)
+	if err != nil {
+		t.Fatalf("GetAll() error = %v", err)
+	}
+	want := map[string]interface{}{"tiles": []interface{}{"b", nil}, "label": nil}
+	if !reflect.DeepEqual(got, map[string]interface{}{"persistent-apps": want}) {
+		t.Fatalf("GetAll() = %#v, want %#v", got, want)
+	}
+}
+
+func TestSetGetURL(t *testing.T) {
+	const key = "TestURLKey"
+	u, _ := url.Parse("https://example.com/support")
+	if err := Set(key, u, testAppID, CurrentUserAnyHost); err != nil {
+		t.Fatalf("Set() error = %v", err)
+	}
+	defer Delete(key, testAppID, CurrentUserAnyHost)
+
+	got, err := Get(key, testAppID, CurrentUserAnyHost)
+	if err != nil {
+		t.Fatalf("Get() error = %v", err)
+	}
+	if got, ok := got.(*url.URL); !ok || got.String() != u.String() {
+		t.Fatalf("Get() = %#v, want %v", got, u)
+	}
+}
+
+func TestSetGetUUID(t *testing.T) {
+	const key = "TestUUIDKey"
+	u := [16]byte{0x68, 0x75, 0x3a, 0x44, 0x4d, 0x6f, 0x12, 0x26, 0x9c, 0x60, 0x00, 0x50, 0xe4, 0xc0, 0x00, 0x67}
+	if err := Set(key, u, testAppID, CurrentUserAnyHost); err != nil {
+		t.Fatalf("Set() error = %v", err)
+	}
+	defer Delete(key, testAppID, CurrentUserAnyHost)
+
+	if got, _ := Get(key, testAppID, CurrentUserAnyHost); got != "68753A44-4D6F-1226-9C60-0050E4C00067" {
+		t.Fatalf("Get() = %#v, want the canonical UUID string", got)
+	}
+	if got, ok, err := GetUUID(key, testAppID, CurrentUserAnyHost); err != nil || !ok || got != u {
+		t.Fatalf("GetUUID() = %X, %v, %v", got, ok, err)
+	}
+}
+
+func TestGetRaw(t *testing.T) {
+	const key = "TestRawKey"
+	value := map[string]interface{}{"name": "dock", "sizes": []interface{}{1, 2.5}}
+	if err := Set(key, value, testAppID, CurrentUserAnyHost); err != nil {
+		t.Fatalf("Set() error = %v", err)
+	}
+	defer Delete(key, testAppID, CurrentUserAnyHost)
+
+	data, format, err := GetRaw(key, testAppID, CurrentUserAnyHost)
+	if err != nil || format != FormatBinary {
+		t.Fatalf("GetRaw() = %v, %v", format, err)
+	}
+	got, err := cf.ParsePlist(data)
+	if err != nil || !reflect.DeepEqual(got, value) {
+		t.Fatalf("ParsePlist(GetRaw()) = %#v, %v, want %#v", got, err, value)
+	}
+
+	if data, _, err := GetRaw("TestRawMissing", testAppID, CurrentUserAnyHost); data != nil || err != nil {
+		t.Fatalf("GetRaw(missing) = %v, %v, want nil, nil", data, err)
+	}
+}
+
+func TestDescribe(t *testing.T) {
+	const key = "TestDescribeKey"
+	if err := Set(key, []interface{}{"tile"}, testAppID, CurrentUserAnyHost); err != nil {
+		t.Fatalf("Set() error = %v", err)
+	}
+	defer Delete(key, testAppID, CurrentUserAnyHost)
+
+	desc, err := Describe(key, testAppID, CurrentUserAnyHost)
+	if err != nil || !strings.Contains(desc, "tile") {
+		t.Fatalf("Describe() = %q, %v, want a description containing the element", desc, err)
+	}
+	if desc, err := Describe("TestDescribeMissing", testAppID, CurrentUserAnyHost); desc != "" || err != nil {
+		t.Fatalf("Describe(missing) = %q, %v, want \"\", nil", desc, err)
+	}
+}
+
+func TestClientStringCache(t *testing.T) {
+	const key = "TestStringCacheKey"
+	c := NewClient(WithStringCache(8))
+	defer c.Delete(key, testAppID, CurrentUserAnyHost)
+
+	for i := 0; i < 3; i++ {
+		if err := c.Set(key, i, testAppID, CurrentUserAnyHost); err != nil {
+			t.Fatalf("Set() error = %v", err)
+		}
+		if got, err := c.Get(key, testAppID, CurrentUserAnyHost); err != nil || got != i {
+			t.Fatalf("Get() = %#v, %v, want %d", got, err, i)
+		}
+	}
+	if n := c.names.Len(); n != 2 {
+		t.Errorf("interned %d strings, want the key and application ID", n)
+	}
+	if err := c.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+	if n := c.names.Len(); n != 0 {
+		t.Errorf("%d strings still interned after Close", n)
+	}
+	if got, err := c.Get(key, testAppID, CurrentUserAnyHost); err != nil || got != 2 {
+		t.Errorf("Get() after Close = %#v, %v, want 2", got, err)
+	}
+}
+
+func TestConcurrentWrites(t *testing.T) {
+	const workers = 8
+	c := NewClient()
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func(w int) {
+			defer wg.Done()
+			key := fmt.Sprintf("TestConcurrentKey%d", w)
+			for i := 0; i < 20; i++ {
+				if err := c.Set(key, i, testAppID, CurrentUserAnyHost); err != nil {
+					t.Error(err)
+					return
+				}
+				if err := SetMultiple(map[string]interface{}{key + "Batch": i}, nil, testAppID, CurrentUserAnyHost); err != nil {
+					t.Error(err)
+					return
+				}
+				if _, err := c.Get(key, testAppID, CurrentUserAnyHost); err != nil {
+					t.Error(err)
+					return
+				}
+			}
+		}(w)
+	}
+	wg.Wait()
+
+	for w := 0; w < workers; w++ {
+		key := fmt.Sprintf("TestConcurrentKey%d", w)
+		got, err := GetMany([]string{key, key + "Batch"}, testAppID, CurrentUserAnyHost)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if want := map[string]interface{}{key: 19, key + "Batch": 19}; !reflect.DeepEqual(got, want) {
+			t.Errorf("GetMany() = %v, want %v", got, want)
+		}
+		SetMultiple(nil, []string{key, key + "Batch"}, testAppID, CurrentUserAnyHost)
+	}
+}
+
+// panickingMarshaler panics when it is converted, standing in for a crash-prone conversion.
+type panickingMarshaler struct{}
+
+func (panickingMarshaler) MarshalPrefs() (interface{}, error) {
+	panic("conversion bug")
+}
+
+func TestPanicsAreReturnedAsErrors(t *testing.T) {
+	const key = "TestPanicKey"
+	err := Set(key, panickingMarshaler{}, testAppID, CurrentUserAnyHost)
+	var pe *PanicError
+	if !errors.As(err, &pe) || pe.Op != "Set" || pe.ApplicationID != testAppID || pe.Key != key {
+		t.Fatalf("Set() error = %#v, want a PanicError for %s", err, key)
+	}
+
+	if err := Set(key, map[string]interface{}{"a": 1}, testAppID, CurrentUserAnyHost); err != nil {
+		t.Fatalf("Set() error = %v", err)
+	}
+	defer Delete(key, testAppID, CurrentUserAnyHost)
+	v, err := GetLazy(key, testAppID, CurrentUserAnyHost)
+	if err != nil {
+		t.Fatalf("GetLazy() error = %v", err)
+	}
+	v.Close()
+	if _, err := v.Value(); !errors.As(err, &pe) {
+		t.Errorf("Value() after Close error = %v, want a PanicError", err)
+	}
+}
+
+func TestGetLazy(t *testing.T) {
+	const key = "TestGetLazyKey"
+	c := NewClient(WithNumberMode(NumberInt64))
+	value := map[string]interface{}{
+		"persistent-apps": []interface{}{
+			map[string]interface{}{"tile-data": map[string]interface{}{"file-label": "Safari"}},
+		},
+		"tilesize": 48,
+	}
+	if err := c.Set(key, value, testAppID, CurrentUserAnyHost); err != nil {
+		t.Fatalf("Set() error = %v", err)
+	}
+	defer c.Delete(key, testAppID, CurrentUserAnyHost)
+
+	testLazyValue(t, c, key, testAppID)
+}
+
+func BenchmarkGetLazy(b *testing.B) {
+	const key = "BenchmarkGetLazyKey"
+	value := make(map[string]interface{}, 5000)
+	for i := 0; i < 5000; i++ {
+		value[fmt.Sprintf("key%d", i)] = map[string]interface{}{"name": "value", "count": i}
+	}
+	if err := Set(key, value, testAppID, CurrentUserAnyHost); err != nil {
+		b.Fatalf("Set() error = %v", err)
+	}
+	defer Delete(key, testAppID, CurrentUserAnyHost)
+
+	b.Run("Get", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			v, err := Get(key, testAppID, CurrentUserAnyHost)
+			if err != nil {
+				b.Fatal(err)
+			}
+			_ = v.(map[string]interface{})["key42"]
+		}
+	})
+	b.Run("GetLazy", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			v, err := GetLazy(key, testAppID, CurrentUserAnyHost)
+			if err != nil {
+				b.Fatal(err)
+			}
+			field, err := v.Path("key42.count")
+			if err != nil {
+				b.Fatal(err)
+			}
+			field.Value()
+			field.Close()
+			v.Close()
+		}
+	})
+}
+
+func BenchmarkGet(b *testing.B) {
+	const key = "BenchmarkGetKey"
+	if err := Set(key, "value", testAppID, CurrentUserAnyHost); err != nil {
+		b.Fatalf("Set() error = %v", err)
+	}
+	defer Delete(key, testAppID, CurrentUserAnyHost)
+
+	for _, size := range []int{0, 16} {
+		c := NewClient(WithStringCache(size))
+		b.Run(fmt.Sprintf("StringCache=%d", size), func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				if _, err := c.Get(key, testAppID, CurrentUserAnyHost); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+		c.Close()
+	}
+}
+
+func TestConverge(t *testing.T) {
+	const appID = testAppID + ".converge"
+	scope := CurrentUserAnyHost
+
+	if err := SetMultiple(map[string]interface{}{
+		"ConvergeSame":   "same",
+		"ConvergeOld":    1,
+		"ConvergeRemove": true,
+		"ConvergeOther":  "untouched",
+	}, nil, appID, scope); err != nil {
+		t.Fatalf("SetMultiple() error = %v", err)
+	}
+	defer SetMultiple(nil, []string{"ConvergeSame", "ConvergeOld", "ConvergeNew", "ConvergeOther", "ConvergeList"}, appID, scope)
+
+	desired := map[string]interface{}{
+		"ConvergeSame":    "same",
+		"ConvergeOld":     2,
+		"ConvergeNew":     "added",
+		"ConvergeRemove":  nil,
+		"ConvergeMissing": nil,
+		"ConvergeList":    []string{"a", "b"},
+	}
+	changes, err := Converge(appID, scope, desired)
+	if err != nil {
+		t.Fatalf("Converge() error = %v", err)
+	}
+	want := Changes{
+		{Key: "ConvergeList", New: []string{"a", "b"}},
+		{Key: "ConvergeNew", New: "added"},
+		{Key: "ConvergeOld", Old: 1, New: 2},
+		{Key: "ConvergeRemove", Old: true},
+	}
+	if !reflect.DeepEqual(changes, want) {
+		t.Fatalf("Converge() got = %v, want %v", changes, want)
+	}
+
+	got, err := GetAll(appID, scope)
+	if err != nil {
+		t.Fatalf("GetAll() error = %v", err)
+	}
+	wantValues := map[string]interface{}{
+		"ConvergeSame":  "same",
+		"ConvergeOld":   2,
+		"ConvergeNew":   "added",
+		"ConvergeOther": "untouched",
+		"ConvergeList":  []interface{}{"a", "b"},
+	}
+	if !reflect.DeepEqual(got, wantValues) {
+		t.Fatalf("GetAll() got = %v, want %v", got, wantValues)
+	}
+
+	again, err := Converge(appID, scope, desired)
+	if err != nil {
+		t.Fatalf("Converge() second run error = %v", err)
+	}
+	if len(again) != 0 {
+		t.Fatalf("Converge() second run got = %v, want no changes", again)
+	}
+}
+
+func TestDiff(t *testing.T) {
+	const appID = testAppID + ".diff"
+	scope := CurrentUserAnyHost
+
+	if err := SetMultiple(map[string]interface{}{
+		"DiffFloat":  2.0,
+		"DiffOld":    "old",
+		"DiffRemove": true,
+		"DiffOther":  "ignored",
+	}, nil, appID, scope); err != nil {
+		t.Fatalf("SetMultiple() error = %v", err)
+	}
+	defer SetMultiple(nil, []string{"DiffFloat", "DiffOld", "DiffRemove", "DiffOther"}, appID, scope)
+
+	added, changed, removed, err := Diff(appID, scope, map[string]interface{}{
+		"DiffFloat":  2,
+		"DiffOld":    "new",
+		"DiffRemove": nil,
+		"DiffAdd":    []interface{}{1},
+	})
+	if err != nil {
+		t.Fatalf("Diff() error = %v", err)
+	}
+	if want := (Changes{{Key: "DiffAdd", New: []interface{}{1}}}); !reflect.DeepEqual(added, want) {
+		t.Errorf("Diff() added = %v, want %v", added, want)
+	}
+	if want := (Changes{{Key: "DiffOld", Old: "old", New: "new"}}); !reflect.DeepEqual(changed, want) {
+		t.Errorf("Diff() changed = %v, want %v", changed, want)
+	}
+	if want := (Changes{{Key: "DiffRemove", Old: true}}); !reflect.DeepEqual(removed, want) {
+		t.Errorf("Diff() removed = %v, want %v", removed, want)
+	}
+
+	value, err := Get("DiffOld", appID, scope)
+	if err != nil || value != "old" {
+		t.Fatalf("Diff() modified the domain: Get() = %v, %v", value, err)
+	}
+}
+
+func TestGetAll(t *testing.T) {
+	const appID = testAppID + ".getall"
+	scope := CurrentUserAnyHost
+
+	if err := SetMultiple(map[string]interface{}{"GetAllA": "a", "GetAllB": 2}, nil, appID, scope); err != nil {
+		t.Fatalf("SetMultiple() error = %v", err)
+	}
+	defer SetMultiple(nil, []string{"GetAllA", "GetAllB"}, appID, scope)
+
+	got, err := GetAll(appID, scope)
+	if err != nil {
+		t.Fatalf("GetAll() error = %v", err)
+	}
+	if want := map[string]interface{}{"GetAllA": "a", "GetAllB": 2}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("GetAll() got = %v, want %v", got, want)
+	}
+
+	empty, err := GetAll(appID+".empty", scope)
+	if err != nil {
+		t.Fatalf("GetAll() empty domain error = %v", err)
+	}
+	if len(empty) != 0 {
+		t.Fatalf("GetAll() empty domain got = %v, want none", empty)
+	}
+}
+
+func TestValidate(t *testing.T) {
+	const appID = testAppID + ".schema"
+	scope := CurrentUserAnyHost
+
+	if err := SetMultiple(map[string]interface{}{"SchemaCount": "three", "SchemaName": "ok"}, nil, appID, scope); err != nil {
+		t.Fatalf("SetMultiple() error = %v", err)
+	}
+	defer SetMultiple(nil, []string{"SchemaCount", "SchemaName"}, appID, scope)
+
+	got, err := Validate(appID, scope, Schema{
+		"SchemaCount": {Kind: KindInt},
+		"SchemaName":  {Kind: KindString, Required: true},
+	})
+	if err != nil {
+		t.Fatalf("Validate() error = %v", err)
+	}
+	want := []Violation{{Key: "SchemaCount", Value: "three", Message: "expected int, got string"}}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("Validate() got = %v, want %v", got, want)
+	}
+}
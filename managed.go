@@ -0,0 +1,132 @@
+package mac_prefs
+
+import (
+	"sort"
+	"strings"
+)
+
+// standardScopes are the four predefined preference scopes.
+var standardScopes = []PreferenceScope{
+	CurrentUserCurrentHost,
+	CurrentUserAnyHost,
+	AnyUserCurrentHost,
+	AnyUserAnyHost,
+}
+
+// ManagedValues returns the preferences of an application whose values are forced by
+// configuration profiles or MCX. Values the user chose themselves are not included. Keys that
+// exist only in the managed preference files in /Library/Managed Preferences are included with
+// the value from the file if cfprefsd does not report one.
+//
+// Parameters:
+//   - appID: The bundle identifier of the application to inspect.
+//
+// Returns:
+//   - map[string]interface{}: The forced keys and their effective values.
+//   - error: An error if the key list or a value cannot be read.
+func ManagedValues(appID string) (map[string]interface{}, error) {
+	keys, layer, err := forcedKeys(appID)
+	if err != nil {
+		return nil, err
+	}
+
+	managed := make(map[string]interface{}, len(keys))
+	for _, key := range keys {
+		value, err := GetApp(key, appID)
+		if err != nil {
+			return nil, err
+		}
+		if value == nil {
+			value = layer[key]
+		}
+		managed[key] = value
+	}
+	return managed, nil
+}
+
+// ForcedKeys lists the keys of an application whose values are forced by configuration
+// profiles or MCX. Every key in the managed preference files that apply to the user the
+// process runs as is forced, even if no other scope defines it. MCX bookkeeping keys such as
+// mcx_union_policy_keys are not preferences and are left out.
+//
+// Parameters:
+//   - appID: The bundle identifier of the application to inspect.
+//
+// Returns:
+//   - []string: The forced keys, sorted alphabetically.
+//   - error: An error if the key list cannot be read.
+func ForcedKeys(appID string) ([]string, error) {
+	keys, _, err := forcedKeys(appID)
+	return keys, err
+}
+
+// forcedKeys returns the forced keys of an application and its managed preference layer.
+func forcedKeys(appID string) ([]string, map[string]interface{}, error) {
+	layer, err := managedLayer(appID)
+	if err != nil {
+		return nil, nil, err
+	}
+	keys, err := domainKeys(appID, layer)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	forcedKeys := make([]string, 0)
+	for _, key := range keys {
+		if strings.HasPrefix(key, mcxKeyPrefix) {
+			continue
+		}
+		forced := false
+		if _, ok := layer[key]; ok {
+			forced = true
+		} else if forced, err = IsForcedApp(key, appID); err != nil {
+			return nil, nil, err
+		}
+		if forced {
+			forcedKeys = append(forcedKeys, key)
+		}
+	}
+	return forcedKeys, layer, nil
+}
+
+// managedLayer returns the managed preferences of an application that apply to the user the
+// process runs as: the device-level file, overridden by the file installed for the user.
+func managedLayer(appID string) (map[string]interface{}, error) {
+	layer, err := ReadManagedPreferences(appID)
+	if err != nil {
+		return nil, err
+	}
+	user, err := ReadUserManagedPreferences(processUser(), appID)
+	if err != nil {
+		return nil, err
+	}
+	for key, value := range user {
+		layer[key] = value
+	}
+	return layer, nil
+}
+
+// domainKeys returns the sorted union of the keys an application defines in every standard
+// scope and in its managed preference layer.
+func domainKeys(appID string, layer map[string]interface{}) ([]string, error) {
+	seen := make(map[string]struct{})
+	for key := range layer {
+		seen[key] = struct{}{}
+	}
+	for _, scope := range standardScopes {
+		keys, err := Keys(appID, scope)
+		if err != nil {
+			return nil, err
+		}
+		for _, key := range keys {
+			seen[key] = struct{}{}
+		}
+	}
+
+	keys := make([]string, 0, len(seen))
+	for key := range seen {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys, nil
+}
@@ -0,0 +1,126 @@
+//go:build darwin && cgo
+
+package mac_prefs
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"testing"
+)
+
+func TestKeys(t *testing.T) {
+	const appID = testAppID + ".keys"
+	scope := CurrentUserAnyHost
+
+	for _, key := range []string{"KeysB", "KeysA"} {
+		if err := Set(key, key, appID, scope); err != nil {
+			t.Fatalf("Set() error = %v", err)
+		}
+		defer Set(key, nil, appID, scope)
+	}
+
+	got, err := Keys(appID, scope)
+	if err != nil {
+		t.Fatalf("Keys() error = %v", err)
+	}
+	sort.Strings(got)
+	if want := []string{"KeysA", "KeysB"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("Keys() got = %v, want %v", got, want)
+	}
+
+	empty, err := Keys(appID+".empty", scope)
+	if err != nil {
+		t.Fatalf("Keys() empty domain error = %v", err)
+	}
+	if len(empty) != 0 {
+		t.Fatalf("Keys() empty domain got = %v, want none", empty)
+	}
+}
+
+func TestManagedValuesExcludesUserValues(t *testing.T) {
+	const appID = testAppID + ".managed"
+	const key = "TestManagedUserKey"
+
+	if err := SetApp(key, "user-chosen", appID); err != nil {
+		t.Fatalf("SetApp() error = %v", err)
+	}
+	defer SetApp(key, nil, appID)
+
+	got, err := ManagedValues(appID)
+	if err != nil {
+		t.Fatalf("ManagedValues() error = %v", err)
+	}
+	if len(got) != 0 {
+		t.Fatalf("ManagedValues() got = %v, want no forced values", got)
+	}
+}
+
+func TestForcedKeysExcludesUserValues(t *testing.T) {
+	const appID = testAppID + ".forced"
+	const key = "TestForcedUserKey"
+
+	if err := SetApp(key, "user-chosen", appID); err != nil {
+		t.Fatalf("SetApp() error = %v", err)
+	}
+	defer SetApp(key, nil, appID)
+
+	got, err := ForcedKeys(appID)
+	if err != nil {
+		t.Fatalf("ForcedKeys() error = %v", err)
+	}
+	if got == nil || len(got) != 0 {
+		t.Fatalf("ForcedKeys() got = %#v, want empty non-nil slice", got)
+	}
+}
+
+func TestManagedValuesProfileOnlyKeys(t *testing.T) {
+	const appID = testAppID + ".profile"
+	dir := t.TempDir()
+	defer func(orig string) { managedPrefsDir = orig }(managedPrefsDir)
+	managedPrefsDir = dir
+
+	user := `<?xml version="1.0" encoding="UTF-8"?>
+<plist version="1.0">
+<dict>
+	<key>Name</key>
+	<string>user</string>
+	<key>mcx_union_policy_keys</key>
+	<array>
+		<dict>
+			<key>mcx_input_key_names</key>
+			<array><string>Name</string></array>
+			<key>mcx_output_key_name</key>
+			<string>Name</string>
+		</dict>
+	</array>
+</dict>
+</plist>
+`
+	if err := os.MkdirAll(filepath.Join(dir, processUser()), 0o755); err != nil {
+		t.Fatalf("MkdirAll() error = %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, appID+".plist"), []byte(testPlistXML), 0o644); err != nil {
+		t.Fatalf("WriteFile() error = %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, processUser(), appID+".plist"), []byte(user), 0o644); err != nil {
+		t.Fatalf("WriteFile() error = %v", err)
+	}
+
+	keys, err := ForcedKeys(appID)
+	if err != nil {
+		t.Fatalf("ForcedKeys() error = %v", err)
+	}
+	if want := []string{"Count", "Name"}; !reflect.DeepEqual(keys, want) {
+		t.Fatalf("ForcedKeys() got = %v, want %v", keys, want)
+	}
+
+	got, err := ManagedValues(appID)
+	if err != nil {
+		t.Fatalf("ManagedValues() error = %v", err)
+	}
+	if want := map[string]interface{}{"Count": 3, "Name": "user"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("ManagedValues() got = %v, want %v", got, want)
+	}
+}
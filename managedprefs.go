@@ -0,0 +1,47 @@
+package mac_prefs
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+)
+
+// managedPrefsDir is where MDM and MCX write the managed preference layer.
+var managedPrefsDir = "/Library/Managed Preferences"
+
+// ReadManagedPreferences reads the device-level managed preferences for an application directly
+// from /Library/Managed Preferences/<appID>.plist. The file is read even when cfprefsd's
+// composite view hides it.
+//
+// Parameters:
+//   - appID: The bundle identifier of the managed application.
+//
+// Returns:
+//   - map[string]interface{}: The managed preferences. Empty if the application has no managed preferences.
+//   - error: An error if the file exists but cannot be read or parsed.
+func ReadManagedPreferences(appID string) (map[string]interface{}, error) {
+	return readManagedPlist(filepath.Join(managedPrefsDir, appID+".plist"))
+}
+
+// ReadUserManagedPreferences reads the managed preferences installed for a specific user directly
+// from /Library/Managed Preferences/<userName>/<appID>.plist.
+//
+// Parameters:
+//   - userName: The short name of the user.
+//   - appID: The bundle identifier of the managed application.
+//
+// Returns:
+//   - map[string]interface{}: The managed preferences. Empty if the user has no managed preferences for the application.
+//   - error: An error if the file exists but cannot be read or parsed.
+func ReadUserManagedPreferences(userName string, appID string) (map[string]interface{}, error) {
+	return readManagedPlist(filepath.Join(managedPrefsDir, userName, appID+".plist"))
+}
+
+// readManagedPlist reads a managed preferences plist, treating a missing file as an empty layer.
+func readManagedPlist(path string) (map[string]interface{}, error) {
+	values, err := readPlistFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return map[string]interface{}{}, nil
+	}
+	return values, err
+}
@@ -0,0 +1,52 @@
+//go:build darwin && cgo
+
+package mac_prefs
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestReadManagedPreferences(t *testing.T) {
+	dir := t.TempDir()
+	defer func(orig string) { managedPrefsDir = orig }(managedPrefsDir)
+	managedPrefsDir = dir
+
+	if err := os.WriteFile(filepath.Join(dir, testAppID+".plist"), []byte(testPlistXML), 0o644); err != nil {
+		t.Fatalf("WriteFile() error = %v", err)
+	}
+	if err := os.MkdirAll(filepath.Join(dir, "alice"), 0o755); err != nil {
+		t.Fatalf("MkdirAll() error = %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "alice", testAppID+".plist"), []byte(testPlistXML), 0o644); err != nil {
+		t.Fatalf("WriteFile() error = %v", err)
+	}
+
+	want := map[string]interface{}{"Name": "container", "Count": 3}
+
+	got, err := ReadManagedPreferences(testAppID)
+	if err != nil {
+		t.Fatalf("ReadManagedPreferences() error = %v", err)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("ReadManagedPreferences() got = %#v, want %#v", got, want)
+	}
+
+	got, err = ReadUserManagedPreferences("alice", testAppID)
+	if err != nil {
+		t.Fatalf("ReadUserManagedPreferences() error = %v", err)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("ReadUserManagedPreferences() got = %#v, want %#v", got, want)
+	}
+
+	got, err = ReadUserManagedPreferences("bob", testAppID)
+	if err != nil {
+		t.Fatalf("ReadUserManagedPreferences() missing error = %v", err)
+	}
+	if len(got) != 0 {
+		t.Fatalf("ReadUserManagedPreferences() missing got = %#v, want empty", got)
+	}
+}
@@ -0,0 +1,34 @@
+package manifest
+
+import (
+	"fmt"
+	"os/exec"
+	"strings"
+
+	"github.com/weswhet/mac_prefs"
+)
+
+// ValidateDomain validates the current values of the manifest's domain in the given scope.
+// When opts.OSVersion is empty, the version of the running system is used.
+//
+// Parameters:
+//   - m: The manifest describing the domain.
+//   - scope: The PreferenceScope whose values are validated.
+//   - opts: Options controlling validation.
+//
+// Returns:
+//   - []Violation: Every violation found, sorted by path.
+//   - error: An error if the domain cannot be read.
+func ValidateDomain(m *Manifest, scope mac_prefs.PreferenceScope, opts Options) ([]Violation, error) {
+	values, err := mac_prefs.GetAll(m.Domain, scope)
+	if err != nil {
+		return nil, fmt.Errorf("manifest: error reading %s: %v", m.Domain, err)
+	}
+
+	if opts.OSVersion == "" {
+		if out, err := exec.Command("/usr/bin/sw_vers", "-productVersion").Output(); err == nil {
+			opts.OSVersion = strings.TrimSpace(string(out))
+		}
+	}
+	return m.Validate(values, opts), nil
+}
@@ -0,0 +1,23 @@
+//go:build darwin && cgo
+
+package manifest
+
+import (
+	"testing"
+
+	"github.com/weswhet/mac_prefs/prefstest"
+)
+
+func TestValidateDomain(t *testing.T) {
+	d := prefstest.New(t)
+	d.Set("Interval", "often")
+
+	m := &Manifest{Domain: d.ID, Subkeys: []Key{{Name: "Interval", Type: "integer"}}}
+	violations, err := ValidateDomain(m, d.Scope, Options{OSVersion: "14.0"})
+	if err != nil {
+		t.Fatalf("ValidateDomain() error = %v", err)
+	}
+	if len(violations) != 1 || violations[0].Path != "Interval" {
+		t.Fatalf("ValidateDomain() got = %v, want one violation for Interval", violations)
+	}
+}
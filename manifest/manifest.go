@@ -0,0 +1,369 @@
+// Package manifest loads ProfileManifests preference manifests and validates preference
+// values against them.
+//
+// See https://github.com/ProfileManifests/ProfileManifests for the manifest format.
+package manifest
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/weswhet/mac_prefs/internal/plist"
+)
+
+// Manifest is a ProfileManifests preference manifest for a single domain.
+type Manifest struct {
+	// Domain is the preference domain the manifest describes (pfm_domain).
+	Domain string
+	// Title is the human readable title (pfm_title).
+	Title string
+	// Subkeys are the top level keys of the domain (pfm_subkeys).
+	Subkeys []Key
+}
+
+// Key describes a single key of a manifest.
+type Key struct {
+	// Name is the key name (pfm_name).
+	Name string
+	// Type is the manifest type (pfm_type): string, integer, real, boolean, date, data, array, dictionary, or url.
+	Type string
+	// Title is the human readable title (pfm_title).
+	Title string
+	// Required reports whether the key must be present (pfm_require == "always").
+	Required bool
+	// RangeList lists the allowed values (pfm_range_list).
+	RangeList []interface{}
+	// RangeMin and RangeMax bound numeric values (pfm_range_min, pfm_range_max).
+	RangeMin, RangeMax *float64
+	// Format is a regular expression string values must match (pfm_format).
+	Format string
+	// MacOSMin and MacOSMax are the macOS versions the key applies to (pfm_macos_min, pfm_macos_max).
+	MacOSMin, MacOSMax string
+	// MacOSDeprecated is the macOS version the key was deprecated in (pfm_macos_deprecated).
+	MacOSDeprecated string
+	// Subkeys describe dictionary keys or, for arrays, the element type.
+	Subkeys []Key
+}
+
+// Load reads a manifest plist file.
+func Load(path string) (*Manifest, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("manifest: error reading %s: %w", path, err)
+	}
+	return Parse(data)
+}
+
+// Parse parses the contents of a manifest plist.
+func Parse(data []byte) (*Manifest, error) {
+	parsed, err := plist.Unmarshal(data)
+	if err != nil {
+		return nil, fmt.Errorf("manifest: %v", err)
+	}
+	root, ok := parsed.(map[string]interface{})
+	if !ok {
+		return nil, errors.New("manifest: manifest is not a dictionary")
+	}
+
+	m := &Manifest{
+		Domain: stringField(root, "pfm_domain"),
+		Title:  stringField(root, "pfm_title"),
+	}
+	if m.Domain == "" {
+		return nil, errors.New("manifest: pfm_domain is missing")
+	}
+	m.Subkeys = parseKeys(root["pfm_subkeys"])
+	return m, nil
+}
+
+func parseKeys(v interface{}) []Key {
+	list, _ := v.([]interface{})
+	keys := make([]Key, 0, len(list))
+	for _, item := range list {
+		d, ok := item.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		k := Key{
+			Name:            stringField(d, "pfm_name"),
+			Type:            stringField(d, "pfm_type"),
+			Title:           stringField(d, "pfm_title"),
+			Required:        stringField(d, "pfm_require") == "always",
+			Format:          stringField(d, "pfm_format"),
+			MacOSMin:        stringField(d, "pfm_macos_min"),
+			MacOSMax:        stringField(d, "pfm_macos_max"),
+			MacOSDeprecated: stringField(d, "pfm_macos_deprecated"),
+			Subkeys:         parseKeys(d["pfm_subkeys"]),
+		}
+		k.RangeList, _ = d["pfm_range_list"].([]interface{})
+		k.RangeMin = numberField(d, "pfm_range_min")
+		k.RangeMax = numberField(d, "pfm_range_max")
+		keys = append(keys, k)
+	}
+	return keys
+}
+
+func stringField(d map[string]interface{}, name string) string {
+	s, _ := d[name].(string)
+	return s
+}
+
+func numberField(d map[string]interface{}, name string) *float64 {
+	f, ok := toFloat(d[name])
+	if !ok {
+		return nil
+	}
+	return &f
+}
+
+// Violation describes a value that does not conform to the manifest.
+type Violation struct {
+	// Path is the key path of the offending value, e.g. "Servers[1].Port".
+	Path string
+	// Message describes the problem.
+	Message string
+}
+
+func (v Violation) String() string {
+	return v.Path + ": " + v.Message
+}
+
+// Options controls validation.
+type Options struct {
+	// OSVersion is the macOS version the values are deployed to, e.g. "13.4". When set, keys
+	// outside their pfm_macos_min/pfm_macos_max range are reported.
+	OSVersion string
+	// AllowUnknown suppresses violations for keys that the manifest does not declare.
+	AllowUnknown bool
+}
+
+// Validate checks values against the manifest's key types, allowed values, ranges, and OS
+// version constraints.
+//
+// Returns:
+//   - []Violation: Every violation found, sorted by path. Empty if the values conform.
+func (m *Manifest) Validate(values map[string]interface{}, opts Options) []Violation {
+	var violations []Violation
+	validateDict(m.Subkeys, values, "", opts, &violations)
+	sort.SliceStable(violations, func(i, j int) bool { return violations[i].Path < violations[j].Path })
+	if violations == nil {
+		violations = []Violation{}
+	}
+	return violations
+}
+
+func validateDict(keys []Key, values map[string]interface{}, path string, opts Options, out *[]Violation) {
+	declared := make(map[string]Key, len(keys))
+	for _, k := range keys {
+		if k.Name == "" {
+			continue
+		}
+		declared[k.Name] = k
+		if _, ok := values[k.Name]; !ok && k.Required {
+			*out = append(*out, Violation{Path: joinPath(path, k.Name), Message: "required key is missing"})
+		}
+	}
+
+	for name, value := range values {
+		p := joinPath(path, name)
+		k, ok := declared[name]
+		if !ok {
+			if !opts.AllowUnknown && !strings.HasPrefix(name, "Payload") {
+				*out = append(*out, Violation{Path: p, Message: "key is not declared in the manifest"})
+			}
+			continue
+		}
+		validateValue(k, value, p, opts, out)
+	}
+}
+
+func validateValue(k Key, value interface{}, path string, opts Options, out *[]Violation) {
+	report := func(format string, args ...interface{}) {
+		*out = append(*out, Violation{Path: path, Message: fmt.Sprintf(format, args...)})
+	}
+
+	if opts.OSVersion != "" {
+		if k.MacOSMin != "" && compareVersions(opts.OSVersion, k.MacOSMin) < 0 {
+			report("requires macOS %s or later", k.MacOSMin)
+		}
+		if k.MacOSMax != "" && compareVersions(opts.OSVersion, k.MacOSMax) > 0 {
+			report("is not supported after macOS %s", k.MacOSMax)
+		}
+		if k.MacOSDeprecated != "" && compareVersions(opts.OSVersion, k.MacOSDeprecated) >= 0 {
+			report("is deprecated since macOS %s", k.MacOSDeprecated)
+		}
+	}
+
+	if !typeMatches(k.Type, value) {
+		report("expected %s, got %s", k.Type, typeName(value))
+		return
+	}
+
+	if len(k.RangeList) > 0 && !inRangeList(k.RangeList, value) {
+		report("value %v is not one of %v", value, k.RangeList)
+	}
+	if s, ok := value.(string); ok && k.Format != "" {
+		if re, err := regexp.Compile(k.Format); err == nil && !re.MatchString(s) {
+			report("value %q does not match format %s", s, k.Format)
+		}
+	}
+	if f, ok := toFloat(value); ok {
+		if k.RangeMin != nil && f < *k.RangeMin {
+			report("value %v is less than minimum %v", value, *k.RangeMin)
+		}
+		if k.RangeMax != nil && f > *k.RangeMax {
+			report("value %v is greater than maximum %v", value, *k.RangeMax)
+		}
+	}
+
+	switch v := value.(type) {
+	case map[string]interface{}:
+		if len(k.Subkeys) > 0 {
+			validateDict(k.Subkeys, v, path, opts, out)
+		}
+	case []interface{}:
+		if len(k.Subkeys) > 0 {
+			for i, elem := range v {
+				validateValue(k.Subkeys[0], elem, fmt.Sprintf("%s[%d]", path, i), opts, out)
+			}
+		}
+	}
+}
+
+// typeMatches reports whether value has the Go type produced for the manifest type.
+func typeMatches(manifestType string, value interface{}) bool {
+	switch manifestType {
+	case "", "any":
+		return true
+	case "string", "url":
+		_, ok := value.(string)
+		return ok
+	case "integer":
+		switch value.(type) {
+		case int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64:
+			return true
+		}
+		return false
+	case "real", "float":
+		_, ok := toFloat(value)
+		return ok
+	case "boolean":
+		_, ok := value.(bool)
+		return ok
+	case "date":
+		_, ok := value.(time.Time)
+		return ok
+	case "data":
+		_, ok := value.([]byte)
+		return ok
+	case "array":
+		_, ok := value.([]interface{})
+		return ok
+	case "dictionary":
+		_, ok := value.(map[string]interface{})
+		return ok
+	default:
+		return true
+	}
+}
+
+func typeName(value interface{}) string {
+	switch value.(type) {
+	case string:
+		return "string"
+	case bool:
+		return "boolean"
+	case int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64:
+		return "integer"
+	case float32, float64:
+		return "real"
+	case time.Time:
+		return "date"
+	case []byte:
+		return "data"
+	case []interface{}:
+		return "array"
+	case map[string]interface{}:
+		return "dictionary"
+	default:
+		return fmt.Sprintf("%T", value)
+	}
+}
+
+func inRangeList(list []interface{}, value interface{}) bool {
+	for _, allowed := range list {
+		if allowed == value {
+			return true
+		}
+		a, aok := toFloat(allowed)
+		v, vok := toFloat(value)
+		if aok && vok && a == v {
+			return true
+		}
+	}
+	return false
+}
+
+func toFloat(v interface{}) (float64, bool) {
+	switch n := v.(type) {
+	case int:
+		return float64(n), true
+	case int8:
+		return float64(n), true
+	case int16:
+		return float64(n), true
+	case int32:
+		return float64(n), true
+	case int64:
+		return float64(n), true
+	case uint:
+		return float64(n), true
+	case uint8:
+		return float64(n), true
+	case uint16:
+		return float64(n), true
+	case uint32:
+		return float64(n), true
+	case uint64:
+		return float64(n), true
+	case float32:
+		return float64(n), true
+	case float64:
+		return n, true
+	}
+	return 0, false
+}
+
+// compareVersions compares dotted version strings numerically, returning -1, 0, or 1.
+func compareVersions(a, b string) int {
+	as, bs := strings.Split(a, "."), strings.Split(b, ".")
+	for i := 0; i < len(as) || i < len(bs); i++ {
+		var x, y int
+		if i < len(as) {
+			x, _ = strconv.Atoi(as[i])
+		}
+		if i < len(bs) {
+			y, _ = strconv.Atoi(bs[i])
+		}
+		if x != y {
+			if x < y {
+				return -1
+			}
+			return 1
+		}
+	}
+	return 0
+}
+
+func joinPath(path, key string) string {
+	if path == "" {
+		return key
+	}
+	return path + "." + key
+}
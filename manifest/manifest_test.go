@@ -0,0 +1,199 @@
+package manifest
+
+import (
+	"reflect"
+	"testing"
+)
+
+const testManifest = `<?xml version="1.0" encoding="UTF-8"?>
+<plist version="1.0">
+<dict>
+	<key>pfm_domain</key>
+	<string>com.example.app</string>
+	<key>pfm_title</key>
+	<string>Example</string>
+	<key>pfm_subkeys</key>
+	<array>
+		<dict>
+			<key>pfm_name</key>
+			<string>Mode</string>
+			<key>pfm_type</key>
+			<string>string</string>
+			<key>pfm_range_list</key>
+			<array>
+				<string>fast</string>
+				<string>safe</string>
+			</array>
+		</dict>
+		<dict>
+			<key>pfm_name</key>
+			<string>Interval</string>
+			<key>pfm_type</key>
+			<string>integer</string>
+			<key>pfm_range_min</key>
+			<integer>1</integer>
+			<key>pfm_range_max</key>
+			<integer>60</integer>
+		</dict>
+		<dict>
+			<key>pfm_name</key>
+			<string>ServerURL</string>
+			<key>pfm_type</key>
+			<string>string</string>
+			<key>pfm_require</key>
+			<string>always</string>
+			<key>pfm_format</key>
+			<string>^https://</string>
+		</dict>
+		<dict>
+			<key>pfm_name</key>
+			<string>NewFeature</string>
+			<key>pfm_type</key>
+			<string>boolean</string>
+			<key>pfm_macos_min</key>
+			<string>14.0</string>
+		</dict>
+		<dict>
+			<key>pfm_name</key>
+			<string>Servers</string>
+			<key>pfm_type</key>
+			<string>array</string>
+			<key>pfm_subkeys</key>
+			<array>
+				<dict>
+					<key>pfm_type</key>
+					<string>dictionary</string>
+					<key>pfm_subkeys</key>
+					<array>
+						<dict>
+							<key>pfm_name</key>
+							<string>Port</string>
+							<key>pfm_type</key>
+							<string>integer</string>
+						</dict>
+					</array>
+				</dict>
+			</array>
+		</dict>
+	</array>
+</dict>
+</plist>
+`
+
+func TestParse(t *testing.T) {
+	m, err := Parse([]byte(testManifest))
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	if m.Domain != "com.example.app" || m.Title != "Example" {
+		t.Errorf("Parse() got domain %q title %q", m.Domain, m.Title)
+	}
+	if len(m.Subkeys) != 5 {
+		t.Fatalf("Parse() got %d subkeys, want 5", len(m.Subkeys))
+	}
+	interval := m.Subkeys[1]
+	if interval.RangeMin == nil || *interval.RangeMin != 1 || interval.RangeMax == nil || *interval.RangeMax != 60 {
+		t.Errorf("Parse() got range %v-%v for Interval", interval.RangeMin, interval.RangeMax)
+	}
+	if !m.Subkeys[2].Required {
+		t.Error("Parse() ServerURL should be required")
+	}
+
+	if _, err := Parse([]byte(`<plist><dict/></plist>`)); err == nil {
+		t.Error("Parse() expected error for manifest without pfm_domain")
+	}
+}
+
+func TestValidate(t *testing.T) {
+	m, err := Parse([]byte(testManifest))
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+
+	tests := []struct {
+		name   string
+		values map[string]interface{}
+		opts   Options
+		want   []string
+	}{
+		{
+			name:   "valid",
+			values: map[string]interface{}{"Mode": "fast", "Interval": 30, "ServerURL": "https://example.com"},
+			want:   []string{},
+		},
+		{
+			name:   "wrong type",
+			values: map[string]interface{}{"Interval": "30", "ServerURL": "https://example.com"},
+			want:   []string{"Interval: expected integer, got string"},
+		},
+		{
+			name:   "range list",
+			values: map[string]interface{}{"Mode": "slow", "ServerURL": "https://example.com"},
+			want:   []string{"Mode: value slow is not one of [fast safe]"},
+		},
+		{
+			name:   "range min and max",
+			values: map[string]interface{}{"Interval": 0, "ServerURL": "https://example.com"},
+			want:   []string{"Interval: value 0 is less than minimum 1"},
+		},
+		{
+			name:   "required and format",
+			values: map[string]interface{}{"Extra": true},
+			want:   []string{"Extra: key is not declared in the manifest", "ServerURL: required key is missing"},
+		},
+		{
+			name:   "format mismatch",
+			values: map[string]interface{}{"ServerURL": "http://example.com"},
+			want:   []string{`ServerURL: value "http://example.com" does not match format ^https://`},
+		},
+		{
+			name:   "os version",
+			values: map[string]interface{}{"NewFeature": true, "ServerURL": "https://example.com"},
+			opts:   Options{OSVersion: "13.6"},
+			want:   []string{"NewFeature: requires macOS 14.0 or later"},
+		},
+		{
+			name: "nested",
+			values: map[string]interface{}{
+				"ServerURL": "https://example.com",
+				"Servers":   []interface{}{map[string]interface{}{"Port": 80}, map[string]interface{}{"Port": "x"}},
+			},
+			want: []string{"Servers[1].Port: expected integer, got string"},
+		},
+		{
+			name:   "allow unknown",
+			values: map[string]interface{}{"Extra": true, "ServerURL": "https://example.com"},
+			opts:   Options{AllowUnknown: true},
+			want:   []string{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := []string{}
+			for _, v := range m.Validate(tt.values, tt.opts) {
+				got = append(got, v.String())
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Validate() got = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCompareVersions(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want int
+	}{
+		{"13.4", "13.4", 0},
+		{"13.4", "13.10", -1},
+		{"14", "13.6.1", 1},
+		{"13.0", "13", 0},
+	}
+	for _, tt := range tests {
+		if got := compareVersions(tt.a, tt.b); got != tt.want {
+			t.Errorf("compareVersions(%q, %q) = %d, want %d", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,61 @@
+package mac_prefs
+
+import (
+	"fmt"
+
+	"github.com/weswhet/mac_prefs/cf"
+)
+
+// Marshaler is implemented by types that store themselves as another value, such as an enum
+// stored as a string. Set, SetApp, SetMultiple, and MemoryStore.Set all call MarshalPrefs
+// before converting the value. See cf.Marshaler.
+type Marshaler = cf.Marshaler
+
+// Unmarshaler is implemented by types that can set themselves from a stored value. See GetInto.
+type Unmarshaler = cf.Unmarshaler
+
+// Null is written as kCFNull. Reads return nil for it, so Null is only needed to write the
+// null elements some domains keep in arrays and dictionaries. See cf.Null.
+type Null = cf.Null
+
+// GetInto retrieves a preference value from one exact (user, host) slot and passes it to the
+// target's UnmarshalPrefs method.
+//
+// Parameters:
+//   - key: The preference key to retrieve.
+//   - applicationID: The bundle identifier of the application for which to retrieve the preference.
+//   - scope: The PreferenceScope to read from.
+//   - target: The value to set from the stored value.
+//
+// Returns:
+//   - bool: Whether the key is set. The target is left unchanged if it is not.
+//   - error: An error if the value cannot be read or UnmarshalPrefs fails.
+func GetInto(key string, applicationID string, scope PreferenceScope, target Unmarshaler) (bool, error) {
+	return NewClient().GetInto(key, applicationID, scope, target)
+}
+
+// GetInto retrieves a preference value and passes it to target. See GetInto.
+func (c *Client) GetInto(key string, applicationID string, scope PreferenceScope, target Unmarshaler) (bool, error) {
+	value, err := c.Get(key, applicationID, scope)
+	if err != nil || value == nil {
+		return false, err
+	}
+	if err := target.UnmarshalPrefs(value); err != nil {
+		return true, fmt.Errorf("error reading %s: %w", key, err)
+	}
+	return true, nil
+}
+
+// marshalValue returns the value a Marshaler stores itself as, or value unchanged if it is not
+// a Marshaler.
+func marshalValue(value interface{}) (interface{}, bool, error) {
+	m, ok := value.(Marshaler)
+	if !ok {
+		return value, false, nil
+	}
+	marshaled, err := m.MarshalPrefs()
+	if err != nil {
+		return nil, true, err
+	}
+	return marshaled, true, nil
+}
@@ -0,0 +1,69 @@
+package mac_prefs
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"testing"
+)
+
+type testVersion struct {
+	Major, Minor int
+}
+
+func (v testVersion) MarshalPrefs() (interface{}, error) {
+	if v.Major < 0 {
+		return nil, errors.New("negative version")
+	}
+	return fmt.Sprintf("%d.%d", v.Major, v.Minor), nil
+}
+
+func (v *testVersion) UnmarshalPrefs(value interface{}) error {
+	s, ok := value.(string)
+	if !ok {
+		return fmt.Errorf("version of type %T is not a string", value)
+	}
+	_, err := fmt.Sscanf(s, "%d.%d", &v.Major, &v.Minor)
+	return err
+}
+
+func TestClientMarshaler(t *testing.T) {
+	store := NewMemoryStore()
+	c := NewClient(WithStore(store))
+	const app = "com.example.marshal"
+
+	if err := c.Set("Version", testVersion{1, 2}, app, CurrentUserAnyHost); err != nil {
+		t.Fatalf("Set() error = %v", err)
+	}
+	value := map[string]interface{}{"Versions": []testVersion{{2, 0}}}
+	if err := c.Set("Nested", value, app, CurrentUserAnyHost); err != nil {
+		t.Fatalf("Set() error = %v", err)
+	}
+	if got, _ := store.Get("Version", app, CurrentUserAnyHost); got != "1.2" {
+		t.Fatalf("stored value = %#v, want \"1.2\"", got)
+	}
+	got, _ := store.Get("Nested", app, CurrentUserAnyHost)
+	if want := map[string]interface{}{"Versions": []interface{}{"2.0"}}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("stored value = %#v, want %#v", got, want)
+	}
+
+	var v testVersion
+	ok, err := c.GetInto("Version", app, CurrentUserAnyHost, &v)
+	if err != nil || !ok || v != (testVersion{1, 2}) {
+		t.Fatalf("GetInto() = %v, %v, %+v", ok, err, v)
+	}
+	if ok, err := c.GetInto("Missing", app, CurrentUserAnyHost, &v); ok || err != nil {
+		t.Fatalf("GetInto(missing) = %v, %v, want false, nil", ok, err)
+	}
+	if ok, err := c.GetInto("Nested", app, CurrentUserAnyHost, &v); !ok || err == nil {
+		t.Fatalf("GetInto(Nested) = %v, %v, want true and an error", ok, err)
+	}
+}
+
+func TestValidateValueMarshalerError(t *testing.T) {
+	err := ValidateValue(map[string]interface{}{"v": testVersion{-1, 0}})
+	var valueErr *ValueError
+	if !errors.As(err, &valueErr) || valueErr.Path != "v" || valueErr.Err == nil {
+		t.Fatalf("ValidateValue() error = %v, want a *ValueError at v", err)
+	}
+}
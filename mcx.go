@@ -0,0 +1,186 @@
+package mac_prefs
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+const (
+	// mcxKeyPrefix starts the names of the MCX bookkeeping keys of managed preference files,
+	// which are not preferences themselves.
+	mcxKeyPrefix = "mcx_"
+	// mcxUnionPolicyKeys lists the union policies of a managed domain.
+	mcxUnionPolicyKeys = "mcx_union_policy_keys"
+	// mcxInputKeyNames names the managed keys merged by a union policy.
+	mcxInputKeyNames = "mcx_input_key_names"
+	// mcxOutputKeyName names the key a union policy produces.
+	mcxOutputKeyName = "mcx_output_key_name"
+	// mcxRemoveDuplicates requests de-duplication of the merged array.
+	mcxRemoveDuplicates = "mcx_remove_duplicates"
+)
+
+// UnionPolicy is an MCX union policy (upk) that merges managed keys into a user key
+// instead of replacing it.
+type UnionPolicy struct {
+	// InputKeys are the managed keys whose values are merged into the output key.
+	InputKeys []string
+	// OutputKey is the key whose effective value is the union.
+	OutputKey string
+	// RemoveDuplicates drops repeated array elements from the union.
+	RemoveDuplicates bool
+}
+
+// MCXComposition is the effective value of a key computed from the managed and user layers.
+type MCXComposition struct {
+	// Key is the composed preference key.
+	Key string
+	// Value is the effective value, or nil if neither layer defines the key.
+	Value interface{}
+	// Policy is the union policy applied, or nil if the value was taken from a single layer.
+	Policy *UnionPolicy
+	// Steps describes each step of the computation in order.
+	Steps []string
+}
+
+// Explain returns a human readable description of how the effective value was computed.
+func (c MCXComposition) Explain() string {
+	return strings.Join(c.Steps, "\n")
+}
+
+// UnionPolicies returns the MCX union policies declared in a managed preferences layer,
+// such as one read with ReadManagedPreferences.
+func UnionPolicies(managed map[string]interface{}) []UnionPolicy {
+	entries, _ := managed[mcxUnionPolicyKeys].([]interface{})
+	policies := make([]UnionPolicy, 0, len(entries))
+	for _, e := range entries {
+		entry, ok := e.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		output, _ := entry[mcxOutputKeyName].(string)
+		if output == "" {
+			continue
+		}
+		policy := UnionPolicy{OutputKey: output}
+		inputs, _ := entry[mcxInputKeyNames].([]interface{})
+		for _, in := range inputs {
+			if name, ok := in.(string); ok {
+				policy.InputKeys = append(policy.InputKeys, name)
+			}
+		}
+		policy.RemoveDuplicates, _ = entry[mcxRemoveDuplicates].(bool)
+		policies = append(policies, policy)
+	}
+	return policies
+}
+
+// ComposeMCX computes the effective value of a key from a managed layer and a user layer.
+// When the managed layer declares a union policy for the key, the user's value is merged with
+// the managed input keys: arrays are concatenated (optionally de-duplicated) and dictionaries
+// are merged with managed entries winning. Otherwise a managed value replaces the user's value.
+//
+// Parameters:
+//   - key: The preference key to compose.
+//   - managed: The managed preferences layer, including any mcx_union_policy_keys metadata.
+//   - user: The user's preferences layer.
+//
+// Returns:
+//   - MCXComposition: The effective value together with an explanation of the computation.
+func ComposeMCX(key string, managed, user map[string]interface{}) MCXComposition {
+	c := MCXComposition{Key: key}
+	userValue, userOK := user[key]
+
+	for _, policy := range UnionPolicies(managed) {
+		if policy.OutputKey != key {
+			continue
+		}
+		policy := policy
+		c.Policy = &policy
+		c.Steps = append(c.Steps, fmt.Sprintf("union policy: %s <- %s (remove duplicates: %t)", key, strings.Join(policy.InputKeys, ", "), policy.RemoveDuplicates))
+
+		value := userValue
+		if userOK {
+			c.Steps = append(c.Steps, fmt.Sprintf("start with user value of %s", key))
+		} else {
+			c.Steps = append(c.Steps, fmt.Sprintf("user layer does not define %s", key))
+		}
+		for _, in := range policy.InputKeys {
+			managedValue, ok := managed[in]
+			if !ok {
+				c.Steps = append(c.Steps, fmt.Sprintf("managed layer does not define %s", in))
+				continue
+			}
+			merged, how := unionValues(value, managedValue)
+			value = merged
+			c.Steps = append(c.Steps, fmt.Sprintf("%s managed %s", how, in))
+		}
+		if policy.RemoveDuplicates {
+			if arr, ok := value.([]interface{}); ok {
+				value = removeDuplicates(arr)
+				c.Steps = append(c.Steps, fmt.Sprintf("removed duplicates: %d -> %d elements", len(arr), len(value.([]interface{}))))
+			}
+		}
+		c.Value = value
+		return c
+	}
+
+	if managedValue, ok := managed[key]; ok {
+		c.Value = managedValue
+		c.Steps = append(c.Steps, fmt.Sprintf("managed value replaces user value of %s", key))
+		return c
+	}
+	if userOK {
+		c.Value = userValue
+		c.Steps = append(c.Steps, fmt.Sprintf("%s is not managed; using user value", key))
+		return c
+	}
+	c.Steps = append(c.Steps, fmt.Sprintf("%s is not defined in either layer", key))
+	return c
+}
+
+// unionValues merges src into dst and describes how the merge was performed.
+func unionValues(dst, src interface{}) (interface{}, string) {
+	switch s := src.(type) {
+	case []interface{}:
+		d, _ := dst.([]interface{})
+		merged := make([]interface{}, 0, len(d)+len(s))
+		merged = append(merged, d...)
+		merged = append(merged, s...)
+		return merged, fmt.Sprintf("appended %d elements from", len(s))
+	case map[string]interface{}:
+		d, _ := dst.(map[string]interface{})
+		merged := make(map[string]interface{}, len(d)+len(s))
+		for k, v := range d {
+			merged[k] = v
+		}
+		keys := make([]string, 0, len(s))
+		for k, v := range s {
+			merged[k] = v
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		return merged, fmt.Sprintf("merged keys [%s] from", strings.Join(keys, ", "))
+	default:
+		return src, "replaced with"
+	}
+}
+
+// removeDuplicates returns arr without repeated elements, keeping the first occurrence.
+func removeDuplicates(arr []interface{}) []interface{} {
+	result := make([]interface{}, 0, len(arr))
+	for _, v := range arr {
+		duplicate := false
+		for _, seen := range result {
+			if reflect.DeepEqual(v, seen) {
+				duplicate = true
+				break
+			}
+		}
+		if !duplicate {
+			result = append(result, v)
+		}
+	}
+	return result
+}
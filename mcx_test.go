@@ -0,0 +1,90 @@
+package mac_prefs
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestComposeMCX(t *testing.T) {
+	managed := map[string]interface{}{
+		"mcx_union_policy_keys": []interface{}{
+			map[string]interface{}{
+				"mcx_input_key_names":   []interface{}{"static-others-raw"},
+				"mcx_output_key_name":   "static-others",
+				"mcx_remove_duplicates": true,
+			},
+		},
+		"static-others-raw": []interface{}{"Downloads", "Applications"},
+		"autohide":          true,
+	}
+	user := map[string]interface{}{
+		"static-others": []interface{}{"Documents", "Downloads"},
+		"autohide":      false,
+		"tilesize":      48,
+	}
+
+	for _, tc := range []struct {
+		name       string
+		key        string
+		want       interface{}
+		wantPolicy bool
+	}{
+		{
+			name:       "union policy",
+			key:        "static-others",
+			want:       []interface{}{"Documents", "Downloads", "Applications"},
+			wantPolicy: true,
+		},
+		{
+			name: "managed replaces user",
+			key:  "autohide",
+			want: true,
+		},
+		{
+			name: "unmanaged user value",
+			key:  "tilesize",
+			want: 48,
+		},
+		{
+			name: "undefined key",
+			key:  "orientation",
+			want: nil,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			got := ComposeMCX(tc.key, managed, user)
+			if !reflect.DeepEqual(got.Value, tc.want) {
+				t.Fatalf("ComposeMCX() value = %#v, want %#v", got.Value, tc.want)
+			}
+			if (got.Policy != nil) != tc.wantPolicy {
+				t.Fatalf("ComposeMCX() policy = %+v, want policy %v", got.Policy, tc.wantPolicy)
+			}
+			if got.Explain() == "" {
+				t.Fatal("ComposeMCX() returned an empty explanation")
+			}
+		})
+	}
+}
+
+func TestComposeMCXExplainsUnion(t *testing.T) {
+	managed := map[string]interface{}{
+		"mcx_union_policy_keys": []interface{}{
+			map[string]interface{}{
+				"mcx_input_key_names": []interface{}{"extra"},
+				"mcx_output_key_name": "settings",
+			},
+		},
+		"extra": map[string]interface{}{"b": 2},
+	}
+	user := map[string]interface{}{"settings": map[string]interface{}{"a": 1}}
+
+	got := ComposeMCX("settings", managed, user)
+	want := map[string]interface{}{"a": 1, "b": 2}
+	if !reflect.DeepEqual(got.Value, want) {
+		t.Fatalf("ComposeMCX() value = %#v, want %#v", got.Value, want)
+	}
+	if !strings.Contains(got.Explain(), "merged keys [b] from managed extra") {
+		t.Fatalf("ComposeMCX() explanation = %q, want dictionary merge step", got.Explain())
+	}
+}
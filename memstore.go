@@ -0,0 +1,364 @@
+package mac_prefs
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"reflect"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/weswhet/mac_prefs/internal/prefstag"
+)
+
+// MemoryStore is a Store that keeps preferences in memory. Every (application ID, scope)
+// slot is independent, as with Get and Set; there is no search list. Watchers are notified
+// as soon as a value changes, so the Watch interval is ignored. A MemoryStore is safe for
+// concurrent use; the zero value is an empty store. Values are copied on the way in and on
+// the way out, so changing a map, slice or []byte after Set or after reading it never
+// changes the store.
+type MemoryStore struct {
+	mu       sync.Mutex
+	domains  map[memoryDomain]map[string]interface{}
+	watchers map[*memoryWatcher]struct{}
+}
+
+type memoryDomain struct {
+	appID string
+	scope PreferenceScope
+}
+
+// NewMemoryStore creates an empty MemoryStore.
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{}
+}
+
+// Get retrieves a value, or nil if the key is not set.
+func (s *MemoryStore) Get(key string, applicationID string, scope PreferenceScope) (interface{}, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return cloneStoredValue(s.domains[memoryDomain{applicationID, scope}][key]), nil
+}
+
+// GetMultiple retrieves several keys. Keys that are not set are left out of the map.
+func (s *MemoryStore) GetMultiple(keys []string, applicationID string, scope PreferenceScope) (map[string]interface{}, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	domain := s.domains[memoryDomain{applicationID, scope}]
+	values := make(map[string]interface{}, len(keys))
+	for _, key := range keys {
+		if value, ok := domain[key]; ok {
+			values[key] = cloneStoredValue(value)
+		}
+	}
+	return values, nil
+}
+
+// Set sets a value. A nil value removes the key. Values are validated like Set validates
+// them, so code that works against a MemoryStore does not fail against CFPreferences, and
+// durations and structs are stored as float64 seconds and dictionaries as CFPreferences
+// stores them.
+func (s *MemoryStore) Set(key string, value interface{}, applicationID string, scope PreferenceScope) error {
+	return s.SetMultiple(map[string]interface{}{key: value}, nil, applicationID, scope)
+}
+
+// SetMultiple sets and removes several keys at once. A nil value removes the key. Values are
+// validated like Set validates them, and nothing is written if any value is invalid.
+func (s *MemoryStore) SetMultiple(keysToSet map[string]interface{}, keysToRemove []string, applicationID string, scope PreferenceScope) error {
+	values := make(map[string]interface{}, len(keysToSet)+len(keysToRemove))
+	for _, key := range keysToRemove {
+		values[key] = nil
+	}
+	for key, value := range keysToSet {
+		if err := ValidateValue(value); err != nil {
+			return fmt.Errorf("invalid value for key %s: %w", key, err)
+		}
+		stored, _ := storedValue(value)
+		values[key] = cloneStoredValue(stored)
+	}
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	d := memoryDomain{applicationID, scope}
+	for _, key := range keys {
+		value := values[key]
+		old := s.domains[d][key]
+		if value == nil {
+			delete(s.domains[d], key)
+		} else {
+			if s.domains == nil {
+				s.domains = make(map[memoryDomain]map[string]interface{})
+			}
+			if s.domains[d] == nil {
+				s.domains[d] = make(map[string]interface{})
+			}
+			s.domains[d][key] = value
+		}
+
+		if (old == nil && value == nil) || (old != nil && value != nil && valuesEqual(old, value)) {
+			continue
+		}
+		e := Event{Change: Change{Key: key, Old: cloneStoredValue(old), New: cloneStoredValue(value)}, ApplicationID: applicationID, Scope: scope, Time: time.Now()}
+		for w := range s.watchers {
+			if w.domain == d {
+				w.push(e)
+			}
+		}
+	}
+	return nil
+}
+
+// Delete removes a key.
+func (s *MemoryStore) Delete(key string, applicationID string, scope PreferenceScope) error {
+	return s.Set(key, nil, applicationID, scope)
+}
+
+// List retrieves every key and value of a slot. The map and the values in it are copies.
+func (s *MemoryStore) List(applicationID string, scope PreferenceScope) (map[string]interface{}, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	values := make(map[string]interface{})
+	for k, v := range s.domains[memoryDomain{applicationID, scope}] {
+		values[k] = cloneStoredValue(v)
+	}
+	return values, nil
+}
+
+// Domains lists the application IDs that have at least one key in scope, sorted.
+func (s *MemoryStore) Domains(scope PreferenceScope) []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	domains := make([]string, 0)
+	for d, values := range s.domains {
+		if d.scope == scope && len(values) > 0 {
+			domains = append(domains, d.appID)
+		}
+	}
+	sort.Strings(domains)
+	return domains
+}
+
+// Watch sends an Event for every change made through the store to a slot, in order, until
+// ctx is done. The interval is ignored.
+func (s *MemoryStore) Watch(ctx context.Context, applicationID string, scope PreferenceScope, interval time.Duration) (<-chan Event, error) {
+	w := &memoryWatcher{
+		domain: memoryDomain{applicationID, scope},
+		ready:  make(chan struct{}, 1),
+	}
+
+	s.mu.Lock()
+	if s.watchers == nil {
+		s.watchers = make(map[*memoryWatcher]struct{})
+	}
+	s.watchers[w] = struct{}{}
+	s.mu.Unlock()
+
+	events := make(chan Event)
+	go func() {
+		defer close(events)
+		defer func() {
+			s.mu.Lock()
+			delete(s.watchers, w)
+			s.mu.Unlock()
+		}()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-w.ready:
+			}
+			for _, e := range w.drain() {
+				select {
+				case events <- e:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}
+	}()
+	return events, nil
+}
+
+// memoryWatcher queues the events of one Watch call, so Set never blocks on a slow reader.
+type memoryWatcher struct {
+	domain memoryDomain
+	ready  chan struct{}
+
+	mu    sync.Mutex
+	queue []Event
+}
+
+func (w *memoryWatcher) push(e Event) {
+	w.mu.Lock()
+	w.queue = append(w.queue, e)
+	w.mu.Unlock()
+	select {
+	case w.ready <- struct{}{}:
+	default:
+	}
+}
+
+func (w *memoryWatcher) drain() []Event {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	queue := w.queue
+	w.queue = nil
+	return queue
+}
+
+// storedValue converts value to the shape CFPreferences stores it in: Marshalers are
+// marshaled, durations become float64 seconds, json.Numbers become int64 or float64, Null
+// becomes nil as CFPreferences reads it back, URLs become *url.URL, UUIDs become strings,
+// structs become dictionaries of their fields, pointers are dereferenced, and map entries
+// holding a nil pointer are dropped. Containers are copied only if they hold such a value.
+func storedValue(value interface{}) (interface{}, bool) {
+	if value == nil {
+		return nil, false
+	}
+	if isNilPointer(value) {
+		return nil, true
+	}
+	if marshaled, ok, err := marshalValue(value); ok && err == nil {
+		stored, _ := storedValue(marshaled)
+		return stored, true
+	}
+	switch v := value.(type) {
+	case time.Duration:
+		return v.Seconds(), true
+	case json.Number:
+		n, _ := jsonNumberValue(v)
+		return n, true
+	case time.Time:
+		return v, false
+	case Null:
+		return nil, true
+	case url.URL:
+		return &v, true
+	case [16]byte:
+		return formatUUID(v), true
+	}
+
+	rv := reflect.ValueOf(value)
+	switch {
+	case rv.Kind() == reflect.Struct:
+		items, _ := storedValue(prefstag.Values(rv))
+		return items, true
+	case rv.Kind() == reflect.Ptr:
+		elem, _ := storedValue(rv.Elem().Interface())
+		return elem, true
+	case rv.Kind() == reflect.Slice && rv.Type().Elem().Kind() != reflect.Uint8:
+		items := make([]interface{}, rv.Len())
+		changed := false
+		for i := range items {
+			var c bool
+			items[i], c = storedValue(rv.Index(i).Interface())
+			changed = changed || c
+		}
+		if changed {
+			return items, true
+		}
+	case rv.Kind() == reflect.Map && rv.Type().Key().Kind() == reflect.String:
+		items := make(map[string]interface{}, rv.Len())
+		changed := false
+		for _, key := range rv.MapKeys() {
+			item := rv.MapIndex(key).Interface()
+			if isNilPointer(item) {
+				changed = true
+				continue
+			}
+			var c bool
+			items[key.String()], c = storedValue(item)
+			changed = changed || c
+		}
+		if changed {
+			return items, true
+		}
+	}
+	return value, false
+}
+
+// cloneStoredValue deep-copies the maps, slices, []byte and *url.URL values in value, so
+// the store never shares them with its callers. Other values are immutable and returned
+// as they are.
+func cloneStoredValue(value interface{}) interface{} {
+	switch v := value.(type) {
+	case nil:
+		return nil
+	case []byte:
+		if v == nil {
+			return v
+		}
+		return append([]byte{}, v...)
+	case *url.URL:
+		if v == nil {
+			return v
+		}
+		u := *v
+		return &u
+	case []interface{}:
+		if v == nil {
+			return v
+		}
+		items := make([]interface{}, len(v))
+		for i, item := range v {
+			items[i] = cloneStoredValue(item)
+		}
+		return items
+	case map[string]interface{}:
+		if v == nil {
+			return v
+		}
+		items := make(map[string]interface{}, len(v))
+		for key, item := range v {
+			items[key] = cloneStoredValue(item)
+		}
+		return items
+	}
+
+	rv := reflect.ValueOf(value)
+	switch rv.Kind() {
+	case reflect.Slice:
+		if rv.IsNil() {
+			return value
+		}
+		items := reflect.MakeSlice(rv.Type(), rv.Len(), rv.Len())
+		for i := 0; i < rv.Len(); i++ {
+			setClone(items.Index(i), rv.Index(i))
+		}
+		return items.Interface()
+	case reflect.Map:
+		if rv.IsNil() {
+			return value
+		}
+		items := reflect.MakeMapWithSize(rv.Type(), rv.Len())
+		for _, key := range rv.MapKeys() {
+			item := reflect.New(rv.Type().Elem()).Elem()
+			setClone(item, rv.MapIndex(key))
+			items.SetMapIndex(key, item)
+		}
+		return items.Interface()
+	}
+	return value
+}
+
+// setClone stores a deep copy of src in dst, which has the same type.
+func setClone(dst, src reflect.Value) {
+	if src.Kind() == reflect.Interface && src.IsNil() {
+		return
+	}
+	if clone := cloneStoredValue(src.Interface()); clone != nil {
+		dst.Set(reflect.ValueOf(clone))
+	}
+}
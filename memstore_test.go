@@ -0,0 +1,381 @@
+package mac_prefs
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+var (
+	_ Store = CFStore{}
+	_ Store = (*MemoryStore)(nil)
+
+	_ BatchStore = CFStore{}
+	_ BatchStore = (*MemoryStore)(nil)
+
+	_ MultiGetStore = CFStore{}
+	_ MultiGetStore = (*MemoryStore)(nil)
+)
+
+func TestMemoryStore(t *testing.T) {
+	s := NewMemoryStore()
+	scope := CurrentUserAnyHost
+
+	if v, err := s.Get("Missing", "com.example", scope); v != nil || err != nil {
+		t.Fatalf("Get() on an empty store = %v, %v", v, err)
+	}
+	if err := s.Set("Size", 48, "com.example", scope); err != nil {
+		t.Fatalf("Set() error = %v", err)
+	}
+	if err := s.Set("Name", "dock", "com.example", scope); err != nil {
+		t.Fatalf("Set() error = %v", err)
+	}
+	if v, _ := s.Get("Size", "com.example", scope); v != 48 {
+		t.Fatalf("Get() = %v, want 48", v)
+	}
+	if v, _ := s.Get("Size", "com.example", CurrentUserCurrentHost); v != nil {
+		t.Fatalf("Get() from another scope = %v, want nil", v)
+	}
+
+	if err := s.Delete("Name", "com.example", scope); err != nil {
+		t.Fatalf("Delete() error = %v", err)
+	}
+	values, err := s.List("com.example", scope)
+	if err != nil {
+		t.Fatalf("List() error = %v", err)
+	}
+	if !reflect.DeepEqual(values, map[string]interface{}{"Size": 48}) {
+		t.Fatalf("List() = %v", values)
+	}
+	values["Size"] = 1
+	if v, _ := s.Get("Size", "com.example", scope); v != 48 {
+		t.Fatalf("modifying the List() result changed the store: %v", v)
+	}
+
+	if got := s.Domains(scope); !reflect.DeepEqual(got, []string{"com.example"}) {
+		t.Fatalf("Domains() = %v", got)
+	}
+}
+
+func TestMemoryStoreCopiesValues(t *testing.T) {
+	s := NewMemoryStore()
+	scope := CurrentUserAnyHost
+	want := map[string]interface{}{
+		"tiles": []interface{}{map[string]interface{}{"label": "Mail"}},
+		"data":  []byte{1, 2},
+		"names": []string{"a", "b"},
+	}
+
+	value := map[string]interface{}{
+		"tiles": []interface{}{map[string]interface{}{"label": "Mail"}},
+		"data":  []byte{1, 2},
+		"names": []string{"a", "b"},
+	}
+	if err := s.Set("Dock", value, "com.example", scope); err != nil {
+		t.Fatalf("Set() error = %v", err)
+	}
+	value["tiles"].([]interface{})[0].(map[string]interface{})["label"] = "Set"
+	value["data"].([]byte)[0] = 9
+	value["names"].([]string)[0] = "z"
+
+	got, _ := s.Get("Dock", "com.example", scope)
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("modifying the Set() value changed the store: %v", got)
+	}
+	got.(map[string]interface{})["tiles"].([]interface{})[0].(map[string]interface{})["label"] = "Get"
+	got.(map[string]interface{})["data"].([]byte)[0] = 9
+
+	many, _ := s.GetMultiple([]string{"Dock"}, "com.example", scope)
+	many["Dock"].(map[string]interface{})["names"].([]string)[0] = "z"
+
+	list, _ := s.List("com.example", scope)
+	delete(list["Dock"].(map[string]interface{}), "tiles")
+
+	if got, _ := s.Get("Dock", "com.example", scope); !reflect.DeepEqual(got, want) {
+		t.Fatalf("modifying a returned value changed the store: %v", got)
+	}
+}
+
+func TestMemoryStoreStoresStructsAsDictionaries(t *testing.T) {
+	type server struct {
+		Host    string        `prefs:"host"`
+		Timeout time.Duration `prefs:"timeout,omitempty"`
+	}
+	s := NewMemoryStore()
+	value := struct {
+		Servers []server
+	}{Servers: []server{{Host: "a", Timeout: 2 * time.Second}, {Host: "b"}}}
+	if err := s.Set("Config", value, "com.example", CurrentUserAnyHost); err != nil {
+		t.Fatalf("Set() error = %v", err)
+	}
+	got, _ := s.Get("Config", "com.example", CurrentUserAnyHost)
+	want := map[string]interface{}{"Servers": []interface{}{
+		map[string]interface{}{"host": "a", "timeout": 2.0},
+		map[string]interface{}{"host": "b"},
+	}}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("Get() = %#v, want %#v", got, want)
+	}
+}
+
+func TestMemoryStoreStoresJSONNumbers(t *testing.T) {
+	s := NewMemoryStore()
+	var value map[string]interface{}
+	d := json.NewDecoder(strings.NewReader(`{"size": 48, "scale": 1.5, "huge": 1e30}`))
+	d.UseNumber()
+	if err := d.Decode(&value); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Set("Config", value, "com.example", CurrentUserAnyHost); err != nil {
+		t.Fatalf("Set() error = %v", err)
+	}
+	got, _ := s.Get("Config", "com.example", CurrentUserAnyHost)
+	want := map[string]interface{}{"size": int64(48), "scale": 1.5, "huge": 1e30}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("Get() = %#v, want %#v", got, want)
+	}
+}
+
+func TestMemoryStorePointers(t *testing.T) {
+	s := NewMemoryStore()
+	size, enabled := 48, true
+	value := struct {
+		Size    *int
+		Enabled *bool
+		Name    *string
+	}{Size: &size, Enabled: &enabled}
+	if err := s.Set("Config", &value, "com.example", CurrentUserAnyHost); err != nil {
+		t.Fatalf("Set() error = %v", err)
+	}
+	got, _ := s.Get("Config", "com.example", CurrentUserAnyHost)
+	if want := map[string]interface{}{"Size": 48, "Enabled": true}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("Get() = %#v, want %#v", got, want)
+	}
+
+	if err := s.Set("Config", (*int)(nil), "com.example", CurrentUserAnyHost); err != nil {
+		t.Fatalf("Set(nil pointer) error = %v", err)
+	}
+	if got, _ := s.Get("Config", "com.example", CurrentUserAnyHost); got != nil {
+		t.Fatalf("Get() after setting a nil pointer = %#v, want nil", got)
+	}
+}
+
+func TestMemoryStoreNull(t *testing.T) {
+	s := NewMemoryStore()
+	value := map[string]interface{}{"tiles": []interface{}{"a", Null{}}}
+	if err := s.Set("Config", value, "com.example", CurrentUserAnyHost); err != nil {
+		t.Fatalf("Set() error = %v", err)
+	}
+	got, _ := s.Get("Config", "com.example", CurrentUserAnyHost)
+	if want := map[string]interface{}{"tiles": []interface{}{"a", nil}}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("Get() = %#v, want %#v", got, want)
+	}
+}
+
+func TestMemoryStoreURL(t *testing.T) {
+	s := NewMemoryStore()
+	u := url.URL{Scheme: "https", Host: "example.com"}
+	if err := s.Set("Homepage", u, "com.example", CurrentUserAnyHost); err != nil {
+		t.Fatalf("Set() error = %v", err)
+	}
+	got, _ := s.Get("Homepage", "com.example", CurrentUserAnyHost)
+	if got, ok := got.(*url.URL); !ok || *got != u {
+		t.Fatalf("Get() = %#v, want %v as a *url.URL", got, u)
+	}
+}
+
+func TestMemoryStoreWatch(t *testing.T) {
+	s := NewMemoryStore()
+	scope := CurrentUserAnyHost
+	s.Set("Size", 1, "com.example", scope)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	events, err := s.Watch(ctx, "com.example", scope, 0)
+	if err != nil {
+		t.Fatalf("Watch() error = %v", err)
+	}
+
+	s.Set("Size", 2, "com.example", scope)
+	s.Set("Size", 2, "com.example", scope)
+	s.Set("Other", true, "com.other", scope)
+	s.Delete("Size", "com.example", scope)
+
+	want := []Change{{Key: "Size", Old: 1, New: 2}, {Key: "Size", Old: 2}}
+	var got []Change
+	for len(got) < len(want) {
+		select {
+		case e := <-events:
+			got = append(got, e.Change)
+		case <-time.After(5 * time.Second):
+			t.Fatalf("Watch() timed out, got = %v", got)
+		}
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("Watch() got = %v, want %v", got, want)
+	}
+
+	cancel()
+	for range events {
+	}
+}
+
+func TestClientWithStore(t *testing.T) {
+	s := NewMemoryStore()
+	c := NewClient(WithStore(s), WithUndo(0))
+	scope := CurrentUserAnyHost
+
+	if err := c.Set("Key", "a", "com.example", scope); err != nil {
+		t.Fatalf("Set() error = %v", err)
+	}
+	if v, _ := s.Get("Key", "com.example", scope); v != "a" {
+		t.Fatalf("store Get() = %v, want a", v)
+	}
+	if err := c.Undo(1); err != nil {
+		t.Fatalf("Undo() error = %v", err)
+	}
+	if v, _ := c.Get("Key", "com.example", scope); v != nil {
+		t.Fatalf("Get() after Undo() = %v, want nil", v)
+	}
+}
+
+func TestClientGetMany(t *testing.T) {
+	c := NewClient(WithStore(NewMemoryStore()), WithNumberMode(NumberInt64))
+	for key, value := range map[string]interface{}{"a": 1, "b": "x"} {
+		if err := c.Set(key, value, "com.example", CurrentUserAnyHost); err != nil {
+			t.Fatal(err)
+		}
+	}
+	c.RegisterDefaults(map[string]interface{}{"c": true, "a": 2})
+
+	got, err := c.GetMany([]string{"a", "b", "c", "missing"}, "com.example", CurrentUserAnyHost)
+	if err != nil {
+		t.Fatalf("GetMany() error = %v", err)
+	}
+	if want := map[string]interface{}{"a": int64(1), "b": "x", "c": true}; !reflect.DeepEqual(got, want) {
+		t.Errorf("GetMany() = %#v, want %#v", got, want)
+	}
+}
+
+func TestClientReplaceAll(t *testing.T) {
+	c := NewClient(WithStore(NewMemoryStore()), WithUndo(0))
+	for key, value := range map[string]interface{}{"keep": 1, "change": "old", "extra": true} {
+		if err := c.Set(key, value, "com.example", CurrentUserAnyHost); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	desired := map[string]interface{}{"keep": 1, "change": "new", "add": 2.5, "absent": nil}
+	if err := c.ReplaceAll("com.example", CurrentUserAnyHost, desired); err != nil {
+		t.Fatalf("ReplaceAll() error = %v", err)
+	}
+	got, err := c.GetAll("com.example", CurrentUserAnyHost)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := map[string]interface{}{"keep": 1, "change": "new", "add": 2.5}; !reflect.DeepEqual(got, want) {
+		t.Errorf("GetAll() = %#v, want %#v", got, want)
+	}
+
+	if err := c.ReplaceAll("com.example", CurrentUserAnyHost, map[string]interface{}{"bad": make(chan int)}); err == nil {
+		t.Error("ReplaceAll() with an invalid value expected error")
+	}
+	if got, _ := c.GetAll("com.example", CurrentUserAnyHost); len(got) != 3 {
+		t.Errorf("GetAll() = %#v after a failed ReplaceAll", got)
+	}
+}
+
+func TestClientCopyDomain(t *testing.T) {
+	c := NewClient(WithStore(NewMemoryStore()))
+	src := map[string]interface{}{"a": 1, "b": []interface{}{"x"}}
+	if err := c.ReplaceAll("com.example.old", CurrentUserAnyHost, src); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.Set("stale", true, "com.example.new", CurrentUserAnyHost); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := c.CopyDomain("com.example.old", "com.example.new", CurrentUserAnyHost); err != nil {
+		t.Fatalf("CopyDomain() error = %v", err)
+	}
+	for _, appID := range []string{"com.example.old", "com.example.new"} {
+		got, err := c.GetAll(appID, CurrentUserAnyHost)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(got, src) {
+			t.Errorf("GetAll(%s) = %#v, want %#v", appID, got, src)
+		}
+	}
+	if err := c.CopyDomain("com.example.old", "com.example.old", CurrentUserAnyHost); err == nil {
+		t.Error("CopyDomain() to itself expected error")
+	}
+}
+
+func TestClientCollectDomains(t *testing.T) {
+	store := failingStore{MemoryStore: NewMemoryStore(), failAppID: "com.example.broken"}
+	c := NewClient(WithStore(store))
+	var appIDs []string
+	want := make(map[string]map[string]interface{})
+	for i := 0; i < 50; i++ {
+		appID := fmt.Sprintf("com.example.app%d", i)
+		appIDs = append(appIDs, appID)
+		want[appID] = map[string]interface{}{}
+		if i%2 == 0 {
+			if err := c.Set("index", i, appID, CurrentUserAnyHost); err != nil {
+				t.Fatal(err)
+			}
+			want[appID]["index"] = i
+		}
+	}
+	appIDs = append(appIDs, appIDs[0])
+
+	for _, concurrency := range []int{0, 1, 8, 100} {
+		got, err := c.CollectDomains(appIDs, CurrentUserAnyHost, concurrency)
+		if err != nil {
+			t.Fatalf("CollectDomains(%d) error = %v", concurrency, err)
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("CollectDomains(%d) = %#v, want %#v", concurrency, got, want)
+		}
+	}
+
+	_, err := c.CollectDomains(append(appIDs, "com.example.broken"), CurrentUserAnyHost, 4)
+	if err == nil || !strings.Contains(err.Error(), "com.example.broken") {
+		t.Errorf("CollectDomains() error = %v, want one naming the broken domain", err)
+	}
+	if got, err := c.CollectDomains(nil, CurrentUserAnyHost, 4); err != nil || len(got) != 0 {
+		t.Errorf("CollectDomains(nil) = %#v, %v", got, err)
+	}
+}
+
+func TestClientMigrateDomain(t *testing.T) {
+	c := NewClient(WithStore(NewMemoryStore()))
+	old := map[string]interface{}{"a": 1, "renamed": "x", "dropped": true, "kept": "old"}
+	if err := c.ReplaceAll("com.example.old", CurrentUserAnyHost, old); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.ReplaceAll("com.example.new", CurrentUserAnyHost, map[string]interface{}{"kept": "new", "other": 2}); err != nil {
+		t.Fatal(err)
+	}
+
+	opts := MigrateOptions{RenameKeys: map[string]string{"renamed": "b", "dropped": ""}, KeepExisting: true}
+	if err := c.MigrateDomain("com.example.old", "com.example.new", CurrentUserAnyHost, opts); err != nil {
+		t.Fatalf("MigrateDomain() error = %v", err)
+	}
+	got, err := c.GetAll("com.example.new", CurrentUserAnyHost)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := map[string]interface{}{"a": 1, "b": "x", "kept": "new", "other": 2}; !reflect.DeepEqual(got, want) {
+		t.Errorf("new domain = %#v, want %#v", got, want)
+	}
+	if got, _ := c.GetAll("com.example.old", CurrentUserAnyHost); len(got) != 0 {
+		t.Errorf("old domain = %#v after migration, want empty", got)
+	}
+}
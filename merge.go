@@ -0,0 +1,117 @@
+package mac_prefs
+
+import (
+	"fmt"
+	"reflect"
+)
+
+// ArrayMerge selects how MergeDict combines an array in the partial dictionary with the
+// array already stored under the same key.
+type ArrayMerge int
+
+const (
+	// MergeReplaceArrays replaces the stored array with the new one.
+	MergeReplaceArrays ArrayMerge = iota
+	// MergeAppendArrays appends the new elements to the stored array.
+	MergeAppendArrays
+	// MergeUnionArrays appends the new elements that are not already in the stored array.
+	MergeUnionArrays
+)
+
+// MergeDict deep-merges partial into the dictionary stored under key instead of replacing it,
+// so keys the caller does not mention, including keys of nested dictionaries, keep their
+// current values. A nil value in partial removes the key. The dictionary is read, merged, and
+// written back while the Client is locked.
+//
+// Parameters:
+//   - key: The preference key holding the dictionary.
+//   - applicationID: The bundle identifier of the application for which to update the preference.
+//   - scope: The PreferenceScope to write to.
+//   - partial: The keys and values to merge.
+//   - arrays: How arrays present in both dictionaries are combined.
+//
+// Returns:
+//   - error: An error if the stored value is not a dictionary or cannot be read or written.
+func MergeDict(key string, applicationID string, scope PreferenceScope, partial map[string]interface{}, arrays ArrayMerge) error {
+	return NewClient().MergeDict(key, applicationID, scope, partial, arrays)
+}
+
+// MergeDict deep-merges partial into the dictionary stored under key. See MergeDict.
+func (c *Client) MergeDict(key string, applicationID string, scope PreferenceScope, partial map[string]interface{}, arrays ArrayMerge) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	current, err := c.store.Get(key, applicationID, scope)
+	if err != nil {
+		return err
+	}
+	dict, ok := current.(map[string]interface{})
+	if !ok && current != nil {
+		return fmt.Errorf("error merging into %s: value of type %T is not a dictionary", key, current)
+	}
+	return c.set(key, mergeDict(dict, partial, arrays), applicationID, scope)
+}
+
+// mergeDict returns a copy of dst with src deep-merged into it.
+func mergeDict(dst, src map[string]interface{}, arrays ArrayMerge) map[string]interface{} {
+	result := make(map[string]interface{}, len(dst)+len(src))
+	for key, value := range dst {
+		result[key] = value
+	}
+	for key, value := range src {
+		if value == nil {
+			delete(result, key)
+			continue
+		}
+		result[key] = mergeValue(result[key], value, arrays)
+	}
+	return result
+}
+
+func mergeValue(dst, src interface{}, arrays ArrayMerge) interface{} {
+	switch d := dst.(type) {
+	case map[string]interface{}:
+		if s, ok := src.(map[string]interface{}); ok {
+			return mergeDict(d, s, arrays)
+		}
+	case []interface{}:
+		s, ok := sliceItems(src)
+		if !ok || arrays == MergeReplaceArrays {
+			break
+		}
+		result := append(make([]interface{}, 0, len(d)+len(s)), d...)
+		for _, item := range s {
+			if arrays == MergeUnionArrays && containsValue(result, item) {
+				continue
+			}
+			result = append(result, item)
+		}
+		return result
+	}
+	return src
+}
+
+// sliceItems returns the elements of a slice other than []byte.
+func sliceItems(value interface{}) ([]interface{}, bool) {
+	if items, ok := value.([]interface{}); ok {
+		return items, true
+	}
+	rv := reflect.ValueOf(value)
+	if rv.Kind() != reflect.Slice || rv.Type().Elem().Kind() == reflect.Uint8 {
+		return nil, false
+	}
+	items := make([]interface{}, rv.Len())
+	for i := range items {
+		items[i] = rv.Index(i).Interface()
+	}
+	return items, true
+}
+
+func containsValue(items []interface{}, value interface{}) bool {
+	for _, item := range items {
+		if valuesEqual(item, value) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,77 @@
+package mac_prefs
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestClientMergeDict(t *testing.T) {
+	stored := map[string]interface{}{
+		"ShowStatusBar": true,
+		"IconViewSettings": map[string]interface{}{
+			"iconSize":    64,
+			"arrangeBy":   "name",
+			"gridSpacing": 54,
+		},
+		"Tags":     []interface{}{"Red", "Blue"},
+		"Obsolete": "x",
+	}
+	partial := map[string]interface{}{
+		"IconViewSettings": map[string]interface{}{"iconSize": 80, "labelOnBottom": false},
+		"Tags":             []string{"Blue", "Green"},
+		"Obsolete":         nil,
+	}
+
+	tests := []struct {
+		arrays ArrayMerge
+		tags   interface{}
+	}{
+		{MergeReplaceArrays, []string{"Blue", "Green"}},
+		{MergeAppendArrays, []interface{}{"Red", "Blue", "Blue", "Green"}},
+		{MergeUnionArrays, []interface{}{"Red", "Blue", "Green"}},
+	}
+	for _, tt := range tests {
+		c := NewClient(WithStore(NewMemoryStore()))
+		if err := c.Set("StandardViewSettings", stored, "com.apple.finder", CurrentUserAnyHost); err != nil {
+			t.Fatal(err)
+		}
+		if err := c.MergeDict("StandardViewSettings", "com.apple.finder", CurrentUserAnyHost, partial, tt.arrays); err != nil {
+			t.Fatalf("MergeDict(%v) error = %v", tt.arrays, err)
+		}
+		got, err := c.Get("StandardViewSettings", "com.apple.finder", CurrentUserAnyHost)
+		if err != nil {
+			t.Fatal(err)
+		}
+		want := map[string]interface{}{
+			"ShowStatusBar": true,
+			"IconViewSettings": map[string]interface{}{
+				"iconSize":      80,
+				"arrangeBy":     "name",
+				"gridSpacing":   54,
+				"labelOnBottom": false,
+			},
+			"Tags": tt.tags,
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("MergeDict(%v) = %#v, want %#v", tt.arrays, got, want)
+		}
+	}
+}
+
+func TestClientMergeDictMissingAndInvalid(t *testing.T) {
+	c := NewClient(WithStore(NewMemoryStore()))
+	partial := map[string]interface{}{"a": 1}
+	if err := c.MergeDict("dict", "com.example", CurrentUserAnyHost, partial, MergeReplaceArrays); err != nil {
+		t.Fatalf("MergeDict() into a missing key error = %v", err)
+	}
+	if got, _ := c.Get("dict", "com.example", CurrentUserAnyHost); !reflect.DeepEqual(got, partial) {
+		t.Errorf("dict = %#v, want %#v", got, partial)
+	}
+
+	if err := c.Set("string", "x", "com.example", CurrentUserAnyHost); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.MergeDict("string", "com.example", CurrentUserAnyHost, partial, MergeReplaceArrays); err == nil {
+		t.Error("MergeDict() into a string expected error")
+	}
+}
@@ -0,0 +1,115 @@
+package mac_prefs
+
+import (
+	"encoding/json"
+	"sync"
+	"time"
+)
+
+// LatencyBuckets are the upper bounds of the latency histograms of Metrics.
+var LatencyBuckets = []time.Duration{
+	100 * time.Microsecond,
+	500 * time.Microsecond,
+	time.Millisecond,
+	5 * time.Millisecond,
+	10 * time.Millisecond,
+	50 * time.Millisecond,
+	100 * time.Millisecond,
+	500 * time.Millisecond,
+	time.Second,
+}
+
+// OperationStats aggregates the operations of one name on one domain.
+type OperationStats struct {
+	// Count is the number of operations.
+	Count int64 `json:"count"`
+	// Errors is the number of operations that returned an error.
+	Errors int64 `json:"errors"`
+	// Total is the sum of the durations of the operations.
+	Total time.Duration `json:"total_ns"`
+	// Buckets is the latency histogram: Buckets[i] counts the operations that took at most
+	// LatencyBuckets[i] and more than the bound before it, and the last element counts the
+	// operations slower than every bound.
+	Buckets []int64 `json:"buckets"`
+}
+
+// Metrics is an Instrumentation that counts operations and errors and records latency
+// histograms per domain and operation name. It is safe for concurrent use. Metrics
+// implements expvar.Var, so it can be published with expvar.Publish. The zero value is
+// an empty Metrics.
+type Metrics struct {
+	mu    sync.Mutex
+	stats map[string]map[string]*OperationStats
+}
+
+// NewMetrics returns an empty Metrics.
+func NewMetrics() *Metrics {
+	return &Metrics{stats: make(map[string]map[string]*OperationStats)}
+}
+
+// Observe records op.
+func (m *Metrics) Observe(op Operation) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.stats == nil {
+		m.stats = make(map[string]map[string]*OperationStats)
+	}
+	domain := m.stats[op.ApplicationID]
+	if domain == nil {
+		domain = make(map[string]*OperationStats)
+		m.stats[op.ApplicationID] = domain
+	}
+	stats := domain[op.Name]
+	if stats == nil {
+		stats = &OperationStats{Buckets: make([]int64, len(LatencyBuckets)+1)}
+		domain[op.Name] = stats
+	}
+	stats.Count++
+	if op.Err != nil {
+		stats.Errors++
+	}
+	stats.Total += op.Duration
+	bucket := len(LatencyBuckets)
+	for i, bound := range LatencyBuckets {
+		if op.Duration <= bound {
+			bucket = i
+			break
+		}
+	}
+	stats.Buckets[bucket]++
+}
+
+// Snapshot returns a copy of the statistics, by domain and then by operation name.
+func (m *Metrics) Snapshot() map[string]map[string]OperationStats {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	snapshot := make(map[string]map[string]OperationStats, len(m.stats))
+	for appID, domain := range m.stats {
+		ops := make(map[string]OperationStats, len(domain))
+		for name, stats := range domain {
+			copied := *stats
+			copied.Buckets = append([]int64(nil), stats.Buckets...)
+			ops[name] = copied
+		}
+		snapshot[appID] = ops
+	}
+	return snapshot
+}
+
+// Reset discards every recorded operation.
+func (m *Metrics) Reset() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.stats = make(map[string]map[string]*OperationStats)
+}
+
+// String returns the snapshot as JSON, for expvar.
+func (m *Metrics) String() string {
+	data, err := json.Marshal(m.Snapshot())
+	if err != nil {
+		return "{}"
+	}
+	return string(data)
+}
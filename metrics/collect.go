@@ -0,0 +1,42 @@
+package metrics
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/weswhet/mac_prefs"
+)
+
+// Collect reads every target from one exact (user, host) slot. The domain NSGlobalDomain
+// refers to the global domain.
+//
+// Parameters:
+//   - targets: The keys to read.
+//   - scope: The PreferenceScope to read from. Use AnyUserAnyHost for system settings stored
+//     in /Library/Preferences, such as com.apple.alf.
+//
+// Returns:
+//   - []Sample: One sample per target, with a nil Value for keys that are not set.
+//   - error: An error if a key cannot be read.
+func Collect(targets []Target, scope mac_prefs.PreferenceScope) ([]Sample, error) {
+	samples := make([]Sample, 0, len(targets))
+	for _, t := range targets {
+		domain := t.Domain
+		if domain == "NSGlobalDomain" || domain == mac_prefs.GlobalDomain {
+			domain = mac_prefs.AnyApplication
+		}
+		value, err := mac_prefs.Get(t.Key, domain, scope)
+		if err != nil {
+			return nil, fmt.Errorf("metrics: error reading %s %s: %v", t.Domain, t.Key, err)
+		}
+		samples = append(samples, Sample{Target: t, Value: value})
+	}
+	return samples, nil
+}
+
+// Handler returns a handler that collects targets from scope on every scrape.
+func Handler(targets []Target, scope mac_prefs.PreferenceScope) http.Handler {
+	return NewHandler(func() ([]Sample, error) {
+		return Collect(targets, scope)
+	})
+}
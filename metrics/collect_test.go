@@ -0,0 +1,22 @@
+//go:build darwin && cgo
+
+package metrics
+
+import (
+	"testing"
+
+	"github.com/weswhet/mac_prefs/prefstest"
+)
+
+func TestCollect(t *testing.T) {
+	d := prefstest.New(t)
+	d.Set("Exported", 3)
+
+	samples, err := Collect([]Target{{d.ID, "Exported"}, {d.ID, "Missing"}}, d.Scope)
+	if err != nil {
+		t.Fatalf("Collect() error = %v", err)
+	}
+	if len(samples) != 2 || samples[0].Value != 3 || samples[1].Value != nil {
+		t.Fatalf("Collect() = %+v", samples)
+	}
+}
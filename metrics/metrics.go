@@ -0,0 +1,165 @@
+// Package metrics exports selected preference values in the Prometheus text exposition
+// format, so monitoring can alert when a critical setting drifts.
+//
+// Every target produces up to three gauges:
+//
+//	macprefs_present{domain="...",key="..."} 1            whether the key is set
+//	macprefs_value{domain="...",key="..."} 1              numbers, booleans (0/1), and dates (Unix seconds)
+//	macprefs_info{domain="...",key="...",value="..."} 1   strings
+package metrics
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DefaultAddr is the address the exporter listens on when none is given.
+const DefaultAddr = "127.0.0.1:9398"
+
+// Target is a preference key to export.
+type Target struct {
+	Domain string
+	Key    string
+}
+
+// Sample is the value of a Target at scrape time.
+type Sample struct {
+	Target
+	// Value is the preference value, or nil if the key is not set.
+	Value interface{}
+}
+
+// ParseTargets reads targets from a configuration file with one "domain key" pair per line.
+// Blank lines and lines starting with # are ignored.
+func ParseTargets(r io.Reader) ([]Target, error) {
+	var targets []Target
+	scanner := bufio.NewScanner(r)
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+		fields := strings.Fields(text)
+		if len(fields) != 2 {
+			return nil, fmt.Errorf("metrics: line %d: expected \"domain key\", got %q", line, text)
+		}
+		targets = append(targets, Target{Domain: fields[0], Key: fields[1]})
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("metrics: error reading targets: %v", err)
+	}
+	return targets, nil
+}
+
+// ParseTarget parses a "domain:key" pair.
+func ParseTarget(s string) (Target, error) {
+	domain, key, ok := strings.Cut(s, ":")
+	if !ok || domain == "" || key == "" {
+		return Target{}, fmt.Errorf("metrics: invalid target %q: expected domain:key", s)
+	}
+	return Target{Domain: domain, Key: key}, nil
+}
+
+// WriteText writes samples in the Prometheus text exposition format, sorted by domain and key.
+func WriteText(w io.Writer, samples []Sample) error {
+	sorted := make([]Sample, len(samples))
+	copy(sorted, samples)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		if sorted[i].Domain != sorted[j].Domain {
+			return sorted[i].Domain < sorted[j].Domain
+		}
+		return sorted[i].Key < sorted[j].Key
+	})
+
+	var present, values, infos []string
+	for _, s := range sorted {
+		labels := fmt.Sprintf(`domain="%s",key="%s"`, escapeLabel(s.Domain), escapeLabel(s.Key))
+		if s.Value == nil {
+			present = append(present, fmt.Sprintf("macprefs_present{%s} 0", labels))
+			continue
+		}
+		present = append(present, fmt.Sprintf("macprefs_present{%s} 1", labels))
+		if str, ok := s.Value.(string); ok {
+			infos = append(infos, fmt.Sprintf(`macprefs_info{%s,value="%s"} 1`, labels, escapeLabel(str)))
+		} else if f, ok := numericValue(s.Value); ok {
+			values = append(values, fmt.Sprintf("macprefs_value{%s} %s", labels, formatFloat(f)))
+		}
+	}
+
+	bw := bufio.NewWriter(w)
+	writeFamily(bw, "macprefs_present", "Whether the preference key is set.", present)
+	writeFamily(bw, "macprefs_value", "Numeric preference values; booleans are 0 or 1 and dates are Unix seconds.", values)
+	writeFamily(bw, "macprefs_info", "String preference values, carried in the value label.", infos)
+	return bw.Flush()
+}
+
+// NewHandler returns a handler that serves the samples returned by collect on every scrape.
+func NewHandler(collect func() ([]Sample, error)) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		samples, err := collect()
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+		WriteText(w, samples)
+	})
+}
+
+func writeFamily(w *bufio.Writer, name, help string, lines []string) {
+	if len(lines) == 0 {
+		return
+	}
+	fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s gauge\n", name, help, name)
+	for _, line := range lines {
+		w.WriteString(line)
+		w.WriteByte('\n')
+	}
+}
+
+// numericValue converts numbers, booleans, and dates to a gauge value.
+func numericValue(v interface{}) (float64, bool) {
+	switch v := v.(type) {
+	case bool:
+		if v {
+			return 1, true
+		}
+		return 0, true
+	case int:
+		return float64(v), true
+	case int64:
+		return float64(v), true
+	case uint64:
+		return float64(v), true
+	case float64:
+		return v, true
+	case time.Time:
+		return float64(v.UnixNano()) / 1e9, true
+	default:
+		return 0, false
+	}
+}
+
+func formatFloat(f float64) string {
+	switch {
+	case math.IsInf(f, 1):
+		return "+Inf"
+	case math.IsInf(f, -1):
+		return "-Inf"
+	case math.IsNaN(f):
+		return "NaN"
+	}
+	return strconv.FormatFloat(f, 'g', -1, 64)
+}
+
+// escapeLabel escapes a label value as the exposition format requires.
+func escapeLabel(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(s)
+}
@@ -0,0 +1,92 @@
+package metrics
+
+import (
+	"errors"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestWriteText(t *testing.T) {
+	samples := []Sample{
+		{Target: Target{"com.apple.screensaver", "askForPassword"}, Value: true},
+		{Target: Target{"com.apple.alf", "globalstate"}, Value: 1},
+		{Target: Target{"com.apple.dock", "orientation"}, Value: "say \"left\"\n"},
+		{Target: Target{"com.apple.dock", "magnification"}, Value: nil},
+		{Target: Target{"com.apple.dock", "tilesize"}, Value: 48.5},
+		{Target: Target{"com.apple.dock", "persistent-apps"}, Value: []interface{}{}},
+		{Target: Target{"com.example", "updated"}, Value: time.Unix(1700000000, 0)},
+	}
+	var b strings.Builder
+	if err := WriteText(&b, samples); err != nil {
+		t.Fatalf("WriteText() error = %v", err)
+	}
+	want := `# HELP macprefs_present Whether the preference key is set.
+# TYPE macprefs_present gauge
+macprefs_present{domain="com.apple.alf",key="globalstate"} 1
+macprefs_present{domain="com.apple.dock",key="magnification"} 0
+macprefs_present{domain="com.apple.dock",key="orientation"} 1
+macprefs_present{domain="com.apple.dock",key="persistent-apps"} 1
+macprefs_present{domain="com.apple.dock",key="tilesize"} 1
+macprefs_present{domain="com.apple.screensaver",key="askForPassword"} 1
+macprefs_present{domain="com.example",key="updated"} 1
+# HELP macprefs_value Numeric preference values; booleans are 0 or 1 and dates are Unix seconds.
+# TYPE macprefs_value gauge
+macprefs_value{domain="com.apple.alf",key="globalstate"} 1
+macprefs_value{domain="com.apple.dock",key="tilesize"} 48.5
+macprefs_value{domain="com.apple.screensaver",key="askForPassword"} 1
+macprefs_value{domain="com.example",key="updated"} 1.7e+09
+# HELP macprefs_info String preference values, carried in the value label.
+# TYPE macprefs_info gauge
+macprefs_info{domain="com.apple.dock",key="orientation",value="say \"left\"\n"} 1
+`
+	if got := b.String(); got != want {
+		t.Fatalf("WriteText() =\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestParseTargets(t *testing.T) {
+	targets, err := ParseTargets(strings.NewReader("# firewall\ncom.apple.alf globalstate\n\n  com.apple.screensaver askForPassword  \n"))
+	if err != nil {
+		t.Fatalf("ParseTargets() error = %v", err)
+	}
+	want := []Target{{"com.apple.alf", "globalstate"}, {"com.apple.screensaver", "askForPassword"}}
+	if len(targets) != len(want) || targets[0] != want[0] || targets[1] != want[1] {
+		t.Fatalf("ParseTargets() = %v, want %v", targets, want)
+	}
+
+	if _, err := ParseTargets(strings.NewReader("com.apple.alf\n")); err == nil {
+		t.Fatalf("ParseTargets() expected error for a line without a key")
+	}
+}
+
+func TestParseTarget(t *testing.T) {
+	got, err := ParseTarget("com.apple.alf:globalstate")
+	if err != nil || got != (Target{"com.apple.alf", "globalstate"}) {
+		t.Fatalf("ParseTarget() = %v, %v", got, err)
+	}
+	for _, s := range []string{"com.apple.alf", ":key", "domain:"} {
+		if _, err := ParseTarget(s); err == nil {
+			t.Errorf("ParseTarget(%q) expected error", s)
+		}
+	}
+}
+
+func TestNewHandler(t *testing.T) {
+	h := NewHandler(func() ([]Sample, error) {
+		return []Sample{{Target: Target{"a", "b"}, Value: 2}}, nil
+	})
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest("GET", "/metrics", nil))
+	if w.Code != 200 || !strings.Contains(w.Body.String(), `macprefs_value{domain="a",key="b"} 2`) {
+		t.Fatalf("GET /metrics = %d %q", w.Code, w.Body.String())
+	}
+
+	h = NewHandler(func() ([]Sample, error) { return nil, errors.New("broken") })
+	w = httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest("GET", "/metrics", nil))
+	if w.Code != 500 {
+		t.Fatalf("GET /metrics with a failing collector = %d", w.Code)
+	}
+}
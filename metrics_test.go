@@ -0,0 +1,117 @@
+package mac_prefs
+
+import (
+	"encoding/json"
+	"expvar"
+	"testing"
+	"time"
+)
+
+var _ expvar.Var = (*Metrics)(nil)
+
+func TestClientInstrumentation(t *testing.T) {
+	m := NewMetrics()
+	store := failingStore{MemoryStore: NewMemoryStore(), failAppID: "com.example.fail"}
+	c := NewClient(WithStore(store), WithCache(CacheOptions{}), WithInstrumentation(m))
+
+	if err := c.Set("key", 1, "com.example", CurrentUserAnyHost); err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 3; i++ {
+		if _, err := c.Get("key", "com.example", CurrentUserAnyHost); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := c.Delete("key", "com.example", CurrentUserAnyHost); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := c.GetAll("com.example.fail", CurrentUserAnyHost); err == nil {
+		t.Fatal("GetAll() error = nil, want an error")
+	}
+	value, err := c.GetLazy("missing", "com.example", CurrentUserAnyHost)
+	if err != nil || value != nil {
+		t.Fatalf("GetLazy() = %v, %v", value, err)
+	}
+
+	snapshot := m.Snapshot()
+	for _, tt := range []struct {
+		domain, op    string
+		count, errors int64
+	}{
+		{"com.example", "set", 1, 0},
+		// The cache serves the repeated reads. GetLazy reads through it after the delete.
+		{"com.example", "get", 2, 0},
+		{"com.example", "delete", 1, 0},
+		{"com.example.fail", "list", 1, 1},
+	} {
+		stats := snapshot[tt.domain][tt.op]
+		if stats.Count != tt.count || stats.Errors != tt.errors {
+			t.Errorf("%s %s = %+v, want count %d and errors %d", tt.domain, tt.op, stats, tt.count, tt.errors)
+		}
+		var bucketed int64
+		for _, n := range stats.Buckets {
+			bucketed += n
+		}
+		if len(stats.Buckets) != len(LatencyBuckets)+1 || bucketed != stats.Count {
+			t.Errorf("%s %s buckets = %v", tt.domain, tt.op, stats.Buckets)
+		}
+	}
+
+	var decoded map[string]map[string]OperationStats
+	if err := json.Unmarshal([]byte(m.String()), &decoded); err != nil {
+		t.Fatalf("String() is not JSON: %v", err)
+	}
+	if decoded["com.example"]["set"].Count != 1 {
+		t.Errorf("String() = %s", m.String())
+	}
+
+	m.Reset()
+	if len(m.Snapshot()) != 0 {
+		t.Errorf("Snapshot() after Reset() = %v", m.Snapshot())
+	}
+}
+
+func TestClientInstrumentationGetLazy(t *testing.T) {
+	m := NewMetrics()
+	c := NewClient(WithStore(NewMemoryStore()), WithInstrumentation(m), WithRetry(RetryPolicy{MaxAttempts: 2}))
+	if err := c.Set("key", []interface{}{1}, "com.example", CurrentUserAnyHost); err != nil {
+		t.Fatal(err)
+	}
+	value, err := c.GetLazy("key", "com.example", CurrentUserAnyHost)
+	if err != nil || value == nil {
+		t.Fatalf("GetLazy() = %v, %v", value, err)
+	}
+	value.Close()
+	snapshot := m.Snapshot()["com.example"]
+	if snapshot["get lazy"].Count != 1 || snapshot["get"].Count != 0 {
+		t.Errorf("Snapshot() = %+v, want one get lazy", snapshot)
+	}
+}
+
+func TestMetricsBuckets(t *testing.T) {
+	m := NewMetrics()
+	for _, d := range []time.Duration{0, LatencyBuckets[0], LatencyBuckets[0] + 1, time.Hour} {
+		m.Observe(Operation{Name: "get", ApplicationID: "com.example", Duration: d})
+	}
+	stats := m.Snapshot()["com.example"]["get"]
+	if stats.Buckets[0] != 2 || stats.Buckets[1] != 1 || stats.Buckets[len(LatencyBuckets)] != 1 {
+		t.Errorf("Buckets = %v", stats.Buckets)
+	}
+	if stats.Total != time.Hour+2*LatencyBuckets[0]+1 {
+		t.Errorf("Total = %v", stats.Total)
+	}
+}
+
+func TestMetricsZeroValue(t *testing.T) {
+	var m Metrics
+	if got := m.Snapshot(); len(got) != 0 {
+		t.Errorf("Snapshot() of a zero Metrics = %v, want empty", got)
+	}
+	c := NewClient(WithStore(NewMemoryStore()), WithInstrumentation(&m))
+	if err := c.Set("tilesize", 48, "com.example", CurrentUserAnyHost); err != nil {
+		t.Fatalf("Set() error = %v", err)
+	}
+	if stats := m.Snapshot()["com.example"]["set"]; stats.Count != 1 {
+		t.Errorf("set stats = %+v, want one operation", stats)
+	}
+}
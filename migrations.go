@@ -0,0 +1,97 @@
+package mac_prefs
+
+import (
+	"fmt"
+	"sort"
+)
+
+// MigrationFunc upgrades the settings of one domain slot by one schema version. It reads and
+// writes through tx, so its writes are applied only if every pending migration succeeds.
+type MigrationFunc func(tx *Tx, applicationID string, scope PreferenceScope) error
+
+// Migrations is an ordered set of settings migrations for an application's own preferences.
+// The schema version of a domain is recorded in the preference named by the version key, and
+// each migration is registered with the version it upgrades the settings to.
+type Migrations struct {
+	versionKey string
+	steps      map[int]MigrationFunc
+}
+
+// NewMigrations creates an empty set of migrations that records the schema version under
+// versionKey, e.g. "SettingsVersion".
+func NewMigrations(versionKey string) *Migrations {
+	return &Migrations{versionKey: versionKey, steps: make(map[int]MigrationFunc)}
+}
+
+// Register adds the migration that upgrades settings to version, which must be positive.
+// Register panics if version is not positive or already has a migration.
+func (m *Migrations) Register(version int, migrate MigrationFunc) {
+	if version <= 0 {
+		panic(fmt.Sprintf("mac_prefs: migration version %d is not positive", version))
+	}
+	if _, ok := m.steps[version]; ok {
+		panic(fmt.Sprintf("mac_prefs: migration %d registered twice", version))
+	}
+	m.steps[version] = migrate
+}
+
+// Run applies the pending migrations to one exact (user, host) slot of a domain. See
+// Client.Migrate.
+//
+// Parameters:
+//   - applicationID: The bundle identifier of the application whose preferences to migrate.
+//   - scope: The PreferenceScope to migrate.
+//
+// Returns:
+//   - int: The schema version of the domain after Run.
+//   - error: An error if the version cannot be read, a migration fails, or the result cannot
+//     be written.
+func (m *Migrations) Run(applicationID string, scope PreferenceScope) (int, error) {
+	return NewClient().Migrate(m, applicationID, scope)
+}
+
+// Migrate applies the migrations registered for versions above the recorded schema version
+// of a domain slot, in ascending order, and records the highest version. A domain without a
+// recorded version is at version 0. All migrations share one transaction, so if any of them
+// fails nothing is written and the recorded version is unchanged.
+func (c *Client) Migrate(m *Migrations, applicationID string, scope PreferenceScope) (int, error) {
+	value, err := c.Get(m.versionKey, applicationID, scope)
+	if err != nil {
+		return 0, err
+	}
+	current := 0
+	if value != nil {
+		var ok bool
+		if current, ok = intElement(value); !ok {
+			return 0, fmt.Errorf("error reading %s: value of type %T is not an integer", m.versionKey, value)
+		}
+	}
+
+	var pending []int
+	for version := range m.steps {
+		if version > current {
+			pending = append(pending, version)
+		}
+	}
+	if len(pending) == 0 {
+		return current, nil
+	}
+	sort.Ints(pending)
+
+	tx := c.Begin()
+	for _, version := range pending {
+		if err := m.steps[version](tx, applicationID, scope); err != nil {
+			tx.Rollback()
+			return current, fmt.Errorf("error running migration %d: %v", version, err)
+		}
+	}
+	latest := pending[len(pending)-1]
+	if err := tx.Set(m.versionKey, latest, applicationID, scope); err != nil {
+		tx.Rollback()
+		return current, err
+	}
+	if err := tx.Commit(); err != nil {
+		return current, err
+	}
+	return latest, nil
+}
@@ -0,0 +1,89 @@
+package mac_prefs
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestClientMigrate(t *testing.T) {
+	c := NewClient(WithStore(NewMemoryStore()))
+	const appID = "com.example"
+	if err := c.Set("Server", "example.com", appID, CurrentUserAnyHost); err != nil {
+		t.Fatal(err)
+	}
+
+	var ran []int
+	m := NewMigrations("SettingsVersion")
+	m.Register(2, func(tx *Tx, appID string, scope PreferenceScope) error {
+		ran = append(ran, 2)
+		host, err := tx.Get("ServerHost", appID, scope)
+		if err != nil {
+			return err
+		}
+		return tx.Set("ServerURL", "https://"+host.(string), appID, scope)
+	})
+	m.Register(1, func(tx *Tx, appID string, scope PreferenceScope) error {
+		ran = append(ran, 1)
+		server, err := tx.Get("Server", appID, scope)
+		if err != nil {
+			return err
+		}
+		if err := tx.Set("ServerHost", server, appID, scope); err != nil {
+			return err
+		}
+		return tx.Delete("Server", appID, scope)
+	})
+
+	version, err := c.Migrate(m, appID, CurrentUserAnyHost)
+	if err != nil || version != 2 {
+		t.Fatalf("Migrate() = %d, %v, want 2", version, err)
+	}
+	if !reflect.DeepEqual(ran, []int{1, 2}) {
+		t.Errorf("migrations ran in order %v, want [1 2]", ran)
+	}
+	got, _ := c.GetAll(appID, CurrentUserAnyHost)
+	want := map[string]interface{}{"ServerHost": "example.com", "ServerURL": "https://example.com", "SettingsVersion": 2}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("GetAll() = %#v, want %#v", got, want)
+	}
+
+	ran = nil
+	if version, err := c.Migrate(m, appID, CurrentUserAnyHost); err != nil || version != 2 || ran != nil {
+		t.Errorf("second Migrate() = %d, %v and ran %v, want 2 and no migrations", version, err, ran)
+	}
+}
+
+func TestClientMigrateFailure(t *testing.T) {
+	c := NewClient(WithStore(NewMemoryStore()))
+	m := NewMigrations("SettingsVersion")
+	m.Register(1, func(tx *Tx, appID string, scope PreferenceScope) error {
+		return tx.Set("a", 1, appID, scope)
+	})
+	m.Register(2, func(*Tx, string, PreferenceScope) error {
+		return errors.New("boom")
+	})
+
+	version, err := c.Migrate(m, "com.example", CurrentUserAnyHost)
+	if err == nil || version != 0 {
+		t.Fatalf("Migrate() = %d, %v, want 0 and an error", version, err)
+	}
+	if got, _ := c.GetAll("com.example", CurrentUserAnyHost); len(got) != 0 {
+		t.Errorf("GetAll() = %#v after a failed migration, want empty", got)
+	}
+}
+
+func TestMigrationsRegisterPanics(t *testing.T) {
+	m := NewMigrations("SettingsVersion")
+	m.Register(1, func(*Tx, string, PreferenceScope) error { return nil })
+	for _, version := range []int{0, 1} {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("Register(%d) did not panic", version)
+				}
+			}()
+			m.Register(version, func(*Tx, string, PreferenceScope) error { return nil })
+		}()
+	}
+}
@@ -0,0 +1,81 @@
+package mac_prefs
+
+import (
+	"bytes"
+
+	"github.com/weswhet/mac_prefs/internal/plist"
+	"github.com/weswhet/mac_prefs/keyedarchive"
+)
+
+// WithNestedPlists makes the Client's Get and GetAll parse data values that hold a binary or
+// XML property list, such as the whole plists com.apple.spaces keeps in data keys, and return
+// the parsed value in their place. Nested plists are parsed recursively. Keyed archives are
+// left as data; see GetArchived. The raw bytes remain available from a Client without this
+// option.
+func WithNestedPlists() Option {
+	return func(c *Client) {
+		c.nestedPlists = true
+	}
+}
+
+// parseNestedPlists replaces the data values in value that hold a property list with the
+// parsed value. Containers are copied only if they hold one.
+func parseNestedPlists(value interface{}) (interface{}, bool) {
+	switch v := value.(type) {
+	case []byte:
+		if !isPlistData(v) || keyedarchive.IsArchive(v) {
+			return value, false
+		}
+		parsed, err := plist.Unmarshal(v)
+		if err != nil {
+			return value, false
+		}
+		parsed, _ = parseNestedPlists(parsed)
+		return parsed, true
+	case []interface{}:
+		var result []interface{}
+		for i, item := range v {
+			parsed, ok := parseNestedPlists(item)
+			if !ok {
+				continue
+			}
+			if result == nil {
+				result = append([]interface{}(nil), v...)
+			}
+			result[i] = parsed
+		}
+		if result != nil {
+			return result, true
+		}
+	case map[string]interface{}:
+		var result map[string]interface{}
+		for key, item := range v {
+			parsed, ok := parseNestedPlists(item)
+			if !ok {
+				continue
+			}
+			if result == nil {
+				result = make(map[string]interface{}, len(v))
+				for k, item := range v {
+					result[k] = item
+				}
+			}
+			result[key] = parsed
+		}
+		if result != nil {
+			return result, true
+		}
+	}
+	return value, false
+}
+
+// isPlistData reports whether data starts like a binary or XML property list.
+func isPlistData(data []byte) bool {
+	if bytes.HasPrefix(data, []byte("bplist00")) {
+		return true
+	}
+	data = bytes.TrimPrefix(data, []byte("\xef\xbb\xbf"))
+	data = bytes.TrimLeft(data, " \t\r\n")
+	return bytes.HasPrefix(data, []byte("<?xml")) && bytes.Contains(data, []byte("<plist")) ||
+		bytes.HasPrefix(data, []byte("<!DOCTYPE plist")) || bytes.HasPrefix(data, []byte("<plist"))
+}
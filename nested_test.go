@@ -0,0 +1,73 @@
+package mac_prefs
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+
+	"github.com/weswhet/mac_prefs/internal/plist"
+	"github.com/weswhet/mac_prefs/keyedarchive"
+)
+
+func TestClientNestedPlists(t *testing.T) {
+	store := NewMemoryStore()
+	const app = "com.example.nested"
+
+	inner, err := plist.MarshalXML(map[string]interface{}{"count": 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	outer, err := plist.MarshalXML(map[string]interface{}{"inner": inner})
+	if err != nil {
+		t.Fatal(err)
+	}
+	archive, err := keyedarchive.Encode("archived")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := store.Set("Config", map[string]interface{}{"spaces": outer, "raw": []byte("raw")}, app, CurrentUserAnyHost); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.Set("Archive", archive, app, CurrentUserAnyHost); err != nil {
+		t.Fatal(err)
+	}
+
+	c := NewClient(WithStore(store), WithNestedPlists())
+	got, err := c.Get("Config", app, CurrentUserAnyHost)
+	if err != nil {
+		t.Fatalf("Get() error = %v", err)
+	}
+	want := map[string]interface{}{
+		"spaces": map[string]interface{}{"inner": map[string]interface{}{"count": 2}},
+		"raw":    []byte("raw"),
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("Get() = %#v, want %#v", got, want)
+	}
+	if got, _ := c.Get("Archive", app, CurrentUserAnyHost); !bytes.Equal(got.([]byte), archive) {
+		t.Fatalf("Get() of a keyed archive = %#v, want the raw data", got)
+	}
+
+	all, err := c.GetAll(app, CurrentUserAnyHost)
+	if err != nil || !reflect.DeepEqual(all["Config"], want) {
+		t.Fatalf("GetAll() = %#v, %v", all, err)
+	}
+
+	raw, _ := NewClient(WithStore(store)).Get("Config", app, CurrentUserAnyHost)
+	if !bytes.Equal(raw.(map[string]interface{})["spaces"].([]byte), outer) {
+		t.Fatal("Get() without WithNestedPlists did not return the raw data")
+	}
+}
+
+func TestIsPlistData(t *testing.T) {
+	for _, data := range []string{"bplist00...", "<?xml version=\"1.0\"?>\n<plist>", "\xef\xbb\xbf<plist>", "  <!DOCTYPE plist>"} {
+		if !isPlistData([]byte(data)) {
+			t.Errorf("isPlistData(%q) = false, want true", data)
+		}
+	}
+	for _, data := range []string{"", "bplist", "<?xml version=\"1.0\"?><html/>", "hello"} {
+		if isPlistData([]byte(data)) {
+			t.Errorf("isPlistData(%q) = true, want false", data)
+		}
+	}
+}
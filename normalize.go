@@ -0,0 +1,162 @@
+package mac_prefs
+
+import (
+	"math"
+	"net/url"
+	"reflect"
+	"strconv"
+	"time"
+
+	"github.com/weswhet/mac_prefs/internal/prefstag"
+)
+
+// NumberMode selects the Go types a Client returns for numbers.
+type NumberMode int
+
+const (
+	// NumberExact returns numbers as the store produced them: int for CFNumber integers and
+	// float64 for reals, or the exact type that was written to a MemoryStore.
+	NumberExact NumberMode = iota
+	// NumberInt64 returns every integer as int64. Reals stay float64, and unsigned values
+	// above math.MaxInt64 stay uint64.
+	NumberInt64
+	// NumberFloat64 returns every number as float64, like encoding/json does.
+	NumberFloat64
+)
+
+// WithNumberMode makes every read of the Client return numbers, including those nested in
+// arrays and dictionaries, with the types selected by mode.
+func WithNumberMode(mode NumberMode) Option {
+	return func(c *Client) {
+		c.numberMode = mode
+	}
+}
+
+// WithLocation makes every read of the Client return dates, including those nested in arrays
+// and dictionaries, in loc instead of UTC, e.g. time.Local.
+func WithLocation(loc *time.Location) Option {
+	return func(c *Client) {
+		c.location = loc
+	}
+}
+
+// normalizeRead converts the numbers in value to the types selected by mode and, if loc is
+// not nil, its dates to loc. Arrays and dictionaries are copied when anything is converted.
+func normalizeRead(value interface{}, mode NumberMode, loc *time.Location) interface{} {
+	if (mode == NumberExact && loc == nil) || value == nil {
+		return value
+	}
+
+	switch v := value.(type) {
+	case []interface{}:
+		result := make([]interface{}, len(v))
+		for i, item := range v {
+			result[i] = normalizeRead(item, mode, loc)
+		}
+		return result
+	case map[string]interface{}:
+		result := make(map[string]interface{}, len(v))
+		for key, item := range v {
+			result[key] = normalizeRead(item, mode, loc)
+		}
+		return result
+	case time.Time:
+		if loc != nil {
+			return v.In(loc)
+		}
+		return value
+	}
+	if mode == NumberExact {
+		return value
+	}
+
+	rv := reflect.ValueOf(value)
+	switch rv.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		if mode == NumberFloat64 {
+			return float64(rv.Int())
+		}
+		return rv.Int()
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		if mode == NumberFloat64 {
+			return float64(rv.Uint())
+		}
+		if rv.Uint() > math.MaxInt64 {
+			return rv.Uint()
+		}
+		return int64(rv.Uint())
+	case reflect.Float32, reflect.Float64:
+		return rv.Float()
+	}
+	return value
+}
+
+// WithUnsignedAsString makes the Client store unsigned integers above math.MaxInt64, which
+// do not fit in a CFNumber, as decimal strings instead of failing with ErrUnsignedOverflow.
+// They read back as strings.
+func WithUnsignedAsString() Option {
+	return func(c *Client) {
+		c.unsignedAsString = true
+	}
+}
+
+// unsignedToString replaces the unsigned integers above math.MaxInt64 in value with decimal
+// strings.
+func unsignedToString(value interface{}) interface{} {
+	return rewriteLeaves(value, func(leaf interface{}) (interface{}, bool) {
+		switch v := leaf.(type) {
+		case uint64:
+			if v > math.MaxInt64 {
+				return strconv.FormatUint(v, 10), true
+			}
+		case uint:
+			if uint64(v) > math.MaxInt64 {
+				return strconv.FormatUint(uint64(v), 10), true
+			}
+		}
+		return leaf, false
+	})
+}
+
+// rewriteLeaves passes every value in value that is not a container to rewrite, which
+// returns a replacement and true, or false to keep the value. Marshalers are marshaled and
+// pointers dereferenced first, and slices, string-keyed maps, and structs are copied as
+// []interface{} and map[string]interface{}.
+func rewriteLeaves(value interface{}, rewrite func(interface{}) (interface{}, bool)) interface{} {
+	if isNilPointer(value) {
+		return value
+	}
+	if marshaled, ok, err := marshalValue(value); ok && err == nil {
+		return rewriteLeaves(marshaled, rewrite)
+	}
+	if replaced, ok := rewrite(value); ok {
+		return replaced
+	}
+	switch value.(type) {
+	case nil, []byte, time.Time, Null, url.URL, *url.URL:
+		return value
+	}
+
+	rv := reflect.ValueOf(value)
+	switch {
+	case rv.Kind() == reflect.Slice:
+		result := make([]interface{}, rv.Len())
+		for i := range result {
+			result[i] = rewriteLeaves(rv.Index(i).Interface(), rewrite)
+		}
+		return result
+	case rv.Kind() == reflect.Map && rv.Type().Key().Kind() == reflect.String:
+		result := make(map[string]interface{}, rv.Len())
+		for _, key := range rv.MapKeys() {
+			if item := rv.MapIndex(key).Interface(); !isNilPointer(item) {
+				result[key.String()] = rewriteLeaves(item, rewrite)
+			}
+		}
+		return result
+	case rv.Kind() == reflect.Struct:
+		return rewriteLeaves(prefstag.Values(rv), rewrite)
+	case rv.Kind() == reflect.Ptr:
+		return rewriteLeaves(rv.Elem().Interface(), rewrite)
+	}
+	return value
+}
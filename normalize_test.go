@@ -0,0 +1,123 @@
+package mac_prefs
+
+import (
+	"errors"
+	"math"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestNormalizeReadNumbers(t *testing.T) {
+	value := map[string]interface{}{
+		"int":    3,
+		"int32":  int32(-4),
+		"uint":   uint16(5),
+		"big":    uint64(math.MaxUint64),
+		"real":   1.5,
+		"single": float32(0.5),
+		"name":   "dock",
+		"list":   []interface{}{1, 2.5, true},
+	}
+	tests := []struct {
+		mode NumberMode
+		want map[string]interface{}
+	}{
+		{NumberExact, value},
+		{NumberInt64, map[string]interface{}{
+			"int": int64(3), "int32": int64(-4), "uint": int64(5), "big": uint64(math.MaxUint64),
+			"real": 1.5, "single": 0.5, "name": "dock", "list": []interface{}{int64(1), 2.5, true},
+		}},
+		{NumberFloat64, map[string]interface{}{
+			"int": 3.0, "int32": -4.0, "uint": 5.0, "big": float64(math.MaxUint64),
+			"real": 1.5, "single": 0.5, "name": "dock", "list": []interface{}{1.0, 2.5, true},
+		}},
+	}
+	for _, tt := range tests {
+		if got := normalizeRead(value, tt.mode, nil); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("normalizeRead(%d) = %#v, want %#v", tt.mode, got, tt.want)
+		}
+	}
+	if value["int"] != 3 {
+		t.Fatal("normalizeRead() modified its input")
+	}
+}
+
+func TestClientNumberMode(t *testing.T) {
+	store := NewMemoryStore()
+	store.Set("tilesize", int32(48), "com.example", CurrentUserAnyHost)
+	store.Set("sizes", []interface{}{16, 32.0}, "com.example", CurrentUserAnyHost)
+
+	c := NewClient(WithStore(store), WithNumberMode(NumberInt64))
+	if got, err := c.Get("tilesize", "com.example", CurrentUserAnyHost); err != nil || got != int64(48) {
+		t.Fatalf("Get() = %#v, %v, want int64(48)", got, err)
+	}
+
+	c = NewClient(WithStore(store), WithNumberMode(NumberFloat64))
+	values, err := c.GetAll("com.example", CurrentUserAnyHost)
+	if err != nil {
+		t.Fatalf("GetAll() error = %v", err)
+	}
+	want := map[string]interface{}{"tilesize": 48.0, "sizes": []interface{}{16.0, 32.0}}
+	if !reflect.DeepEqual(values, want) {
+		t.Fatalf("GetAll() = %#v, want %#v", values, want)
+	}
+
+	c = NewClient(WithStore(store))
+	if got, _ := c.Get("tilesize", "com.example", CurrentUserAnyHost); got != int32(48) {
+		t.Fatalf("Get() with NumberExact = %#v, want int32(48)", got)
+	}
+}
+
+func TestClientUnsignedOverflow(t *testing.T) {
+	store := NewMemoryStore()
+	big := uint64(math.MaxUint64)
+
+	err := NewClient(WithStore(store)).Set("id", big, "com.example", CurrentUserAnyHost)
+	if !errors.Is(err, ErrUnsignedOverflow) {
+		t.Fatalf("Set() error = %v, want ErrUnsignedOverflow", err)
+	}
+
+	c := NewClient(WithStore(store), WithUnsignedAsString())
+	if err := c.Set("id", big, "com.example", CurrentUserAnyHost); err != nil {
+		t.Fatalf("Set() error = %v", err)
+	}
+	if err := c.Set("ids", []uint64{1, big}, "com.example", CurrentUserAnyHost); err != nil {
+		t.Fatalf("Set() error = %v", err)
+	}
+	values, _ := c.GetAll("com.example", CurrentUserAnyHost)
+	want := map[string]interface{}{
+		"id":  "18446744073709551615",
+		"ids": []interface{}{uint64(1), "18446744073709551615"},
+	}
+	if !reflect.DeepEqual(values, want) {
+		t.Fatalf("GetAll() = %#v, want %#v", values, want)
+	}
+}
+
+func TestClientLocation(t *testing.T) {
+	loc := time.FixedZone("PDT", -7*3600)
+	when := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	store := NewMemoryStore()
+	store.Set("LastRun", when, "com.example", CurrentUserAnyHost)
+	store.Set("History", []interface{}{map[string]interface{}{"at": when}}, "com.example", CurrentUserAnyHost)
+
+	c := NewClient(WithStore(store), WithLocation(loc))
+	got, err := c.Get("LastRun", "com.example", CurrentUserAnyHost)
+	if err != nil {
+		t.Fatalf("Get() error = %v", err)
+	}
+	if got.(time.Time).Location() != loc || !got.(time.Time).Equal(when) {
+		t.Fatalf("Get() = %v, want %v in %v", got, when, loc)
+	}
+
+	values, _ := c.GetAll("com.example", CurrentUserAnyHost)
+	nested := values["History"].([]interface{})[0].(map[string]interface{})["at"].(time.Time)
+	if nested.Location() != loc || !nested.Equal(when) {
+		t.Fatalf("GetAll() nested date = %v, want %v in %v", nested, when, loc)
+	}
+
+	if got, _ := NewClient(WithStore(store)).Get("LastRun", "com.example", CurrentUserAnyHost); got.(time.Time).Location() != time.UTC {
+		t.Fatalf("Get() without WithLocation = %v, want UTC", got)
+	}
+}
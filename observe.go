@@ -0,0 +1,158 @@
+package mac_prefs
+
+import (
+	"context"
+	"time"
+)
+
+// Operation describes one call a Client made into its store, or one event a watcher sent.
+type Operation struct {
+	// Name is "get", "get multiple", "get lazy", "list", "set", "delete", or "watch" for calls,
+	// and "watch add", "watch remove", or "watch change" for watcher events.
+	Name string
+	// ApplicationID is the domain of the operation.
+	ApplicationID string
+	// Key is the key of the operation, or empty for operations on a whole domain.
+	Key string
+	// Scope is the (user, host) slot of the operation.
+	Scope PreferenceScope
+	// Duration is how long the call took. For watcher events it is the time from the poll
+	// that observed the change to its delivery.
+	Duration time.Duration
+	// Found reports whether a get found the key. It is true for other operations.
+	Found bool
+	// Err is the error the operation returned, if any.
+	Err error
+}
+
+// Instrumentation receives every Operation of a Client configured with WithInstrumentation.
+// Observe is called synchronously after each operation, from the goroutine that made it, and
+// must be safe for concurrent use. Metrics is an implementation that aggregates operations.
+type Instrumentation interface {
+	Observe(op Operation)
+}
+
+// WithInstrumentation reports every call the Client makes into CFPreferences, or into the
+// store set with WithStore, to inst. Calls are measured below the cache and the retry policy
+// of the Client, so cache hits are not reported and each retried attempt is, and the
+// durations are those of the cgo calls. Use WithLogger to observe calls as the Client sees
+// them.
+func WithInstrumentation(inst Instrumentation) Option {
+	return func(c *Client) {
+		c.instrumentation = inst
+	}
+}
+
+// observedStore is a Store that reports the calls to the Store it wraps to observe.
+type observedStore struct {
+	Store
+	observe func(Operation)
+}
+
+// report calls observe with an operation that started at start.
+func (s *observedStore) report(name string, key string, applicationID string, scope PreferenceScope, start time.Time, found bool, err error) {
+	s.observe(Operation{
+		Name:          name,
+		ApplicationID: applicationID,
+		Key:           key,
+		Scope:         scope,
+		Duration:      time.Since(start),
+		Found:         found,
+		Err:           err,
+	})
+}
+
+// Get retrieves a value and reports the read.
+func (s *observedStore) Get(key string, applicationID string, scope PreferenceScope) (interface{}, error) {
+	start := time.Now()
+	value, err := s.Store.Get(key, applicationID, scope)
+	s.report("get", key, applicationID, scope, start, value != nil, err)
+	return value, err
+}
+
+// GetMultiple retrieves several keys, in one read if the wrapped Store is a MultiGetStore,
+// and reports the read.
+func (s *observedStore) GetMultiple(keys []string, applicationID string, scope PreferenceScope) (map[string]interface{}, error) {
+	start := time.Now()
+	values, err := readSlot(s.Store, prefSlot{applicationID, scope}, keys)
+	s.report("get multiple", "", applicationID, scope, start, true, err)
+	return values, err
+}
+
+// List retrieves a domain and reports the read.
+func (s *observedStore) List(applicationID string, scope PreferenceScope) (map[string]interface{}, error) {
+	start := time.Now()
+	values, err := s.Store.List(applicationID, scope)
+	s.report("list", "", applicationID, scope, start, true, err)
+	return values, err
+}
+
+// Set sets a value and reports the write, or the delete if value is nil.
+func (s *observedStore) Set(key string, value interface{}, applicationID string, scope PreferenceScope) error {
+	start := time.Now()
+	err := s.Store.Set(key, value, applicationID, scope)
+	name := "set"
+	if value == nil {
+		name = "delete"
+	}
+	s.report(name, key, applicationID, scope, start, true, err)
+	return err
+}
+
+// Delete removes a key and reports the delete.
+func (s *observedStore) Delete(key string, applicationID string, scope PreferenceScope) error {
+	start := time.Now()
+	err := s.Store.Delete(key, applicationID, scope)
+	s.report("delete", key, applicationID, scope, start, true, err)
+	return err
+}
+
+// SetMultiple writes several keys, in one write if the wrapped Store is a BatchStore, and
+// reports one operation per key.
+func (s *observedStore) SetMultiple(keysToSet map[string]interface{}, keysToRemove []string, applicationID string, scope PreferenceScope) error {
+	start := time.Now()
+	err := writeSlot(s.Store, prefSlot{applicationID, scope}, batchValues(keysToSet, keysToRemove))
+	for key := range keysToSet {
+		s.report("set", key, applicationID, scope, start, true, err)
+	}
+	for _, key := range keysToRemove {
+		s.report("delete", key, applicationID, scope, start, true, err)
+	}
+	return err
+}
+
+// Watch starts a watcher, reporting its start and every event it sends.
+func (s *observedStore) Watch(ctx context.Context, applicationID string, scope PreferenceScope, interval time.Duration) (<-chan Event, error) {
+	start := time.Now()
+	events, err := s.Store.Watch(ctx, applicationID, scope, interval)
+	s.report("watch", "", applicationID, scope, start, true, err)
+	if err != nil {
+		return nil, err
+	}
+
+	observed := make(chan Event)
+	go func() {
+		defer close(observed)
+		for event := range events {
+			s.report("watch "+changeKind(event.Change), event.Key, applicationID, scope, event.Time, true, nil)
+			select {
+			case observed <- event:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return observed, nil
+}
+
+// changeKind names the kind of a Change for operation names.
+func changeKind(c Change) string {
+	switch {
+	case c.Old == nil:
+		return "add"
+	case c.New == nil:
+		return "remove"
+	default:
+		return "change"
+	}
+}
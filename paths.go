@@ -0,0 +1,182 @@
+package mac_prefs
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"os/exec"
+	"os/user"
+	"path/filepath"
+	"regexp"
+	"sync"
+)
+
+const (
+	// systemPrefsDir is where AnyUser preferences are stored.
+	systemPrefsDir = "/Library/Preferences"
+	// byHostDir is the subdirectory holding CurrentHost preferences.
+	byHostDir = "ByHost"
+)
+
+var (
+	platformUUIDOnce sync.Once
+	platformUUID     string
+	platformUUIDErr  error
+
+	platformUUIDPattern = regexp.MustCompile(`"IOPlatformUUID" = "([0-9A-Fa-f-]+)"`)
+)
+
+// ContainerPrefsPath returns the path of the preferences plist for a sandboxed application.
+// Sandboxed applications store their preferences inside their container rather than in
+// ~/Library/Preferences.
+//
+// Parameters:
+//   - appID: The bundle identifier of the sandboxed application.
+//
+// Returns:
+//   - string: The path to ~/Library/Containers/<appID>/Data/Library/Preferences/<appID>.plist.
+//   - error: An error if the current user's home directory cannot be determined.
+func ContainerPrefsPath(appID string) (string, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("error resolving home directory: %v", err)
+	}
+	return filepath.Join(containerPrefsDir(home, appID), appID+".plist"), nil
+}
+
+// PlistPath returns the path of the plist file backing an application's preferences in the given scope.
+// For user scopes the sandbox container is used when the application has one, so the returned
+// path points at the plist the application actually reads.
+//
+// Parameters:
+//   - appID: The bundle identifier of the application.
+//   - scope: The PreferenceScope defining the user and host scope for the preferences.
+//
+// Returns:
+//   - string: The path to the backing plist file. The file may not exist yet.
+//   - error: An error if the user's home directory or the host UUID cannot be determined.
+func PlistPath(appID string, scope PreferenceScope) (string, error) {
+	return NewClient().PlistPath(appID, scope)
+}
+
+// plistPath returns the path of the plist file backing a domain slot on the volume mounted at
+// root. An empty root is the booted system.
+func plistPath(root, appID string, scope PreferenceScope) (string, error) {
+	dir, err := prefsDir(root, appID, scope.User)
+	if err != nil {
+		return "", err
+	}
+
+	switch scope.Host {
+	case AnyHost:
+		return filepath.Join(dir, appID+".plist"), nil
+	case CurrentHost:
+		return byHostPlistPath(filepath.Join(dir, byHostDir), appID)
+	default:
+		return "", fmt.Errorf("invalid host type in scope: must be CurrentHost or AnyHost")
+	}
+}
+
+// prefsDir returns the Preferences directory for the given application and user on the volume
+// mounted at root.
+func prefsDir(root, appID string, userName UserType) (string, error) {
+	if userName == AnyUser {
+		return filepath.Join(root, systemPrefsDir), nil
+	}
+	home, err := rootHomeDir(root, userName)
+	if err != nil {
+		return "", err
+	}
+
+	if isSandboxed(home, appID) {
+		return containerPrefsDir(home, appID), nil
+	}
+	return filepath.Join(home, "Library", "Preferences"), nil
+}
+
+// userHomeDir returns the home directory of the current user or a literal username.
+func userHomeDir(userName UserType) (string, error) {
+	if userName == CurrentUser {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return "", fmt.Errorf("error resolving home directory: %v", err)
+		}
+		return home, nil
+	}
+	u, err := user.Lookup(string(userName))
+	if err != nil {
+		return "", fmt.Errorf("error looking up user %q: %v", userName, err)
+	}
+	return u.HomeDir, nil
+}
+
+// rootHomeDir returns the home directory of a user on the volume mounted at root. The accounts
+// of the booted system do not describe another volume, so a named user is looked up as
+// <root>/Users/<name> there, and the current user by the path of their home directory.
+func rootHomeDir(root string, userName UserType) (string, error) {
+	if root == "" || userName == CurrentUser {
+		home, err := userHomeDir(userName)
+		if err != nil {
+			return "", err
+		}
+		return filepath.Join(root, home), nil
+	}
+	return filepath.Join(root, "Users", string(userName)), nil
+}
+
+// containerPrefsDir returns the Preferences directory inside an application's sandbox container.
+func containerPrefsDir(home, appID string) string {
+	return filepath.Join(home, "Library", "Containers", appID, "Data", "Library", "Preferences")
+}
+
+// isSandboxed reports whether the application has a sandbox container in the given home directory.
+func isSandboxed(home, appID string) bool {
+	info, err := os.Stat(filepath.Join(home, "Library", "Containers", appID, "Data"))
+	return err == nil && info.IsDir()
+}
+
+// byHostPlistPath returns the ByHost plist for appID in dir. An existing file is preferred so
+// that machines using older MAC-address based names resolve correctly.
+func byHostPlistPath(dir, appID string) (string, error) {
+	matches, err := filepath.Glob(filepath.Join(dir, escapeGlob(appID)+".*.plist"))
+	if err == nil && len(matches) == 1 {
+		return matches[0], nil
+	}
+
+	hostID, err := hostUUID()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, appID+"."+hostID+".plist"), nil
+}
+
+// hostUUID returns the hardware UUID used to name ByHost preference files.
+func hostUUID() (string, error) {
+	platformUUIDOnce.Do(func() {
+		out, err := exec.Command("/usr/sbin/ioreg", "-rd1", "-c", "IOPlatformExpertDevice").Output()
+		if err != nil {
+			platformUUIDErr = fmt.Errorf("error reading IOPlatformUUID: %v", err)
+			return
+		}
+		m := platformUUIDPattern.FindSubmatch(out)
+		if m == nil {
+			platformUUIDErr = fmt.Errorf("IOPlatformUUID not found in ioreg output")
+			return
+		}
+		platformUUID = string(bytes.ToUpper(m[1]))
+	})
+	return platformUUID, platformUUIDErr
+}
+
+// escapeGlob escapes glob metacharacters in s.
+func escapeGlob(s string) string {
+	var b bytes.Buffer
+	for _, r := range s {
+		switch r {
+		case '*', '?', '[', '\\':
+			b.WriteRune('\\')
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
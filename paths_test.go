@@ -0,0 +1,165 @@
+//go:build darwin && cgo
+
+package mac_prefs
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+const testPlistXML = `<?xml version="1.0" encoding="UTF-8"?>
+<!DOCTYPE plist PUBLIC "-//Apple//DTD PLIST 1.0//EN" "http://www.apple.com/DTDs/PropertyList-1.0.dtd">
+<plist version="1.0">
+<dict>
+	<key>Name</key>
+	<string>container</string>
+	<key>Count</key>
+	<integer>3</integer>
+</dict>
+</plist>
+`
+
+func TestContainerPrefsPath(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+
+	got, err := ContainerPrefsPath(testAppID)
+	if err != nil {
+		t.Fatalf("ContainerPrefsPath() error = %v", err)
+	}
+	want := filepath.Join(home, "Library", "Containers", testAppID, "Data", "Library", "Preferences", testAppID+".plist")
+	if got != want {
+		t.Fatalf("ContainerPrefsPath() got = %q, want %q", got, want)
+	}
+}
+
+func TestPlistPath(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+
+	const sandboxedID = "com.github.weswhet.mac_prefs.sandboxed"
+	if err := os.MkdirAll(filepath.Join(home, "Library", "Containers", sandboxedID, "Data"), 0o755); err != nil {
+		t.Fatalf("MkdirAll() error = %v", err)
+	}
+
+	byHost := filepath.Join(home, "Library", "Preferences", "ByHost")
+	if err := os.MkdirAll(byHost, 0o755); err != nil {
+		t.Fatalf("MkdirAll() error = %v", err)
+	}
+	byHostFile := filepath.Join(byHost, testAppID+".0123-4567.plist")
+	if err := os.WriteFile(byHostFile, []byte(testPlistXML), 0o644); err != nil {
+		t.Fatalf("WriteFile() error = %v", err)
+	}
+
+	for _, tc := range []struct {
+		name  string
+		appID string
+		scope PreferenceScope
+		want  string
+	}{
+		{
+			name:  "current user any host",
+			appID: testAppID,
+			scope: CurrentUserAnyHost,
+			want:  filepath.Join(home, "Library", "Preferences", testAppID+".plist"),
+		},
+		{
+			name:  "current user current host",
+			appID: testAppID,
+			scope: CurrentUserCurrentHost,
+			want:  byHostFile,
+		},
+		{
+			name:  "any user any host",
+			appID: testAppID,
+			scope: AnyUserAnyHost,
+			want:  filepath.Join("/Library", "Preferences", testAppID+".plist"),
+		},
+		{
+			name:  "sandboxed application",
+			appID: sandboxedID,
+			scope: CurrentUserAnyHost,
+			want:  filepath.Join(home, "Library", "Containers", sandboxedID, "Data", "Library", "Preferences", sandboxedID+".plist"),
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := PlistPath(tc.appID, tc.scope)
+			if err != nil {
+				t.Fatalf("PlistPath() error = %v", err)
+			}
+			if got != tc.want {
+				t.Fatalf("PlistPath() got = %q, want %q", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestReadDomainFileUsesContainer(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+
+	path, err := ContainerPrefsPath(testAppID)
+	if err != nil {
+		t.Fatalf("ContainerPrefsPath() error = %v", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		t.Fatalf("MkdirAll() error = %v", err)
+	}
+	if err := os.WriteFile(path, []byte(testPlistXML), 0o644); err != nil {
+		t.Fatalf("WriteFile() error = %v", err)
+	}
+
+	got, err := ReadDomainFile(testAppID, CurrentUserAnyHost)
+	if err != nil {
+		t.Fatalf("ReadDomainFile() error = %v", err)
+	}
+	want := map[string]interface{}{"Name": "container", "Count": 3}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("ReadDomainFile() got = %#v, want %#v", got, want)
+	}
+}
+
+func TestReadDomainFileMissing(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+
+	if _, err := ReadDomainFile(testAppID, CurrentUserAnyHost); !errors.Is(err, os.ErrNotExist) {
+		t.Fatalf("ReadDomainFile() error = %v, want not-exist error", err)
+	}
+}
+
+func TestClientRootReadAndDiff(t *testing.T) {
+	root := t.TempDir()
+	file := filepath.Join(root, "Users", "alice", "Library", "Preferences", testAppID+".plist")
+	if err := os.MkdirAll(filepath.Dir(file), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(file, []byte(testPlistXML), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	c := NewClient(WithRoot(root))
+	scope := PreferenceScope{User: "alice", Host: AnyHost}
+
+	got, err := c.ReadDomainFile(testAppID, scope)
+	if err != nil {
+		t.Fatalf("ReadDomainFile() error = %v", err)
+	}
+	if want := map[string]interface{}{"Name": "container", "Count": 3}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("ReadDomainFile() got = %#v, want %#v", got, want)
+	}
+
+	added, changed, removed, err := c.Diff(testAppID, scope, map[string]interface{}{"Name": "live", "Count": 3, "New": true})
+	if err != nil {
+		t.Fatalf("Diff() error = %v", err)
+	}
+	if len(added) != 1 || len(changed) != 1 || len(removed) != 0 || changed[0].Old != "container" {
+		t.Errorf("Diff() = %v, %v, %v", added, changed, removed)
+	}
+
+	added, _, _, err = c.Diff(testAppID+".missing", scope, map[string]interface{}{"Name": "live"})
+	if err != nil || len(added) != 1 {
+		t.Errorf("Diff() of a missing file = %v, %v, want one added key", added, err)
+	}
+}
@@ -0,0 +1,329 @@
+package mac_prefs
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"os/user"
+	"path/filepath"
+	"strconv"
+
+	"github.com/weswhet/mac_prefs/internal/plist"
+)
+
+// ReadDomainFile reads the plist file backing an application's preferences directly from disk,
+// bypassing cfprefsd. The file is located with PlistPath, so sandboxed applications are read
+// from their container.
+//
+// Parameters:
+//   - appID: The bundle identifier of the application.
+//   - scope: The PreferenceScope defining the user and host scope for the preferences.
+//
+// Returns:
+//   - map[string]interface{}: The preferences stored in the file.
+//   - error: An error if the file cannot be read or parsed. A missing file satisfies errors.Is(err, os.ErrNotExist).
+func ReadDomainFile(appID string, scope PreferenceScope) (map[string]interface{}, error) {
+	return NewClient().ReadDomainFile(appID, scope)
+}
+
+// ReadDomainFile reads the plist file backing a domain slot, under the root of the Client if
+// one is set, applying the read options of the Client to its values. See ReadDomainFile.
+func (c *Client) ReadDomainFile(appID string, scope PreferenceScope) (map[string]interface{}, error) {
+	path, err := c.PlistPath(appID, scope)
+	if err != nil {
+		return nil, err
+	}
+	values, err := readPlistFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return c.readValues(values), nil
+}
+
+// ReadPlistFile reads a preferences plist file at any path, in XML or binary format, without
+// going through cfprefsd. Use it to inspect plists from backups, other volumes, or another
+// machine; the values are converted exactly as GetAll converts them.
+//
+// Parameters:
+//   - path: The path of the plist file.
+//
+// Returns:
+//   - map[string]interface{}: The root dictionary of the file.
+//   - error: An error if the file cannot be read or parsed, or its root is not a dictionary.
+//     A missing file satisfies errors.Is(err, os.ErrNotExist).
+func ReadPlistFile(path string) (map[string]interface{}, error) {
+	return NewClient().ReadPlistFile(path)
+}
+
+// ReadPlistFile reads a plist file at any path, applying the read options of the Client to
+// its values. See ReadPlistFile.
+func (c *Client) ReadPlistFile(path string) (map[string]interface{}, error) {
+	values, err := readPlistFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return c.readValues(values), nil
+}
+
+// readPlistFile parses the plist file at path and converts its root dictionary to a Go map.
+func readPlistFile(path string) (map[string]interface{}, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("error reading plist file: %w", err)
+	}
+
+	value, err := parsePlistData(data)
+	if err != nil {
+		return nil, fmt.Errorf("error parsing plist file %s: %v", path, err)
+	}
+	m, ok := value.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("plist file %s does not contain a dictionary", path)
+	}
+	return m, nil
+}
+
+// Format is a property list serialization format.
+type Format int
+
+const (
+	// FormatXML is the XML property list format.
+	FormatXML Format = iota
+	// FormatBinary is the binary property list format (bplist00).
+	FormatBinary
+)
+
+// WritePlistOptions configures WritePlistFile.
+type WritePlistOptions struct {
+	// Format is the plist format. cfprefsd itself writes FormatBinary.
+	Format Format
+	// Mode is the permission of the file. 0600, the mode of the files cfprefsd writes, is used
+	// if it is zero.
+	Mode os.FileMode
+	// Owner is the user name or numeric user ID that owns the file. The file keeps the owner
+	// of the process if it is empty. Changing the owner requires root.
+	Owner string
+	// Group is the group name or numeric group ID of the file. The file keeps the group of
+	// the process if it is empty.
+	Group string
+}
+
+// WritePlistFile writes values as a preferences plist file at any path, without going
+// through cfprefsd. The file is written to a temporary file in the same directory, given
+// its mode and ownership, and renamed over path, so readers never see a partial file. Use it
+// when a root agent must author a plist for a user, for example before their first login;
+// cfprefsd does not notice files changed behind its back while it has the domain cached.
+//
+// Parameters:
+//   - path: The path of the plist file. Its directory must exist.
+//   - values: The keys and values to write.
+//   - opts: The format, mode, and ownership of the file.
+//
+// Returns:
+//   - error: An error if a value cannot be serialized, the owner or group does not exist,
+//     or the file cannot be written. path is left untouched on error.
+func WritePlistFile(path string, values map[string]interface{}, opts WritePlistOptions) error {
+	return NewClient().WritePlistFile(path, values, opts)
+}
+
+// WritePlistFile writes a plist file at any path, applying the write options of the Client
+// to the values. A read-only Client returns ErrReadOnly, and with WithDryRun the values are
+// serialized but nothing is written. See WritePlistFile.
+func (c *Client) WritePlistFile(path string, values map[string]interface{}, opts WritePlistOptions) error {
+	if c.readOnly {
+		return ErrReadOnly
+	}
+	written := make(map[string]interface{}, len(values))
+	for key, value := range values {
+		written[key] = c.writeValue(value)
+	}
+	data, err := marshalPlistData(written, opts.Format)
+	if err != nil {
+		return err
+	}
+	uid, gid, err := fileOwner(opts.Owner, opts.Group)
+	if err != nil {
+		return err
+	}
+	if c.dryRun {
+		return nil
+	}
+	mode := opts.Mode
+	if mode == 0 {
+		mode = 0o600
+	}
+	return writeFileAtomic(path, data, mode, uid, gid)
+}
+
+// fileOwner resolves the owner and group of WritePlistOptions to IDs, or -1 if empty.
+func fileOwner(owner, group string) (uid, gid int, err error) {
+	uid, gid = -1, -1
+	if owner != "" {
+		if uid, err = strconv.Atoi(owner); err != nil {
+			u, err := user.Lookup(owner)
+			if err != nil {
+				return 0, 0, fmt.Errorf("error looking up owner %s: %v", owner, err)
+			}
+			uid, _ = strconv.Atoi(u.Uid)
+		}
+	}
+	if group != "" {
+		if gid, err = strconv.Atoi(group); err != nil {
+			g, err := user.LookupGroup(group)
+			if err != nil {
+				return 0, 0, fmt.Errorf("error looking up group %s: %v", group, err)
+			}
+			gid, _ = strconv.Atoi(g.Gid)
+		}
+	}
+	return uid, gid, nil
+}
+
+// writeFileAtomic writes data to a temporary file next to path, sets its mode and, unless
+// both are -1, its owner and group, and renames it over path.
+func writeFileAtomic(path string, data []byte, mode os.FileMode, uid, gid int) (err error) {
+	f, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return fmt.Errorf("error creating plist file: %v", err)
+	}
+	defer func() {
+		if err != nil {
+			f.Close()
+			os.Remove(f.Name())
+		}
+	}()
+
+	if _, err := f.Write(data); err != nil {
+		return fmt.Errorf("error writing plist file: %v", err)
+	}
+	if err := f.Chmod(mode); err != nil {
+		return fmt.Errorf("error setting mode of plist file: %v", err)
+	}
+	if uid != -1 || gid != -1 {
+		if err := f.Chown(uid, gid); err != nil {
+			return fmt.Errorf("error setting owner of plist file: %v", err)
+		}
+	}
+	if err := f.Sync(); err != nil {
+		return fmt.Errorf("error writing plist file: %v", err)
+	}
+	if err := f.Close(); err != nil {
+		return fmt.Errorf("error writing plist file: %v", err)
+	}
+	if err := os.Rename(f.Name(), path); err != nil {
+		return fmt.Errorf("error replacing plist file: %v", err)
+	}
+	return nil
+}
+
+// Export serializes every key and value in one exact (user, host) slot of a domain as a plist.
+// The values of sensitive keys are replaced by Redacted, as DefaultRedactor masks them, so an
+// exported domain can be shared without its secrets. To export the real values, for example
+// to Import them back, use a Client created with WithRedactor(nil).
+//
+// Parameters:
+//   - appID: The bundle identifier of the application to export.
+//   - scope: The PreferenceScope defining the user and host scope to export.
+//   - w: The writer the plist is written to.
+//   - format: The plist format, FormatXML or FormatBinary.
+//
+// Returns:
+//   - error: An error if the domain cannot be read or serialized, or w returns an error.
+func Export(appID string, scope PreferenceScope, w io.Writer, format Format) error {
+	return NewClient().Export(appID, scope, w, format)
+}
+
+// writePlist serializes values as a plist and writes it to w.
+func writePlist(w io.Writer, values map[string]interface{}, format Format) error {
+	data, err := marshalPlistData(values, format)
+	if err != nil {
+		return err
+	}
+	if _, err := w.Write(data); err != nil {
+		return fmt.Errorf("error writing plist: %v", err)
+	}
+	return nil
+}
+
+// ImportMode controls how Import combines imported keys with the existing domain.
+type ImportMode int
+
+const (
+	// ImportMerge writes the imported keys and leaves other existing keys untouched.
+	ImportMerge ImportMode = iota
+	// ImportReplace writes the imported keys and removes every existing key that is not imported.
+	ImportReplace
+)
+
+// Import reads XML or binary plist data and writes its keys to one exact (user, host) slot of a
+// domain with a single SetMultiple call. It is the counterpart of Export.
+//
+// Parameters:
+//   - appID: The bundle identifier of the application to import into.
+//   - scope: The PreferenceScope defining the user and host scope to import into.
+//   - r: The reader the plist is read from. The root of the plist must be a dictionary.
+//   - mode: Whether to merge with or replace the existing keys.
+//
+// Returns:
+//   - error: An error if the plist cannot be read or parsed, or the domain cannot be written.
+func Import(appID string, scope PreferenceScope, r io.Reader, mode ImportMode) error {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return fmt.Errorf("error reading plist: %v", err)
+	}
+	value, err := parsePlistData(data)
+	if err != nil {
+		return fmt.Errorf("error parsing plist: %v", err)
+	}
+	values, ok := value.(map[string]interface{})
+	if !ok {
+		return fmt.Errorf("plist does not contain a dictionary")
+	}
+	return ImportValues(appID, scope, values, mode)
+}
+
+// ImportValues writes already decoded values to one exact (user, host) slot of a domain with a
+// single SetMultiple call, merging with or replacing the existing keys. It lets callers import
+// formats other than plist, such as JSON.
+//
+// Parameters:
+//   - appID: The bundle identifier of the application to import into.
+//   - scope: The PreferenceScope defining the user and host scope to import into.
+//   - values: The keys and values to write.
+//   - mode: Whether to merge with or replace the existing keys.
+//
+// Returns:
+//   - error: An error if the domain cannot be read or written.
+func ImportValues(appID string, scope PreferenceScope, values map[string]interface{}, mode ImportMode) error {
+	var toRemove []string
+	switch mode {
+	case ImportMerge:
+	case ImportReplace:
+		keys, err := Keys(appID, scope)
+		if err != nil {
+			return err
+		}
+		for _, key := range keys {
+			if _, ok := values[key]; !ok {
+				toRemove = append(toRemove, key)
+			}
+		}
+	default:
+		return fmt.Errorf("unsupported import mode %d", int(mode))
+	}
+	return SetMultiple(values, toRemove, appID, scope)
+}
+
+// FormatDefaults renders a value or a whole domain, such as one returned by GetAll, in the
+// OpenStep text format printed by `defaults read`, so output can be diffed against existing
+// defaults-based scripts and runbooks.
+//
+// Parameters:
+//   - value: The value to render.
+//
+// Returns:
+//   - string: The rendered value.
+//   - error: An error if the value contains an unsupported type.
+func FormatDefaults(value interface{}) (string, error) {
+	return plist.FormatDefaults(value)
+}
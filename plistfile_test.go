@@ -0,0 +1,203 @@
+//go:build darwin && cgo
+
+package mac_prefs
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestExport(t *testing.T) {
+	const appID = testAppID + ".export"
+	scope := CurrentUserAnyHost
+
+	values := map[string]interface{}{"ExportName": "exported", "ExportCount": 3}
+	if err := SetMultiple(values, nil, appID, scope); err != nil {
+		t.Fatalf("SetMultiple() error = %v", err)
+	}
+	defer SetMultiple(nil, []string{"ExportName", "ExportCount"}, appID, scope)
+
+	var buf bytes.Buffer
+	if err := Export(appID, scope, &buf, FormatXML); err != nil {
+		t.Fatalf("Export() error = %v", err)
+	}
+	if !strings.Contains(buf.String(), "<key>ExportName</key>") {
+		t.Fatalf("Export() output is not an XML plist:\n%s", buf.String())
+	}
+
+	got, err := parsePlistData(buf.Bytes())
+	if err != nil {
+		t.Fatalf("parsePlistData() error = %v", err)
+	}
+	if !reflect.DeepEqual(got, values) {
+		t.Fatalf("Export() round trip got = %v, want %v", got, values)
+	}
+
+	if err := Set("ExportToken", "secret", appID, scope); err != nil {
+		t.Fatalf("Set() error = %v", err)
+	}
+	defer Delete("ExportToken", appID, scope)
+	buf.Reset()
+	if err := Export(appID, scope, &buf, FormatXML); err != nil {
+		t.Fatalf("Export() error = %v", err)
+	}
+	got, err = parsePlistData(buf.Bytes())
+	if err != nil {
+		t.Fatalf("parsePlistData() error = %v", err)
+	}
+	if token := got.(map[string]interface{})["ExportToken"]; token != Redacted {
+		t.Errorf("Export() ExportToken = %v, want %s", token, Redacted)
+	}
+
+	if err := Export(appID, scope, &buf, Format(99)); err == nil {
+		t.Fatal("Export() expected error for unknown format")
+	}
+}
+
+func TestImport(t *testing.T) {
+	const appID = testAppID + ".import"
+	scope := CurrentUserAnyHost
+	keys := []string{"ImportExisting", "ImportName", "ImportList"}
+
+	data, err := marshalPlistData(map[string]interface{}{"ImportName": "imported", "ImportList": []interface{}{1, 2}}, FormatXML)
+	if err != nil {
+		t.Fatalf("marshalPlistData() error = %v", err)
+	}
+
+	tests := []struct {
+		name         string
+		mode         ImportMode
+		wantExisting interface{}
+	}{
+		{name: "merge", mode: ImportMerge, wantExisting: "kept"},
+		{name: "replace", mode: ImportReplace, wantExisting: nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := Set("ImportExisting", "kept", appID, scope); err != nil {
+				t.Fatalf("Set() error = %v", err)
+			}
+			defer SetMultiple(nil, keys, appID, scope)
+
+			if err := Import(appID, scope, bytes.NewReader(data), tt.mode); err != nil {
+				t.Fatalf("Import() error = %v", err)
+			}
+			got, err := GetAll(appID, scope)
+			if err != nil {
+				t.Fatalf("GetAll() error = %v", err)
+			}
+			if got["ImportName"] != "imported" || !reflect.DeepEqual(got["ImportList"], []interface{}{1, 2}) {
+				t.Fatalf("Import() got = %v", got)
+			}
+			if got["ImportExisting"] != tt.wantExisting {
+				t.Fatalf("Import() ImportExisting = %v, want %v", got["ImportExisting"], tt.wantExisting)
+			}
+		})
+	}
+
+	if err := Import(appID, scope, strings.NewReader("not a plist"), ImportMerge); err == nil {
+		t.Fatal("Import() expected error for invalid plist")
+	}
+}
+
+func TestFormatDefaults(t *testing.T) {
+	got, err := FormatDefaults(map[string]interface{}{"autohide": true, "show-recents": false})
+	if err != nil {
+		t.Fatalf("FormatDefaults() error = %v", err)
+	}
+	want := "{\n    autohide = 1;\n    \"show-recents\" = 0;\n}"
+	if got != want {
+		t.Fatalf("FormatDefaults() got = %q, want %q", got, want)
+	}
+}
+
+func TestReadPlistFile(t *testing.T) {
+	dir := t.TempDir()
+	values := map[string]interface{}{"Name": "backup", "Count": 3, "List": []interface{}{true, 1.5}}
+	data, err := marshalPlistData(values, FormatXML)
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(dir, "com.example.plist")
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := ReadPlistFile(path)
+	if err != nil {
+		t.Fatalf("ReadPlistFile() error = %v", err)
+	}
+	if !reflect.DeepEqual(got, values) {
+		t.Errorf("ReadPlistFile() = %#v, want %#v", got, values)
+	}
+	got, err = NewClient(WithNumberMode(NumberFloat64)).ReadPlistFile(path)
+	if err != nil || got["Count"] != 3.0 {
+		t.Errorf("Client.ReadPlistFile() = %#v, %v, want Count as float64", got, err)
+	}
+
+	if _, err := ReadPlistFile(filepath.Join(dir, "missing.plist")); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("ReadPlistFile() of a missing file error = %v, want not-exist error", err)
+	}
+	array := filepath.Join(dir, "array.plist")
+	os.WriteFile(array, []byte(`<?xml version="1.0" encoding="UTF-8"?><plist version="1.0"><array/></plist>`), 0o644)
+	if _, err := ReadPlistFile(array); err == nil {
+		t.Error("ReadPlistFile() of an array expected error")
+	}
+}
+
+func TestWritePlistFile(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "com.example.plist")
+	if err := os.WriteFile(path, []byte("old"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	values := map[string]interface{}{"Name": "authored", "Count": 2}
+	opts := WritePlistOptions{Mode: 0o640, Owner: strconv.Itoa(os.Getuid()), Group: strconv.Itoa(os.Getgid())}
+	if err := WritePlistFile(path, values, opts); err != nil {
+		t.Fatalf("WritePlistFile() error = %v", err)
+	}
+	got, err := ReadPlistFile(path)
+	if err != nil || !reflect.DeepEqual(got, values) {
+		t.Errorf("ReadPlistFile() = %#v, %v, want %#v", got, err, values)
+	}
+	if info, _ := os.Stat(path); info.Mode().Perm() != 0o640 {
+		t.Errorf("mode = %v, want 0640", info.Mode().Perm())
+	}
+
+	for name, write := range map[string]func() error{
+		"invalid value": func() error {
+			return WritePlistFile(path, map[string]interface{}{"bad": make(chan int)}, WritePlistOptions{})
+		},
+		"unknown owner": func() error {
+			return WritePlistFile(path, values, WritePlistOptions{Owner: "no-such-user-mac-prefs"})
+		},
+		"read-only": func() error {
+			return NewClient(WithReadOnly()).WritePlistFile(path, values, WritePlistOptions{})
+		},
+		"missing directory": func() error {
+			return WritePlistFile(filepath.Join(dir, "missing", "a.plist"), values, WritePlistOptions{})
+		},
+	} {
+		if err := write(); err == nil {
+			t.Errorf("%s: WritePlistFile() expected error", name)
+		}
+	}
+	if got, _ := ReadPlistFile(path); !reflect.DeepEqual(got, values) {
+		t.Errorf("failed writes changed the file: %#v", got)
+	}
+
+	if err := NewClient(WithDryRun()).WritePlistFile(filepath.Join(dir, "dry.plist"), values, WritePlistOptions{}); err != nil {
+		t.Fatalf("dry run WritePlistFile() error = %v", err)
+	}
+	entries, _ := os.ReadDir(dir)
+	if len(entries) != 1 {
+		t.Errorf("directory holds %d files, want only the plist", len(entries))
+	}
+}
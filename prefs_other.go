@@ -0,0 +1,136 @@
+//go:build !darwin || !cgo
+
+package mac_prefs
+
+// The functions below stand in for the CFPreferences backend on other platforms and in builds
+// without cgo, so the package and its callers build everywhere. Every one returns
+// ErrUnsupportedPlatform.
+
+// Set sets a preference value. It returns ErrUnsupportedPlatform on this platform.
+func Set(key string, value interface{}, applicationID string, scope PreferenceScope) error {
+	return ErrUnsupportedPlatform
+}
+
+// Delete removes a preference key. It returns ErrUnsupportedPlatform on this platform.
+func Delete(key string, applicationID string, scope PreferenceScope) error {
+	return ErrUnsupportedPlatform
+}
+
+// SetApp sets a preference value for the current user. It returns ErrUnsupportedPlatform on
+// this platform.
+func SetApp(key string, value interface{}, appID string) error {
+	return ErrUnsupportedPlatform
+}
+
+// SetMultiple sets and removes several preference values. It returns ErrUnsupportedPlatform
+// on this platform.
+func SetMultiple(keysToSet map[string]interface{}, keysToRemove []string, applicationID string, scope PreferenceScope) error {
+	return ErrUnsupportedPlatform
+}
+
+// Get retrieves a preference value. It returns ErrUnsupportedPlatform on this platform.
+func Get(key string, applicationID string, scope PreferenceScope) (interface{}, error) {
+	return nil, ErrUnsupportedPlatform
+}
+
+// GetApp retrieves a preference value for the current user. It returns
+// ErrUnsupportedPlatform on this platform.
+func GetApp(key string, appID string) (interface{}, error) {
+	return nil, ErrUnsupportedPlatform
+}
+
+// GetComposite retrieves the effective value of a key. It returns ErrUnsupportedPlatform on
+// this platform.
+func GetComposite(key string, appID string) (interface{}, error) {
+	return nil, ErrUnsupportedPlatform
+}
+
+// IsForcedApp reports whether a key is managed. It returns ErrUnsupportedPlatform on this
+// platform.
+func IsForcedApp(key string, appID string) (bool, error) {
+	return false, ErrUnsupportedPlatform
+}
+
+// Keys lists the keys of a domain. It returns ErrUnsupportedPlatform on this platform.
+func Keys(applicationID string, scope PreferenceScope) ([]string, error) {
+	return nil, ErrUnsupportedPlatform
+}
+
+// GetAll retrieves every key and value of a domain. It returns ErrUnsupportedPlatform on this
+// platform.
+func GetAll(applicationID string, scope PreferenceScope) (map[string]interface{}, error) {
+	return nil, ErrUnsupportedPlatform
+}
+
+// GetMany retrieves several keys of a domain. It returns ErrUnsupportedPlatform on this
+// platform.
+func GetMany(keys []string, applicationID string, scope PreferenceScope) (map[string]interface{}, error) {
+	return nil, ErrUnsupportedPlatform
+}
+
+// GetRaw retrieves a preference value as plist data. It returns ErrUnsupportedPlatform on
+// this platform.
+func GetRaw(key string, applicationID string, scope PreferenceScope) ([]byte, Format, error) {
+	return nil, FormatBinary, ErrUnsupportedPlatform
+}
+
+// Describe returns the description of a stored value. It returns ErrUnsupportedPlatform on
+// this platform.
+func Describe(key string, applicationID string, scope PreferenceScope) (string, error) {
+	return "", ErrUnsupportedPlatform
+}
+
+func applicationList(scope PreferenceScope) ([]string, error) {
+	return nil, ErrUnsupportedPlatform
+}
+
+func readFresh(appID string, scope PreferenceScope) (map[string]interface{}, error) {
+	return nil, ErrUnsupportedPlatform
+}
+
+func flushCaches(appID string, scope PreferenceScope) (bool, error) {
+	return false, ErrUnsupportedPlatform
+}
+
+func parsePlistData(data []byte) (interface{}, error) {
+	return nil, ErrUnsupportedPlatform
+}
+
+func marshalPlistData(values map[string]interface{}, format Format) ([]byte, error) {
+	return nil, ErrUnsupportedPlatform
+}
+
+// stringCache stands in for the CFString interning cache, which holds nothing here.
+type stringCache struct{}
+
+func newStringCache(capacity int) *stringCache {
+	return nil
+}
+
+func (*stringCache) Close() error {
+	return nil
+}
+
+func setValue(names *stringCache, key string, value interface{}, applicationID string, scope PreferenceScope) error {
+	return ErrUnsupportedPlatform
+}
+
+func setMultiple(names *stringCache, keysToSet map[string]interface{}, keysToRemove []string, applicationID string, scope PreferenceScope) error {
+	return ErrUnsupportedPlatform
+}
+
+func getValue(names *stringCache, key string, applicationID string, scope PreferenceScope) (interface{}, error) {
+	return nil, ErrUnsupportedPlatform
+}
+
+func copyLazy(store Store, key string, applicationID string, scope PreferenceScope) (lazyNode, error) {
+	return storeNode(store, key, applicationID, scope)
+}
+
+func getMultiple(names *stringCache, keys []string, applicationID string, scope PreferenceScope) (map[string]interface{}, error) {
+	return nil, ErrUnsupportedPlatform
+}
+
+func getAll(names *stringCache, applicationID string, scope PreferenceScope) (map[string]interface{}, error) {
+	return nil, ErrUnsupportedPlatform
+}
@@ -0,0 +1,32 @@
+//go:build !darwin || !cgo
+
+package mac_prefs
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestUnsupportedPlatform(t *testing.T) {
+	if _, err := Get("Key", "com.example", CurrentUserAnyHost); !errors.Is(err, ErrUnsupportedPlatform) {
+		t.Errorf("Get() error = %v, want ErrUnsupportedPlatform", err)
+	}
+	if _, _, err := GetRaw("Key", "com.example", CurrentUserAnyHost); !errors.Is(err, ErrUnsupportedPlatform) {
+		t.Errorf("GetRaw() error = %v, want ErrUnsupportedPlatform", err)
+	}
+	if _, err := Describe("Key", "com.example", CurrentUserAnyHost); !errors.Is(err, ErrUnsupportedPlatform) {
+		t.Errorf("Describe() error = %v, want ErrUnsupportedPlatform", err)
+	}
+	if err := Set("Key", 1, "com.example", CurrentUserAnyHost); !errors.Is(err, ErrUnsupportedPlatform) {
+		t.Errorf("Set() error = %v, want ErrUnsupportedPlatform", err)
+	}
+	if _, err := Converge("com.example", CurrentUserAnyHost, map[string]interface{}{"Key": 1}); !errors.Is(err, ErrUnsupportedPlatform) {
+		t.Errorf("Converge() error = %v, want ErrUnsupportedPlatform", err)
+	}
+	if _, err := ListDomains(CurrentUserAnyHost); !errors.Is(err, ErrUnsupportedPlatform) {
+		t.Errorf("ListDomains() error = %v, want ErrUnsupportedPlatform", err)
+	}
+	if err := NewClient().Set("Key", 1, "com.example", CurrentUserAnyHost); !errors.Is(err, ErrUnsupportedPlatform) {
+		t.Errorf("Client.Set() error = %v, want ErrUnsupportedPlatform", err)
+	}
+}
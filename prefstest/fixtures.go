@@ -0,0 +1,92 @@
+package prefstest
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/weswhet/mac_prefs"
+	"github.com/weswhet/mac_prefs/internal/plist"
+)
+
+// hostSuffix matches the host identifier of a ByHost plist name: a hardware UUID, or the
+// MAC address used by older releases.
+var hostSuffix = regexp.MustCompile(`\.([0-9A-Fa-f]{8}-[0-9A-Fa-f]{4}-[0-9A-Fa-f]{4}-[0-9A-Fa-f]{4}-[0-9A-Fa-f]{12}|[0-9A-Fa-f]{12})$`)
+
+// FromPlistDir builds a MemoryStore seeded from plist fixtures laid out like
+// ~/Library/Preferences, so code that takes a Store can be tested deterministically on any
+// OS. XML and binary plists are both read.
+//
+//   - <dir>/<domain>.plist seeds <domain> in CurrentUserAnyHost.
+//   - <dir>/ByHost/<domain>.<host id>.plist seeds <domain> in CurrentUserCurrentHost. The host
+//     id is optional.
+//   - .GlobalPreferences seeds AnyApplication.
+//
+// Parameters:
+//   - dir: The fixture directory, e.g. "testdata".
+//
+// Returns:
+//   - *mac_prefs.MemoryStore: The seeded store.
+//   - error: An error if a fixture cannot be read or is not a dictionary.
+func FromPlistDir(dir string) (*mac_prefs.MemoryStore, error) {
+	store := mac_prefs.NewMemoryStore()
+	if err := loadPlistDir(store, dir, mac_prefs.CurrentUserAnyHost); err != nil {
+		return nil, err
+	}
+
+	byHost := filepath.Join(dir, "ByHost")
+	if _, err := os.Stat(byHost); err == nil {
+		if err := loadPlistDir(store, byHost, mac_prefs.CurrentUserCurrentHost); err != nil {
+			return nil, err
+		}
+	}
+	return store, nil
+}
+
+func loadPlistDir(store *mac_prefs.MemoryStore, dir string, scope mac_prefs.PreferenceScope) error {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return fmt.Errorf("prefstest: error reading fixtures: %v", err)
+	}
+
+	for _, entry := range entries {
+		if entry.IsDir() || filepath.Ext(entry.Name()) != ".plist" {
+			continue
+		}
+		path := filepath.Join(dir, entry.Name())
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return fmt.Errorf("prefstest: error reading fixture: %v", err)
+		}
+		decoded, err := plist.Unmarshal(data)
+		if err != nil {
+			return fmt.Errorf("prefstest: error decoding %s: %v", path, err)
+		}
+		values, ok := decoded.(map[string]interface{})
+		if !ok {
+			return fmt.Errorf("prefstest: %s is a %T, not a dictionary", path, decoded)
+		}
+
+		domain := fixtureDomain(entry.Name(), scope)
+		for key, value := range values {
+			if err := store.Set(key, value, domain, scope); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// fixtureDomain derives the application ID of a fixture from its file name.
+func fixtureDomain(name string, scope mac_prefs.PreferenceScope) string {
+	domain := strings.TrimSuffix(name, ".plist")
+	if scope.Host == mac_prefs.CurrentHost {
+		domain = hostSuffix.ReplaceAllString(domain, "")
+	}
+	if domain == ".GlobalPreferences" {
+		return mac_prefs.AnyApplication
+	}
+	return domain
+}
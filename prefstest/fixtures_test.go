@@ -0,0 +1,68 @@
+package prefstest
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/weswhet/mac_prefs"
+)
+
+func TestFromPlistDir(t *testing.T) {
+	store, err := FromPlistDir("testdata")
+	if err != nil {
+		t.Fatalf("FromPlistDir() error = %v", err)
+	}
+
+	tests := []struct {
+		domain string
+		scope  mac_prefs.PreferenceScope
+		want   map[string]interface{}
+	}{
+		{"com.apple.dock", mac_prefs.CurrentUserAnyHost, map[string]interface{}{
+			"autohide":    true,
+			"tilesize":    48,
+			"orientation": "left",
+			"persistent-apps": []interface{}{
+				map[string]interface{}{"tile-data": map[string]interface{}{"bundle-identifier": "com.apple.Safari"}},
+			},
+		}},
+		{"com.example.xml", mac_prefs.CurrentUserAnyHost, map[string]interface{}{"enabled": false}},
+		{"com.example.dates", mac_prefs.CurrentUserAnyHost, map[string]interface{}{
+			"distantPast":   time.Date(0, 12, 30, 0, 0, 0, 0, time.UTC),
+			"distantFuture": time.Date(4001, 1, 1, 0, 0, 0, 0, time.UTC),
+		}},
+		{mac_prefs.AnyApplication, mac_prefs.CurrentUserAnyHost, map[string]interface{}{
+			"AppleInterfaceStyle": "Dark",
+			"AppleLanguages":      []interface{}{"en-US"},
+		}},
+		{"com.apple.screensaver", mac_prefs.CurrentUserCurrentHost, map[string]interface{}{"idleTime": 300}},
+	}
+	for _, tt := range tests {
+		got, err := store.List(tt.domain, tt.scope)
+		if err != nil {
+			t.Fatalf("List(%s) error = %v", tt.domain, err)
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("List(%s, %v) = %#v, want %#v", tt.domain, tt.scope, got, tt.want)
+		}
+	}
+
+	if got := store.Domains(mac_prefs.CurrentUserCurrentHost); !reflect.DeepEqual(got, []string{"com.apple.screensaver"}) {
+		t.Errorf("Domains(CurrentUserCurrentHost) = %v", got)
+	}
+}
+
+func TestFromPlistDirErrors(t *testing.T) {
+	if _, err := FromPlistDir(filepath.Join(t.TempDir(), "missing")); err == nil {
+		t.Error("FromPlistDir(missing) expected error")
+	}
+
+	dir := t.TempDir()
+	os.WriteFile(filepath.Join(dir, "array.plist"), []byte("<plist><array/></plist>"), 0644)
+	if _, err := FromPlistDir(dir); err == nil {
+		t.Error("FromPlistDir(array) expected error")
+	}
+}
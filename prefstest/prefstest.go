@@ -0,0 +1,174 @@
+// Package prefstest provides isolated preference domains for tests. Each domain has a unique
+// application ID and is removed when the test finishes, including its ByHost plist, so tests
+// never touch or leave behind real preferences.
+//
+//	func TestDock(t *testing.T) {
+//		d := prefstest.New(t)
+//		d.Set("autohide", true)
+//		configureDock(d.ID)
+//		d.AssertValue("tilesize", 48)
+//	}
+package prefstest
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/weswhet/mac_prefs"
+)
+
+// Prefix is the application ID prefix of every domain created by New.
+const Prefix = "com.github.weswhet.mac_prefs.prefstest."
+
+// Domain is an isolated preference domain owned by one test. Its methods read and write
+// Scope and fail the test on error.
+type Domain struct {
+	// ID is the unique application ID of the domain.
+	ID string
+	// Scope is the scope the methods read and write, CurrentUserAnyHost for New.
+	Scope mac_prefs.PreferenceScope
+
+	t testing.TB
+}
+
+var unsafeChars = regexp.MustCompile(`[^A-Za-z0-9-]+`)
+
+// New creates a unique domain for t and registers a cleanup that removes every key written
+// to it in both current user scopes, along with the backing plist files.
+func New(t testing.TB) *Domain {
+	t.Helper()
+
+	var suffix [4]byte
+	if _, err := rand.Read(suffix[:]); err != nil {
+		t.Fatalf("prefstest: error generating domain name: %v", err)
+	}
+	name := strings.Trim(unsafeChars.ReplaceAllString(t.Name(), "-"), "-")
+	d := &Domain{
+		ID:    Prefix + name + "." + hex.EncodeToString(suffix[:]),
+		Scope: mac_prefs.CurrentUserAnyHost,
+		t:     t,
+	}
+	t.Cleanup(d.remove)
+	return d
+}
+
+// ByHost returns a view of the same domain that reads and writes CurrentUserCurrentHost.
+func (d *Domain) ByHost() *Domain {
+	byHost := *d
+	byHost.Scope = mac_prefs.CurrentUserCurrentHost
+	return &byHost
+}
+
+// remove deletes every key and plist file of the domain. Errors are ignored, since the
+// domain may never have been written.
+func (d *Domain) remove() {
+	for _, scope := range []mac_prefs.PreferenceScope{mac_prefs.CurrentUserAnyHost, mac_prefs.CurrentUserCurrentHost} {
+		if keys, err := mac_prefs.Keys(d.ID, scope); err == nil && len(keys) > 0 {
+			mac_prefs.SetMultiple(nil, keys, d.ID, scope)
+		}
+		if path, err := mac_prefs.PlistPath(d.ID, scope); err == nil {
+			os.Remove(path)
+		}
+	}
+}
+
+// Set writes a value, or removes the key if value is nil.
+func (d *Domain) Set(key string, value interface{}) {
+	d.t.Helper()
+	if err := mac_prefs.Set(key, value, d.ID, d.Scope); err != nil {
+		d.t.Fatalf("prefstest: Set(%q) error = %v", key, err)
+	}
+}
+
+// SetAll writes several values at once.
+func (d *Domain) SetAll(values map[string]interface{}) {
+	d.t.Helper()
+	if err := mac_prefs.SetMultiple(values, nil, d.ID, d.Scope); err != nil {
+		d.t.Fatalf("prefstest: SetMultiple() error = %v", err)
+	}
+}
+
+// Get reads a value, or nil if the key is not set.
+func (d *Domain) Get(key string) interface{} {
+	d.t.Helper()
+	value, err := mac_prefs.Get(key, d.ID, d.Scope)
+	if err != nil {
+		d.t.Fatalf("prefstest: Get(%q) error = %v", key, err)
+	}
+	return value
+}
+
+// Values reads every key and value.
+func (d *Domain) Values() map[string]interface{} {
+	d.t.Helper()
+	values, err := mac_prefs.GetAll(d.ID, d.Scope)
+	if err != nil {
+		d.t.Fatalf("prefstest: GetAll() error = %v", err)
+	}
+	return values
+}
+
+// AssertValue reports an error if key does not hold want. Values are compared like Diff does,
+// so 48 matches 48.0 and []string{"a"} matches []interface{}{"a"}.
+func (d *Domain) AssertValue(key string, want interface{}) {
+	d.t.Helper()
+	if want == nil {
+		d.AssertMissing(key)
+		return
+	}
+	d.assertDiff(map[string]interface{}{key: want})
+}
+
+// AssertMissing reports an error if key is set.
+func (d *Domain) AssertMissing(key string) {
+	d.t.Helper()
+	if got := d.Get(key); got != nil {
+		d.t.Errorf("prefstest: %s %s = %s, want it unset", d.ID, key, format(got))
+	}
+}
+
+// AssertValues reports an error unless the domain holds exactly want.
+func (d *Domain) AssertValues(want map[string]interface{}) {
+	d.t.Helper()
+	desired := make(map[string]interface{}, len(want))
+	for key, value := range want {
+		desired[key] = value
+	}
+	for key := range d.Values() {
+		if _, ok := desired[key]; !ok {
+			desired[key] = nil
+		}
+	}
+	d.assertDiff(desired)
+}
+
+func (d *Domain) assertDiff(desired map[string]interface{}) {
+	d.t.Helper()
+	added, changed, removed, err := mac_prefs.Diff(d.ID, d.Scope, desired)
+	if err != nil {
+		d.t.Fatalf("prefstest: Diff() error = %v", err)
+	}
+	var problems []string
+	for _, c := range added {
+		problems = append(problems, c.Key+" is unset, want "+format(c.New))
+	}
+	for _, c := range changed {
+		problems = append(problems, c.Key+" = "+format(c.Old)+", want "+format(c.New))
+	}
+	for _, c := range removed {
+		problems = append(problems, c.Key+" = "+format(c.Old)+", want it unset")
+	}
+	sort.Strings(problems)
+	for _, p := range problems {
+		d.t.Errorf("prefstest: %s %s", d.ID, p)
+	}
+}
+
+func format(v interface{}) string {
+	return strings.TrimSpace(mac_prefs.Dump(v))
+}
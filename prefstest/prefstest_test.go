@@ -0,0 +1,74 @@
+//go:build darwin && cgo
+
+package prefstest
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/weswhet/mac_prefs"
+)
+
+// recorder captures the failures reported through it instead of failing the test.
+type recorder struct {
+	testing.TB
+	errors []string
+}
+
+func (r *recorder) Errorf(format string, args ...interface{}) {
+	r.errors = append(r.errors, fmt.Sprintf(format, args...))
+}
+
+func TestNewCleansUp(t *testing.T) {
+	var id string
+	t.Run("write", func(t *testing.T) {
+		d := New(t)
+		id = d.ID
+		if !strings.HasPrefix(id, Prefix+"TestNewCleansUp-write.") {
+			t.Fatalf("New() ID = %s", id)
+		}
+		d.Set("Key", "value")
+		d.ByHost().Set("HostKey", 1)
+		d.AssertValue("Key", "value")
+		d.ByHost().AssertValue("HostKey", 1.0)
+		d.AssertMissing("HostKey")
+	})
+
+	for _, scope := range []mac_prefs.PreferenceScope{mac_prefs.CurrentUserAnyHost, mac_prefs.CurrentUserCurrentHost} {
+		keys, err := mac_prefs.Keys(id, scope)
+		if err != nil {
+			t.Fatalf("Keys() error = %v", err)
+		}
+		if len(keys) != 0 {
+			t.Errorf("keys left behind in %v: %v", scope, keys)
+		}
+	}
+
+	if a, b := New(t), New(t); a.ID == b.ID {
+		t.Fatalf("New() returned the same ID twice: %s", a.ID)
+	}
+}
+
+func TestAssertions(t *testing.T) {
+	d := New(t)
+	d.SetAll(map[string]interface{}{"Size": 48, "Apps": []interface{}{"Mail"}})
+	d.AssertValues(map[string]interface{}{"Size": 48.0, "Apps": []string{"Mail"}})
+
+	r := &recorder{TB: t}
+	failing := &Domain{ID: d.ID, Scope: d.Scope, t: r}
+	failing.AssertValue("Size", 32)
+	failing.AssertValue("Missing", true)
+	failing.AssertMissing("Size")
+	failing.AssertValues(map[string]interface{}{"Size": 48})
+
+	want := []string{
+		"prefstest: " + d.ID + " Size = int 48, want int 32",
+		"prefstest: " + d.ID + " Missing is unset, want bool true",
+		"prefstest: " + d.ID + " Size = int 48, want it unset",
+		"prefstest: " + d.ID + " Apps = array (1) [\n  [0]: string \"Mail\"\n], want it unset",
+	}
+	if strings.Join(r.errors, "\n") != strings.Join(want, "\n") {
+		t.Fatalf("assertion failures =\n%s\nwant\n%s", strings.Join(r.errors, "\n"), strings.Join(want, "\n"))
+	}
+}
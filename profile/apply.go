@@ -0,0 +1,30 @@
+package profile
+
+import (
+	"fmt"
+
+	"github.com/weswhet/mac_prefs"
+)
+
+// Apply writes the preference payloads of a Configuration Profile as plain preferences, without
+// installing the profile. Each domain is written with a single SetMultiple call. The values
+// are not forced, so this is useful for testing a profile's effect before deploying it through MDM.
+//
+// Parameters:
+//   - payload: The contents of a .mobileconfig file.
+//   - scope: The PreferenceScope to write the preferences to.
+//
+// Returns:
+//   - error: An error if the profile cannot be parsed or a domain cannot be written.
+func Apply(payload []byte, scope mac_prefs.PreferenceScope) error {
+	domains, err := Preferences(payload)
+	if err != nil {
+		return err
+	}
+	for domain, values := range domains {
+		if err := mac_prefs.SetMultiple(values, nil, domain, scope); err != nil {
+			return fmt.Errorf("profile: error applying preferences for %s: %v", domain, err)
+		}
+	}
+	return nil
+}
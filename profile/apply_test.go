@@ -0,0 +1,22 @@
+//go:build darwin && cgo
+
+package profile
+
+import (
+	"testing"
+
+	"github.com/weswhet/mac_prefs/prefstest"
+)
+
+func TestApply(t *testing.T) {
+	d := prefstest.New(t)
+
+	data, err := Generate(d.ID, map[string]interface{}{"ProfileKey": "applied"}, Options{})
+	if err != nil {
+		t.Fatalf("Generate() error = %v", err)
+	}
+	if err := Apply(data, d.Scope); err != nil {
+		t.Fatalf("Apply() error = %v", err)
+	}
+	d.AssertValue("ProfileKey", "applied")
+}
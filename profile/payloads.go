@@ -0,0 +1,139 @@
+package profile
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/weswhet/mac_prefs/internal/plist"
+)
+
+// ApplePreferencePayloadTypes are the com.apple payload types that are plain preference
+// domains. Apple reserves the com.apple prefix for system payloads, such as
+// com.apple.wifi.managed and com.apple.webClip.managed, which configure the system rather than
+// a domain, and new releases add more of them; so a com.apple payload is only treated as a
+// preference domain if it is listed here. Payload types outside com.apple are always
+// preference domains. Add a domain to apply custom settings payloads for it, or deliver them
+// as com.apple.ManagedClient.preferences payloads, which always name preference domains.
+var ApplePreferencePayloadTypes = map[string]bool{
+	".GlobalPreferences":                                 true,
+	"com.apple.AppleMultitouchMouse":                     true,
+	"com.apple.AppleMultitouchTrackpad":                  true,
+	"com.apple.Safari":                                   true,
+	"com.apple.SoftwareUpdate":                           true,
+	"com.apple.Terminal":                                 true,
+	"com.apple.TimeMachine":                              true,
+	"com.apple.commerce":                                 true,
+	"com.apple.controlcenter":                            true,
+	"com.apple.desktopservices":                          true,
+	"com.apple.dock":                                     true,
+	"com.apple.driver.AppleBluetoothMultitouch.trackpad": true,
+	"com.apple.finder":                                   true,
+	"com.apple.loginwindow":                              true,
+	"com.apple.menuextra.clock":                          true,
+	"com.apple.screencapture":                            true,
+	"com.apple.screensaver":                              true,
+	"com.apple.systemuiserver":                           true,
+	"com.apple.universalaccess":                          true,
+}
+
+// isPreferencePayloadType reports whether a custom settings payload of payloadType names a
+// preference domain.
+func isPreferencePayloadType(payloadType string) bool {
+	if payloadType == "" || payloadType == ConfigurationPayloadType {
+		return false
+	}
+	if strings.HasPrefix(payloadType, "com.apple.") {
+		return ApplePreferencePayloadTypes[payloadType]
+	}
+	return true
+}
+
+// Preferences extracts the preference payloads from a Configuration Profile. Custom settings
+// payloads contribute their non-Payload keys to the domain named by their PayloadType, and
+// com.apple.ManagedClient.preferences payloads contribute the mcx_preference_settings of every
+// domain they manage. System payloads are skipped; see ApplePreferencePayloadTypes. Signed
+// profiles are accepted; the signature is not verified.
+//
+// Parameters:
+//   - data: The contents of a .mobileconfig file.
+//
+// Returns:
+//   - map[string]map[string]interface{}: The preferences to apply, keyed by domain.
+//   - error: An error if the profile cannot be parsed.
+func Preferences(data []byte) (map[string]map[string]interface{}, error) {
+	parsed, err := plist.Unmarshal(unwrapSigned(data))
+	if err != nil {
+		return nil, fmt.Errorf("profile: error parsing profile: %v", err)
+	}
+	root, ok := parsed.(map[string]interface{})
+	if !ok {
+		return nil, errors.New("profile: profile is not a dictionary")
+	}
+
+	payloads, ok := root["PayloadContent"].([]interface{})
+	if !ok {
+		return nil, errors.New("profile: profile has no PayloadContent array")
+	}
+
+	domains := make(map[string]map[string]interface{})
+	merge := func(domain string, values map[string]interface{}) {
+		if domains[domain] == nil {
+			domains[domain] = make(map[string]interface{})
+		}
+		for k, v := range values {
+			domains[domain][k] = v
+		}
+	}
+
+	for _, p := range payloads {
+		payload, ok := p.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		payloadType, _ := payload["PayloadType"].(string)
+		switch {
+		case payloadType == ManagedClientPayloadType:
+			content, _ := payload["PayloadContent"].(map[string]interface{})
+			for domain, d := range content {
+				states, _ := d.(map[string]interface{})
+				for _, state := range []string{"Forced", "Set-Once", "Often"} {
+					entries, _ := states[state].([]interface{})
+					for _, e := range entries {
+						entry, _ := e.(map[string]interface{})
+						if settings, ok := entry["mcx_preference_settings"].(map[string]interface{}); ok {
+							merge(domain, settings)
+						}
+					}
+				}
+			}
+		case !isPreferencePayloadType(payloadType):
+			continue
+		default:
+			values := make(map[string]interface{})
+			for k, v := range payload {
+				if !strings.HasPrefix(k, "Payload") {
+					values[k] = v
+				}
+			}
+			merge(payloadType, values)
+		}
+	}
+	return domains, nil
+}
+
+// unwrapSigned returns the property list embedded in a CMS-signed profile, or data unchanged
+// if it is not signed.
+func unwrapSigned(data []byte) []byte {
+	trimmed := bytes.TrimSpace(data)
+	if bytes.HasPrefix(trimmed, []byte("<")) {
+		return data
+	}
+	start := bytes.Index(data, []byte("<?xml"))
+	end := bytes.LastIndex(data, []byte("</plist>"))
+	if start < 0 || end < start {
+		return data
+	}
+	return data[start : end+len("</plist>")]
+}
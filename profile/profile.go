@@ -0,0 +1,184 @@
+// Package profile converts between preference domains and Configuration Profiles (.mobileconfig).
+package profile
+
+import (
+	"bytes"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+
+	"github.com/weswhet/mac_prefs/internal/plist"
+)
+
+// PayloadFormat selects how preferences are wrapped inside a profile.
+type PayloadFormat int
+
+const (
+	// CustomSettings emits a payload whose PayloadType is the preference domain itself.
+	CustomSettings PayloadFormat = iota
+	// ManagedClient emits a legacy com.apple.ManagedClient.preferences (MCX) payload.
+	ManagedClient
+)
+
+const (
+	// ManagedClientPayloadType is the PayloadType of MCX preference payloads.
+	ManagedClientPayloadType = "com.apple.ManagedClient.preferences"
+	// ConfigurationPayloadType is the PayloadType of the top level profile.
+	ConfigurationPayloadType = "Configuration"
+)
+
+// Options controls profile generation.
+type Options struct {
+	// Format selects the payload format. Defaults to CustomSettings.
+	Format PayloadFormat
+	// Identifier is the ProfileIdentifier. Defaults to "<appID>.profile".
+	Identifier string
+	// DisplayName is the PayloadDisplayName shown to users. Defaults to the application ID.
+	DisplayName string
+	// Description is the optional PayloadDescription.
+	Description string
+	// Organization is the optional PayloadOrganization.
+	Organization string
+	// Scope is the PayloadScope, "System" or "User". Defaults to "System".
+	Scope string
+	// UUID is the profile PayloadUUID. A random UUID is generated when empty.
+	UUID string
+	// MCXState is the MCX management state for ManagedClient payloads: "Forced", "Set-Once", or "Often".
+	// Defaults to "Forced".
+	MCXState string
+	// SigningIdentity, when set, signs the profile with the named keychain identity using
+	// `security cms -S`. Signing is only available on macOS.
+	SigningIdentity string
+}
+
+// Generate builds a Configuration Profile that applies keys to the preference domain appID.
+//
+// Parameters:
+//   - appID: The bundle identifier of the preference domain to manage.
+//   - keys: The preference keys and values the profile should set.
+//   - opts: Options controlling the payload format, metadata, and signing.
+//
+// Returns:
+//   - []byte: The XML profile, or the CMS-signed profile when opts.SigningIdentity is set.
+//   - error: An error if a value cannot be encoded or signing fails.
+func Generate(appID string, keys map[string]interface{}, opts Options) ([]byte, error) {
+	if appID == "" {
+		return nil, errors.New("profile: application ID is required")
+	}
+
+	profileUUID := opts.UUID
+	if profileUUID == "" {
+		var err error
+		if profileUUID, err = newUUID(); err != nil {
+			return nil, err
+		}
+	}
+	payloadUUID, err := newUUID()
+	if err != nil {
+		return nil, err
+	}
+
+	identifier := opts.Identifier
+	if identifier == "" {
+		identifier = appID + ".profile"
+	}
+	displayName := opts.DisplayName
+	if displayName == "" {
+		displayName = appID
+	}
+	scope := opts.Scope
+	if scope == "" {
+		scope = "System"
+	}
+
+	payload := map[string]interface{}{
+		"PayloadIdentifier":  identifier + "." + payloadUUID,
+		"PayloadUUID":        payloadUUID,
+		"PayloadVersion":     1,
+		"PayloadDisplayName": displayName,
+	}
+	switch opts.Format {
+	case CustomSettings:
+		for k, v := range keys {
+			payload[k] = v
+		}
+		payload["PayloadType"] = appID
+	case ManagedClient:
+		state := opts.MCXState
+		if state == "" {
+			state = "Forced"
+		}
+		payload["PayloadType"] = ManagedClientPayloadType
+		payload["PayloadContent"] = map[string]interface{}{
+			appID: map[string]interface{}{
+				state: []interface{}{
+					map[string]interface{}{"mcx_preference_settings": keys},
+				},
+			},
+		}
+	default:
+		return nil, fmt.Errorf("profile: unknown payload format %d", opts.Format)
+	}
+
+	profile := map[string]interface{}{
+		"PayloadContent":     []interface{}{payload},
+		"PayloadDisplayName": displayName,
+		"PayloadIdentifier":  identifier,
+		"PayloadScope":       scope,
+		"PayloadType":        ConfigurationPayloadType,
+		"PayloadUUID":        profileUUID,
+		"PayloadVersion":     1,
+	}
+	if opts.Description != "" {
+		profile["PayloadDescription"] = opts.Description
+	}
+	if opts.Organization != "" {
+		profile["PayloadOrganization"] = opts.Organization
+	}
+
+	data, err := plist.MarshalXML(profile)
+	if err != nil {
+		return nil, fmt.Errorf("profile: error encoding profile: %v", err)
+	}
+	if opts.SigningIdentity == "" {
+		return data, nil
+	}
+	return sign(data, opts.SigningIdentity)
+}
+
+// sign wraps data in a CMS signature made with a keychain identity.
+func sign(data []byte, identity string) ([]byte, error) {
+	dir, err := os.MkdirTemp("", "mac_prefs-profile")
+	if err != nil {
+		return nil, fmt.Errorf("profile: error creating temporary directory: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	in := filepath.Join(dir, "unsigned.mobileconfig")
+	out := filepath.Join(dir, "signed.mobileconfig")
+	if err := os.WriteFile(in, data, 0o600); err != nil {
+		return nil, fmt.Errorf("profile: error writing unsigned profile: %v", err)
+	}
+
+	var stderr bytes.Buffer
+	cmd := exec.Command("/usr/bin/security", "cms", "-S", "-N", identity, "-i", in, "-o", out)
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return nil, fmt.Errorf("profile: error signing profile: %v: %s", err, bytes.TrimSpace(stderr.Bytes()))
+	}
+	return os.ReadFile(out)
+}
+
+// newUUID returns a random RFC 4122 version 4 UUID in upper case, as profiles conventionally use.
+func newUUID() (string, error) {
+	var b [16]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		return "", fmt.Errorf("profile: error generating UUID: %v", err)
+	}
+	b[6] = (b[6] & 0x0f) | 0x40
+	b[8] = (b[8] & 0x3f) | 0x80
+	return fmt.Sprintf("%X-%X-%X-%X-%X", b[0:4], b[4:6], b[6:8], b[8:10], b[10:16]), nil
+}
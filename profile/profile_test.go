@@ -0,0 +1,123 @@
+package profile
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/weswhet/mac_prefs/internal/plist"
+)
+
+func TestGenerateCustomSettings(t *testing.T) {
+	data, err := Generate("com.apple.dock", map[string]interface{}{"autohide": true, "tilesize": 48}, Options{
+		DisplayName: "Dock",
+		UUID:        "11111111-1111-4111-8111-111111111111",
+	})
+	if err != nil {
+		t.Fatalf("Generate() error = %v", err)
+	}
+
+	for _, want := range []string{
+		"<key>PayloadType</key>\n\t\t\t<string>com.apple.dock</string>",
+		"<key>autohide</key>\n\t\t\t<true/>",
+		"<key>tilesize</key>\n\t\t\t<integer>48</integer>",
+		"<key>PayloadUUID</key>\n\t<string>11111111-1111-4111-8111-111111111111</string>",
+		"<key>PayloadType</key>\n\t<string>Configuration</string>",
+		"<key>PayloadIdentifier</key>\n\t<string>com.apple.dock.profile</string>",
+	} {
+		if !strings.Contains(string(data), want) {
+			t.Errorf("Generate() output missing %q:\n%s", want, data)
+		}
+	}
+}
+
+func TestGenerateManagedClient(t *testing.T) {
+	data, err := Generate("com.apple.dock", map[string]interface{}{"autohide": true}, Options{Format: ManagedClient})
+	if err != nil {
+		t.Fatalf("Generate() error = %v", err)
+	}
+
+	for _, want := range []string{
+		"<string>com.apple.ManagedClient.preferences</string>",
+		"<key>Forced</key>",
+		"<key>mcx_preference_settings</key>",
+	} {
+		if !strings.Contains(string(data), want) {
+			t.Errorf("Generate() output missing %q:\n%s", want, data)
+		}
+	}
+}
+
+func TestGenerateRejectsUnsupportedValues(t *testing.T) {
+	if _, err := Generate("com.apple.dock", map[string]interface{}{"bad": make(chan int)}, Options{}); err == nil {
+		t.Fatal("Generate() expected error for unsupported value")
+	}
+	if _, err := Generate("", nil, Options{}); err == nil {
+		t.Fatal("Generate() expected error for empty application ID")
+	}
+}
+
+func TestPreferencesRoundTrip(t *testing.T) {
+	keys := map[string]interface{}{"autohide": true, "tilesize": 48, "persistent-others": []interface{}{"Downloads"}}
+
+	for _, format := range []PayloadFormat{CustomSettings, ManagedClient} {
+		data, err := Generate("com.apple.dock", keys, Options{Format: format})
+		if err != nil {
+			t.Fatalf("Generate() error = %v", err)
+		}
+
+		got, err := Preferences(data)
+		if err != nil {
+			t.Fatalf("Preferences() error = %v", err)
+		}
+		want := map[string]map[string]interface{}{"com.apple.dock": keys}
+		if !reflect.DeepEqual(got, want) {
+			t.Fatalf("Preferences() format %d got = %#v, want %#v", format, got, want)
+		}
+	}
+}
+
+func TestPreferencesSignedProfile(t *testing.T) {
+	data, err := Generate("com.example.app", map[string]interface{}{"Enabled": true}, Options{})
+	if err != nil {
+		t.Fatalf("Generate() error = %v", err)
+	}
+	signed := append(append([]byte{0x30, 0x80, 0x06, 0x09}, data...), 0x00, 0x00, 0xa0)
+
+	got, err := Preferences(signed)
+	if err != nil {
+		t.Fatalf("Preferences() error = %v", err)
+	}
+	if got["com.example.app"]["Enabled"] != true {
+		t.Fatalf("Preferences() got = %#v, want Enabled for com.example.app", got)
+	}
+}
+
+func TestPreferencesSkipsSystemPayloads(t *testing.T) {
+	profile := map[string]interface{}{
+		"PayloadType": "Configuration",
+		"PayloadContent": []interface{}{
+			map[string]interface{}{"PayloadType": "com.apple.wifi.managed", "SSID_STR": "corp"},
+			map[string]interface{}{"PayloadType": "com.apple.webClip.managed", "URL": "https://example.com"},
+			map[string]interface{}{"PayloadType": "com.apple.unreleased.payload", "Enabled": true},
+			map[string]interface{}{"PayloadType": "com.apple.dock", "autohide": true},
+			map[string]interface{}{"PayloadType": "com.example.app", "Enabled": true},
+		},
+	}
+	data, err := plist.MarshalXML(profile)
+	if err != nil {
+		t.Fatalf("MarshalXML() error = %v", err)
+	}
+
+	got, err := Preferences(data)
+	if err != nil {
+		t.Fatalf("Preferences() error = %v", err)
+	}
+	want := map[string]map[string]interface{}{
+		"com.apple.dock":  {"autohide": true},
+		"com.example.app": {"Enabled": true},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("Preferences() got = %#v, want only the preference domains %#v", got, want)
+	}
+}
@@ -0,0 +1,129 @@
+package mac_prefs
+
+import (
+	"fmt"
+	"os/exec"
+	"sort"
+)
+
+const (
+	// profilesCommand is the configuration profiles tool.
+	profilesCommand = "/usr/bin/profiles"
+	// computerLevelProfiles is the key under which device profiles are listed.
+	computerLevelProfiles = "_computerlevel"
+	// managedClientPayloadType is the payload type of legacy MCX preference payloads.
+	managedClientPayloadType = "com.apple.ManagedClient.preferences"
+)
+
+// ManagingProfile identifies an installed configuration profile that supplies a preference value.
+type ManagingProfile struct {
+	// DisplayName is the profile's ProfileDisplayName.
+	DisplayName string
+	// Identifier is the profile's ProfileIdentifier.
+	Identifier string
+	// UUID is the profile's ProfileUUID.
+	UUID string
+	// PayloadType is the type of the payload that contains the key.
+	PayloadType string
+	// Owner is "_computerlevel" for device profiles, or the short name of the user the profile is installed for.
+	Owner string
+}
+
+// WhoManages reports which installed configuration profiles supply a value for a key.
+// Both custom settings payloads (PayloadType equal to the domain) and
+// com.apple.ManagedClient.preferences payloads are inspected. Reading computer-level profiles
+// requires root privileges.
+//
+// Parameters:
+//   - appID: The bundle identifier of the managed application.
+//   - key: The preference key to look up.
+//
+// Returns:
+//   - []ManagingProfile: The profiles that define the key, sorted by owner and display name. Empty if none do.
+//   - error: An error if the profiles tool fails or its output cannot be parsed.
+func WhoManages(appID string, key string) ([]ManagingProfile, error) {
+	out, err := exec.Command(profilesCommand, "-P", "-o", "stdout-xml").Output()
+	if err != nil {
+		return nil, fmt.Errorf("error listing configuration profiles: %v", err)
+	}
+
+	installed, err := parsePlistData(out)
+	if err != nil {
+		return nil, fmt.Errorf("error parsing configuration profiles: %v", err)
+	}
+	return findManagingProfiles(installed, appID, key), nil
+}
+
+// findManagingProfiles searches parsed `profiles -P -o stdout-xml` output for payloads defining key.
+func findManagingProfiles(installed interface{}, appID string, key string) []ManagingProfile {
+	owners, _ := installed.(map[string]interface{})
+	matches := make([]ManagingProfile, 0)
+	for owner, list := range owners {
+		profiles, _ := list.([]interface{})
+		for _, p := range profiles {
+			profile, ok := p.(map[string]interface{})
+			if !ok {
+				continue
+			}
+			items, _ := profile["ProfileItems"].([]interface{})
+			for _, i := range items {
+				item, ok := i.(map[string]interface{})
+				if !ok {
+					continue
+				}
+				payloadType, _ := item["PayloadType"].(string)
+				if !payloadDefinesKey(item, payloadType, appID, key) {
+					continue
+				}
+				matches = append(matches, ManagingProfile{
+					DisplayName: stringValue(profile["ProfileDisplayName"]),
+					Identifier:  stringValue(profile["ProfileIdentifier"]),
+					UUID:        stringValue(profile["ProfileUUID"]),
+					PayloadType: payloadType,
+					Owner:       owner,
+				})
+			}
+		}
+	}
+
+	sort.Slice(matches, func(i, j int) bool {
+		if matches[i].Owner != matches[j].Owner {
+			return matches[i].Owner < matches[j].Owner
+		}
+		return matches[i].DisplayName < matches[j].DisplayName
+	})
+	return matches
+}
+
+// payloadDefinesKey reports whether a profile payload sets key for appID.
+func payloadDefinesKey(item map[string]interface{}, payloadType, appID, key string) bool {
+	content, _ := item["PayloadContent"].(map[string]interface{})
+
+	switch payloadType {
+	case appID:
+		if _, ok := content[key]; ok {
+			return true
+		}
+		_, ok := item[key]
+		return ok
+	case managedClientPayloadType:
+		domain, _ := content[appID].(map[string]interface{})
+		for _, state := range []string{"Forced", "Set-Once", "Often"} {
+			entries, _ := domain[state].([]interface{})
+			for _, e := range entries {
+				entry, _ := e.(map[string]interface{})
+				settings, _ := entry["mcx_preference_settings"].(map[string]interface{})
+				if _, ok := settings[key]; ok {
+					return true
+				}
+			}
+		}
+	}
+	return false
+}
+
+// stringValue returns v if it is a string, or the empty string otherwise.
+func stringValue(v interface{}) string {
+	s, _ := v.(string)
+	return s
+}
@@ -0,0 +1,90 @@
+package mac_prefs
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestFindManagingProfiles(t *testing.T) {
+	installed := map[string]interface{}{
+		"_computerlevel": []interface{}{
+			map[string]interface{}{
+				"ProfileDisplayName": "Dock Settings",
+				"ProfileIdentifier":  "com.example.dock",
+				"ProfileUUID":        "11111111-1111-1111-1111-111111111111",
+				"ProfileItems": []interface{}{
+					map[string]interface{}{
+						"PayloadType":    "com.apple.dock",
+						"PayloadContent": map[string]interface{}{"autohide": true},
+					},
+				},
+			},
+			map[string]interface{}{
+				"ProfileDisplayName": "Legacy MCX",
+				"ProfileIdentifier":  "com.example.mcx",
+				"ProfileUUID":        "22222222-2222-2222-2222-222222222222",
+				"ProfileItems": []interface{}{
+					map[string]interface{}{
+						"PayloadType": "com.apple.ManagedClient.preferences",
+						"PayloadContent": map[string]interface{}{
+							"com.apple.dock": map[string]interface{}{
+								"Forced": []interface{}{
+									map[string]interface{}{
+										"mcx_preference_settings": map[string]interface{}{"tilesize": 48},
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+		"alice": []interface{}{
+			map[string]interface{}{
+				"ProfileDisplayName": "Alice Dock",
+				"ProfileIdentifier":  "com.example.alice",
+				"ProfileUUID":        "33333333-3333-3333-3333-333333333333",
+				"ProfileItems": []interface{}{
+					map[string]interface{}{
+						"PayloadType":    "com.apple.dock",
+						"PayloadContent": map[string]interface{}{"autohide": false},
+					},
+				},
+			},
+		},
+	}
+
+	for _, tc := range []struct {
+		name string
+		key  string
+		want []ManagingProfile
+	}{
+		{
+			name: "custom settings payload",
+			key:  "autohide",
+			want: []ManagingProfile{
+				{DisplayName: "Dock Settings", Identifier: "com.example.dock", UUID: "11111111-1111-1111-1111-111111111111", PayloadType: "com.apple.dock", Owner: "_computerlevel"},
+				{DisplayName: "Alice Dock", Identifier: "com.example.alice", UUID: "33333333-3333-3333-3333-333333333333", PayloadType: "com.apple.dock", Owner: "alice"},
+			},
+		},
+		{
+			name: "managed client payload",
+			key:  "tilesize",
+			want: []ManagingProfile{
+				{DisplayName: "Legacy MCX", Identifier: "com.example.mcx", UUID: "22222222-2222-2222-2222-222222222222", PayloadType: "com.apple.ManagedClient.preferences", Owner: "_computerlevel"},
+			},
+		},
+		{
+			name: "unmanaged key",
+			key:  "orientation",
+			want: []ManagingProfile{},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			got := findManagingProfiles(installed, "com.apple.dock", tc.key)
+			if !reflect.DeepEqual(got, tc.want) {
+				t.Fatalf("findManagingProfiles() got = %+v, want %+v", got, tc.want)
+			}
+		})
+	}
+}
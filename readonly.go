@@ -0,0 +1,43 @@
+package mac_prefs
+
+import "errors"
+
+// ErrReadOnly is returned by every write through a Client created with WithReadOnly.
+var ErrReadOnly = errors.New("mac_prefs: client is read-only")
+
+// WithReadOnly makes every write through the Client fail with an error wrapping ErrReadOnly
+// before it reaches CFPreferences or the store set with WithStore, so reporting and inventory
+// tools cannot modify preferences. Reads and watchers work as usual. Combined with WithDryRun,
+// writes are still planned, since planning writes nothing.
+//
+// Only the Client is guarded; the package level functions still write.
+func WithReadOnly() Option {
+	return func(c *Client) {
+		c.readOnly = true
+	}
+}
+
+// readOnlyStore is a Store that rejects every write.
+type readOnlyStore struct {
+	Store
+}
+
+// GetMultiple retrieves several keys, in one read if the wrapped Store is a MultiGetStore.
+func (s readOnlyStore) GetMultiple(keys []string, applicationID string, scope PreferenceScope) (map[string]interface{}, error) {
+	return readSlot(s.Store, prefSlot{applicationID, scope}, keys)
+}
+
+// Set returns ErrReadOnly.
+func (readOnlyStore) Set(key string, value interface{}, applicationID string, scope PreferenceScope) error {
+	return ErrReadOnly
+}
+
+// Delete returns ErrReadOnly.
+func (readOnlyStore) Delete(key string, applicationID string, scope PreferenceScope) error {
+	return ErrReadOnly
+}
+
+// SetMultiple returns ErrReadOnly.
+func (readOnlyStore) SetMultiple(keysToSet map[string]interface{}, keysToRemove []string, applicationID string, scope PreferenceScope) error {
+	return ErrReadOnly
+}
@@ -0,0 +1,79 @@
+package mac_prefs
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestClientReadOnly(t *testing.T) {
+	store := NewMemoryStore()
+	if err := store.Set("list", []interface{}{1}, "com.example", CurrentUserAnyHost); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.Set("dict", map[string]interface{}{"a": 1}, "com.example", CurrentUserAnyHost); err != nil {
+		t.Fatal(err)
+	}
+	before, _ := store.List("com.example", CurrentUserAnyHost)
+	c := NewClient(WithStore(store), WithReadOnly(), WithUndo(0))
+
+	writes := map[string]func() error{
+		"Set":    func() error { return c.Set("key", 1, "com.example", CurrentUserAnyHost) },
+		"Delete": func() error { return c.Delete("list", "com.example", CurrentUserAnyHost) },
+		"Increment": func() error {
+			_, err := c.Increment("count", "com.example", CurrentUserAnyHost, 1)
+			return err
+		},
+		"SetIfAbsent": func() error {
+			_, err := c.SetIfAbsent("key", 1, "com.example", CurrentUserAnyHost)
+			return err
+		},
+		"ArrayAppend": func() error { return c.ArrayAppend("list", "com.example", CurrentUserAnyHost, false, 2) },
+		"SetPath":     func() error { return c.SetPath("com.example", "dict.b", 2, CurrentUserAnyHost) },
+		"MergeDict": func() error {
+			return c.MergeDict("dict", "com.example", CurrentUserAnyHost, map[string]interface{}{"b": 2}, MergeReplaceArrays)
+		},
+		"Converge": func() error {
+			_, err := c.Converge("com.example", CurrentUserAnyHost, map[string]interface{}{"key": 1})
+			return err
+		},
+		"ReplaceAll": func() error { return c.ReplaceAll("com.example", CurrentUserAnyHost, nil) },
+		"CopyDomain": func() error { return c.CopyDomain("com.example", "com.example.copy", CurrentUserAnyHost) },
+		"MigrateDomain": func() error {
+			return c.MigrateDomain("com.example", "com.example.new", CurrentUserAnyHost, MigrateOptions{})
+		},
+		"Commit": func() error {
+			tx := c.Begin()
+			if err := tx.Set("key", 1, "com.example", CurrentUserAnyHost); err != nil {
+				return err
+			}
+			return tx.Commit()
+		},
+	}
+	for name, write := range writes {
+		if err := write(); !errors.Is(err, ErrReadOnly) {
+			t.Errorf("%s() error = %v, want ErrReadOnly", name, err)
+		}
+	}
+
+	after, _ := store.List("com.example", CurrentUserAnyHost)
+	if !reflect.DeepEqual(after, before) {
+		t.Errorf("store = %v, want it unchanged %v", after, before)
+	}
+	if domains := store.Domains(CurrentUserAnyHost); len(domains) != 1 {
+		t.Errorf("Domains() = %v, want only com.example", domains)
+	}
+	if got, err := c.Get("list", "com.example", CurrentUserAnyHost); err != nil || !reflect.DeepEqual(got, []interface{}{1}) {
+		t.Errorf("Get() = %v, %v", got, err)
+	}
+}
+
+func TestClientReadOnlyDryRun(t *testing.T) {
+	c := NewClient(WithStore(NewMemoryStore()), WithReadOnly(), WithDryRun())
+	if err := c.Set("key", 1, "com.example", CurrentUserAnyHost); err != nil {
+		t.Fatalf("Set() error = %v", err)
+	}
+	if len(c.Planned()) != 1 {
+		t.Errorf("Planned() = %v", c.Planned())
+	}
+}
@@ -0,0 +1,48 @@
+package mac_prefs
+
+import (
+	"fmt"
+	"runtime/debug"
+)
+
+// PanicError reports a panic recovered in a call into CoreFoundation, such as a conversion
+// of an exotic value or the use of a released reference, so a bug in one operation does not
+// take down the whole process. Crashes in C code, such as a segmentation fault, cannot be
+// recovered.
+type PanicError struct {
+	// Op is the operation that panicked, e.g. "Set".
+	Op string
+	// ApplicationID is the domain the operation was working on, if any.
+	ApplicationID string
+	// Key is the preference key the operation was working on, if any.
+	Key string
+	// Value is the value passed to panic.
+	Value interface{}
+	// Stack is the stack trace of the panicking goroutine.
+	Stack []byte
+}
+
+func (e *PanicError) Error() string {
+	msg := "mac_prefs: panic in " + e.Op
+	if e.ApplicationID != "" {
+		msg += " of " + e.ApplicationID
+	}
+	if e.Key != "" {
+		msg += " for key " + e.Key
+	}
+	return fmt.Sprintf("%s: %v", msg, e.Value)
+}
+
+// Unwrap returns the value passed to panic if it is an error.
+func (e *PanicError) Unwrap() error {
+	err, _ := e.Value.(error)
+	return err
+}
+
+// recoverPanic turns a panic into a *PanicError stored in *err. It must be deferred directly
+// by the function whose panics it recovers.
+func recoverPanic(err *error, op string, applicationID string, key string) {
+	if r := recover(); r != nil {
+		*err = &PanicError{Op: op, ApplicationID: applicationID, Key: key, Value: r, Stack: debug.Stack()}
+	}
+}
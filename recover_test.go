@@ -0,0 +1,54 @@
+package mac_prefs
+
+import (
+	"errors"
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestRecoverPanic(t *testing.T) {
+	op := func(value interface{}) (err error) {
+		defer recoverPanic(&err, "Set", "com.example", "key")
+		panic(value)
+	}
+
+	err := op("boom")
+	var pe *PanicError
+	if !errors.As(err, &pe) {
+		t.Fatalf("error = %v, want a *PanicError", err)
+	}
+	if pe.Op != "Set" || pe.ApplicationID != "com.example" || pe.Key != "key" || pe.Value != "boom" || len(pe.Stack) == 0 {
+		t.Errorf("PanicError = %+v", pe)
+	}
+	if got, want := err.Error(), "mac_prefs: panic in Set of com.example for key key: boom"; got != want {
+		t.Errorf("Error() = %q, want %q", got, want)
+	}
+	if err := op(io.EOF); !errors.Is(err, io.EOF) {
+		t.Errorf("error = %v, want one wrapping io.EOF", err)
+	}
+
+	ok := func() (err error) {
+		defer recoverPanic(&err, "Get", "", "")
+		return io.ErrUnexpectedEOF
+	}
+	if err := ok(); err != io.ErrUnexpectedEOF {
+		t.Errorf("error = %v, want the returned error unchanged", err)
+	}
+}
+
+// panickingNode is a lazyNode whose conversions panic, like a released CF reference.
+type panickingNode struct{ goNode }
+
+func (panickingNode) get(key string) (lazyNode, error) { panic("use of closed Ref") }
+func (panickingNode) value() (interface{}, error)      { panic("use of closed Ref") }
+
+func TestLazyValueRecoversPanics(t *testing.T) {
+	v := &LazyValue{node: panickingNode{goNode{map[string]interface{}{}}}, read: func(v interface{}) interface{} { return v }}
+	if _, err := v.Get("a"); err == nil || !strings.Contains(err.Error(), "LazyValue.Get") {
+		t.Errorf("Get() error = %v, want a PanicError", err)
+	}
+	if _, err := v.Value(); err == nil || !strings.Contains(err.Error(), "use of closed Ref") {
+		t.Errorf("Value() error = %v, want a PanicError", err)
+	}
+}
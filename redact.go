@@ -0,0 +1,143 @@
+package mac_prefs
+
+import (
+	"fmt"
+	"io"
+	"path"
+	"strings"
+)
+
+// Redacted replaces the values of sensitive keys in redacted output.
+const Redacted = "<redacted>"
+
+// DefaultSensitiveKeys are the patterns of DefaultRedactor. They match keys that commonly hold
+// secrets or identifiers, such as "AuthToken", "password", and "SerialNumber".
+var DefaultSensitiveKeys = []string{
+	"*token*",
+	"*password*",
+	"*passwd*",
+	"*secret*",
+	"*credential*",
+	"*apikey*",
+	"*api_key*",
+	"*privatekey*",
+	"*private_key*",
+	"*serial*",
+}
+
+// DefaultRedactor masks the keys matching DefaultSensitiveKeys. It is used by the String
+// methods of Change and PlannedChange, and by Clients created without WithRedactor.
+var DefaultRedactor = mustRedactor(DefaultSensitiveKeys...)
+
+// Redactor masks the values of sensitive keys in logs, audit events, exports, and String
+// output. Values read with Get and the other explicit reads are never masked.
+//
+// A nil Redactor masks nothing.
+type Redactor struct {
+	patterns []string
+}
+
+// NewRedactor returns a Redactor masking keys that match any of patterns. Patterns use the
+// syntax of path.Match, such as "*token*", and are matched case-insensitively against each
+// key, including the keys of nested dictionaries.
+//
+// Parameters:
+//   - patterns: The key patterns to mask.
+//
+// Returns:
+//   - *Redactor: The Redactor.
+//   - error: An error if a pattern is malformed.
+func NewRedactor(patterns ...string) (*Redactor, error) {
+	r := &Redactor{patterns: make([]string, len(patterns))}
+	for i, pattern := range patterns {
+		pattern = strings.ToLower(pattern)
+		if _, err := path.Match(pattern, ""); err != nil {
+			return nil, fmt.Errorf("invalid redaction pattern %q: %v", patterns[i], err)
+		}
+		r.patterns[i] = pattern
+	}
+	return r, nil
+}
+
+func mustRedactor(patterns ...string) *Redactor {
+	r, err := NewRedactor(patterns...)
+	if err != nil {
+		panic(err)
+	}
+	return r
+}
+
+// Sensitive reports whether key matches a pattern of the Redactor.
+func (r *Redactor) Sensitive(key string) bool {
+	if r == nil {
+		return false
+	}
+	key = strings.ToLower(key)
+	for _, pattern := range r.patterns {
+		if ok, _ := path.Match(pattern, key); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// Redact returns value, the value of key, with sensitive values replaced by Redacted. The
+// value of a sensitive key is replaced whole; otherwise the entries of nested dictionaries,
+// including dictionaries in arrays, are redacted by their own keys. Booleans are never
+// masked: they cannot hold a secret, and keys such as askForPassword are plain settings.
+// value is not modified.
+func (r *Redactor) Redact(key string, value interface{}) interface{} {
+	if r == nil || value == nil {
+		return value
+	}
+	if _, ok := value.(bool); ok {
+		return value
+	}
+	if r.Sensitive(key) {
+		return Redacted
+	}
+	switch v := value.(type) {
+	case map[string]interface{}:
+		return r.RedactValues(v)
+	case []interface{}:
+		result := make([]interface{}, len(v))
+		for i, item := range v {
+			result[i] = r.Redact("", item)
+		}
+		return result
+	default:
+		return value
+	}
+}
+
+// RedactValues returns a copy of the values of a domain with sensitive values replaced by
+// Redacted.
+func (r *Redactor) RedactValues(values map[string]interface{}) map[string]interface{} {
+	if r == nil || values == nil {
+		return values
+	}
+	result := make(map[string]interface{}, len(values))
+	for key, value := range values {
+		result[key] = r.Redact(key, value)
+	}
+	return result
+}
+
+// WithRedactor sets the Redactor that masks sensitive values in the audit events and exports
+// of the Client. DefaultRedactor is used if it is not set; a nil Redactor masks nothing.
+func WithRedactor(r *Redactor) Option {
+	return func(c *Client) {
+		c.redactor = r
+	}
+}
+
+// Export serializes one slot of a domain as a plist with the values of sensitive keys
+// masked by the Redactor of the Client. With WithRedactor(nil), the real values are
+// exported. See Export.
+func (c *Client) Export(appID string, scope PreferenceScope, w io.Writer, format Format) error {
+	values, err := c.GetAll(appID, scope)
+	if err != nil {
+		return err
+	}
+	return writePlist(w, c.redactor.RedactValues(values), format)
+}
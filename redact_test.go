@@ -0,0 +1,97 @@
+package mac_prefs
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestRedactor(t *testing.T) {
+	if _, err := NewRedactor("["); err == nil {
+		t.Error("NewRedactor() of a malformed pattern expected error")
+	}
+
+	r, err := NewRedactor("*Token*", "serial")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for key, want := range map[string]bool{"AuthToken": true, "tokens": true, "SERIAL": true, "SerialNumber": false, "name": false} {
+		if got := r.Sensitive(key); got != want {
+			t.Errorf("Sensitive(%q) = %t, want %t", key, got, want)
+		}
+	}
+
+	value := map[string]interface{}{
+		"name":      "app",
+		"authToken": "abc",
+		"enabled":   true,
+		"servers":   []interface{}{map[string]interface{}{"host": "a", "token": []byte{1}}},
+		"tokens":    map[string]interface{}{"a": "b"},
+		"useToken":  true,
+	}
+	want := map[string]interface{}{
+		"name":      "app",
+		"authToken": Redacted,
+		"enabled":   true,
+		"servers":   []interface{}{map[string]interface{}{"host": "a", "token": Redacted}},
+		"tokens":    Redacted,
+		"useToken":  true,
+	}
+	if got := r.RedactValues(value); !reflect.DeepEqual(got, want) {
+		t.Errorf("RedactValues() = %v, want %v", got, want)
+	}
+	if value["authToken"] != "abc" {
+		t.Error("RedactValues() modified its argument")
+	}
+
+	var none *Redactor
+	if got := none.Redact("authToken", "abc"); got != "abc" {
+		t.Errorf("nil Redactor.Redact() = %v", got)
+	}
+}
+
+func TestChangeStringRedacts(t *testing.T) {
+	for _, tt := range []struct {
+		change Change
+		want   string
+	}{
+		{Change{Key: "APIToken", New: "abc"}, "add APIToken = <redacted>"},
+		{Change{Key: "Password", Old: "abc"}, "remove Password (was <redacted>)"},
+		{Change{Key: "SerialNumber", Old: "C02", New: "C03"}, "change SerialNumber: <redacted> -> <redacted>"},
+		{Change{Key: "askForPassword", Old: false, New: true}, "change askForPassword: false -> true"},
+		{Change{Key: "name", New: "abc"}, "add name = abc"},
+	} {
+		if got := tt.change.String(); got != tt.want {
+			t.Errorf("String() = %q, want %q", got, tt.want)
+		}
+	}
+}
+
+func TestClientAuditRedacts(t *testing.T) {
+	r, err := NewRedactor("*pin*")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, tt := range []struct {
+		opts []Option
+		key  string
+		want interface{}
+	}{
+		{nil, "AuthToken", Redacted},
+		{nil, "PIN", "1234"},
+		{[]Option{WithRedactor(r)}, "PIN", Redacted},
+		{[]Option{WithRedactor(nil)}, "AuthToken", "1234"},
+	} {
+		c := NewClient(append([]Option{WithStore(NewMemoryStore())}, tt.opts...)...)
+		var events []AuditEvent
+		c.OnMutation(func(e AuditEvent) { events = append(events, e) })
+		if err := c.Set(tt.key, "1234", "com.example", CurrentUserAnyHost); err != nil {
+			t.Fatal(err)
+		}
+		if len(events) != 1 || events[0].New != tt.want {
+			t.Errorf("%s: events = %+v, want New %v", tt.key, events, tt.want)
+		}
+		if got, _ := c.Get(tt.key, "com.example", CurrentUserAnyHost); got != "1234" {
+			t.Errorf("%s: Get() = %v, want the value unmasked", tt.key, got)
+		}
+	}
+}
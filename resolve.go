@@ -0,0 +1,109 @@
+package mac_prefs
+
+// Layer identifies one level of the CFPreferences search list.
+type Layer string
+
+const (
+	// LayerManaged holds values forced by configuration profiles or MCX.
+	LayerManaged Layer = "managed"
+	// LayerByHost holds the current user's values for the application on the current host.
+	LayerByHost Layer = "byhost"
+	// LayerUser holds the current user's values for the application on any host.
+	LayerUser Layer = "user"
+	// LayerGlobalByHost holds the current user's global values on the current host.
+	LayerGlobalByHost Layer = "global-byhost"
+	// LayerGlobal holds the current user's global values on any host.
+	LayerGlobal Layer = "global"
+	// LayerAnyUserByHost holds values for the application shared by all users on the current host.
+	LayerAnyUserByHost Layer = "anyuser-byhost"
+	// LayerAnyUser holds values for the application shared by all users on any host.
+	LayerAnyUser Layer = "anyuser"
+	// LayerAnyUserGlobalByHost holds global values shared by all users on the current host.
+	LayerAnyUserGlobalByHost Layer = "anyuser-global-byhost"
+	// LayerAnyUserGlobal holds global values shared by all users on any host.
+	LayerAnyUserGlobal Layer = "anyuser-global"
+	// LayerDefaults holds fallback values supplied by the application, like registered defaults.
+	LayerDefaults Layer = "defaults"
+)
+
+// LayerValue reports the value of a key at a single layer of the search list.
+type LayerValue struct {
+	// Layer is the search list layer that was inspected.
+	Layer Layer
+	// ApplicationID is the domain that was read. It is AnyApplication for global layers.
+	ApplicationID string
+	// Scope is the user and host scope that was read. It is the zero value for LayerManaged.
+	Scope PreferenceScope
+	// Value is the value stored at this layer, or nil when Found is false.
+	Value interface{}
+	// Found reports whether the key is defined at this layer.
+	Found bool
+}
+
+// searchLayer describes an exact-slot layer of the search list.
+type searchLayer struct {
+	layer  Layer
+	global bool
+	scope  PreferenceScope
+}
+
+// searchList is the order in which CFPreferencesCopyAppValue consults the exact-slot layers.
+var searchList = []searchLayer{
+	{layer: LayerByHost, scope: CurrentUserCurrentHost},
+	{layer: LayerUser, scope: CurrentUserAnyHost},
+	{layer: LayerGlobalByHost, global: true, scope: CurrentUserCurrentHost},
+	{layer: LayerGlobal, global: true, scope: CurrentUserAnyHost},
+	{layer: LayerAnyUserByHost, scope: AnyUserCurrentHost},
+	{layer: LayerAnyUser, scope: AnyUserAnyHost},
+	{layer: LayerAnyUserGlobalByHost, global: true, scope: AnyUserCurrentHost},
+	{layer: LayerAnyUserGlobal, global: true, scope: AnyUserAnyHost},
+}
+
+// Resolve reports the value of a key at every layer of the preferences search list, in search order.
+// The first entry with Found set is the value GetApp returns.
+//
+// Parameters:
+//   - key: The preference key to trace.
+//   - appID: The bundle identifier of the application for which to trace the preference.
+//
+// Returns:
+//   - []LayerValue: One entry per layer, starting with LayerManaged.
+//   - error: An error if any layer cannot be read.
+func Resolve(key string, appID string) ([]LayerValue, error) {
+	layers := make([]LayerValue, 0, len(searchList)+1)
+
+	forced, err := IsForcedApp(key, appID)
+	if err != nil {
+		return nil, err
+	}
+	managed := LayerValue{Layer: LayerManaged, ApplicationID: appID}
+	if forced {
+		value, err := GetApp(key, appID)
+		if err != nil {
+			return nil, err
+		}
+		managed.Value = value
+		managed.Found = value != nil
+	}
+	layers = append(layers, managed)
+
+	for _, l := range searchList {
+		domain := appID
+		if l.global {
+			domain = AnyApplication
+		}
+		value, err := Get(key, domain, l.scope)
+		if err != nil {
+			return nil, err
+		}
+		layers = append(layers, LayerValue{
+			Layer:         l.layer,
+			ApplicationID: domain,
+			Scope:         l.scope,
+			Value:         value,
+			Found:         value != nil,
+		})
+	}
+
+	return layers, nil
+}
@@ -0,0 +1,54 @@
+//go:build darwin && cgo
+
+package mac_prefs
+
+import (
+	"testing"
+)
+
+func TestResolve(t *testing.T) {
+	const key = "TestResolveKey"
+
+	if err := Set(key, "byhost", testAppID, CurrentUserCurrentHost); err != nil {
+		t.Fatalf("Set() byhost error = %v", err)
+	}
+	if err := Set(key, "user", testAppID, CurrentUserAnyHost); err != nil {
+		t.Fatalf("Set() user error = %v", err)
+	}
+	defer func() {
+		for _, scope := range []PreferenceScope{CurrentUserCurrentHost, CurrentUserAnyHost} {
+			if err := Set(key, nil, testAppID, scope); err != nil {
+				t.Fatalf("cleanup Set() error = %v", err)
+			}
+		}
+	}()
+
+	layers, err := Resolve(key, testAppID)
+	if err != nil {
+		t.Fatalf("Resolve() error = %v", err)
+	}
+	if len(layers) != len(searchList)+1 {
+		t.Fatalf("Resolve() returned %d layers, want %d", len(layers), len(searchList)+1)
+	}
+	if layers[0].Layer != LayerManaged || layers[0].Found {
+		t.Fatalf("Resolve() managed layer = %+v, want unforced %s", layers[0], LayerManaged)
+	}
+
+	want := map[Layer]interface{}{
+		LayerByHost: "byhost",
+		LayerUser:   "user",
+	}
+	for _, lv := range layers[1:] {
+		wantValue, ok := want[lv.Layer]
+		if lv.Found != ok {
+			t.Fatalf("Resolve() layer %s Found = %v, want %v", lv.Layer, lv.Found, ok)
+		}
+		if ok && lv.Value != wantValue {
+			t.Fatalf("Resolve() layer %s Value = %v, want %v", lv.Layer, lv.Value, wantValue)
+		}
+	}
+
+	if layers[1].Layer != LayerByHost || layers[2].Layer != LayerUser {
+		t.Fatalf("Resolve() layers out of search order: %s, %s", layers[1].Layer, layers[2].Layer)
+	}
+}
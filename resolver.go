@@ -0,0 +1,138 @@
+package mac_prefs
+
+// ResolverLayer is one source of values for a Resolver.
+type ResolverLayer struct {
+	// Layer names the layer in the results of the Resolver.
+	Layer Layer
+	// ApplicationID overrides the application ID the layer reads, e.g. AnyApplication for a
+	// global layer. If empty, the application ID passed to the Resolver is used.
+	ApplicationID string
+	// Scope is the scope the layer reads, reported in LayerValue.Scope.
+	Scope PreferenceScope
+	// Lookup returns the value of key in the layer, or nil if the layer does not define it.
+	Lookup func(key string, applicationID string) (interface{}, error)
+}
+
+// DefaultsLayer returns a layer holding fixed fallback values for every application ID.
+func DefaultsLayer(defaults map[string]interface{}) ResolverLayer {
+	return ResolverLayer{
+		Layer: LayerDefaults,
+		Lookup: func(key string, applicationID string) (interface{}, error) {
+			return defaults[key], nil
+		},
+	}
+}
+
+// StoreLayer returns a layer reading one exact (user, host) slot of store, e.g. a CFStore or
+// MemoryStore.
+func StoreLayer(layer Layer, store Store, scope PreferenceScope) ResolverLayer {
+	return ResolverLayer{
+		Layer: layer,
+		Scope: scope,
+		Lookup: func(key string, applicationID string) (interface{}, error) {
+			return store.Get(key, applicationID, scope)
+		},
+	}
+}
+
+// ManagedLayer returns a layer holding the values forced by configuration profiles or MCX.
+func ManagedLayer() ResolverLayer {
+	return ResolverLayer{
+		Layer: LayerManaged,
+		Lookup: func(key string, applicationID string) (interface{}, error) {
+			forced, err := IsForcedApp(key, applicationID)
+			if err != nil || !forced {
+				return nil, err
+			}
+			return GetApp(key, applicationID)
+		},
+	}
+}
+
+// Resolver looks a key up in an ordered list of layers and reports which layer supplied the
+// value, for tools that need deterministic and explainable precedence. Unlike GetApp, which
+// leaves the order to CFPreferences, the layers and their order are chosen by the caller.
+type Resolver struct {
+	layers []ResolverLayer
+}
+
+// NewResolver creates a Resolver from layers in increasing order of precedence: a value in a
+// later layer overrides the values in earlier ones.
+func NewResolver(layers ...ResolverLayer) *Resolver {
+	return &Resolver{layers: layers}
+}
+
+// NewDefaultResolver creates a Resolver with the usual precedence for one user: defaults, then
+// the user's AnyHost values, then the CurrentHost values, and finally managed values, which
+// always win.
+func NewDefaultResolver(defaults map[string]interface{}) *Resolver {
+	return NewResolver(
+		DefaultsLayer(defaults),
+		StoreLayer(LayerUser, CFStore{}, CurrentUserAnyHost),
+		StoreLayer(LayerByHost, CFStore{}, CurrentUserCurrentHost),
+		ManagedLayer(),
+	)
+}
+
+// Resolve looks up the effective value of a key.
+//
+// Parameters:
+//   - key: The preference key to resolve.
+//   - appID: The bundle identifier of the application.
+//
+// Returns:
+//   - interface{}: The value from the layer with the highest precedence that defines the key,
+//     or nil if no layer does.
+//   - Layer: The layer that supplied the value, or "" if no layer does.
+//   - error: An error if a layer cannot be read.
+func (r *Resolver) Resolve(key string, appID string) (interface{}, Layer, error) {
+	for i := len(r.layers) - 1; i >= 0; i-- {
+		l := r.layers[i]
+		value, err := l.Lookup(key, l.applicationID(appID))
+		if err != nil {
+			return nil, "", err
+		}
+		if value != nil {
+			return value, l.Layer, nil
+		}
+	}
+	return nil, "", nil
+}
+
+// Trace reports the value of a key at every layer, starting with the layer with the highest
+// precedence, like the package level Resolve does for the CFPreferences search list.
+//
+// Parameters:
+//   - key: The preference key to trace.
+//   - appID: The bundle identifier of the application.
+//
+// Returns:
+//   - []LayerValue: One entry per layer. The first entry with Found set supplies the value
+//     returned by Resolve.
+//   - error: An error if a layer cannot be read.
+func (r *Resolver) Trace(key string, appID string) ([]LayerValue, error) {
+	values := make([]LayerValue, 0, len(r.layers))
+	for i := len(r.layers) - 1; i >= 0; i-- {
+		l := r.layers[i]
+		domain := l.applicationID(appID)
+		value, err := l.Lookup(key, domain)
+		if err != nil {
+			return nil, err
+		}
+		values = append(values, LayerValue{
+			Layer:         l.Layer,
+			ApplicationID: domain,
+			Scope:         l.Scope,
+			Value:         value,
+			Found:         value != nil,
+		})
+	}
+	return values, nil
+}
+
+func (l ResolverLayer) applicationID(appID string) string {
+	if l.ApplicationID != "" {
+		return l.ApplicationID
+	}
+	return appID
+}
@@ -0,0 +1,80 @@
+package mac_prefs
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestResolver(t *testing.T) {
+	store := NewMemoryStore()
+	r := NewResolver(
+		DefaultsLayer(map[string]interface{}{"tilesize": 48, "autohide": false, "orientation": "bottom"}),
+		StoreLayer(LayerUser, store, CurrentUserAnyHost),
+		StoreLayer(LayerByHost, store, CurrentUserCurrentHost),
+		ResolverLayer{Layer: LayerGlobal, ApplicationID: AnyApplication, Scope: CurrentUserAnyHost, Lookup: func(key, appID string) (interface{}, error) {
+			return store.Get(key, appID, CurrentUserAnyHost)
+		}},
+	)
+	if err := store.Set("tilesize", 64, "com.apple.dock", CurrentUserAnyHost); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.Set("tilesize", 32, "com.apple.dock", CurrentUserCurrentHost); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.Set("autohide", true, "com.apple.dock", CurrentUserAnyHost); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.Set("orientation", "left", AnyApplication, CurrentUserAnyHost); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		key   string
+		value interface{}
+		layer Layer
+	}{
+		{"tilesize", 32, LayerByHost},
+		{"autohide", true, LayerUser},
+		{"orientation", "left", LayerGlobal},
+		{"missing", nil, ""},
+	}
+	for _, tt := range tests {
+		value, layer, err := r.Resolve(tt.key, "com.apple.dock")
+		if err != nil || value != tt.value || layer != tt.layer {
+			t.Errorf("Resolve(%s) = %#v, %q, %v, want %#v, %q", tt.key, value, layer, err, tt.value, tt.layer)
+		}
+	}
+
+	trace, err := r.Trace("tilesize", "com.apple.dock")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []LayerValue{
+		{Layer: LayerGlobal, ApplicationID: AnyApplication, Scope: CurrentUserAnyHost},
+		{Layer: LayerByHost, ApplicationID: "com.apple.dock", Scope: CurrentUserCurrentHost, Value: 32, Found: true},
+		{Layer: LayerUser, ApplicationID: "com.apple.dock", Scope: CurrentUserAnyHost, Value: 64, Found: true},
+		{Layer: LayerDefaults, ApplicationID: "com.apple.dock", Value: 48, Found: true},
+	}
+	if len(trace) != len(want) {
+		t.Fatalf("Trace() = %#v, want %#v", trace, want)
+	}
+	for i := range want {
+		if trace[i] != want[i] {
+			t.Errorf("Trace()[%d] = %#v, want %#v", i, trace[i], want[i])
+		}
+	}
+}
+
+func TestResolverError(t *testing.T) {
+	errRead := errors.New("read failed")
+	r := NewResolver(
+		DefaultsLayer(map[string]interface{}{"a": 1}),
+		ResolverLayer{Layer: LayerManaged, Lookup: func(string, string) (interface{}, error) { return nil, errRead }},
+	)
+	if _, _, err := r.Resolve("a", "com.example"); err != errRead {
+		t.Errorf("Resolve() error = %v, want %v", err, errRead)
+	}
+	if _, err := r.Trace("a", "com.example"); err != errRead {
+		t.Errorf("Trace() error = %v, want %v", err, errRead)
+	}
+}
@@ -0,0 +1,113 @@
+package mac_prefs
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// ErrSynchronizeFailed is returned when CFPreferencesSynchronize cannot write a domain, which
+// can be transient while cfprefsd is busy or a network or FileVault home directory is not yet
+// available. WithRetry retries writes that fail with it.
+var ErrSynchronizeFailed = errors.New("failed to synchronize preferences")
+
+// RetryPolicy configures how WithRetry retries writes.
+type RetryPolicy struct {
+	// MaxAttempts is the number of attempts, including the first. Values below 2 disable
+	// retries.
+	MaxAttempts int
+	// InitialDelay is the delay before the first retry. 100ms is used if it is zero or less.
+	InitialDelay time.Duration
+	// MaxDelay caps the delay, which doubles after every retry. 5s is used if it is zero or
+	// less.
+	MaxDelay time.Duration
+}
+
+// RetryError reports a write that still failed after it was retried.
+type RetryError struct {
+	// Attempts is the number of attempts made.
+	Attempts int
+	// Elapsed is the time from the first attempt to the last failure.
+	Elapsed time.Duration
+	// Err is the error of the last attempt.
+	Err error
+}
+
+func (e *RetryError) Error() string {
+	return fmt.Sprintf("%v (after %d attempts in %v)", e.Err, e.Attempts, e.Elapsed.Round(time.Millisecond))
+}
+
+// Unwrap returns the error of the last attempt.
+func (e *RetryError) Unwrap() error {
+	return e.Err
+}
+
+// WithRetry makes the Client retry writes that fail with ErrSynchronizeFailed, waiting with
+// exponential backoff between attempts. Other errors, such as invalid values, are returned
+// at once. A write that fails every attempt returns a *RetryError.
+func WithRetry(policy RetryPolicy) Option {
+	return func(c *Client) {
+		c.retryPolicy = &policy
+	}
+}
+
+// retryStore is a Store that retries the writes of the Store it wraps.
+type retryStore struct {
+	Store
+	policy RetryPolicy
+}
+
+func newRetryStore(store Store, policy RetryPolicy) *retryStore {
+	if policy.InitialDelay <= 0 {
+		policy.InitialDelay = 100 * time.Millisecond
+	}
+	if policy.MaxDelay <= 0 {
+		policy.MaxDelay = 5 * time.Second
+	}
+	return &retryStore{Store: store, policy: policy}
+}
+
+// retry calls write until it succeeds, fails with an error other than ErrSynchronizeFailed,
+// or has been attempted MaxAttempts times.
+func (s *retryStore) retry(write func() error) error {
+	start := time.Now()
+	delay := s.policy.InitialDelay
+	for attempt := 1; ; attempt++ {
+		err := write()
+		if err == nil || !errors.Is(err, ErrSynchronizeFailed) {
+			return err
+		}
+		if attempt >= s.policy.MaxAttempts {
+			if attempt == 1 {
+				return err
+			}
+			return &RetryError{Attempts: attempt, Elapsed: time.Since(start), Err: err}
+		}
+		time.Sleep(delay)
+		if delay *= 2; delay > s.policy.MaxDelay {
+			delay = s.policy.MaxDelay
+		}
+	}
+}
+
+// GetMultiple retrieves several keys, in one read if the wrapped Store is a MultiGetStore.
+func (s *retryStore) GetMultiple(keys []string, applicationID string, scope PreferenceScope) (map[string]interface{}, error) {
+	return readSlot(s.Store, prefSlot{applicationID, scope}, keys)
+}
+
+// Set sets a value, retrying failed synchronizes.
+func (s *retryStore) Set(key string, value interface{}, applicationID string, scope PreferenceScope) error {
+	return s.retry(func() error { return s.Store.Set(key, value, applicationID, scope) })
+}
+
+// Delete removes a key, retrying failed synchronizes.
+func (s *retryStore) Delete(key string, applicationID string, scope PreferenceScope) error {
+	return s.retry(func() error { return s.Store.Delete(key, applicationID, scope) })
+}
+
+// SetMultiple writes several keys, in one write if the wrapped Store is a BatchStore,
+// retrying failed synchronizes.
+func (s *retryStore) SetMultiple(keysToSet map[string]interface{}, keysToRemove []string, applicationID string, scope PreferenceScope) error {
+	values := batchValues(keysToSet, keysToRemove)
+	return s.retry(func() error { return writeSlot(s.Store, prefSlot{applicationID, scope}, values) })
+}
@@ -0,0 +1,111 @@
+package mac_prefs
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+// flakyStore is a MemoryStore whose writes fail to synchronize until failures reaches zero.
+type flakyStore struct {
+	*MemoryStore
+	failures int
+	attempts int
+}
+
+func (s *flakyStore) fail() error {
+	s.attempts++
+	if s.failures > 0 {
+		s.failures--
+		return ErrSynchronizeFailed
+	}
+	return nil
+}
+
+func (s *flakyStore) Set(key string, value interface{}, applicationID string, scope PreferenceScope) error {
+	if err := s.fail(); err != nil {
+		return err
+	}
+	return s.MemoryStore.Set(key, value, applicationID, scope)
+}
+
+func (s *flakyStore) SetMultiple(keysToSet map[string]interface{}, keysToRemove []string, applicationID string, scope PreferenceScope) error {
+	if err := s.fail(); err != nil {
+		return err
+	}
+	return s.MemoryStore.SetMultiple(keysToSet, keysToRemove, applicationID, scope)
+}
+
+func TestClientRetry(t *testing.T) {
+	policy := RetryPolicy{MaxAttempts: 3, InitialDelay: time.Millisecond, MaxDelay: 2 * time.Millisecond}
+
+	store := &flakyStore{MemoryStore: NewMemoryStore(), failures: 2}
+	c := NewClient(WithStore(store), WithRetry(policy))
+	if err := c.Set("key", "value", "com.example", CurrentUserAnyHost); err != nil {
+		t.Fatalf("Set() error = %v", err)
+	}
+	if store.attempts != 3 {
+		t.Errorf("attempts = %d, want 3", store.attempts)
+	}
+	if got, _ := c.Get("key", "com.example", CurrentUserAnyHost); got != "value" {
+		t.Errorf("Get() = %v, want value", got)
+	}
+
+	store = &flakyStore{MemoryStore: NewMemoryStore(), failures: 5}
+	c = NewClient(WithStore(store), WithRetry(policy))
+	err := c.Set("key", "value", "com.example", CurrentUserAnyHost)
+	var retryErr *RetryError
+	if !errors.As(err, &retryErr) {
+		t.Fatalf("Set() error = %v, want a *RetryError", err)
+	}
+	if retryErr.Attempts != 3 || store.attempts != 3 {
+		t.Errorf("Attempts = %d after %d attempts, want 3", retryErr.Attempts, store.attempts)
+	}
+	if !errors.Is(err, ErrSynchronizeFailed) {
+		t.Errorf("Set() error = %v, want it to wrap ErrSynchronizeFailed", err)
+	}
+
+	store = &flakyStore{MemoryStore: NewMemoryStore(), failures: 1}
+	c = NewClient(WithStore(store), WithRetry(policy))
+	tx := c.Begin()
+	if err := tx.Set("a", 1, "com.example", CurrentUserAnyHost); err != nil {
+		t.Fatal(err)
+	}
+	if err := tx.Set("b", 2, "com.example", CurrentUserAnyHost); err != nil {
+		t.Fatal(err)
+	}
+	if err := tx.Commit(); err != nil {
+		t.Fatalf("Commit() error = %v", err)
+	}
+	if store.attempts != 2 {
+		t.Errorf("attempts = %d, want 2", store.attempts)
+	}
+}
+
+func TestClientRetryOtherErrors(t *testing.T) {
+	store := failingStore{MemoryStore: NewMemoryStore(), failAppID: "com.example.fail"}
+	c := NewClient(WithStore(store), WithRetry(RetryPolicy{MaxAttempts: 3, InitialDelay: time.Hour}))
+	tx := c.Begin()
+	if err := tx.Set("a", 1, "com.example.fail", CurrentUserAnyHost); err != nil {
+		t.Fatal(err)
+	}
+	err := tx.Commit()
+	if err == nil {
+		t.Fatal("Commit() error = nil, want an error")
+	}
+	var retryErr *RetryError
+	if errors.As(err, &retryErr) {
+		t.Errorf("Commit() error = %v, want the error returned without retrying", err)
+	}
+}
+
+func TestClientRetryDisabled(t *testing.T) {
+	store := &flakyStore{MemoryStore: NewMemoryStore(), failures: 1}
+	c := NewClient(WithStore(store), WithRetry(RetryPolicy{MaxAttempts: 1}))
+	if err := c.Set("key", "value", "com.example", CurrentUserAnyHost); err != ErrSynchronizeFailed {
+		t.Errorf("Set() error = %v, want ErrSynchronizeFailed", err)
+	}
+	if store.attempts != 1 {
+		t.Errorf("attempts = %d, want 1", store.attempts)
+	}
+}
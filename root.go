@@ -0,0 +1,23 @@
+package mac_prefs
+
+// WithRoot points the file based APIs of the Client at the volume mounted at root, such as a
+// Time Machine snapshot or another boot volume, to compare or recover the settings of a system
+// that is not running. PlistPath, ReadDomainFile, and Diff read the plist files under root, and
+// ListDomains scans the Preferences directories under root instead of asking cfprefsd.
+//
+// The accounts of the booted system do not describe the volume, so named users are found at
+// <root>/Users/<name>, and CurrentUser at the path of the current user's home directory under
+// root. ByHost files are matched by domain; the hardware UUID of the booted machine is only
+// used when a domain has no ByHost file or several. Reads and writes through cfprefsd, such
+// as Get and Set, are not affected.
+func WithRoot(root string) Option {
+	return func(c *Client) {
+		c.root = root
+	}
+}
+
+// PlistPath returns the path of the plist file backing a domain slot, under the root of the
+// Client if one is set. See PlistPath.
+func (c *Client) PlistPath(appID string, scope PreferenceScope) (string, error) {
+	return plistPath(c.root, appID, scope)
+}
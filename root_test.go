@@ -0,0 +1,55 @@
+package mac_prefs
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestClientRoot(t *testing.T) {
+	root := t.TempDir()
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	c := NewClient(WithRoot(root))
+
+	for _, tt := range []struct {
+		scope PreferenceScope
+		want  string
+	}{
+		{AnyUserAnyHost, filepath.Join(root, "Library", "Preferences", "com.example.plist")},
+		{CurrentUserAnyHost, filepath.Join(root, home, "Library", "Preferences", "com.example.plist")},
+		{PreferenceScope{User: "alice", Host: AnyHost}, filepath.Join(root, "Users", "alice", "Library", "Preferences", "com.example.plist")},
+	} {
+		if got, err := c.PlistPath("com.example", tt.scope); err != nil || got != tt.want {
+			t.Errorf("PlistPath(%v) = %q, %v, want %q", tt.scope, got, err, tt.want)
+		}
+	}
+
+	for _, file := range []string{
+		filepath.Join(root, "Library", "Preferences", "com.example.system.plist"),
+		filepath.Join(root, "Users", "alice", "Library", "Preferences", "com.example.a.plist"),
+		filepath.Join(root, "Users", "alice", "Library", "Preferences", byHostDir, "com.example.b.0123-4567.plist"),
+	} {
+		if err := os.MkdirAll(filepath.Dir(file), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(file, nil, 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	for _, tt := range []struct {
+		scope PreferenceScope
+		want  []string
+	}{
+		{AnyUserAnyHost, []string{"com.example.system"}},
+		{PreferenceScope{User: "alice", Host: AnyHost}, []string{"com.example.a"}},
+		{PreferenceScope{User: "alice", Host: CurrentHost}, []string{"com.example.b"}},
+		{CurrentUserAnyHost, []string{}},
+	} {
+		if got, err := c.ListDomains(tt.scope); err != nil || !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("ListDomains(%v) = %v, %v, want %v", tt.scope, got, err, tt.want)
+		}
+	}
+}
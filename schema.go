@@ -0,0 +1,259 @@
+package mac_prefs
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"time"
+)
+
+// Kind is the expected type of a preference value in a Schema.
+type Kind int
+
+const (
+	// KindAny accepts a value of any type.
+	KindAny Kind = iota
+	// KindString expects a string.
+	KindString
+	// KindInt expects an integer.
+	KindInt
+	// KindFloat expects a floating point number. Integers are accepted, since CFNumber does
+	// not preserve the distinction for whole numbers.
+	KindFloat
+	// KindBool expects a boolean.
+	KindBool
+	// KindDate expects a time.Time.
+	KindDate
+	// KindData expects a []byte.
+	KindData
+	// KindArray expects a []interface{}.
+	KindArray
+	// KindDictionary expects a map[string]interface{}.
+	KindDictionary
+)
+
+var kindNames = map[Kind]string{
+	KindAny:        "any",
+	KindString:     "string",
+	KindInt:        "int",
+	KindFloat:      "float",
+	KindBool:       "bool",
+	KindDate:       "date",
+	KindData:       "data",
+	KindArray:      "array",
+	KindDictionary: "dictionary",
+}
+
+func (k Kind) String() string {
+	if name, ok := kindNames[k]; ok {
+		return name
+	}
+	return fmt.Sprintf("Kind(%d)", int(k))
+}
+
+// KeySchema describes the expected value of a single preference key.
+type KeySchema struct {
+	// Kind is the expected type of the value.
+	Kind Kind
+	// Required reports whether the key must be present.
+	Required bool
+	// Allowed lists the permitted values. Any value of the right kind is accepted when empty.
+	Allowed []interface{}
+	// Min and Max bound numeric values when set.
+	Min, Max *float64
+}
+
+// Schema describes what a domain is expected to contain, keyed by preference key. Keys that
+// are not in the schema are not checked.
+type Schema map[string]KeySchema
+
+// Violation describes a preference value that does not conform to a Schema.
+type Violation struct {
+	// Key is the offending preference key.
+	Key string
+	// Value is the value found, or nil if a required key is missing.
+	Value interface{}
+	// Message describes the problem.
+	Message string
+}
+
+func (v Violation) String() string {
+	return v.Key + ": " + v.Message
+}
+
+// Check validates values against the schema.
+//
+// Parameters:
+//   - values: The preference keys and values to check.
+//
+// Returns:
+//   - []Violation: Every violation found, sorted by key. Empty if the values conform.
+func (s Schema) Check(values map[string]interface{}) []Violation {
+	keys := make([]string, 0, len(s))
+	for key := range s {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	violations := make([]Violation, 0)
+	for _, key := range keys {
+		ks := s[key]
+		value, ok := values[key]
+		if !ok || value == nil {
+			if ks.Required {
+				violations = append(violations, Violation{Key: key, Message: "required key is missing"})
+			}
+			continue
+		}
+		if msg := ks.check(value); msg != "" {
+			violations = append(violations, Violation{Key: key, Value: value, Message: msg})
+		}
+	}
+	return violations
+}
+
+// check returns a description of why value does not conform, or "" if it does.
+func (ks KeySchema) check(value interface{}) string {
+	if !kindMatches(ks.Kind, value) {
+		return fmt.Sprintf("expected %s, got %T", ks.Kind, value)
+	}
+	if len(ks.Allowed) > 0 {
+		allowed := false
+		for _, a := range ks.Allowed {
+			if valuesEqual(a, value) {
+				allowed = true
+				break
+			}
+		}
+		if !allowed {
+			return fmt.Sprintf("value %v is not one of %v", value, ks.Allowed)
+		}
+	}
+	if f, ok := numberValue(value); ok {
+		if ks.Min != nil && f < *ks.Min {
+			return fmt.Sprintf("value %v is less than minimum %v", value, *ks.Min)
+		}
+		if ks.Max != nil && f > *ks.Max {
+			return fmt.Sprintf("value %v is greater than maximum %v", value, *ks.Max)
+		}
+	}
+	return ""
+}
+
+func kindMatches(kind Kind, value interface{}) bool {
+	switch kind {
+	case KindAny:
+		return true
+	case KindString:
+		_, ok := value.(string)
+		return ok
+	case KindInt:
+		switch value.(type) {
+		case int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64:
+			return true
+		}
+		return false
+	case KindFloat:
+		_, ok := numberValue(value)
+		return ok
+	case KindBool:
+		_, ok := value.(bool)
+		return ok
+	case KindDate:
+		_, ok := value.(time.Time)
+		return ok
+	case KindData:
+		_, ok := value.([]byte)
+		return ok
+	case KindArray:
+		_, ok := value.([]interface{})
+		return ok
+	case KindDictionary:
+		_, ok := value.(map[string]interface{})
+		return ok
+	default:
+		return false
+	}
+}
+
+// numberValue returns value as a float64 if it is a Go numeric type.
+func numberValue(value interface{}) (float64, bool) {
+	v := reflect.ValueOf(value)
+	switch v.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(v.Int()), true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return float64(v.Uint()), true
+	case reflect.Float32, reflect.Float64:
+		return v.Float(), true
+	default:
+		return 0, false
+	}
+}
+
+// valuesEqual reports whether a and b are deeply equal, treating numbers of different Go types
+// as equal when they have the same value, since CFNumber does not preserve the Go type.
+func valuesEqual(a, b interface{}) bool {
+	if fa, ok := numberValue(a); ok {
+		fb, ok := numberValue(b)
+		return ok && fa == fb
+	}
+	if at, ok := a.(time.Time); ok {
+		bt, ok := b.(time.Time)
+		return ok && at.Equal(bt)
+	}
+
+	// Compare collections element by element so typed slices and maps, such as []string, equal
+	// the []interface{} and map[string]interface{} values read back from CoreFoundation.
+	av, bv := reflect.ValueOf(a), reflect.ValueOf(b)
+	if isCollection(av) && isCollection(bv) && av.Kind() == bv.Kind() {
+		if av.Len() != bv.Len() {
+			return false
+		}
+		if av.Kind() == reflect.Slice {
+			for i := 0; i < av.Len(); i++ {
+				if !valuesEqual(av.Index(i).Interface(), bv.Index(i).Interface()) {
+					return false
+				}
+			}
+			return true
+		}
+		for _, k := range av.MapKeys() {
+			w := bv.MapIndex(reflect.ValueOf(k.String()).Convert(bv.Type().Key()))
+			if !w.IsValid() || !valuesEqual(av.MapIndex(k).Interface(), w.Interface()) {
+				return false
+			}
+		}
+		return true
+	}
+	return reflect.DeepEqual(a, b)
+}
+
+// isCollection reports whether v is a slice other than []byte or a map with string keys.
+func isCollection(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.Slice:
+		return v.Type().Elem().Kind() != reflect.Uint8
+	case reflect.Map:
+		return v.Type().Key().Kind() == reflect.String
+	}
+	return false
+}
+
+// Validate checks the values of one exact (user, host) slot of a domain against a schema.
+//
+// Parameters:
+//   - appID: The bundle identifier of the application to validate.
+//   - scope: The PreferenceScope defining the user and host scope to validate.
+//   - schema: The expected keys and values.
+//
+// Returns:
+//   - []Violation: Every violation found, sorted by key. Empty if the domain conforms.
+//   - error: An error if the domain cannot be read.
+func Validate(appID string, scope PreferenceScope, schema Schema) ([]Violation, error) {
+	values, err := GetAll(appID, scope)
+	if err != nil {
+		return nil, err
+	}
+	return schema.Check(values), nil
+}
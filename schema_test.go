@@ -0,0 +1,55 @@
+package mac_prefs
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSchemaCheck(t *testing.T) {
+	min, max := 16.0, 128.0
+	schema := Schema{
+		"autohide":    {Kind: KindBool, Required: true},
+		"tilesize":    {Kind: KindInt, Min: &min, Max: &max},
+		"orientation": {Kind: KindString, Allowed: []interface{}{"left", "bottom", "right"}},
+		"magnify":     {Kind: KindFloat},
+	}
+
+	tests := []struct {
+		name   string
+		values map[string]interface{}
+		want   []string
+	}{
+		{
+			name:   "valid",
+			values: map[string]interface{}{"autohide": true, "tilesize": 48, "orientation": "left", "magnify": 2, "other": "x"},
+			want:   []string{},
+		},
+		{
+			name:   "missing required",
+			values: map[string]interface{}{},
+			want:   []string{"autohide: required key is missing"},
+		},
+		{
+			name:   "wrong kind",
+			values: map[string]interface{}{"autohide": "yes", "tilesize": "48"},
+			want:   []string{"autohide: expected bool, got string", "tilesize: expected int, got string"},
+		},
+		{
+			name:   "allowed and range",
+			values: map[string]interface{}{"autohide": false, "tilesize": 8, "orientation": "top"},
+			want:   []string{"orientation: value top is not one of [left bottom right]", "tilesize: value 8 is less than minimum 16"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := []string{}
+			for _, v := range schema.Check(tt.values) {
+				got = append(got, v.String())
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Check() got = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,147 @@
+package mac_prefs
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// SearchOptions controls Search.
+type SearchOptions struct {
+	// Regexp interprets the pattern as a regular expression instead of a glob.
+	Regexp bool
+	// IgnoreCase matches case-insensitively, like `defaults find`.
+	IgnoreCase bool
+	// Keys searches key names. Values searches string values, including strings nested in
+	// arrays and dictionaries. When neither is set both are searched.
+	Keys, Values bool
+	// Scopes are the scopes to search. Defaults to CurrentUserAnyHost and CurrentUserCurrentHost.
+	Scopes []PreferenceScope
+	// Include and Exclude filter domains by glob pattern, as in DumpAllOptions.
+	Include, Exclude []string
+}
+
+// Match is a key or string value found by Search.
+type Match struct {
+	// Domain is the application ID the match was found in.
+	Domain string
+	// Scope is the scope the match was found in.
+	Scope PreferenceScope
+	// Key is the top level preference key.
+	Key string
+	// Path locates the match inside the key's value, e.g. "persistent-apps[0].tile-data.file-label".
+	// It equals Key for matches on the key itself or on a top level string value.
+	Path string
+	// Value is the value at Path.
+	Value interface{}
+	// MatchedKey reports whether the pattern matched a key name rather than a string value.
+	MatchedKey bool
+}
+
+// Search scans every preference domain for keys or string values matching a pattern, like
+// `defaults find` but with structured results. Glob patterns must match the whole key or
+// value; use "*dock*" to find substrings.
+//
+// Parameters:
+//   - pattern: A glob pattern (* and ?), or a regular expression when opts.Regexp is set.
+//   - opts: Options selecting what and where to search.
+//
+// Returns:
+//   - []Match: The matches, sorted by domain, key, and path.
+//   - error: An error if the pattern is malformed or a domain cannot be read.
+func Search(pattern string, opts SearchOptions) ([]Match, error) {
+	re, err := compileSearchPattern(pattern, opts.Regexp, opts.IgnoreCase)
+	if err != nil {
+		return nil, err
+	}
+	searchKeys, searchValues := opts.Keys, opts.Values
+	if !searchKeys && !searchValues {
+		searchKeys, searchValues = true, true
+	}
+	scopes := opts.Scopes
+	if len(scopes) == 0 {
+		scopes = []PreferenceScope{CurrentUserAnyHost, CurrentUserCurrentHost}
+	}
+
+	matches := make([]Match, 0)
+	err = walkDomains(scopes, opts.Include, opts.Exclude, func(domain string, scope PreferenceScope, values map[string]interface{}) {
+		for key, value := range values {
+			if searchKeys && re.MatchString(key) {
+				matches = append(matches, Match{Domain: domain, Scope: scope, Key: key, Path: key, Value: value, MatchedKey: true})
+			}
+			if searchValues {
+				searchValue(re, value, key, func(path string, v interface{}) {
+					matches = append(matches, Match{Domain: domain, Scope: scope, Key: key, Path: path, Value: v})
+				})
+			}
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	sort.SliceStable(matches, func(i, j int) bool {
+		a, b := matches[i], matches[j]
+		if a.Domain != b.Domain {
+			return a.Domain < b.Domain
+		}
+		if a.Key != b.Key {
+			return a.Key < b.Key
+		}
+		return a.Path < b.Path
+	})
+	return matches, nil
+}
+
+// compileSearchPattern compiles a glob or regular expression search pattern.
+func compileSearchPattern(pattern string, isRegexp, ignoreCase bool) (*regexp.Regexp, error) {
+	expr := pattern
+	if !isRegexp {
+		var b strings.Builder
+		b.WriteString("^")
+		for _, r := range pattern {
+			switch r {
+			case '*':
+				b.WriteString(".*")
+			case '?':
+				b.WriteString(".")
+			default:
+				b.WriteString(regexp.QuoteMeta(string(r)))
+			}
+		}
+		b.WriteString("$")
+		expr = b.String()
+	}
+	if ignoreCase {
+		expr = "(?i)" + expr
+	}
+	re, err := regexp.Compile(expr)
+	if err != nil {
+		return nil, fmt.Errorf("invalid search pattern %q: %v", pattern, err)
+	}
+	return re, nil
+}
+
+// searchValue calls fn for every string inside value that matches re.
+func searchValue(re *regexp.Regexp, value interface{}, path string, fn func(path string, v interface{})) {
+	switch v := value.(type) {
+	case string:
+		if re.MatchString(v) {
+			fn(path, v)
+		}
+	case []interface{}:
+		for i, item := range v {
+			searchValue(re, item, fmt.Sprintf("%s[%d]", path, i), fn)
+		}
+	case map[string]interface{}:
+		keys := make([]string, 0, len(v))
+		for k := range v {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			searchValue(re, v[k], path+"."+k, fn)
+		}
+	}
+}
@@ -0,0 +1,56 @@
+//go:build darwin && cgo
+
+package mac_prefs
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSearch(t *testing.T) {
+	const appID = testAppID + ".search"
+	scope := CurrentUserAnyHost
+
+	if err := SetMultiple(map[string]interface{}{
+		"SearchDockSize": 48,
+		"SearchLabel":    "Dock label",
+		"SearchNested":   []interface{}{map[string]interface{}{"name": "the dock"}},
+	}, nil, appID, scope); err != nil {
+		t.Fatalf("SetMultiple() error = %v", err)
+	}
+	defer SetMultiple(nil, []string{"SearchDockSize", "SearchLabel", "SearchNested"}, appID, scope)
+
+	include := []string{appID}
+	got, err := Search("*dock*", SearchOptions{IgnoreCase: true, Include: include})
+	if err != nil {
+		t.Fatalf("Search() error = %v", err)
+	}
+	want := []Match{
+		{Domain: appID, Scope: scope, Key: "SearchDockSize", Path: "SearchDockSize", Value: 48, MatchedKey: true},
+		{Domain: appID, Scope: scope, Key: "SearchLabel", Path: "SearchLabel", Value: "Dock label"},
+		{Domain: appID, Scope: scope, Key: "SearchNested", Path: "SearchNested[0].name", Value: "the dock"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("Search() got = %+v, want %+v", got, want)
+	}
+
+	keysOnly, err := Search(`^Search\w+Size$`, SearchOptions{Regexp: true, Keys: true, Include: include})
+	if err != nil {
+		t.Fatalf("Search() error = %v", err)
+	}
+	if len(keysOnly) != 1 || keysOnly[0].Key != "SearchDockSize" {
+		t.Fatalf("Search() keys only got = %+v", keysOnly)
+	}
+
+	caseSensitive, err := Search("*dock*", SearchOptions{Values: true, Include: include})
+	if err != nil {
+		t.Fatalf("Search() error = %v", err)
+	}
+	if len(caseSensitive) != 1 || caseSensitive[0].Path != "SearchNested[0].name" {
+		t.Fatalf("Search() case sensitive got = %+v", caseSensitive)
+	}
+
+	if _, err := Search("(", SearchOptions{Regexp: true}); err == nil {
+		t.Fatal("Search() expected error for malformed regexp")
+	}
+}
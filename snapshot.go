@@ -0,0 +1,53 @@
+package mac_prefs
+
+import "time"
+
+// Snapshot is a copy of every key and value in one exact (user, host) slot of a domain.
+type Snapshot struct {
+	// ApplicationID is the domain the snapshot was taken from.
+	ApplicationID string
+	// Scope is the scope the snapshot was taken from.
+	Scope PreferenceScope
+	// Values are the keys and values at the time of the snapshot.
+	Values map[string]interface{}
+	// Taken is when the snapshot was taken.
+	Taken time.Time
+}
+
+// TakeSnapshot captures all keys and values of one exact (user, host) slot of a domain so they
+// can be reinstated later with Restore.
+//
+// Parameters:
+//   - appID: The bundle identifier of the application to capture.
+//   - scope: The PreferenceScope defining the user and host scope to capture.
+//
+// Returns:
+//   - Snapshot: The captured keys and values.
+//   - error: An error if the domain cannot be read.
+func TakeSnapshot(appID string, scope PreferenceScope) (Snapshot, error) {
+	values, err := GetAll(appID, scope)
+	if err != nil {
+		return Snapshot{}, err
+	}
+	return Snapshot{ApplicationID: appID, Scope: scope, Values: values, Taken: time.Now()}, nil
+}
+
+// Restore reinstates the domain exactly as it was when the snapshot was taken. Keys added since
+// are removed and changed or removed keys are written back, in a single SetMultiple call.
+//
+// Returns:
+//   - error: An error if the domain cannot be read or written.
+func (s Snapshot) Restore() error {
+	current, err := GetAll(s.ApplicationID, s.Scope)
+	if err != nil {
+		return err
+	}
+
+	var toRemove []string
+	for key := range current {
+		if _, ok := s.Values[key]; !ok {
+			toRemove = append(toRemove, key)
+		}
+	}
+	return SetMultiple(s.Values, toRemove, s.ApplicationID, s.Scope)
+}
@@ -0,0 +1,43 @@
+//go:build darwin && cgo
+
+package mac_prefs
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSnapshotRestore(t *testing.T) {
+	const appID = testAppID + ".snapshot"
+	scope := CurrentUserAnyHost
+
+	original := map[string]interface{}{
+		"SnapshotKeep":   "keep",
+		"SnapshotChange": 1,
+		"SnapshotDelete": []interface{}{"a"},
+	}
+	if err := SetMultiple(original, nil, appID, scope); err != nil {
+		t.Fatalf("SetMultiple() error = %v", err)
+	}
+	defer SetMultiple(nil, []string{"SnapshotKeep", "SnapshotChange", "SnapshotDelete", "SnapshotAdd"}, appID, scope)
+
+	snapshot, err := TakeSnapshot(appID, scope)
+	if err != nil {
+		t.Fatalf("TakeSnapshot() error = %v", err)
+	}
+
+	if err := SetMultiple(map[string]interface{}{"SnapshotChange": 2, "SnapshotAdd": true}, []string{"SnapshotDelete"}, appID, scope); err != nil {
+		t.Fatalf("SetMultiple() error = %v", err)
+	}
+
+	if err := snapshot.Restore(); err != nil {
+		t.Fatalf("Restore() error = %v", err)
+	}
+	got, err := GetAll(appID, scope)
+	if err != nil {
+		t.Fatalf("GetAll() error = %v", err)
+	}
+	if !reflect.DeepEqual(got, original) {
+		t.Fatalf("Restore() got = %v, want %v", got, original)
+	}
+}
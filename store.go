@@ -0,0 +1,134 @@
+package mac_prefs
+
+import (
+	"context"
+	"time"
+)
+
+// Store is a source of preferences. CFStore reads and writes the real preferences through
+// CFPreferences; MemoryStore keeps them in memory, for unit tests and dry runs of code that
+// would otherwise mutate the machine's preferences.
+type Store interface {
+	// Get retrieves a value from one exact (user, host) slot, or nil if the key is not set.
+	Get(key string, applicationID string, scope PreferenceScope) (interface{}, error)
+	// Set sets a value. A nil value removes the key.
+	Set(key string, value interface{}, applicationID string, scope PreferenceScope) error
+	// Delete removes a key.
+	Delete(key string, applicationID string, scope PreferenceScope) error
+	// List retrieves every key and value of one exact (user, host) slot.
+	List(applicationID string, scope PreferenceScope) (map[string]interface{}, error)
+	// Watch sends an Event for every change to one exact (user, host) slot until ctx is done.
+	Watch(ctx context.Context, applicationID string, scope PreferenceScope, interval time.Duration) (<-chan Event, error)
+}
+
+// BatchStore is a Store that can set and remove several keys of one slot in a single write.
+// Transactions use it when the store of their Client implements it.
+type BatchStore interface {
+	Store
+	// SetMultiple sets and removes several keys of one exact (user, host) slot. A nil value
+	// removes the key. Nothing is written if any value is invalid.
+	SetMultiple(keysToSet map[string]interface{}, keysToRemove []string, applicationID string, scope PreferenceScope) error
+}
+
+// MultiGetStore is a Store that can read several keys of one slot at once. Client.GetMany uses
+// it when the store of the Client implements it.
+type MultiGetStore interface {
+	Store
+	// GetMultiple retrieves several keys of one exact (user, host) slot. Keys that are not set
+	// are left out of the map.
+	GetMultiple(keys []string, applicationID string, scope PreferenceScope) (map[string]interface{}, error)
+}
+
+// prefSlot is one exact (user, host) slot of a domain.
+type prefSlot struct {
+	appID string
+	scope PreferenceScope
+}
+
+// writeSlot writes values, where nil removes a key, to one slot of store.
+func writeSlot(store Store, slot prefSlot, values map[string]interface{}) error {
+	if batch, ok := store.(BatchStore); ok {
+		return batch.SetMultiple(values, nil, slot.appID, slot.scope)
+	}
+	for key, value := range values {
+		if err := store.Set(key, value, slot.appID, slot.scope); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// batchValues merges the arguments of SetMultiple into the values writeSlot takes, where nil
+// removes a key.
+func batchValues(keysToSet map[string]interface{}, keysToRemove []string) map[string]interface{} {
+	values := make(map[string]interface{}, len(keysToSet)+len(keysToRemove))
+	for _, key := range keysToRemove {
+		values[key] = nil
+	}
+	for key, value := range keysToSet {
+		values[key] = value
+	}
+	return values
+}
+
+// readSlot reads keys from one slot of store, which are left out of the map if they are not
+// set.
+func readSlot(store Store, slot prefSlot, keys []string) (map[string]interface{}, error) {
+	if multi, ok := store.(MultiGetStore); ok {
+		return multi.GetMultiple(keys, slot.appID, slot.scope)
+	}
+	values := make(map[string]interface{}, len(keys))
+	for _, key := range keys {
+		value, err := store.Get(key, slot.appID, slot.scope)
+		if err != nil {
+			return nil, err
+		}
+		if value != nil {
+			values[key] = value
+		}
+	}
+	return values, nil
+}
+
+// CFStore is the Store backed by CFPreferences. Its methods call the package level functions.
+// The zero value creates new CFStrings for every call; a Client configured with
+// WithStringCache uses a CFStore that interns them.
+type CFStore struct {
+	names *stringCache
+}
+
+// Get retrieves a value. See Get.
+func (s CFStore) Get(key string, applicationID string, scope PreferenceScope) (interface{}, error) {
+	return getValue(s.names, key, applicationID, scope)
+}
+
+// Set sets a value. See Set.
+func (s CFStore) Set(key string, value interface{}, applicationID string, scope PreferenceScope) error {
+	return setValue(s.names, key, value, applicationID, scope)
+}
+
+// Delete removes a key. See Delete.
+func (s CFStore) Delete(key string, applicationID string, scope PreferenceScope) error {
+	return setValue(s.names, key, nil, applicationID, scope)
+}
+
+// GetMultiple retrieves several keys in one CFPreferencesCopyMultiple call. See GetMany.
+func (s CFStore) GetMultiple(keys []string, applicationID string, scope PreferenceScope) (map[string]interface{}, error) {
+	return getMultiple(s.names, keys, applicationID, scope)
+}
+
+// SetMultiple sets and removes several keys in one CFPreferencesSetMultiple call. See
+// SetMultiple.
+func (s CFStore) SetMultiple(keysToSet map[string]interface{}, keysToRemove []string, applicationID string, scope PreferenceScope) error {
+	return setMultiple(s.names, keysToSet, keysToRemove, applicationID, scope)
+}
+
+// List retrieves every key and value of a slot. See GetAll.
+func (s CFStore) List(applicationID string, scope PreferenceScope) (map[string]interface{}, error) {
+	return getAll(s.names, applicationID, scope)
+}
+
+// Watch polls a slot for changes. See Watch.
+func (CFStore) Watch(ctx context.Context, applicationID string, scope PreferenceScope, interval time.Duration) (<-chan Event, error) {
+	return Watch(ctx, applicationID, scope, interval)
+}
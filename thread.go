@@ -0,0 +1,115 @@
+package mac_prefs
+
+import (
+	"context"
+	"runtime"
+	"sync"
+	"time"
+)
+
+// WithDedicatedThread makes the Client run every call into its store on one goroutine locked
+// to its own OS thread, for code that integrates with run loops or is debugging thread
+// affinity issues with cfprefsd. Calls from any goroutine are handed to the thread and wait
+// for it, so they are also serialized. Watchers poll on the thread as well. GetLazy returns
+// values that are already converted, since a LazyValue converts on the goroutine that uses
+// it. Call Close to stop the thread; later calls run on the calling goroutine.
+func WithDedicatedThread() Option {
+	return func(c *Client) {
+		c.dedicatedThread = true
+	}
+}
+
+// threadStore is a Store that runs the calls of the Store it wraps on a dedicated OS thread.
+type threadStore struct {
+	Store
+	work chan func()
+	stop chan struct{}
+	once sync.Once
+}
+
+func newThreadStore(store Store) *threadStore {
+	s := &threadStore{Store: store, work: make(chan func()), stop: make(chan struct{})}
+	go s.loop()
+	return s
+}
+
+func (s *threadStore) loop() {
+	// The goroutine exits with the thread still locked, so the thread is not reused.
+	runtime.LockOSThread()
+	for {
+		select {
+		case f := <-s.work:
+			f()
+		case <-s.stop:
+			return
+		}
+	}
+}
+
+// do runs f on the dedicated thread and waits for it to return, or runs it on the calling
+// goroutine once the thread is stopped.
+func (s *threadStore) do(f func()) {
+	done := make(chan struct{})
+	select {
+	case s.work <- func() { defer close(done); f() }:
+		<-done
+	case <-s.stop:
+		f()
+	}
+}
+
+// close stops the dedicated thread.
+func (s *threadStore) close() {
+	s.once.Do(func() { close(s.stop) })
+}
+
+// Get retrieves a value on the dedicated thread.
+func (s *threadStore) Get(key string, applicationID string, scope PreferenceScope) (value interface{}, err error) {
+	s.do(func() { value, err = s.Store.Get(key, applicationID, scope) })
+	return value, err
+}
+
+// Set sets a value on the dedicated thread.
+func (s *threadStore) Set(key string, value interface{}, applicationID string, scope PreferenceScope) (err error) {
+	s.do(func() { err = s.Store.Set(key, value, applicationID, scope) })
+	return err
+}
+
+// Delete removes a key on the dedicated thread.
+func (s *threadStore) Delete(key string, applicationID string, scope PreferenceScope) (err error) {
+	s.do(func() { err = s.Store.Delete(key, applicationID, scope) })
+	return err
+}
+
+// List retrieves every key and value of a slot on the dedicated thread.
+func (s *threadStore) List(applicationID string, scope PreferenceScope) (values map[string]interface{}, err error) {
+	s.do(func() { values, err = s.Store.List(applicationID, scope) })
+	return values, err
+}
+
+// GetMultiple retrieves several keys on the dedicated thread, in one read if the wrapped
+// Store is a MultiGetStore.
+func (s *threadStore) GetMultiple(keys []string, applicationID string, scope PreferenceScope) (values map[string]interface{}, err error) {
+	s.do(func() { values, err = readSlot(s.Store, prefSlot{applicationID, scope}, keys) })
+	return values, err
+}
+
+// SetMultiple writes several keys on the dedicated thread, in one write if the wrapped Store
+// is a BatchStore.
+func (s *threadStore) SetMultiple(keysToSet map[string]interface{}, keysToRemove []string, applicationID string, scope PreferenceScope) (err error) {
+	values := batchValues(keysToSet, keysToRemove)
+	s.do(func() { err = writeSlot(s.Store, prefSlot{applicationID, scope}, values) })
+	return err
+}
+
+// Watch polls a slot for changes. The polls of a CFStore run on the dedicated thread; other
+// stores are watched as they watch themselves.
+func (s *threadStore) Watch(ctx context.Context, applicationID string, scope PreferenceScope, interval time.Duration) (<-chan Event, error) {
+	if _, ok := s.Store.(CFStore); !ok {
+		return s.Store.Watch(ctx, applicationID, scope, interval)
+	}
+	return pollDomain(ctx, applicationID, scope, interval, func() (values map[string]interface{}, err error) {
+		s.do(func() { values, err = readFresh(applicationID, scope) })
+		return values, err
+	})
+}
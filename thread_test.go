@@ -0,0 +1,96 @@
+package mac_prefs
+
+import (
+	"bytes"
+	"context"
+	"runtime"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+)
+
+// goroutineID returns the ID of the calling goroutine, parsed from its stack trace.
+func goroutineID() uint64 {
+	buf := make([]byte, 64)
+	buf = buf[:runtime.Stack(buf, false)]
+	buf = bytes.TrimPrefix(buf, []byte("goroutine "))
+	id, _ := strconv.ParseUint(string(buf[:bytes.IndexByte(buf, ' ')]), 10, 64)
+	return id
+}
+
+// goroutineStore is a MemoryStore that records the goroutines its methods run on.
+type goroutineStore struct {
+	*MemoryStore
+	mu  sync.Mutex
+	ids map[uint64]bool
+}
+
+func (s *goroutineStore) record() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.ids[goroutineID()] = true
+}
+
+func (s *goroutineStore) Get(key string, applicationID string, scope PreferenceScope) (interface{}, error) {
+	s.record()
+	return s.MemoryStore.Get(key, applicationID, scope)
+}
+
+func (s *goroutineStore) SetMultiple(keysToSet map[string]interface{}, keysToRemove []string, applicationID string, scope PreferenceScope) error {
+	s.record()
+	return s.MemoryStore.SetMultiple(keysToSet, keysToRemove, applicationID, scope)
+}
+
+func TestClientDedicatedThread(t *testing.T) {
+	store := &goroutineStore{MemoryStore: NewMemoryStore(), ids: make(map[uint64]bool)}
+	c := NewClient(WithStore(store), WithDedicatedThread())
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			if err := c.Set("key", i, "com.example", CurrentUserAnyHost); err != nil {
+				t.Error(err)
+			}
+			if _, err := c.Get("key", "com.example", CurrentUserAnyHost); err != nil {
+				t.Error(err)
+			}
+			if _, err := c.Increment("counter", "com.example", CurrentUserAnyHost, 1); err != nil {
+				t.Error(err)
+			}
+		}(i)
+	}
+	wg.Wait()
+	if len(store.ids) != 1 || store.ids[goroutineID()] {
+		t.Errorf("store calls ran on goroutines %v, want one dedicated goroutine", store.ids)
+	}
+	if got, _ := c.Get("counter", "com.example", CurrentUserAnyHost); got != int64(8) {
+		t.Errorf("counter = %v, want 8", got)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	events, err := c.Watch(ctx, "com.example", CurrentUserAnyHost, time.Millisecond)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := store.Set("watched", true, "com.example", CurrentUserAnyHost); err != nil {
+		t.Fatal(err)
+	}
+	if e := <-events; e.Key != "watched" {
+		t.Errorf("Watch() event for %s, want watched", e.Key)
+	}
+	cancel()
+
+	if err := c.Close(); err != nil {
+		t.Fatal(err)
+	}
+	store.ids = make(map[uint64]bool)
+	if _, err := c.Get("key", "com.example", CurrentUserAnyHost); err != nil {
+		t.Fatal(err)
+	}
+	if !store.ids[goroutineID()] {
+		t.Error("Get() after Close did not run on the calling goroutine")
+	}
+}
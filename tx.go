@@ -0,0 +1,123 @@
+package mac_prefs
+
+import (
+	"errors"
+	"fmt"
+)
+
+// ErrTxDone is returned by the methods of a Tx that has already been committed or rolled back.
+var ErrTxDone = errors.New("mac_prefs: transaction has already been committed or rolled back")
+
+// Tx buffers preference writes so they are applied together by Commit or discarded by
+// Rollback. A Tx is created by Client.Begin and must not be used from several goroutines at
+// once.
+type Tx struct {
+	c      *Client
+	slots  []prefSlot
+	writes map[prefSlot]map[string]interface{}
+	done   bool
+}
+
+// Begin starts a transaction on the Client. Nothing is written until Commit.
+func (c *Client) Begin() *Tx {
+	return &Tx{c: c, writes: make(map[prefSlot]map[string]interface{})}
+}
+
+// Set buffers a preference value. A nil value removes the key. The value is validated
+// immediately, so an invalid value is reported here rather than by Commit.
+func (tx *Tx) Set(key string, value interface{}, applicationID string, scope PreferenceScope) error {
+	if tx.done {
+		return ErrTxDone
+	}
+	if err := ValidateValue(value); err != nil {
+		return fmt.Errorf("invalid value for key %s: %w", key, err)
+	}
+	slot := prefSlot{applicationID, scope}
+	if tx.writes[slot] == nil {
+		tx.slots = append(tx.slots, slot)
+		tx.writes[slot] = make(map[string]interface{})
+	}
+	tx.writes[slot][key] = value
+	return nil
+}
+
+// Get retrieves a preference value as the transaction would leave it: a buffered value if the
+// key has been set or deleted in the transaction, or the value read through the Client.
+func (tx *Tx) Get(key string, applicationID string, scope PreferenceScope) (interface{}, error) {
+	if tx.done {
+		return nil, ErrTxDone
+	}
+	if value, ok := tx.writes[prefSlot{applicationID, scope}][key]; ok {
+		return value, nil
+	}
+	return tx.c.Get(key, applicationID, scope)
+}
+
+// Delete buffers the removal of a preference key.
+func (tx *Tx) Delete(key string, applicationID string, scope PreferenceScope) error {
+	return tx.Set(key, nil, applicationID, scope)
+}
+
+// Commit writes the buffered values, each slot with a single write if the store of the Client
+// is a BatchStore, e.g. one CFPreferencesSetMultiple call. The current values of every key
+// are captured first; if any write fails, they are written back so the transaction is not
+// left half applied. Committed writes are recorded in the undo log of the Client.
+//
+// Returns:
+//   - error: An error if a value cannot be read or written, or ErrTxDone.
+func (tx *Tx) Commit() error {
+	if tx.done {
+		return ErrTxDone
+	}
+	tx.done = true
+
+	c := tx.c
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	previous := make(map[prefSlot]map[string]interface{}, len(tx.slots))
+	written := make(map[prefSlot]map[string]interface{}, len(tx.slots))
+	var mutations []mutation
+	for _, slot := range tx.slots {
+		previous[slot] = make(map[string]interface{}, len(tx.writes[slot]))
+		written[slot] = make(map[string]interface{}, len(tx.writes[slot]))
+		for key, value := range tx.writes[slot] {
+			old, err := c.store.Get(key, slot.appID, slot.scope)
+			if err != nil {
+				return fmt.Errorf("error reading previous value of %s: %v", key, err)
+			}
+			value = c.writeValue(value)
+			previous[slot][key] = old
+			written[slot][key] = value
+			mutations = append(mutations, mutation{key: key, appID: slot.appID, scope: slot.scope, old: old, new: value})
+		}
+	}
+
+	for i, slot := range tx.slots {
+		if err := writeSlot(c.store, slot, written[slot]); err != nil {
+			err = fmt.Errorf("error committing transaction to %s: %w", slot.appID, err)
+			for _, restored := range tx.slots[:i+1] {
+				if restoreErr := writeSlot(c.store, restored, previous[restored]); restoreErr != nil {
+					err = fmt.Errorf("%w; error restoring previous values of %s: %v", err, restored.appID, restoreErr)
+				}
+			}
+			return err
+		}
+	}
+
+	c.record(mutations...)
+	return nil
+}
+
+// Rollback discards the buffered values.
+//
+// Returns:
+//   - error: ErrTxDone if the transaction has already been committed or rolled back.
+func (tx *Tx) Rollback() error {
+	if tx.done {
+		return ErrTxDone
+	}
+	tx.done = true
+	tx.slots, tx.writes = nil, nil
+	return nil
+}
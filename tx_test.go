@@ -0,0 +1,141 @@
+package mac_prefs
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+// failingStore is a MemoryStore whose batch writes to and listings of one application ID fail.
+type failingStore struct {
+	*MemoryStore
+	failAppID string
+}
+
+func (s failingStore) SetMultiple(keysToSet map[string]interface{}, keysToRemove []string, applicationID string, scope PreferenceScope) error {
+	if applicationID == s.failAppID {
+		return errors.New("disk full")
+	}
+	return s.MemoryStore.SetMultiple(keysToSet, keysToRemove, applicationID, scope)
+}
+
+func (s failingStore) List(applicationID string, scope PreferenceScope) (map[string]interface{}, error) {
+	if applicationID == s.failAppID {
+		return nil, errors.New("permission denied")
+	}
+	return s.MemoryStore.List(applicationID, scope)
+}
+
+func TestTxCommit(t *testing.T) {
+	for _, store := range []Store{NewMemoryStore(), struct{ Store }{NewMemoryStore()}} {
+		c := NewClient(WithStore(store), WithUndo(0))
+		if err := c.Set("obsolete", true, "com.example", CurrentUserAnyHost); err != nil {
+			t.Fatal(err)
+		}
+
+		tx := c.Begin()
+		if err := tx.Set("a", 1, "com.example", CurrentUserAnyHost); err != nil {
+			t.Fatal(err)
+		}
+		if err := tx.Set("b", "x", "com.example.other", CurrentUserAnyHost); err != nil {
+			t.Fatal(err)
+		}
+		if err := tx.Delete("obsolete", "com.example", CurrentUserAnyHost); err != nil {
+			t.Fatal(err)
+		}
+		if err := tx.Set("invalid", make(chan int), "com.example", CurrentUserAnyHost); err == nil {
+			t.Error("Tx.Set() of an invalid value expected error")
+		}
+		if got, _ := c.Get("a", "com.example", CurrentUserAnyHost); got != nil {
+			t.Errorf("a = %#v before Commit", got)
+		}
+		if err := tx.Commit(); err != nil {
+			t.Fatalf("Commit() error = %v", err)
+		}
+
+		got, _ := c.GetAll("com.example", CurrentUserAnyHost)
+		if want := map[string]interface{}{"a": 1}; !reflect.DeepEqual(got, want) {
+			t.Errorf("com.example = %#v, want %#v", got, want)
+		}
+		if got, _ := c.Get("b", "com.example.other", CurrentUserAnyHost); got != "x" {
+			t.Errorf("b = %#v, want x", got)
+		}
+		if n := c.UndoLen(); n != 4 {
+			t.Errorf("UndoLen() = %d, want 4", n)
+		}
+		if err := tx.Commit(); err != ErrTxDone {
+			t.Errorf("second Commit() error = %v, want ErrTxDone", err)
+		}
+	}
+}
+
+func TestTxRollback(t *testing.T) {
+	c := NewClient(WithStore(NewMemoryStore()))
+	tx := c.Begin()
+	if err := tx.Set("a", 1, "com.example", CurrentUserAnyHost); err != nil {
+		t.Fatal(err)
+	}
+	if err := tx.Rollback(); err != nil {
+		t.Fatalf("Rollback() error = %v", err)
+	}
+	if err := tx.Commit(); err != ErrTxDone {
+		t.Errorf("Commit() after Rollback error = %v, want ErrTxDone", err)
+	}
+	if err := tx.Set("a", 1, "com.example", CurrentUserAnyHost); err != ErrTxDone {
+		t.Errorf("Set() after Rollback error = %v, want ErrTxDone", err)
+	}
+	if got, _ := c.Get("a", "com.example", CurrentUserAnyHost); got != nil {
+		t.Errorf("a = %#v after Rollback", got)
+	}
+}
+
+func TestTxCommitFailureRestores(t *testing.T) {
+	store := failingStore{MemoryStore: NewMemoryStore(), failAppID: "com.example.broken"}
+	c := NewClient(WithStore(store))
+	if err := c.Set("a", "old", "com.example", CurrentUserAnyHost); err != nil {
+		t.Fatal(err)
+	}
+
+	tx := c.Begin()
+	if err := tx.Set("a", "new", "com.example", CurrentUserAnyHost); err != nil {
+		t.Fatal(err)
+	}
+	if err := tx.Set("b", "new", "com.example", CurrentUserAnyHost); err != nil {
+		t.Fatal(err)
+	}
+	if err := tx.Set("c", "new", "com.example.broken", CurrentUserAnyHost); err != nil {
+		t.Fatal(err)
+	}
+	err := tx.Commit()
+	if err == nil {
+		t.Fatal("Commit() expected error")
+	}
+
+	got, _ := c.GetAll("com.example", CurrentUserAnyHost)
+	if want := map[string]interface{}{"a": "old"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("com.example = %#v after failed Commit, want %#v", got, want)
+	}
+}
+
+func TestTxGet(t *testing.T) {
+	c := NewClient(WithStore(NewMemoryStore()))
+	if err := c.Set("a", "stored", "com.example", CurrentUserAnyHost); err != nil {
+		t.Fatal(err)
+	}
+	tx := c.Begin()
+	if got, err := tx.Get("a", "com.example", CurrentUserAnyHost); err != nil || got != "stored" {
+		t.Errorf("Get() = %#v, %v, want stored", got, err)
+	}
+	if err := tx.Set("a", "buffered", "com.example", CurrentUserAnyHost); err != nil {
+		t.Fatal(err)
+	}
+	if got, err := tx.Get("a", "com.example", CurrentUserAnyHost); err != nil || got != "buffered" {
+		t.Errorf("Get() = %#v, %v, want buffered", got, err)
+	}
+	if err := tx.Delete("a", "com.example", CurrentUserAnyHost); err != nil {
+		t.Fatal(err)
+	}
+	if got, err := tx.Get("a", "com.example", CurrentUserAnyHost); err != nil || got != nil {
+		t.Errorf("Get() of a deleted key = %#v, %v, want nil", got, err)
+	}
+}
@@ -0,0 +1,182 @@
+package mac_prefs
+
+import (
+	"fmt"
+	"math"
+)
+
+// The typed getters below read a value and convert it to a common Go shape. Each returns the
+// zero value and false if the key is not set, and an error if the value has another shape.
+// Set accepts the same shapes directly.
+
+// GetStrings retrieves an array of strings. See Get.
+func GetStrings(key string, applicationID string, scope PreferenceScope) ([]string, bool, error) {
+	return NewClient().GetStrings(key, applicationID, scope)
+}
+
+// GetInts retrieves an array of integers. Reals with an integral value are accepted. See Get.
+func GetInts(key string, applicationID string, scope PreferenceScope) ([]int, bool, error) {
+	return NewClient().GetInts(key, applicationID, scope)
+}
+
+// GetFloats retrieves an array of numbers. See Get.
+func GetFloats(key string, applicationID string, scope PreferenceScope) ([]float64, bool, error) {
+	return NewClient().GetFloats(key, applicationID, scope)
+}
+
+// GetBools retrieves an array of booleans. See Get.
+func GetBools(key string, applicationID string, scope PreferenceScope) ([]bool, bool, error) {
+	return NewClient().GetBools(key, applicationID, scope)
+}
+
+// GetStringMap retrieves a dictionary of strings. See Get.
+func GetStringMap(key string, applicationID string, scope PreferenceScope) (map[string]string, bool, error) {
+	return NewClient().GetStringMap(key, applicationID, scope)
+}
+
+// GetIntMap retrieves a dictionary of integers. Reals with an integral value are accepted.
+// See Get.
+func GetIntMap(key string, applicationID string, scope PreferenceScope) (map[string]int, bool, error) {
+	return NewClient().GetIntMap(key, applicationID, scope)
+}
+
+// GetStrings retrieves an array of strings. See GetStrings.
+func (c *Client) GetStrings(key string, applicationID string, scope PreferenceScope) ([]string, bool, error) {
+	return getTyped(c, key, applicationID, scope, StringsValue)
+}
+
+// GetInts retrieves an array of integers. See GetInts.
+func (c *Client) GetInts(key string, applicationID string, scope PreferenceScope) ([]int, bool, error) {
+	return getTyped(c, key, applicationID, scope, IntsValue)
+}
+
+// GetFloats retrieves an array of numbers. See GetFloats.
+func (c *Client) GetFloats(key string, applicationID string, scope PreferenceScope) ([]float64, bool, error) {
+	return getTyped(c, key, applicationID, scope, FloatsValue)
+}
+
+// GetBools retrieves an array of booleans. See GetBools.
+func (c *Client) GetBools(key string, applicationID string, scope PreferenceScope) ([]bool, bool, error) {
+	return getTyped(c, key, applicationID, scope, BoolsValue)
+}
+
+// GetStringMap retrieves a dictionary of strings. See GetStringMap.
+func (c *Client) GetStringMap(key string, applicationID string, scope PreferenceScope) (map[string]string, bool, error) {
+	return getTyped(c, key, applicationID, scope, StringMapValue)
+}
+
+// GetIntMap retrieves a dictionary of integers. See GetIntMap.
+func (c *Client) GetIntMap(key string, applicationID string, scope PreferenceScope) (map[string]int, bool, error) {
+	return getTyped(c, key, applicationID, scope, IntMapValue)
+}
+
+func getTyped[T any](c *Client, key string, applicationID string, scope PreferenceScope, convert func(interface{}) (T, error)) (T, bool, error) {
+	var zero T
+	value, err := c.lookup(key, applicationID, scope)
+	if err != nil || value == nil {
+		return zero, false, err
+	}
+	typed, err := convert(value)
+	if err != nil {
+		return zero, true, fmt.Errorf("error reading %s: %v", key, err)
+	}
+	return typed, true, nil
+}
+
+// StringsValue converts an array read from preferences to a []string.
+func StringsValue(value interface{}) ([]string, error) {
+	return convertArray(value, stringElement)
+}
+
+// IntsValue converts an array read from preferences to a []int.
+func IntsValue(value interface{}) ([]int, error) {
+	return convertArray(value, intElement)
+}
+
+// FloatsValue converts an array read from preferences to a []float64.
+func FloatsValue(value interface{}) ([]float64, error) {
+	return convertArray(value, floatElement)
+}
+
+// BoolsValue converts an array read from preferences to a []bool.
+func BoolsValue(value interface{}) ([]bool, error) {
+	return convertArray(value, boolElement)
+}
+
+// StringMapValue converts a dictionary read from preferences to a map[string]string.
+func StringMapValue(value interface{}) (map[string]string, error) {
+	return convertDictionary(value, stringElement)
+}
+
+// IntMapValue converts a dictionary read from preferences to a map[string]int.
+func IntMapValue(value interface{}) (map[string]int, error) {
+	return convertDictionary(value, intElement)
+}
+
+func convertArray[T any](value interface{}, element func(interface{}) (T, bool)) ([]T, error) {
+	if typed, ok := value.([]T); ok {
+		return typed, nil
+	}
+	items, ok := value.([]interface{})
+	if !ok {
+		return nil, fmt.Errorf("value of type %T is not an array", value)
+	}
+	result := make([]T, len(items))
+	for i, item := range items {
+		if result[i], ok = element(item); !ok {
+			return nil, fmt.Errorf("array item at index %d has type %T, want %T", i, item, result[i])
+		}
+	}
+	return result, nil
+}
+
+func convertDictionary[T any](value interface{}, element func(interface{}) (T, bool)) (map[string]T, error) {
+	if typed, ok := value.(map[string]T); ok {
+		return typed, nil
+	}
+	items, ok := value.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("value of type %T is not a dictionary", value)
+	}
+	result := make(map[string]T, len(items))
+	for key, item := range items {
+		typed, ok := element(item)
+		if !ok {
+			return nil, fmt.Errorf("dictionary value for key %s has type %T, want %T", key, item, typed)
+		}
+		result[key] = typed
+	}
+	return result, nil
+}
+
+func stringElement(v interface{}) (string, bool) {
+	s, ok := v.(string)
+	return s, ok
+}
+
+func boolElement(v interface{}) (bool, bool) {
+	b, ok := v.(bool)
+	return b, ok
+}
+
+func floatElement(v interface{}) (float64, bool) {
+	switch n := normalizeRead(v, NumberFloat64, nil).(type) {
+	case float64:
+		return n, true
+	}
+	return 0, false
+}
+
+func intElement(v interface{}) (int, bool) {
+	switch n := normalizeRead(v, NumberInt64, nil).(type) {
+	case int64:
+		if n >= math.MinInt && n <= math.MaxInt {
+			return int(n), true
+		}
+	case float64:
+		if n == math.Trunc(n) && n >= math.MinInt && n <= math.MaxInt {
+			return int(n), true
+		}
+	}
+	return 0, false
+}
@@ -0,0 +1,81 @@
+package mac_prefs
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestTypedValues(t *testing.T) {
+	strs, err := StringsValue([]interface{}{"a", "b"})
+	if err != nil || !reflect.DeepEqual(strs, []string{"a", "b"}) {
+		t.Errorf("StringsValue = %v, %v", strs, err)
+	}
+	ints, err := IntsValue([]interface{}{int64(1), int32(2), 3.0})
+	if err != nil || !reflect.DeepEqual(ints, []int{1, 2, 3}) {
+		t.Errorf("IntsValue = %v, %v", ints, err)
+	}
+	floats, err := FloatsValue([]interface{}{1.5, int64(2)})
+	if err != nil || !reflect.DeepEqual(floats, []float64{1.5, 2}) {
+		t.Errorf("FloatsValue = %v, %v", floats, err)
+	}
+	bools, err := BoolsValue([]interface{}{true, false})
+	if err != nil || !reflect.DeepEqual(bools, []bool{true, false}) {
+		t.Errorf("BoolsValue = %v, %v", bools, err)
+	}
+	sm, err := StringMapValue(map[string]interface{}{"a": "x"})
+	if err != nil || !reflect.DeepEqual(sm, map[string]string{"a": "x"}) {
+		t.Errorf("StringMapValue = %v, %v", sm, err)
+	}
+	im, err := IntMapValue(map[string]interface{}{"a": int64(7)})
+	if err != nil || !reflect.DeepEqual(im, map[string]int{"a": 7}) {
+		t.Errorf("IntMapValue = %v, %v", im, err)
+	}
+
+	if _, err := StringsValue([]interface{}{"a", 1}); err == nil {
+		t.Error("StringsValue with a mixed array expected error")
+	}
+	if _, err := IntsValue([]interface{}{1.5}); err == nil {
+		t.Error("IntsValue with a fractional real expected error")
+	}
+	if _, err := BoolsValue("true"); err == nil {
+		t.Error("BoolsValue with a string expected error")
+	}
+	if _, err := IntMapValue(map[string]interface{}{"a": "7"}); err == nil {
+		t.Error("IntMapValue with a string value expected error")
+	}
+}
+
+func TestClientTypedGetters(t *testing.T) {
+	c := NewClient(WithStore(NewMemoryStore()))
+	const app = "com.example.typed"
+
+	if err := c.Set("strings", []string{"a", "b"}, app, CurrentUserAnyHost); err != nil {
+		t.Fatalf("Set: %v", err)
+	}
+	if err := c.Set("ints", []int{1, 2}, app, CurrentUserAnyHost); err != nil {
+		t.Fatalf("Set: %v", err)
+	}
+	if err := c.Set("counts", map[string]int{"a": 1}, app, CurrentUserAnyHost); err != nil {
+		t.Fatalf("Set: %v", err)
+	}
+
+	strs, ok, err := c.GetStrings("strings", app, CurrentUserAnyHost)
+	if err != nil || !ok || !reflect.DeepEqual(strs, []string{"a", "b"}) {
+		t.Errorf("GetStrings = %v, %v, %v", strs, ok, err)
+	}
+	ints, ok, err := c.GetInts("ints", app, CurrentUserAnyHost)
+	if err != nil || !ok || !reflect.DeepEqual(ints, []int{1, 2}) {
+		t.Errorf("GetInts = %v, %v, %v", ints, ok, err)
+	}
+	counts, ok, err := c.GetIntMap("counts", app, CurrentUserAnyHost)
+	if err != nil || !ok || !reflect.DeepEqual(counts, map[string]int{"a": 1}) {
+		t.Errorf("GetIntMap = %v, %v, %v", counts, ok, err)
+	}
+
+	if _, ok, err := c.GetBools("missing", app, CurrentUserAnyHost); ok || err != nil {
+		t.Errorf("GetBools(missing) = %v, %v, want false, nil", ok, err)
+	}
+	if _, ok, err := c.GetBools("strings", app, CurrentUserAnyHost); !ok || err == nil {
+		t.Errorf("GetBools(strings) = %v, %v, want true and an error", ok, err)
+	}
+}
@@ -0,0 +1,47 @@
+package mac_prefs
+
+import "errors"
+
+// ErrUnsupportedPlatform is returned by every function that reads or writes preferences when
+// the package is built for an operating system other than macOS, or without cgo. Code that
+// must build and test on other platforms can use a MemoryStore through the Store interface
+// instead.
+var ErrUnsupportedPlatform = errors.New("mac_prefs: preferences are only supported on macOS with cgo")
+
+// AnyApplication is the application ID of the global preferences domain (NSGlobalDomain),
+// whose values are visible to every application.
+const AnyApplication = "kCFPreferencesAnyApplication"
+
+// UserType represents the user scope for preferences.
+// Use the predefined constants or a literal username.
+type UserType string
+
+// HostType represents the type of host for preferences
+type HostType string
+
+// PreferenceScope defines the scope for preferences.
+// User may be a predefined constant or a literal username.
+type PreferenceScope struct {
+	User UserType
+	Host HostType
+}
+
+var (
+	// CurrentUser represents the current user's preferences
+	CurrentUser UserType = "kCFPreferencesCurrentUser"
+	// AnyUser represents preferences for any user
+	AnyUser UserType = "kCFPreferencesAnyUser"
+	// CurrentHost represents the current host's preferences
+	CurrentHost HostType = "kCFPreferencesCurrentHost"
+	// AnyHost represents preferences for any host
+	AnyHost HostType = "kCFPreferencesAnyHost"
+
+	// CurrentUserCurrentHost represents preferences for the current user on the current host
+	CurrentUserCurrentHost = PreferenceScope{User: CurrentUser, Host: CurrentHost}
+	// CurrentUserAnyHost represents preferences for the current user on any host
+	CurrentUserAnyHost = PreferenceScope{User: CurrentUser, Host: AnyHost}
+	// AnyUserCurrentHost represents preferences for any user on the current host
+	AnyUserCurrentHost = PreferenceScope{User: AnyUser, Host: CurrentHost}
+	// AnyUserAnyHost represents preferences for any user on any host
+	AnyUserAnyHost = PreferenceScope{User: AnyUser, Host: AnyHost}
+)
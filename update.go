@@ -0,0 +1,36 @@
+package mac_prefs
+
+// Update reads a preference value, passes it to update, and writes the value update returns,
+// all under a lock shared by the package level read-modify-write functions, so concurrent
+// updaters in the process run one after another. The value passed to update is nil if the key is not set and is read as with Get;
+// returning nil removes the key. If update returns an error, nothing is written and the error
+// is returned unchanged.
+//
+// Parameters:
+//   - key: The preference key to update.
+//   - applicationID: The bundle identifier of the application for which to update the preference.
+//   - scope: The PreferenceScope to write to.
+//   - update: The function computing the new value from the current one.
+//
+// Returns:
+//   - error: An error if update fails or the preference cannot be read or written.
+func Update(key string, applicationID string, scope PreferenceScope, update func(current interface{}) (interface{}, error)) error {
+	return defaultClient.Update(key, applicationID, scope, update)
+}
+
+// Update replaces a preference value with the result of update while the Client is locked,
+// so concurrent updaters using the same Client run one after another. See Update.
+func (c *Client) Update(key string, applicationID string, scope PreferenceScope, update func(current interface{}) (interface{}, error)) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	current, err := c.store.Get(key, applicationID, scope)
+	if err != nil {
+		return err
+	}
+	value, err := update(c.readValue(current))
+	if err != nil {
+		return err
+	}
+	return c.set(key, value, applicationID, scope)
+}
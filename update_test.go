@@ -0,0 +1,82 @@
+package mac_prefs
+
+import (
+	"errors"
+	"sync"
+	"testing"
+)
+
+// TestUpdateConcurrent checks that package level updaters are serialized; run it with -race.
+func TestUpdateConcurrent(t *testing.T) {
+	useDefaultStore(t, NewMemoryStore())
+	appendItem := func(current interface{}) (interface{}, error) {
+		items, _ := current.([]interface{})
+		return append(items, len(items)), nil
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := Update("items", "com.example", CurrentUserAnyHost, appendItem); err != nil {
+				t.Error(err)
+			}
+		}()
+	}
+	wg.Wait()
+	got, err := defaultClient.Get("items", "com.example", CurrentUserAnyHost)
+	if err != nil {
+		t.Fatalf("Get() error = %v", err)
+	}
+	if items, _ := got.([]interface{}); len(items) != 20 {
+		t.Errorf("items = %#v, want 20 items", got)
+	}
+}
+
+func TestClientUpdate(t *testing.T) {
+	c := NewClient(WithStore(NewMemoryStore()), WithNumberMode(NumberInt64))
+	appendItem := func(current interface{}) (interface{}, error) {
+		items, _ := current.([]interface{})
+		return append(items, int64(len(items))), nil
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := c.Update("items", "com.example", CurrentUserAnyHost, appendItem); err != nil {
+				t.Error(err)
+			}
+		}()
+	}
+	wg.Wait()
+	got, err := c.Get("items", "com.example", CurrentUserAnyHost)
+	if err != nil {
+		t.Fatal(err)
+	}
+	items := got.([]interface{})
+	for i, item := range items {
+		if item != int64(i) {
+			t.Fatalf("items = %#v, updates were lost or reordered", items)
+		}
+	}
+	if len(items) != 20 {
+		t.Errorf("len(items) = %d, want 20", len(items))
+	}
+
+	errStop := errors.New("stop")
+	err = c.Update("items", "com.example", CurrentUserAnyHost, func(interface{}) (interface{}, error) {
+		return "unused", errStop
+	})
+	if err != errStop {
+		t.Errorf("Update() error = %v, want %v", err, errStop)
+	}
+	if err := c.Update("items", "com.example", CurrentUserAnyHost, func(interface{}) (interface{}, error) { return nil, nil }); err != nil {
+		t.Fatal(err)
+	}
+	if got, _ := c.Get("items", "com.example", CurrentUserAnyHost); got != nil {
+		t.Errorf("items = %#v after Update returned nil", got)
+	}
+}
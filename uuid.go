@@ -0,0 +1,85 @@
+package mac_prefs
+
+import (
+	"encoding/hex"
+	"fmt"
+	"strings"
+)
+
+// A [16]byte value is a UUID. It is stored in the canonical string form that CFUUID uses,
+// e.g. "68753A44-4D6F-1226-9C60-0050E4C00067", or as 16 bytes of data with WithUUIDAsData.
+
+// WithUUIDAsData makes the Client store [16]byte values as 16 bytes of data instead of their
+// canonical string form.
+func WithUUIDAsData() Option {
+	return func(c *Client) {
+		c.uuidAsData = true
+	}
+}
+
+// UUIDValue converts a value read from preferences to a UUID. The canonical string form, in
+// either case and optionally wrapped in braces, and 16 bytes of data are accepted.
+//
+// Parameters:
+//   - value: The value returned by Get or a Client.
+//
+// Returns:
+//   - [16]byte: The UUID.
+//   - error: An error if value is not a UUID string or 16 bytes of data.
+func UUIDValue(value interface{}) ([16]byte, error) {
+	var u [16]byte
+	switch v := value.(type) {
+	case [16]byte:
+		return v, nil
+	case []byte:
+		if len(v) != len(u) {
+			return u, fmt.Errorf("%d bytes of data is not a UUID", len(v))
+		}
+		copy(u[:], v)
+		return u, nil
+	case string:
+		s := strings.TrimSuffix(strings.TrimPrefix(v, "{"), "}")
+		if len(s) != 36 || s[8] != '-' || s[13] != '-' || s[18] != '-' || s[23] != '-' {
+			return u, fmt.Errorf("%q is not a UUID", v)
+		}
+		s = s[0:8] + s[9:13] + s[14:18] + s[19:23] + s[24:]
+		if _, err := hex.Decode(u[:], []byte(s)); err != nil {
+			return u, fmt.Errorf("%q is not a UUID", v)
+		}
+		return u, nil
+	}
+	return u, fmt.Errorf("cannot convert %T to a UUID", value)
+}
+
+// GetUUID retrieves a UUID stored as a string or as data. See Get and UUIDValue.
+//
+// Parameters:
+//   - key: The preference key to retrieve.
+//   - applicationID: The bundle identifier of the application for which to retrieve the preference.
+//   - scope: The PreferenceScope to read from.
+//
+// Returns:
+//   - [16]byte: The UUID, or the zero UUID if the key is not set.
+//   - bool: Whether the key is set.
+//   - error: An error if the value cannot be read or is not a UUID.
+func GetUUID(key string, applicationID string, scope PreferenceScope) ([16]byte, bool, error) {
+	return NewClient().GetUUID(key, applicationID, scope)
+}
+
+// GetUUID retrieves a UUID stored as a string or as data. See GetUUID.
+func (c *Client) GetUUID(key string, applicationID string, scope PreferenceScope) ([16]byte, bool, error) {
+	return getTyped(c, key, applicationID, scope, UUIDValue)
+}
+
+// formatUUID returns the canonical string form of u.
+func formatUUID(u [16]byte) string {
+	return fmt.Sprintf("%X-%X-%X-%X-%X", u[0:4], u[4:6], u[6:8], u[8:10], u[10:16])
+}
+
+// uuidToData is a rewriteLeaves function that stores UUIDs as data.
+func uuidToData(value interface{}) (interface{}, bool) {
+	if u, ok := value.([16]byte); ok {
+		return u[:], true
+	}
+	return value, false
+}
@@ -0,0 +1,58 @@
+package mac_prefs
+
+import (
+	"bytes"
+	"testing"
+)
+
+var testUUID = [16]byte{0x68, 0x75, 0x3a, 0x44, 0x4d, 0x6f, 0x12, 0x26, 0x9c, 0x60, 0x00, 0x50, 0xe4, 0xc0, 0x00, 0x67}
+
+func TestUUIDValue(t *testing.T) {
+	for _, value := range []interface{}{
+		testUUID,
+		"68753A44-4D6F-1226-9C60-0050E4C00067",
+		"68753a44-4d6f-1226-9c60-0050e4c00067",
+		"{68753A44-4D6F-1226-9C60-0050E4C00067}",
+		testUUID[:],
+	} {
+		got, err := UUIDValue(value)
+		if err != nil || got != testUUID {
+			t.Errorf("UUIDValue(%#v) = %X, %v", value, got, err)
+		}
+	}
+
+	for _, value := range []interface{}{nil, 1, "68753A44", "68753A44-4D6F-1226-9C60-0050E4C0006Z", "68753A444D6F12269C600050E4C00067AAAA", []byte{1, 2}} {
+		if _, err := UUIDValue(value); err == nil {
+			t.Errorf("UUIDValue(%#v) expected error", value)
+		}
+	}
+}
+
+func TestClientUUID(t *testing.T) {
+	store := NewMemoryStore()
+	const app = "com.example.uuid"
+
+	c := NewClient(WithStore(store))
+	if err := c.Set("ID", testUUID, app, CurrentUserAnyHost); err != nil {
+		t.Fatalf("Set() error = %v", err)
+	}
+	if got, _ := store.Get("ID", app, CurrentUserAnyHost); got != "68753A44-4D6F-1226-9C60-0050E4C00067" {
+		t.Fatalf("stored value = %#v, want the canonical string", got)
+	}
+	if got, ok, err := c.GetUUID("ID", app, CurrentUserAnyHost); err != nil || !ok || got != testUUID {
+		t.Fatalf("GetUUID() = %X, %v, %v", got, ok, err)
+	}
+
+	c = NewClient(WithStore(store), WithUUIDAsData())
+	if err := c.Set("IDs", [][16]byte{testUUID}, app, CurrentUserAnyHost); err != nil {
+		t.Fatalf("Set() error = %v", err)
+	}
+	got, _ := store.Get("IDs", app, CurrentUserAnyHost)
+	if items, ok := got.([]interface{}); !ok || len(items) != 1 || !bytes.Equal(items[0].([]byte), testUUID[:]) {
+		t.Fatalf("stored value = %#v, want the UUID as data", got)
+	}
+
+	if _, ok, err := c.GetUUID("Missing", app, CurrentUserAnyHost); ok || err != nil {
+		t.Fatalf("GetUUID(missing) = %v, %v, want false, nil", ok, err)
+	}
+}
@@ -0,0 +1,176 @@
+package mac_prefs
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"net/url"
+	"reflect"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/weswhet/mac_prefs/internal/prefstag"
+)
+
+// ErrUnsignedOverflow is wrapped by the ValueError for an unsigned integer above
+// math.MaxInt64, which does not fit in a CFNumber. Use WithUnsignedAsString to store such
+// values as decimal strings instead.
+var ErrUnsignedOverflow = errors.New("unsigned integer overflows signed 64-bit CFNumber")
+
+// ValueError reports an element of a value that cannot be stored as a preference.
+type ValueError struct {
+	// Path locates the element, e.g. "Servers[2].Port". It is empty for the value itself.
+	Path string
+	// Value is the offending element.
+	Value interface{}
+	// Reason describes the problem.
+	Reason string
+	// Err is the underlying error, such as ErrUnsignedOverflow, or nil.
+	Err error
+}
+
+func (e *ValueError) Error() string {
+	path := e.Path
+	if path == "" {
+		path = "value"
+	}
+	return fmt.Sprintf("%s: %s", path, e.Reason)
+}
+
+// Unwrap returns the underlying error.
+func (e *ValueError) Unwrap() error {
+	return e.Err
+}
+
+// ValidateValue walks a value and reports the first element that cannot be converted to a
+// CoreFoundation property list type, without creating any CF objects. It accepts the same
+// values as Set: strings, booleans, numbers, json.Number, time.Time, time.Duration, url.URL,
+// []byte, [16]byte UUIDs, slices, string-keyed maps, structs, and pointers to those, and
+// Marshalers. A nil value or nil pointer is valid, since it removes a key, map entries and
+// struct fields holding a nil pointer are left out, and nil elements of arrays and dictionaries
+// are stored as kCFNull; nil pointers in slices are not valid. Map keys are visited in sorted
+// order, so the reported element is deterministic.
+//
+// Parameters:
+//   - value: The value to check.
+//
+// Returns:
+//   - error: A *ValueError locating the unsupported element, or nil if the value can be stored.
+func ValidateValue(value interface{}) error {
+	if value == nil || isNilPointer(value) {
+		return nil
+	}
+	return validateValue(value, "")
+}
+
+func validateValue(value interface{}, path string) error {
+	if marshaled, ok, err := marshalValue(value); ok {
+		if err != nil {
+			return &ValueError{Path: path, Value: value, Reason: fmt.Sprintf("error marshaling %T: %v", value, err), Err: err}
+		}
+		return validateValue(marshaled, path)
+	}
+	switch v := value.(type) {
+	case nil:
+		// Nil elements of arrays and dictionaries are stored as kCFNull.
+		return nil
+	case string, []byte, [16]byte, bool, time.Time, time.Duration, Null, url.URL, int, int8, int16, int32, int64, uint8, uint16, uint32, float32, float64:
+		return nil
+	case json.Number:
+		if _, err := jsonNumberValue(v); err != nil {
+			return &ValueError{Path: path, Value: value, Reason: err.Error()}
+		}
+		return nil
+	case uint:
+		return validateUint(uint64(v), path)
+	case uint64:
+		return validateUint(v, path)
+	case []interface{}:
+		for i, item := range v {
+			if err := validateValue(item, indexPath(path, i)); err != nil {
+				return err
+			}
+		}
+		return nil
+	case map[string]interface{}:
+		keys := make([]string, 0, len(v))
+		for key := range v {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			if isNilPointer(v[key]) {
+				continue
+			}
+			if err := validateValue(v[key], keyPath(path, key)); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	rv := reflect.ValueOf(value)
+	switch {
+	case rv.Kind() == reflect.Slice:
+		for i := 0; i < rv.Len(); i++ {
+			if err := validateValue(rv.Index(i).Interface(), indexPath(path, i)); err != nil {
+				return err
+			}
+		}
+		return nil
+	case rv.Kind() == reflect.Map && rv.Type().Key().Kind() == reflect.String:
+		values := make(map[string]interface{}, rv.Len())
+		for _, key := range rv.MapKeys() {
+			values[key.String()] = rv.MapIndex(key).Interface()
+		}
+		return validateValue(values, path)
+	case rv.Kind() == reflect.Struct:
+		return validateValue(prefstag.Values(rv), path)
+	case rv.Kind() == reflect.Ptr:
+		if rv.IsNil() {
+			return &ValueError{Path: path, Reason: "nil element"}
+		}
+		return validateValue(rv.Elem().Interface(), path)
+	case rv.Kind() == reflect.Map:
+		return &ValueError{Path: path, Value: value, Reason: fmt.Sprintf("unsupported map key type %s", rv.Type().Key())}
+	}
+	return &ValueError{Path: path, Value: value, Reason: fmt.Sprintf("unsupported type %T", value)}
+}
+
+// isNilPointer reports whether value is a nil pointer.
+func isNilPointer(value interface{}) bool {
+	rv := reflect.ValueOf(value)
+	return rv.Kind() == reflect.Ptr && rv.IsNil()
+}
+
+// jsonNumberValue converts n to an int64 if it is an integer that fits, otherwise to a float64.
+func jsonNumberValue(n json.Number) (interface{}, error) {
+	if i, err := n.Int64(); err == nil {
+		return i, nil
+	}
+	f, err := n.Float64()
+	if err != nil {
+		return nil, fmt.Errorf("invalid json.Number %q", string(n))
+	}
+	return f, nil
+}
+
+func validateUint(v uint64, path string) error {
+	if v > math.MaxInt64 {
+		return &ValueError{Path: path, Value: v, Reason: "unsigned integer overflows signed 64-bit CFNumber", Err: ErrUnsignedOverflow}
+	}
+	return nil
+}
+
+func indexPath(path string, i int) string {
+	return path + "[" + strconv.Itoa(i) + "]"
+}
+
+func keyPath(path, key string) string {
+	if path == "" {
+		return key
+	}
+	return path + "." + key
+}
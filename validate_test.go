@@ -0,0 +1,92 @@
+package mac_prefs
+
+import (
+	"encoding/json"
+	"errors"
+	"math"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestValidateValue(t *testing.T) {
+	type name string
+
+	valid := []interface{}{
+		nil,
+		"a",
+		[]byte("b"),
+		true,
+		time.Now(),
+		-1,
+		uint64(math.MaxInt64),
+		1.5,
+		[]string{"a"},
+		[]interface{}{1, "a", []interface{}{}},
+		map[string]interface{}{"a": map[string]int{"b": 1}},
+		map[name]interface{}{"a": 1},
+		json.Number("42"),
+		json.Number("1.5e3"),
+		(*int)(nil),
+		new(bool),
+		map[string]*int{"a": nil},
+		struct{ Enabled *bool }{},
+		[]interface{}{"a", Null{}},
+		[]interface{}{"a", nil},
+		map[string]interface{}{"a": nil},
+		url.URL{Scheme: "https", Host: "example.com"},
+		&url.URL{Scheme: "file", Path: "/Applications"},
+	}
+	for _, v := range valid {
+		if err := ValidateValue(v); err != nil {
+			t.Errorf("ValidateValue(%#v) error = %v", v, err)
+		}
+	}
+
+	tests := []struct {
+		value interface{}
+		path  string
+	}{
+		{make(chan int), ""},
+		{uint64(math.MaxUint64), ""},
+		{map[string]interface{}{"n": json.Number("abc")}, "n"},
+		{[]*int{nil}, "[0]"},
+		{map[int]string{1: "a"}, ""},
+		{map[string]interface{}{"b": 1, "a": []interface{}{0, map[string]interface{}{"c": make(chan int)}}}, "a[1].c"},
+		{map[string]interface{}{"Servers": []map[string]interface{}{{"Port": 1}, {"Port": make(chan int)}}}, "Servers[1].Port"},
+		{struct {
+			Servers []struct {
+				Port interface{} `prefs:"port"`
+			}
+		}{Servers: []struct {
+			Port interface{} `prefs:"port"`
+		}{{Port: 1}, {Port: make(chan int)}}}, "Servers[1].port"},
+	}
+	if err := ValidateValue(uint64(math.MaxUint64)); err == nil || strings.Contains(err.Error(), "18446744073709551615") {
+		t.Errorf("ValidateValue(MaxUint64) error = %v, want the value left out", err)
+	}
+	for _, tt := range tests {
+		err := ValidateValue(tt.value)
+		var valueErr *ValueError
+		if !errors.As(err, &valueErr) {
+			t.Errorf("ValidateValue(%#v) error = %v, want a *ValueError", tt.value, err)
+			continue
+		}
+		if valueErr.Path != tt.path {
+			t.Errorf("ValidateValue(%#v) path = %q, want %q", tt.value, valueErr.Path, tt.path)
+		}
+	}
+}
+
+func TestMemoryStoreRejectsInvalidValues(t *testing.T) {
+	s := NewMemoryStore()
+	err := s.Set("Key", []interface{}{1, make(chan int)}, "com.example", CurrentUserAnyHost)
+	var valueErr *ValueError
+	if !errors.As(err, &valueErr) || valueErr.Path != "[1]" {
+		t.Fatalf("Set() error = %v, want a *ValueError at [1]", err)
+	}
+	if v, _ := s.Get("Key", "com.example", CurrentUserAnyHost); v != nil {
+		t.Fatalf("Get() = %v, want nil after a rejected Set", v)
+	}
+}
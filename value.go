@@ -0,0 +1,187 @@
+package mac_prefs
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Value wraps a preference value with typed accessors, as an alternative to type switches
+// on the interface{} returned by Get. Accessor errors name the key the value was read from.
+//
+// A Value is strict by default: AsString accepts only a string, AsInt only an integer, and so
+// on. Lenient returns a copy that also converts between strings, numbers, and booleans the
+// way `defaults` does, e.g. "1" or "YES" for true.
+type Value struct {
+	key     string
+	value   interface{}
+	lenient bool
+}
+
+// NewValue wraps a value, e.g. one already read with Get.
+func NewValue(value interface{}) Value {
+	return Value{value: value}
+}
+
+// GetValue retrieves a preference value from one exact (user, host) slot as a Value. A key
+// that is not set yields a Value for which IsNil is true. See Get.
+func GetValue(key string, applicationID string, scope PreferenceScope) (Value, error) {
+	return NewClient().GetValue(key, applicationID, scope)
+}
+
+// GetValue retrieves a preference value as a Value. See GetValue.
+func (c *Client) GetValue(key string, applicationID string, scope PreferenceScope) (Value, error) {
+	value, err := c.Get(key, applicationID, scope)
+	if err != nil {
+		return Value{}, err
+	}
+	return Value{key: key, value: value}, nil
+}
+
+// Lenient returns a copy of v whose accessors convert between strings, numbers, and booleans.
+func (v Value) Lenient() Value {
+	v.lenient = true
+	return v
+}
+
+// Interface returns the wrapped value.
+func (v Value) Interface() interface{} {
+	return v.value
+}
+
+// IsNil reports whether the value is nil, e.g. because the key is not set.
+func (v Value) IsNil() bool {
+	return v.value == nil
+}
+
+// Type returns the Kind of the value: KindString, KindInt, KindFloat, KindBool, KindDate,
+// KindData, KindArray, or KindDictionary, or KindAny for nil and other types.
+func (v Value) Type() Kind {
+	for _, kind := range []Kind{KindString, KindInt, KindFloat, KindBool, KindDate, KindData, KindArray, KindDictionary} {
+		if kindMatches(kind, v.value) {
+			return kind
+		}
+	}
+	return KindAny
+}
+
+// AsString returns the value as a string. Lenient values also convert numbers and booleans.
+func (v Value) AsString() (string, error) {
+	switch s := v.value.(type) {
+	case string:
+		return s, nil
+	case bool:
+		if v.lenient {
+			if s {
+				return "1", nil
+			}
+			return "0", nil
+		}
+	}
+	if _, ok := numberValue(v.value); ok && v.lenient {
+		return fmt.Sprint(v.value), nil
+	}
+	return "", v.errorf("string")
+}
+
+// AsInt returns the value as an int. Lenient values also convert reals with an integral
+// value, numeric strings, and booleans.
+func (v Value) AsInt() (int, error) {
+	if n, ok := intElement(v.value); ok && (v.lenient || kindMatches(KindInt, v.value)) {
+		return n, nil
+	}
+	if v.lenient {
+		switch s := v.value.(type) {
+		case string:
+			if n, err := strconv.Atoi(strings.TrimSpace(s)); err == nil {
+				return n, nil
+			}
+		case bool:
+			if s {
+				return 1, nil
+			}
+			return 0, nil
+		}
+	}
+	return 0, v.errorf("int")
+}
+
+// AsFloat returns the value as a float64. Any number is accepted; lenient values also convert
+// numeric strings.
+func (v Value) AsFloat() (float64, error) {
+	if n, ok := numberValue(v.value); ok {
+		return n, nil
+	}
+	if s, ok := v.value.(string); ok && v.lenient {
+		if n, err := strconv.ParseFloat(strings.TrimSpace(s), 64); err == nil && !math.IsNaN(n) {
+			return n, nil
+		}
+	}
+	return 0, v.errorf("float")
+}
+
+// AsBool returns the value as a bool. Lenient values also convert numbers, which are true
+// when non-zero, and the strings "YES", "NO", "true", "false", "1", and "0" in any case.
+func (v Value) AsBool() (bool, error) {
+	if b, ok := v.value.(bool); ok {
+		return b, nil
+	}
+	if v.lenient {
+		if n, ok := numberValue(v.value); ok {
+			return n != 0, nil
+		}
+		if s, ok := v.value.(string); ok {
+			switch strings.ToLower(strings.TrimSpace(s)) {
+			case "yes", "true", "1":
+				return true, nil
+			case "no", "false", "0":
+				return false, nil
+			}
+		}
+	}
+	return false, v.errorf("bool")
+}
+
+// AsTime returns the value as a time.Time. Lenient values also parse RFC 3339 strings.
+func (v Value) AsTime() (time.Time, error) {
+	if t, ok := v.value.(time.Time); ok {
+		return t, nil
+	}
+	if s, ok := v.value.(string); ok && v.lenient {
+		if t, err := time.Parse(time.RFC3339, strings.TrimSpace(s)); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, v.errorf("date")
+}
+
+// AsSlice returns the value as an array.
+func (v Value) AsSlice() ([]interface{}, error) {
+	if items, ok := v.value.([]interface{}); ok {
+		return items, nil
+	}
+	return nil, v.errorf("array")
+}
+
+// AsMap returns the value as a dictionary.
+func (v Value) AsMap() (map[string]interface{}, error) {
+	if items, ok := v.value.(map[string]interface{}); ok {
+		return items, nil
+	}
+	return nil, v.errorf("dictionary")
+}
+
+// errorf returns the error of a failed conversion to want. The value is masked by
+// DefaultRedactor, since the error may end up in a log.
+func (v Value) errorf(want string) error {
+	name := v.key
+	if name == "" {
+		name = "value"
+	}
+	if v.value == nil {
+		return fmt.Errorf("%s: cannot convert nil to %s", name, want)
+	}
+	return fmt.Errorf("%s: cannot convert %T %v to %s", name, v.value, DefaultRedactor.Redact(v.key, v.value), want)
+}
@@ -0,0 +1,128 @@
+package mac_prefs
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestValueStrict(t *testing.T) {
+	if s, err := NewValue("dock").AsString(); err != nil || s != "dock" {
+		t.Errorf("AsString() = %q, %v", s, err)
+	}
+	if n, err := NewValue(int64(48)).AsInt(); err != nil || n != 48 {
+		t.Errorf("AsInt() = %d, %v", n, err)
+	}
+	if f, err := NewValue(48).AsFloat(); err != nil || f != 48 {
+		t.Errorf("AsFloat() = %v, %v", f, err)
+	}
+	if b, err := NewValue(true).AsBool(); err != nil || !b {
+		t.Errorf("AsBool() = %v, %v", b, err)
+	}
+	now := time.Now()
+	if got, err := NewValue(now).AsTime(); err != nil || !got.Equal(now) {
+		t.Errorf("AsTime() = %v, %v", got, err)
+	}
+	if items, err := NewValue([]interface{}{1}).AsSlice(); err != nil || len(items) != 1 {
+		t.Errorf("AsSlice() = %v, %v", items, err)
+	}
+	if items, err := NewValue(map[string]interface{}{"a": 1}).AsMap(); err != nil || len(items) != 1 {
+		t.Errorf("AsMap() = %v, %v", items, err)
+	}
+
+	if _, err := NewValue("1").AsInt(); err == nil {
+		t.Error("strict AsInt() of a string expected error")
+	}
+	if _, err := NewValue(1.5).AsInt(); err == nil {
+		t.Error("strict AsInt() of a real expected error")
+	}
+	if _, err := NewValue(1).AsBool(); err == nil {
+		t.Error("strict AsBool() of an integer expected error")
+	}
+	if _, err := NewValue(nil).AsString(); err == nil {
+		t.Error("AsString() of nil expected error")
+	}
+}
+
+func TestValueLenient(t *testing.T) {
+	if n, err := NewValue(" 48 ").Lenient().AsInt(); err != nil || n != 48 {
+		t.Errorf("AsInt() = %d, %v", n, err)
+	}
+	if n, err := NewValue(48.0).Lenient().AsInt(); err != nil || n != 48 {
+		t.Errorf("AsInt() = %d, %v", n, err)
+	}
+	if s, err := NewValue(48).Lenient().AsString(); err != nil || s != "48" {
+		t.Errorf("AsString() = %q, %v", s, err)
+	}
+	for _, value := range []interface{}{"YES", "true", "1", 1, 2.5} {
+		if b, err := NewValue(value).Lenient().AsBool(); err != nil || !b {
+			t.Errorf("AsBool(%#v) = %v, %v, want true", value, b, err)
+		}
+	}
+	if b, err := NewValue("no").Lenient().AsBool(); err != nil || b {
+		t.Errorf("AsBool(no) = %v, %v, want false", b, err)
+	}
+	if f, err := NewValue("1.5").Lenient().AsFloat(); err != nil || f != 1.5 {
+		t.Errorf("AsFloat() = %v, %v", f, err)
+	}
+	if _, err := NewValue("2024-01-02T03:04:05Z").Lenient().AsTime(); err != nil {
+		t.Errorf("AsTime() error = %v", err)
+	}
+	if _, err := NewValue("maybe").Lenient().AsBool(); err == nil {
+		t.Error("AsBool(maybe) expected error")
+	}
+}
+
+func TestValueType(t *testing.T) {
+	tests := []struct {
+		value interface{}
+		want  Kind
+	}{
+		{"a", KindString},
+		{1, KindInt},
+		{1.5, KindFloat},
+		{true, KindBool},
+		{time.Now(), KindDate},
+		{[]byte("a"), KindData},
+		{[]interface{}{}, KindArray},
+		{map[string]interface{}{}, KindDictionary},
+		{nil, KindAny},
+	}
+	for _, tt := range tests {
+		if got := NewValue(tt.value).Type(); got != tt.want {
+			t.Errorf("Type(%#v) = %v, want %v", tt.value, got, tt.want)
+		}
+	}
+}
+
+func TestClientGetValue(t *testing.T) {
+	c := NewClient(WithStore(NewMemoryStore()))
+	if err := c.Set("tilesize", "48", "com.example", CurrentUserAnyHost); err != nil {
+		t.Fatal(err)
+	}
+	v, err := c.GetValue("tilesize", "com.example", CurrentUserAnyHost)
+	if err != nil {
+		t.Fatalf("GetValue() error = %v", err)
+	}
+	if _, err := v.AsInt(); err == nil || !strings.HasPrefix(err.Error(), "tilesize: ") {
+		t.Errorf("AsInt() error = %v, want an error naming the key", err)
+	}
+	if err := c.Set("AuthToken", "s3cret", "com.example", CurrentUserAnyHost); err != nil {
+		t.Fatalf("Set() error = %v", err)
+	}
+	token, err := c.GetValue("AuthToken", "com.example", CurrentUserAnyHost)
+	if err != nil {
+		t.Fatalf("GetValue() error = %v", err)
+	}
+	if _, err := token.AsInt(); err == nil || strings.Contains(err.Error(), "s3cret") {
+		t.Errorf("AsInt() error = %v, want the value masked", err)
+	}
+	if n, err := v.Lenient().AsInt(); err != nil || n != 48 {
+		t.Errorf("Lenient().AsInt() = %d, %v", n, err)
+	}
+
+	missing, err := c.GetValue("missing", "com.example", CurrentUserAnyHost)
+	if err != nil || !missing.IsNil() {
+		t.Errorf("GetValue(missing) = %v, %v, want a nil Value", missing, err)
+	}
+}
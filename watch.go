@@ -0,0 +1,101 @@
+package mac_prefs
+
+import (
+	"context"
+	"time"
+)
+
+// DefaultWatchInterval is the polling interval Watch uses when none is given.
+const DefaultWatchInterval = time.Second
+
+// Event is a change to a watched domain.
+type Event struct {
+	Change
+	// ApplicationID is the domain that changed.
+	ApplicationID string
+	// Scope is the (user, host) slot that changed.
+	Scope PreferenceScope
+	// Time is when the change was observed.
+	Time time.Time
+}
+
+// Watch polls one exact (user, host) slot of a domain and sends an Event for every key that
+// is added, changed, or removed, whichever process made the change. Events of one poll are
+// sent in key order. The channel is closed when ctx is done. A poll that fails to read the
+// domain is skipped.
+//
+// Parameters:
+//   - ctx: Stops the watcher when done.
+//   - appID: The bundle identifier of the application to watch.
+//   - scope: The PreferenceScope defining the user and host scope to watch.
+//   - interval: How often to poll. DefaultWatchInterval is used if it is zero or less.
+//
+// Returns:
+//   - <-chan Event: The change events.
+//   - error: An error if the domain cannot be read initially.
+func Watch(ctx context.Context, appID string, scope PreferenceScope, interval time.Duration) (<-chan Event, error) {
+	return pollDomain(ctx, appID, scope, interval, func() (map[string]interface{}, error) {
+		return readFresh(appID, scope)
+	})
+}
+
+// pollDomain implements Watch, reading the domain with read.
+func pollDomain(ctx context.Context, appID string, scope PreferenceScope, interval time.Duration, read func() (map[string]interface{}, error)) (<-chan Event, error) {
+	if interval <= 0 {
+		interval = DefaultWatchInterval
+	}
+
+	previous, err := read()
+	if err != nil {
+		return nil, err
+	}
+
+	events := make(chan Event)
+	go func() {
+		defer close(events)
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+
+			current, err := read()
+			if err != nil {
+				continue
+			}
+			now := time.Now()
+			for _, c := range watchChanges(previous, current) {
+				select {
+				case events <- Event{Change: c, ApplicationID: appID, Scope: scope, Time: now}:
+				case <-ctx.Done():
+					return
+				}
+			}
+			previous = current
+		}
+	}()
+	return events, nil
+}
+
+// Watch watches one exact (user, host) slot through the store of the Client. See Watch.
+func (c *Client) Watch(ctx context.Context, applicationID string, scope PreferenceScope, interval time.Duration) (<-chan Event, error) {
+	return c.store.Watch(ctx, applicationID, scope, interval)
+}
+
+// watchChanges computes the changes between two reads of a domain.
+func watchChanges(previous, current map[string]interface{}) Changes {
+	desired := make(map[string]interface{}, len(current)+len(previous))
+	for key, value := range current {
+		desired[key] = value
+	}
+	for key := range previous {
+		if _, ok := current[key]; !ok {
+			desired[key] = nil
+		}
+	}
+	return convergeChanges(previous, desired)
+}
@@ -0,0 +1,71 @@
+//go:build darwin && cgo
+
+package mac_prefs
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestWatch(t *testing.T) {
+	const appID = testAppID + ".watch"
+	scope := CurrentUserAnyHost
+
+	if err := SetMultiple(map[string]interface{}{"WatchChange": 1, "WatchRemove": true}, nil, appID, scope); err != nil {
+		t.Fatalf("SetMultiple() error = %v", err)
+	}
+	defer SetMultiple(nil, []string{"WatchChange", "WatchRemove", "WatchAdd"}, appID, scope)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	events, err := Watch(ctx, appID, scope, 10*time.Millisecond)
+	if err != nil {
+		t.Fatalf("Watch() error = %v", err)
+	}
+
+	if err := SetMultiple(map[string]interface{}{"WatchChange": 2, "WatchAdd": "new"}, []string{"WatchRemove"}, appID, scope); err != nil {
+		t.Fatalf("SetMultiple() error = %v", err)
+	}
+
+	want := []Change{
+		{Key: "WatchAdd", New: "new"},
+		{Key: "WatchChange", Old: 1, New: 2},
+		{Key: "WatchRemove", Old: true},
+	}
+	var got []Change
+	timeout := time.After(5 * time.Second)
+	for len(got) < len(want) {
+		select {
+		case e := <-events:
+			if e.ApplicationID != appID || e.Scope != scope || e.Time.IsZero() {
+				t.Fatalf("Watch() event = %+v", e)
+			}
+			got = append(got, e.Change)
+		case <-timeout:
+			t.Fatalf("Watch() timed out, got = %v", got)
+		}
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("Watch() got = %v, want %v", got, want)
+	}
+
+	cancel()
+	for range events {
+	}
+}
+
+func TestWatchChanges(t *testing.T) {
+	previous := map[string]interface{}{"same": 1, "changed": "a", "removed": true}
+	current := map[string]interface{}{"same": 1.0, "changed": "b", "added": []interface{}{}}
+	got := watchChanges(previous, current)
+	want := Changes{
+		{Key: "added", New: []interface{}{}},
+		{Key: "changed", Old: "a", New: "b"},
+		{Key: "removed", Old: true},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("watchChanges() got = %v, want %v", got, want)
+	}
+}